@@ -181,6 +181,7 @@ func configureAndRunChecks(cmd *cobra.Command, wout io.Writer, werr io.Writer, o
 			checks = append(checks, healthcheck.LinkerdIdentityDataPlane)
 			checks = append(checks, healthcheck.LinkerdOpaquePortsDefinitionChecks)
 		} else {
+			checks = append(checks, healthcheck.LinkerdDestinationChecks)
 			checks = append(checks, healthcheck.LinkerdControlPlaneVersionChecks)
 			checks = append(checks, healthcheck.LinkerdExtensionChecks)
 		}
@@ -27,6 +27,7 @@ This command provides subcommands to diagnose the functionality of Linkerd.`,
 	}
 
 	diagnosticsCmd.AddCommand(newCmdControllerMetrics())
+	diagnosticsCmd.AddCommand(newCmdDestinationStreams())
 	diagnosticsCmd.AddCommand(newCmdEndpoints())
 	diagnosticsCmd.AddCommand(newCmdMetrics())
 	diagnosticsCmd.AddCommand(newCmdPolicy())
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/linkerd/linkerd2/controller/api/destination"
+	pkgcmd "github.com/linkerd/linkerd2/pkg/cmd"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	destinationContainerName = "destination"
+	destinationStreamsPath   = "/debug/streams"
+)
+
+type destinationStreamsOptions struct {
+	outputFormat   string
+	destinationPod string
+}
+
+type (
+	// streamsFetcher returns the streams served by a destination pod, as
+	// listed by its admin server.
+	streamsFetcher func(pod corev1.Pod) ([]byte, error)
+
+	destinationStreams struct {
+		pod     string
+		streams []destination.StreamInfo
+		err     error
+	}
+
+	destinationStreamJSON struct {
+		Pod string `json:"pod"`
+		destination.StreamInfo
+	}
+)
+
+// validate performs all validation on the command-line options.
+// It returns the first error encountered, or `nil` if the options are valid.
+func (o *destinationStreamsOptions) validate() error {
+	if o.outputFormat == tableOutput || o.outputFormat == jsonOutput {
+		return nil
+	}
+
+	return fmt.Errorf("--output currently only supports %s and %s", tableOutput, jsonOutput)
+}
+
+func newDestinationStreamsOptions() *destinationStreamsOptions {
+	return &destinationStreamsOptions{
+		outputFormat: tableOutput,
+	}
+}
+
+func newCmdDestinationStreams() *cobra.Command {
+	options := newDestinationStreamsOptions()
+
+	example := `  # List the endpoint streams served by all the destination pods
+  linkerd diagnostics destination-streams
+
+  # List the endpoint streams served by a single destination pod, in json format
+  linkerd diagnostics destination-streams --destination-pod linkerd-destination-7d4b8f6c9-x2x5w -o json`
+
+	cmd := &cobra.Command{
+		Use:   "destination-streams [flags]",
+		Short: "List the endpoint streams served by the destination controller",
+		Long: `List the endpoint streams served by the destination controller.
+
+This command port-forwards to the admin server of each destination pod, and
+lists the endpoint streams it's serving: the authority each one resolved, the
+number of views feeding it, and how long ago the endpoints it was sent last
+changed. This helps finding proxies stuck on a particular destination replica.`,
+		Example: example,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := options.validate()
+			if err != nil {
+				return err
+			}
+
+			k8sAPI, err := k8s.NewAPI(kubeconfigPath, kubeContext, impersonate, impersonateGroup, 0)
+			if err != nil {
+				return err
+			}
+
+			var pods []corev1.Pod
+			if options.destinationPod != "" {
+				pod, err := k8sAPI.CoreV1().Pods(controlPlaneNamespace).Get(cmd.Context(), options.destinationPod, metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				pods = []corev1.Pod{*pod}
+			} else {
+				podList, err := k8sAPI.CoreV1().Pods(controlPlaneNamespace).List(cmd.Context(), metav1.ListOptions{
+					LabelSelector: fmt.Sprintf("%s=%s", k8s.ControllerComponentLabel, destinationContainerName),
+				})
+				if err != nil {
+					return err
+				}
+				pods = podList.Items
+			}
+
+			results := getDestinationStreams(pods, func(pod corev1.Pod) ([]byte, error) {
+				container, err := getDestinationContainer(pod)
+				if err != nil {
+					return nil, err
+				}
+				return k8s.GetContainerPath(k8sAPI, pod, container, verbose, k8s.AdminHTTPPortName, destinationStreamsPath)
+			})
+			for _, result := range results {
+				if result.err != nil {
+					fmt.Fprintf(os.Stderr, "Error getting streams from %s: %s\n", result.pod, result.err)
+				}
+			}
+
+			if options.outputFormat == jsonOutput {
+				return writeDestinationStreamsJSON(os.Stdout, results)
+			}
+			return writeDestinationStreams(os.Stdout, results)
+		},
+	}
+
+	cmd.PersistentFlags().StringVarP(&options.outputFormat, "output", "o", options.outputFormat, fmt.Sprintf("Output format; one of: \"%s\" or \"%s\"", tableOutput, jsonOutput))
+	cmd.PersistentFlags().StringVar(&options.destinationPod, "destination-pod", "", "Only list the streams of this destination Pod")
+
+	pkgcmd.ConfigureOutputFlagCompletion(cmd)
+
+	return cmd
+}
+
+func getDestinationContainer(pod corev1.Pod) (corev1.Container, error) {
+	if pod.Status.Phase != corev1.PodRunning {
+		return corev1.Container{}, fmt.Errorf("pod not running: %s", pod.GetName())
+	}
+	for _, container := range pod.Spec.Containers {
+		if container.Name == destinationContainerName {
+			return container, nil
+		}
+	}
+	return corev1.Container{}, fmt.Errorf("no %s container found in pod %s", destinationContainerName, pod.GetName())
+}
+
+// getDestinationStreams returns the streams of each pod, sorted by pod name.
+func getDestinationStreams(pods []corev1.Pod, fetch streamsFetcher) []destinationStreams {
+	results := make([]destinationStreams, 0, len(pods))
+	for _, pod := range pods {
+		result := destinationStreams{pod: pod.GetName()}
+		body, err := fetch(pod)
+		if err == nil {
+			err = json.Unmarshal(body, &result.streams)
+		}
+		result.err = err
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].pod < results[j].pod })
+	return results
+}
+
+func writeDestinationStreams(w io.Writer, results []destinationStreams) error {
+	tw := tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
+	fmt.Fprintln(tw, "POD\tID\tAUTHORITY\tVIEWS\tSNAPSHOT AGE\tPINNED")
+	for _, result := range results {
+		for _, stream := range result.streams {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%d\t%s\t%t\n",
+				result.pod, stream.ID, stream.Authority, stream.Views, valueOrNone(stream.SnapshotAge), stream.Pinned)
+		}
+	}
+	return tw.Flush()
+}
+
+func writeDestinationStreamsJSON(w io.Writer, results []destinationStreams) error {
+	streams := []destinationStreamJSON{}
+	for _, result := range results {
+		for _, stream := range result.streams {
+			streams = append(streams, destinationStreamJSON{result.pod, stream})
+		}
+	}
+
+	b, err := json.MarshalIndent(streams, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
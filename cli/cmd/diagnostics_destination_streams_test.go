@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDestinationStreams(t *testing.T) {
+	responses := map[string]struct {
+		body string
+		err  error
+	}{
+		"linkerd-destination-b": {
+			body: `[
+  {"id": 3, "authority": "web-svc.emojivoto.svc.cluster.local:80", "views": 1, "pinned": true, "snapshotAge": "2m3.5s"},
+  {"id": 7, "authority": "voting-svc.emojivoto.svc.cluster.local:8080", "views": 1, "pinned": false}
+]`,
+		},
+		"linkerd-destination-a": {
+			body: `[{"id": 1, "authority": "emoji-svc.emojivoto.svc.cluster.local:8080", "views": 1, "pinned": false, "snapshotAge": "15ms"}]`,
+		},
+		"linkerd-destination-c": {
+			err: errors.New("no destination container found in pod linkerd-destination-c"),
+		},
+		"linkerd-destination-d": {
+			body: "404 page not found",
+		},
+	}
+
+	pods := []corev1.Pod{}
+	for _, name := range []string{"linkerd-destination-b", "linkerd-destination-a", "linkerd-destination-c", "linkerd-destination-d"} {
+		pods = append(pods, corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+
+	results := getDestinationStreams(pods, func(pod corev1.Pod) ([]byte, error) {
+		response := responses[pod.GetName()]
+		return []byte(response.body), response.err
+	})
+
+	for _, result := range results {
+		failing := result.pod == "linkerd-destination-c" || result.pod == "linkerd-destination-d"
+		if failing && result.err == nil {
+			t.Fatalf("Expected an error for %s", result.pod)
+		}
+		if !failing && result.err != nil {
+			t.Fatalf("Unexpected error for %s: %s", result.pod, result.err)
+		}
+	}
+
+	t.Run("table output", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeDestinationStreams(&buf, results); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		testDataDiffer.DiffTestdata(t, "diagnostics_destination_streams_output.golden", buf.String())
+	})
+
+	t.Run("json output", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeDestinationStreamsJSON(&buf, results); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		testDataDiffer.DiffTestdata(t, "diagnostics_destination_streams_output_json.golden", buf.String())
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"text/tabwriter"
 
 	destinationPb "github.com/linkerd/linkerd2-proxy-api/go/destination"
 	"github.com/linkerd/linkerd2/controller/api/destination"
@@ -16,6 +17,7 @@ import (
 )
 
 type diagProfileOptions struct {
+	outputFormat   string
 	destinationPod string
 	contextToken   string
 }
@@ -23,18 +25,27 @@ type diagProfileOptions struct {
 // validate performs all validation on the command-line options.
 // It returns the first error encountered, or `nil` if the options are valid.
 func (o *diagProfileOptions) validate() error {
-	return nil
+	if o.outputFormat == tableOutput || o.outputFormat == jsonOutput {
+		return nil
+	}
+
+	return fmt.Errorf("--output currently only supports %s and %s", tableOutput, jsonOutput)
 }
 
 func newDiagProfileOptions() *diagProfileOptions {
-	return &diagProfileOptions{}
+	return &diagProfileOptions{
+		outputFormat: tableOutput,
+	}
 }
 
 func newCmdDiagnosticsProfile() *cobra.Command {
 	options := newDiagProfileOptions()
 
 	example := `  # Get the service profile for the service or endpoint at 10.20.2.4:8080
-  linkerd diagnostics profile 10.20.2.4:8080`
+  linkerd diagnostics profile 10.20.2.4:8080
+
+  # Get the service profile for an authority in json format
+  linkerd diagnostics profile -o json web-svc.emojivoto.svc.cluster.local:80`
 
 	cmd := &cobra.Command{
 		Use:     "profile [flags] address",
@@ -83,10 +94,14 @@ destination.`,
 				os.Exit(1)
 			}
 
-			return writeProfileJSON(os.Stdout, profile)
+			if options.outputFormat == jsonOutput {
+				return writeProfileJSON(os.Stdout, profile)
+			}
+			return writeProfile(os.Stdout, profile)
 		},
 	}
 
+	cmd.PersistentFlags().StringVarP(&options.outputFormat, "output", "o", options.outputFormat, fmt.Sprintf("Output format; one of: \"%s\" or \"%s\"", tableOutput, jsonOutput))
 	cmd.PersistentFlags().StringVar(&options.destinationPod, "destination-pod", "", "Target a specific destination Pod when there are multiple running")
 	cmd.PersistentFlags().StringVar(&options.contextToken, "token", "", "The context token to use when making the request to the destination API")
 
@@ -109,6 +124,57 @@ func requestProfileFromAPI(client destinationPb.DestinationClient, token string,
 	return rsp.Recv()
 }
 
+// writeProfile renders the parts of the profile the proxy acts upon in a
+// human readable form.
+func writeProfile(w io.Writer, profile *destinationPb.DestinationProfile) error {
+	tw := tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
+
+	fmt.Fprintf(tw, "Fully qualified name:\t%s\n", valueOrNone(profile.GetFullyQualifiedName()))
+	fmt.Fprintf(tw, "Opaque protocol:\t%t\n", profile.GetOpaqueProtocol())
+	if budget := profile.GetRetryBudget(); budget != nil {
+		fmt.Fprintf(tw, "Retry budget:\t%g%% of requests, at least %d/s, over %s\n",
+			budget.GetRetryRatio()*100, budget.GetMinRetriesPerSecond(), budget.GetTtl().AsDuration())
+	} else {
+		fmt.Fprintf(tw, "Retry budget:\t%s\n", noValue)
+	}
+	if ep := profile.GetEndpoint(); ep != nil {
+		fmt.Fprintf(tw, "Endpoint:\t%s (identity: %s)\n", formatTCPAddress(ep.GetAddr()), valueOrNone(getIdentity(ep.GetTlsIdentity())))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if routes := profile.GetRoutes(); len(routes) > 0 {
+		fmt.Fprintln(w, "\nRoutes:")
+		tw = tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
+		fmt.Fprintln(tw, "ROUTE\tTIMEOUT\tRETRYABLE")
+		for _, route := range routes {
+			timeout := noValue
+			if route.GetTimeout() != nil {
+				timeout = route.GetTimeout().AsDuration().String()
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%t\n", valueOrNone(route.GetMetricsLabels()["route"]), timeout, route.GetIsRetryable())
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if overrides := profile.GetDstOverrides(); len(overrides) > 0 {
+		fmt.Fprintln(w, "\nDestination overrides:")
+		tw = tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
+		fmt.Fprintln(tw, "AUTHORITY\tWEIGHT")
+		for _, dst := range overrides {
+			fmt.Fprintf(tw, "%s\t%d\n", dst.GetAuthority(), dst.GetWeight())
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func writeProfileJSON(w io.Writer, profile *destinationPb.DestinationProfile) error {
 	b, err := json.MarshalIndent(profile, "", "  ")
 	if err != nil {
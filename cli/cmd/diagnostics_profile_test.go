@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+	"github.com/linkerd/linkerd2/controller/api/util"
+)
+
+func TestDiagnosticsProfile(t *testing.T) {
+	profile := &pb.DestinationProfile{
+		FullyQualifiedName: "web-svc.emojivoto.svc.cluster.local",
+		RetryBudget: &pb.RetryBudget{
+			RetryRatio:          0.2,
+			MinRetriesPerSecond: 10,
+			Ttl:                 &duration.Duration{Seconds: 10},
+		},
+		Routes: []*pb.Route{
+			{
+				MetricsLabels: map[string]string{"route": "GET /api/list"},
+				IsRetryable:   true,
+				Timeout:       &duration.Duration{Seconds: 5},
+			},
+			{
+				MetricsLabels: map[string]string{"route": "POST /api/vote"},
+			},
+		},
+		DstOverrides: []*pb.WeightedDst{
+			{Authority: "web-svc.emojivoto.svc.cluster.local:80", Weight: 9000},
+			{Authority: "web-svc-v2.emojivoto.svc.cluster.local:80", Weight: 1000},
+		},
+	}
+
+	mockClient := &util.MockAPIClient{
+		DestinationGetProfileClientToReturn: &util.MockDestinationGetProfileClient{
+			ProfilesToReturn: []*pb.DestinationProfile{profile},
+		},
+	}
+
+	received, err := requestProfileFromAPI(mockClient, "", "web-svc.emojivoto.svc.cluster.local:80")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := writeProfile(&buf, received); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testDataDiffer.DiffTestdata(t, "diagnostics_profile_output.golden", buf.String())
+}
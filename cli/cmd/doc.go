@@ -2,12 +2,15 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
 	cobradoc "github.com/spf13/cobra/doc"
+	"github.com/spf13/pflag"
 	"sigs.k8s.io/yaml"
 
 	"github.com/linkerd/linkerd2/pkg/k8s"
@@ -21,6 +24,7 @@ type references struct {
 type cmdOption struct {
 	Name         string
 	Shorthand    string
+	Type         string
 	DefaultValue string
 	Usage        string
 }
@@ -38,15 +42,23 @@ type cmdDoc struct {
 type annotationDoc struct {
 	Name        string
 	Description string
+	Deprecated  bool   `json:",omitempty"`
+	Replacement string `json:",omitempty"`
 }
 
 func newCmdDoc() *cobra.Command {
+	format := yamlOutput
+
 	cmd := &cobra.Command{
 		Use:    "doc",
 		Hidden: true,
-		Short:  "Generate YAML documentation for the Linkerd CLI & Proxy annotations",
+		Short:  "Generate YAML or JSON documentation for the Linkerd CLI & Proxy annotations",
 		Args:   cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != yamlOutput && format != jsonOutput {
+				return fmt.Errorf("--format currently only supports %s and %s", yamlOutput, jsonOutput)
+			}
+
 			cmdList, err := generateCLIDocs(RootCmd)
 			if err != nil {
 				return err
@@ -58,21 +70,42 @@ func newCmdDoc() *cobra.Command {
 				CLIReference:         cmdList,
 				AnnotationsReference: annotations,
 			}
-			out, err := yaml.Marshal(ref)
-			if err != nil {
-				return err
-			}
-
-			warn := "# Automatically generated by the linkerd doc command, do not manually edit"
-			fmt.Printf("%s\n\n%s\n", warn, out)
 
-			return nil
+			return writeReferences(ref, os.Stdout, format)
 		},
 	}
 
+	cmd.Flags().StringVar(&format, "format", format, fmt.Sprintf("Output format; one of: \"%s\" or \"%s\"", yamlOutput, jsonOutput))
+
 	return cmd
 }
 
+// writeReferences renders the references in the given format. The YAML output
+// is prefixed with a comment header, which JSON has no room for.
+func writeReferences(ref references, w io.Writer, format string) error {
+	switch format {
+	case yamlOutput:
+		out, err := yaml.Marshal(ref)
+		if err != nil {
+			return err
+		}
+
+		warn := "# Automatically generated by the linkerd doc command, do not manually edit"
+		_, err = fmt.Fprintf(w, "%s\n\n%s\n", warn, out)
+		return err
+	case jsonOutput:
+		out, err := json.MarshalIndent(ref, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintf(w, "%s\n", out)
+		return err
+	default:
+		return fmt.Errorf("unknown output format: %s", format)
+	}
+}
+
 // generateCLIDocs takes a command and recursively walks the tree of commands,
 // adding each as an item to cmdList.
 func generateCLIDocs(cmd *cobra.Command) ([]cmdDoc, error) {
@@ -102,6 +135,11 @@ func generateCLIDocs(cmd *cobra.Command) ([]cmdDoc, error) {
 		return nil, err
 	}
 
+	// Cobra's YAML output doesn't include the flags' value types, so fill them
+	// in from the command's flag sets.
+	setOptionTypes(doc.Options, cmd.NonInheritedFlags())
+	setOptionTypes(doc.InheritedOptions, cmd.InheritedFlags())
+
 	// Cobra names start with linkerd, strip that off for the docs.
 	doc.Name = strings.TrimPrefix(doc.Name, "linkerd ")
 
@@ -113,8 +151,34 @@ func generateCLIDocs(cmd *cobra.Command) ([]cmdDoc, error) {
 	return cmdList, nil
 }
 
+// setOptionTypes sets the Type of each option to the value type (e.g.
+// "string", "bool", "duration") of the matching flag in flags.
+func setOptionTypes(options []cmdOption, flags *pflag.FlagSet) {
+	for i := range options {
+		if flag := flags.Lookup(options[i].Name); flag != nil {
+			options[i].Type = flag.Value.Type()
+		}
+	}
+}
+
+// markDeprecatedAnnotations flags the annotations found in deprecated,
+// recording their replacement, if any.
+func markDeprecatedAnnotations(docs []annotationDoc, deprecated map[string]string) []annotationDoc {
+	for i := range docs {
+		if replacement, ok := deprecated[docs[i].Name]; ok {
+			docs[i].Deprecated = true
+			docs[i].Replacement = replacement
+		}
+	}
+	return docs
+}
+
 // generateAnnotationsDocs make list of annotations and its docs
 func generateAnnotationsDocs() []annotationDoc {
+	return markDeprecatedAnnotations(annotationsDocs(), k8s.DeprecatedAnnotations)
+}
+
+func annotationsDocs() []annotationDoc {
 	return []annotationDoc{
 		{
 			Name:        k8s.ProxyInjectAnnotation,
@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/linkerd/linkerd2/pkg/k8s"
+)
+
+func TestWriteReferences(t *testing.T) {
+	cmdList, err := generateCLIDocs(RootCmd)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	ref := references{
+		CLIReference:         cmdList,
+		AnnotationsReference: generateAnnotationsDocs(),
+	}
+
+	t.Run("Round-trips JSON output", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeReferences(ref, &buf, jsonOutput); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		var decoded references
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatalf("Failed to decode JSON output: %v", err)
+		}
+
+		if !reflect.DeepEqual(ref, decoded) {
+			t.Fatalf("Decoded references do not match the original")
+		}
+	})
+
+	t.Run("Fails with invalid format", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := writeReferences(ref, &buf, "foo"); err == nil {
+			t.Fatalf("Unexpected success for invalid format")
+		}
+	})
+}
+
+func TestGenerateCLIDocsFlagTypes(t *testing.T) {
+	cmdList, err := generateCLIDocs(RootCmd)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var check *cmdDoc
+	for i := range cmdList {
+		if cmdList[i].Name == "check" {
+			check = &cmdList[i]
+			break
+		}
+	}
+	if check == nil {
+		t.Fatalf("No docs generated for the check command")
+	}
+
+	testCases := []struct {
+		name    string
+		options []cmdOption
+		expType string
+	}{
+		{"output", check.Options, "string"},
+		{"pre", check.Options, "bool"},
+		{"wait", check.Options, "duration"},
+		{"kubeconfig", check.InheritedOptions, "string"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			for _, opt := range tc.options {
+				if opt.Name != tc.name {
+					continue
+				}
+				if opt.Type != tc.expType {
+					t.Fatalf("Expected type %q for --%s, got %q", tc.expType, tc.name, opt.Type)
+				}
+				return
+			}
+			t.Fatalf("No option found for --%s", tc.name)
+		})
+	}
+}
+
+func TestMarkDeprecatedAnnotations(t *testing.T) {
+	docs := []annotationDoc{
+		{Name: "config.linkerd.io/current"},
+		{Name: "config.linkerd.io/old"},
+		{Name: "config.linkerd.io/removed"},
+	}
+	deprecated := map[string]string{
+		"config.linkerd.io/old":     "config.linkerd.io/current",
+		"config.linkerd.io/removed": "",
+	}
+
+	expected := []annotationDoc{
+		{Name: "config.linkerd.io/current"},
+		{Name: "config.linkerd.io/old", Deprecated: true, Replacement: "config.linkerd.io/current"},
+		{Name: "config.linkerd.io/removed", Deprecated: true},
+	}
+
+	docs = markDeprecatedAnnotations(docs, deprecated)
+	if !reflect.DeepEqual(expected, docs) {
+		t.Fatalf("Expected %+v, got %+v", expected, docs)
+	}
+}
+
+func TestGenerateAnnotationsDocsDeprecated(t *testing.T) {
+	// No annotation is deprecated in this release
+	for _, doc := range generateAnnotationsDocs() {
+		if doc.Deprecated {
+			t.Fatalf("Expected %s not to be deprecated", doc.Name)
+		}
+	}
+
+	saved := k8s.DeprecatedAnnotations
+	defer func() { k8s.DeprecatedAnnotations = saved }()
+	k8s.DeprecatedAnnotations = map[string]string{
+		k8s.ProxyWaitBeforeExitSecondsAnnotation: k8s.ProxyShutdownGracePeriodAnnotation,
+	}
+
+	var found *annotationDoc
+	docs := generateAnnotationsDocs()
+	for i := range docs {
+		if docs[i].Name == k8s.ProxyWaitBeforeExitSecondsAnnotation {
+			found = &docs[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("No doc found for %s", k8s.ProxyWaitBeforeExitSecondsAnnotation)
+	}
+	if !found.Deprecated || found.Replacement != k8s.ProxyShutdownGracePeriodAnnotation {
+		t.Fatalf("Expected %s to be deprecated in favor of %s, got %+v", found.Name, k8s.ProxyShutdownGracePeriodAnnotation, found)
+	}
+
+	var buf bytes.Buffer
+	if err := writeReferences(references{AnnotationsReference: docs}, &buf, yamlOutput); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, expected := range []string{"Deprecated: true", "Replacement: " + k8s.ProxyShutdownGracePeriodAnnotation} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Fatalf("Expected the YAML output to contain %q, got:\n%s", expected, buf.String())
+		}
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -29,18 +31,21 @@ type endpointsOptions struct {
 	outputFormat   string
 	destinationPod string
 	contextToken   string
+	watch          bool
 }
 
 type (
 	// map[ServiceID]map[Port][]podData
 	endpointsInfo map[string]map[uint32][]podData
 	podData       struct {
-		name    string
-		address string
-		ip      string
-		weight  uint32
-		labels  map[string]string
-		http2   *destinationPb.Http2ClientParams
+		name         string
+		address      string
+		ip           string
+		weight       uint32
+		identity     string
+		protocolHint string
+		labels       map[string]string
+		http2        *destinationPb.Http2ClientParams
 	}
 )
 
@@ -48,11 +53,20 @@ const (
 	podHeader       = "POD"
 	namespaceHeader = "NAMESPACE"
 	padding         = 3
+	noValue         = "-"
+
+	// watchCoalesceWindow is how long updates are accumulated in watch mode
+	// before being printed together, so that bursts during a rollout remain
+	// readable.
+	watchCoalesceWindow = 500 * time.Millisecond
 )
 
 // validate performs all validation on the command-line options.
 // It returns the first error encountered, or `nil` if the options are valid.
 func (o *endpointsOptions) validate() error {
+	if o.watch && o.outputFormat != tableOutput {
+		return fmt.Errorf("--watch only supports the %s output", tableOutput)
+	}
 	if o.outputFormat == tableOutput || o.outputFormat == jsonOutput {
 		return nil
 	}
@@ -76,7 +90,10 @@ func newCmdEndpoints() *cobra.Command {
   linkerd diagnostics endpoints -o json emoji-svc.emojivoto.svc.cluster.local:8080 web-svc.emojivoto.svc.cluster.local:80
 
   # get the endpoints for authorities in Linkerd's control-plane itself
-  linkerd diagnostics endpoints web.linkerd-viz.svc.cluster.local:8084`
+  linkerd diagnostics endpoints web.linkerd-viz.svc.cluster.local:8084
+
+  # print endpoint updates as they happen, e.g. during a rollout
+  linkerd diagnostics endpoints --watch emoji-svc.emojivoto.svc.cluster.local:8080`
 
 	cmd := &cobra.Command{
 		Use:     "endpoints [flags] authorities",
@@ -87,7 +104,7 @@ func newCmdEndpoints() *cobra.Command {
 This command provides debug information about the internal state of the
 control-plane's destination container. It queries the same Destination service
 endpoint as the linkerd-proxy's, and returns the addresses associated with that
-destination.`,
+destination, along with their weight, TLS identity and protocol hint.`,
 		Example: example,
 		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -119,6 +136,10 @@ destination.`,
 
 			defer conn.Close()
 
+			if options.watch {
+				return watchEndpointsFromAPI(cmd.Context(), client, options.contextToken, args, watchCoalesceWindow, os.Stdout, time.Now)
+			}
+
 			endpoints, err := requestEndpointsFromAPI(client, options.contextToken, args)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Destination API error: %s\n", err)
@@ -135,6 +156,7 @@ destination.`,
 	cmd.PersistentFlags().StringVarP(&options.outputFormat, "output", "o", options.outputFormat, fmt.Sprintf("Output format; one of: \"%s\" or \"%s\"", tableOutput, jsonOutput))
 	cmd.PersistentFlags().StringVar(&options.destinationPod, "destination-pod", "", "Target a specific destination Pod when there are multiple running")
 	cmd.PersistentFlags().StringVar(&options.contextToken, "token", "", "The context token to use when making the request to the destination API")
+	cmd.PersistentFlags().BoolVarP(&options.watch, "watch", "w", false, "Keep watching and print endpoint updates as they arrive")
 
 	pkgcmd.ConfigureOutputFlagCompletion(cmd)
 
@@ -208,12 +230,14 @@ func requestEndpointsFromAPI(client destinationPb.DestinationClient, token strin
 
 				labels := addr.GetMetricLabels()
 				info[serviceID][port] = append(info[serviceID][port], podData{
-					name:    labels["pod"],
-					address: tcpAddr.String(),
-					ip:      getIP(tcpAddr),
-					weight:  addr.GetWeight(),
-					labels:  addr.GetMetricLabels(),
-					http2:   addr.GetHttp2(),
+					name:         labels["pod"],
+					address:      tcpAddr.String(),
+					ip:           getIP(tcpAddr),
+					weight:       addr.GetWeight(),
+					identity:     getIdentity(addr.GetTlsIdentity()),
+					protocolHint: getProtocolHint(addr.GetProtocolHint()),
+					labels:       addr.GetMetricLabels(),
+					http2:        addr.GetHttp2(),
 				})
 			}
 		case <-timeout.C:
@@ -222,6 +246,158 @@ func requestEndpointsFromAPI(client destinationPb.DestinationClient, token strin
 	}
 }
 
+type (
+	// authorityUpdate is a destination update received for an authority
+	authorityUpdate struct {
+		authority string
+		update    *destinationPb.Update
+	}
+
+	// endpointEvent is the last change observed for an address during a
+	// coalescing window
+	endpointEvent struct {
+		authority string
+		event     string
+		address   string
+		pod       string
+	}
+)
+
+// watchEndpointsFromAPI streams the updates for the given authorities and
+// writes them to w. Updates received within window of each other are
+// coalesced, keeping the last change for each address, and printed together
+// with the time they were flushed. It returns when all the streams are closed
+// or on the first error.
+func watchEndpointsFromAPI(
+	ctx context.Context,
+	client destinationPb.DestinationClient,
+	token string,
+	authorities []string,
+	window time.Duration,
+	w io.Writer,
+	now func() time.Time,
+) error {
+	updates := make(chan authorityUpdate)
+	errs := make(chan error, len(authorities))
+	done := make(chan struct{}, len(authorities))
+
+	for _, authority := range authorities {
+		go func(authority string) {
+			dest := &destinationPb.GetDestination{
+				Scheme:       "http:",
+				Path:         authority,
+				ContextToken: token,
+			}
+			rsp, err := client.Get(ctx, dest)
+			if err != nil {
+				errs <- err
+				return
+			}
+			for {
+				update, err := rsp.Recv()
+				if errors.Is(err, io.EOF) {
+					done <- struct{}{}
+					return
+				} else if err != nil {
+					if grpcError, ok := status.FromError(err); ok {
+						err = errors.New(grpcError.Message())
+					}
+					errs <- err
+					return
+				}
+				select {
+				case updates <- authorityUpdate{authority, update}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(authority)
+	}
+
+	// pods keeps track of the pod names of the added addresses, so they can
+	// be shown when the addresses are removed.
+	pods := make(map[string]string)
+	pending := make(map[string]endpointEvent)
+	var flush <-chan time.Time
+	open := len(authorities)
+
+	for open > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errs:
+			writeEndpointEvents(w, now(), pending)
+			return err
+		case <-done:
+			open--
+		case u := <-updates:
+			for _, event := range endpointEvents(u, pods) {
+				pending[event.authority+"/"+event.address] = event
+			}
+			if flush == nil {
+				flush = time.After(window)
+			}
+		case <-flush:
+			writeEndpointEvents(w, now(), pending)
+			pending = make(map[string]endpointEvent)
+			flush = nil
+		}
+	}
+
+	writeEndpointEvents(w, now(), pending)
+	return nil
+}
+
+// endpointEvents converts a destination update into endpoint events,
+// recording the pod names of added addresses into pods.
+func endpointEvents(u authorityUpdate, pods map[string]string) []endpointEvent {
+	var events []endpointEvent
+	switch update := u.update.GetUpdate().(type) {
+	case *destinationPb.Update_Add:
+		for _, addr := range update.Add.GetAddrs() {
+			address := formatTCPAddress(addr.GetAddr())
+			pod := addr.GetMetricLabels()["pod"]
+			pods[u.authority+"/"+address] = pod
+			events = append(events, endpointEvent{u.authority, "ADD", address, pod})
+		}
+	case *destinationPb.Update_Remove:
+		for _, addr := range update.Remove.GetAddrs() {
+			address := formatTCPAddress(addr)
+			key := u.authority + "/" + address
+			events = append(events, endpointEvent{u.authority, "REMOVE", address, pods[key]})
+			delete(pods, key)
+		}
+	case *destinationPb.Update_NoEndpoints:
+		events = append(events, endpointEvent{u.authority, "NO_ENDPOINTS", "", ""})
+	}
+	return events
+}
+
+func writeEndpointEvents(w io.Writer, ts time.Time, events map[string]endpointEvent) {
+	if len(events) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(events))
+	for key := range events {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	tw := tabwriter.NewWriter(w, 0, 0, padding, ' ', 0)
+	for _, key := range keys {
+		event := events[key]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			ts.UTC().Format(time.RFC3339), event.authority, event.event,
+			valueOrNone(event.address), valueOrNone(event.pod))
+	}
+	tw.Flush()
+}
+
+func formatTCPAddress(tcpAddr *netPb.TcpAddress) string {
+	return net.JoinHostPort(getIP(tcpAddr), strconv.Itoa(int(tcpAddr.GetPort())))
+}
+
 func getIP(tcpAddr *netPb.TcpAddress) string {
 	ip := addr.FromProxyAPI(tcpAddr.GetIp())
 	if ip == nil {
@@ -230,6 +406,24 @@ func getIP(tcpAddr *netPb.TcpAddress) string {
 	return addr.PublicIPToString(ip)
 }
 
+func getIdentity(id *destinationPb.TlsIdentity) string {
+	if name := id.GetDnsLikeIdentity().GetName(); name != "" {
+		return name
+	}
+	return id.GetUriLikeIdentity().GetUri()
+}
+
+func getProtocolHint(hint *destinationPb.ProtocolHint) string {
+	switch hint.GetProtocol().(type) {
+	case *destinationPb.ProtocolHint_H2_:
+		return "h2"
+	case *destinationPb.ProtocolHint_Opaque_:
+		return "opaque"
+	default:
+		return ""
+	}
+}
+
 func renderEndpoints(endpoints endpointsInfo, options *endpointsOptions) string {
 	var buffer bytes.Buffer
 	w := tabwriter.NewWriter(&buffer, 0, 0, padding, ' ', 0)
@@ -247,6 +441,9 @@ type rowEndpoint struct {
 	Service   string `json:"service"`
 	Weight    uint32 `json:"weight"`
 
+	Identity     string `json:"identity,omitempty"`
+	ProtocolHint string `json:"protocolHint,omitempty"`
+
 	Http2 *destinationPb.Http2ClientParams `json:"http2,omitempty"`
 
 	Labels map[string]string `json:"labels"`
@@ -270,14 +467,16 @@ func writeEndpointsToBuffer(endpoints endpointsInfo, w *tabwriter.Writer, option
 					name = parts[1]
 				}
 				row := rowEndpoint{
-					Namespace: namespace,
-					IP:        pod.ip,
-					Port:      port,
-					Pod:       name,
-					Service:   serviceID,
-					Weight:    pod.weight,
-					Labels:    pod.labels,
-					Http2:     pod.http2,
+					Namespace:    namespace,
+					IP:           pod.ip,
+					Port:         port,
+					Pod:          name,
+					Service:      serviceID,
+					Weight:       pod.weight,
+					Identity:     pod.identity,
+					ProtocolHint: pod.protocolHint,
+					Labels:       pod.labels,
+					Http2:        pod.http2,
 				}
 
 				endpointsTables[namespace] = append(endpointsTables[namespace], row)
@@ -322,7 +521,7 @@ func printEndpointsTables(endpointsTables map[string][]rowEndpoint, w *tabwriter
 
 func printEndpointsTable(namespace string, rows []rowEndpoint, w *tabwriter.Writer, maxPodLength int, maxNamespaceLength int) {
 	headers := make([]string, 0)
-	templateString := "%s\t%d\t%s\t%s\n"
+	templateString := "%s\t%d\t%s\t%s\t%d\t%s\t%s\n"
 
 	headers = append(headers, namespaceHeader+strings.Repeat(" ", maxNamespaceLength-len(namespaceHeader)))
 	templateString = "%s\t" + templateString
@@ -332,6 +531,9 @@ func printEndpointsTable(namespace string, rows []rowEndpoint, w *tabwriter.Writ
 		"PORT",
 		podHeader + strings.Repeat(" ", maxPodLength-len(podHeader)),
 		"SERVICE",
+		"WEIGHT",
+		"IDENTITY",
+		"PROTOCOL",
 	}...)
 	fmt.Fprintln(w, strings.Join(headers, "\t"))
 
@@ -342,12 +544,22 @@ func printEndpointsTable(namespace string, rows []rowEndpoint, w *tabwriter.Writ
 			row.Port,
 			row.Pod,
 			row.Service,
+			row.Weight,
+			valueOrNone(row.Identity),
+			valueOrNone(row.ProtocolHint),
 		}
 
 		fmt.Fprintf(w, templateString, values...)
 	}
 }
 
+func valueOrNone(value string) string {
+	if value == "" {
+		return noValue
+	}
+	return value
+}
+
 func printEndpointsJSON(endpointsTables map[string][]rowEndpoint, w *tabwriter.Writer) {
 	entries := []rowEndpoint{}
 
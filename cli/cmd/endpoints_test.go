@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"testing"
+	"time"
 
 	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+	"github.com/linkerd/linkerd2-proxy-api/go/net"
 	"github.com/linkerd/linkerd2/controller/api/util"
 )
 
@@ -81,6 +85,30 @@ func TestEndpoints(t *testing.T) {
 		}, t)
 	})
 
+	t.Run("Returns endpoints with identities and protocol hints", func(t *testing.T) {
+		testEndpointsCall(endpointsExp{
+			options:     options,
+			authorities: []string{"emoji-svc.emojivoto.svc.cluster.local:8080"},
+			endpoints: []util.AuthorityEndpoints{
+				{
+					Namespace: "emojivoto",
+					ServiceID: "emoji-svc",
+					Pods: []util.PodDetails{
+						{
+							Name:     "emoji-6bf9f47bd5-jjcrl",
+							IP:       16909060,
+							Port:     8080,
+							Weight:   10000,
+							Identity: "emoji.emojivoto.serviceaccount.identity.linkerd.cluster.local",
+							H2:       true,
+						},
+					},
+				},
+			},
+			file: "endpoints_identity_output.golden",
+		}, t)
+	})
+
 	options.outputFormat = jsonOutput
 	t.Run("Returns endpoints same namespace (json)", func(t *testing.T) {
 		testEndpointsCall(endpointsExp{
@@ -113,6 +141,30 @@ func TestEndpoints(t *testing.T) {
 			file: "endpoints_one_output_json.golden",
 		}, t)
 	})
+
+	t.Run("Returns endpoints with identities and protocol hints (json)", func(t *testing.T) {
+		testEndpointsCall(endpointsExp{
+			options:     options,
+			authorities: []string{"emoji-svc.emojivoto.svc.cluster.local:8080"},
+			endpoints: []util.AuthorityEndpoints{
+				{
+					Namespace: "emojivoto",
+					ServiceID: "emoji-svc",
+					Pods: []util.PodDetails{
+						{
+							Name:     "emoji-6bf9f47bd5-jjcrl",
+							IP:       16909060,
+							Port:     8080,
+							Weight:   10000,
+							Identity: "emoji.emojivoto.serviceaccount.identity.linkerd.cluster.local",
+							H2:       true,
+						},
+					},
+				},
+			},
+			file: "endpoints_identity_output_json.golden",
+		}, t)
+	})
 }
 
 func testEndpointsCall(exp endpointsExp, t *testing.T) {
@@ -139,3 +191,43 @@ func testEndpointsCall(exp endpointsExp, t *testing.T) {
 
 	testDataDiffer.DiffTestdata(t, exp.file, output)
 }
+
+func TestWatchEndpoints(t *testing.T) {
+	added := util.BuildAddrSet(util.AuthorityEndpoints{
+		Namespace: "emojivoto",
+		ServiceID: "emoji-svc",
+		Pods: []util.PodDetails{
+			{Name: "emoji-6bf9f47bd5-jjcrl", IP: 16909060, Port: 8080},
+			{Name: "emoji-6bf9f47bd5-kkdrm", IP: 84281096, Port: 8080},
+		},
+	})
+	removed := &pb.AddrSet{
+		Addrs: []*net.TcpAddress{added.GetAddrs()[0].GetAddr()},
+	}
+
+	mockClient := &util.MockAPIClient{
+		DestinationGetClientToReturn: &util.MockDestinationGetClient{
+			UpdatesToReturn: []pb.Update{
+				{Update: &pb.Update_Add{Add: added}},
+				{Update: &pb.Update_Remove{Remove: removed}},
+			},
+		},
+	}
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var buf bytes.Buffer
+	err := watchEndpointsFromAPI(
+		context.Background(),
+		mockClient,
+		"",
+		[]string{"emoji-svc.emojivoto.svc.cluster.local:8080"},
+		time.Minute,
+		&buf,
+		func() time.Time { return ts },
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	testDataDiffer.DiffTestdata(t, "endpoints_watch_output.golden", buf.String())
+}
@@ -130,6 +130,8 @@ This command initiates a port-forward to a given pod or a set of pods and fetche
 	pkgcmd.ConfigureNamespaceFlagCompletion(cmd, []string{"namespace"},
 		kubeconfigPath, impersonate, impersonateGroup, kubeContext)
 
+	cmd.AddCommand(newCmdIdentityInspect(options))
+
 	return cmd
 }
 
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	pkgcmd "github.com/linkerd/linkerd2/pkg/cmd"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// certInspection holds the decoded fields of a proxy's leaf certificate that
+// matter when debugging mTLS, along with the problems found with them.
+type certInspection struct {
+	subject          string
+	sans             []string
+	issuer           string
+	notBefore        time.Time
+	notAfter         time.Time
+	expectedIdentity string
+	problems         []string
+}
+
+func newCmdIdentityInspect(options *identityOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "inspect [flags] (PODS)",
+		Short: "Decode the certificate(s) of one or more selected pod(s) and check them against their expected identity",
+		Long: `Decode the certificate(s) of one or more selected pod(s) and check them against their expected identity.
+
+This command fetches the TLS certificate of each pod's proxy, like "linkerd identity" does, and prints its
+subject, SANs, issuer and validity. It flags certificates that are expired or not yet valid, and
+certificates whose SANs don't include the identity the proxy is expected to have.
+		`,
+		Example: `
+ # Inspect the certificate of pod foo-bar in the default namespace.
+ linkerd identity inspect foo-bar
+
+ # Inspect the certificates of all pods with the label name=nginx
+ linkerd identity inspect -l name=nginx
+		`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if options.namespace == "" {
+				options.namespace = pkgcmd.GetDefaultNamespace(kubeconfigPath, kubeContext)
+			}
+			k8sAPI, err := k8s.NewAPI(kubeconfigPath, kubeContext, impersonate, impersonateGroup, 0)
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 0 && options.selector == "" {
+				return fmt.Errorf("Provide the pod name argument or use the selector flag")
+			}
+
+			pods, err := getPods(cmd.Context(), k8sAPI, options.namespace, options.selector, args)
+			if err != nil {
+				return err
+			}
+
+			problems := false
+			for i, pod := range pods {
+				fmt.Printf("\nPOD %s (%d of %d)\n\n", pod.GetName(), i+1, len(pods))
+				inspections, err := inspectPod(k8sAPI, pod, time.Now())
+				if err != nil {
+					fmt.Printf("%s\n", err)
+					problems = true
+					continue
+				}
+				for _, ci := range inspections {
+					renderCertInspection(os.Stdout, ci)
+					problems = problems || len(ci.problems) > 0
+				}
+			}
+
+			if problems {
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// inspectPod fetches the certificate presented by the pod's proxy and
+// inspects each of its leaf certificates.
+func inspectPod(k8sAPI *k8s.KubernetesAPI, pod corev1.Pod, now time.Time) ([]certInspection, error) {
+	expectedIdentity, err := k8s.PodIdentity(&pod)
+	if err != nil {
+		return nil, err
+	}
+
+	results := getCertificate(k8sAPI, []corev1.Pod{pod}, k8s.ProxyAdminPortName, emitLog)
+	if len(results) == 0 {
+		return nil, fmt.Errorf("could not fetch certificate. Ensure that the pod is meshed by running `linkerd inject`")
+	}
+	if results[0].err != nil {
+		return nil, results[0].err
+	}
+
+	var inspections []certInspection
+	for _, cert := range results[0].Certificate {
+		if cert.IsCA {
+			continue
+		}
+		inspections = append(inspections, inspectCertificate(cert, expectedIdentity, now))
+	}
+	return inspections, nil
+}
+
+// inspectCertificate decodes cert and checks that it's valid at now and that
+// its SANs include expectedIdentity. The identity check is skipped if
+// expectedIdentity is empty.
+func inspectCertificate(cert *x509.Certificate, expectedIdentity string, now time.Time) certInspection {
+	ci := certInspection{
+		subject:          cert.Subject.String(),
+		sans:             cert.DNSNames,
+		issuer:           cert.Issuer.String(),
+		notBefore:        cert.NotBefore,
+		notAfter:         cert.NotAfter,
+		expectedIdentity: expectedIdentity,
+	}
+
+	if now.Before(cert.NotBefore) {
+		ci.problems = append(ci.problems, fmt.Sprintf("certificate is not valid before %s", cert.NotBefore.UTC().Format(time.RFC3339)))
+	}
+	if now.After(cert.NotAfter) {
+		ci.problems = append(ci.problems, fmt.Sprintf("certificate expired at %s", cert.NotAfter.UTC().Format(time.RFC3339)))
+	}
+
+	if expectedIdentity != "" {
+		if err := cert.VerifyHostname(expectedIdentity); err != nil {
+			ci.problems = append(ci.problems, fmt.Sprintf("certificate SANs don't include the expected identity %s", expectedIdentity))
+		}
+	}
+
+	return ci
+}
+
+func renderCertInspection(w io.Writer, ci certInspection) {
+	fmt.Fprintf(w, "Subject:    %s\n", ci.subject)
+	fmt.Fprintf(w, "SANs:       %s\n", strings.Join(ci.sans, ", "))
+	fmt.Fprintf(w, "Issuer:     %s\n", ci.issuer)
+	fmt.Fprintf(w, "Not Before: %s\n", ci.notBefore.UTC().Format(time.RFC3339))
+	fmt.Fprintf(w, "Not After:  %s\n", ci.notAfter.UTC().Format(time.RFC3339))
+	if ci.expectedIdentity != "" {
+		fmt.Fprintf(w, "Expected:   %s\n", ci.expectedIdentity)
+	}
+	fmt.Fprintln(w)
+
+	if len(ci.problems) == 0 {
+		fmt.Fprintf(w, "%s certificate is valid and matches the expected identity\n", okStatus)
+		return
+	}
+	for _, problem := range ci.problems {
+		fmt.Fprintf(w, "%s %s\n", failStatus, problem)
+	}
+}
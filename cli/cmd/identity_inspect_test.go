@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pkgTls "github.com/linkerd/linkerd2/pkg/tls"
+)
+
+func TestInspectCertificate(t *testing.T) {
+	identity := "web.emojivoto.serviceaccount.identity.linkerd.cluster.local"
+
+	ca, err := pkgTls.GenerateRootCAWithDefaults("identity.linkerd.cluster.local")
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %s", err)
+	}
+	cred, err := ca.GenerateEndEntityCred(identity)
+	if err != nil {
+		t.Fatalf("Failed to generate certificate: %s", err)
+	}
+
+	// Decode the certificate from PEM, as it would be read from a proxy.
+	certs, err := pkgTls.DecodePEMCertificates(cred.Crt.EncodeCertificatePEM())
+	if err != nil {
+		t.Fatalf("Failed to decode certificate: %s", err)
+	}
+	cert := certs[0]
+
+	testCases := []struct {
+		name             string
+		expectedIdentity string
+		now              time.Time
+		expectedProblems []string
+	}{
+		{
+			name:             "valid certificate",
+			expectedIdentity: identity,
+			now:              cert.NotBefore.Add(time.Minute),
+		},
+		{
+			name: "no expected identity",
+			now:  cert.NotBefore.Add(time.Minute),
+		},
+		{
+			name:             "expired certificate",
+			expectedIdentity: identity,
+			now:              cert.NotAfter.Add(time.Minute),
+			expectedProblems: []string{
+				"certificate expired at " + cert.NotAfter.UTC().Format(time.RFC3339),
+			},
+		},
+		{
+			name:             "not yet valid certificate",
+			expectedIdentity: identity,
+			now:              cert.NotBefore.Add(-time.Minute),
+			expectedProblems: []string{
+				"certificate is not valid before " + cert.NotBefore.UTC().Format(time.RFC3339),
+			},
+		},
+		{
+			name:             "SAN mismatch",
+			expectedIdentity: "default.emojivoto.serviceaccount.identity.linkerd.cluster.local",
+			now:              cert.NotBefore.Add(time.Minute),
+			expectedProblems: []string{
+				"certificate SANs don't include the expected identity default.emojivoto.serviceaccount.identity.linkerd.cluster.local",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			ci := inspectCertificate(cert, tc.expectedIdentity, tc.now)
+
+			if !reflect.DeepEqual(ci.sans, []string{identity}) {
+				t.Fatalf("Unexpected SANs: %v", ci.sans)
+			}
+			if ci.issuer != "CN=identity.linkerd.cluster.local" {
+				t.Fatalf("Unexpected issuer: %s", ci.issuer)
+			}
+			if !reflect.DeepEqual(ci.problems, tc.expectedProblems) {
+				t.Fatalf("Expected problems %v, got %v", tc.expectedProblems, ci.problems)
+			}
+		})
+	}
+}
+
+func TestRenderCertInspection(t *testing.T) {
+	notBefore := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ci := certInspection{
+		subject:          "CN=web.emojivoto.serviceaccount.identity.linkerd.cluster.local",
+		sans:             []string{"web.emojivoto.serviceaccount.identity.linkerd.cluster.local"},
+		issuer:           "CN=identity.linkerd.cluster.local",
+		notBefore:        notBefore,
+		notAfter:         notBefore.Add(24 * time.Hour),
+		expectedIdentity: "default.emojivoto.serviceaccount.identity.linkerd.cluster.local",
+		problems: []string{
+			"certificate expired at 2024-01-02T00:00:00Z",
+		},
+	}
+
+	var buf bytes.Buffer
+	renderCertInspection(&buf, ci)
+
+	expected := strings.Join([]string{
+		"Subject:    CN=web.emojivoto.serviceaccount.identity.linkerd.cluster.local",
+		"SANs:       web.emojivoto.serviceaccount.identity.linkerd.cluster.local",
+		"Issuer:     CN=identity.linkerd.cluster.local",
+		"Not Before: 2024-01-01T00:00:00Z",
+		"Not After:  2024-01-02T00:00:00Z",
+		"Expected:   default.emojivoto.serviceaccount.identity.linkerd.cluster.local",
+		"",
+		failStatus + " certificate expired at 2024-01-02T00:00:00Z",
+		"",
+	}, "\n")
+	if buf.String() != expected {
+		t.Fatalf("Expected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
@@ -0,0 +1,84 @@
+package destination
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/linkerd/linkerd2/controller/api/destination/watcher"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// configJSON is the JSON representation of a Config served for debugging
+// purposes. It should never include any secret material.
+type configJSON struct {
+	ControllerNS                 string          `json:"controllerNamespace"`
+	IdentityTrustDomain          string          `json:"identityTrustDomain"`
+	ClusterDomain                string          `json:"clusterDomain"`
+	EnableH2Upgrade              bool            `json:"enableH2Upgrade"`
+	EnableEndpointSlices         bool            `json:"enableEndpointSlices"`
+	EndpointsSource              string          `json:"endpointsSource"`
+	EnableIPv6                   bool            `json:"enableIPv6"`
+	ExtEndpointZoneWeights       bool            `json:"extEndpointZoneWeights"`
+	NormalizeEndpointZoneWeights bool            `json:"normalizeEndpointZoneWeights"`
+	StableEndpointOrder          bool            `json:"stableEndpointOrder"`
+	MeshedHttp2ClientParams      json.RawMessage `json:"meshedHttp2ClientParams,omitempty"`
+	DefaultOpaquePorts           []uint32        `json:"defaultOpaquePorts"`
+	DefaultOpaquePortNames       []string        `json:"defaultOpaquePortNames"`
+	MaxEndpointsPerUpdate        int             `json:"maxEndpointsPerUpdate"`
+	MaxEndpointsPerService       int             `json:"maxEndpointsPerService"`
+	NoEndpointsGracePeriod       string          `json:"noEndpointsGracePeriod"`
+	InitialEmptyAdd              bool            `json:"initialEmptyAdd"`
+	ProfileServiceLabels         []string        `json:"profileServiceLabels"`
+}
+
+// NewConfigHandler returns an http.Handler that serves the given config as
+// JSON, so that the configuration a destination pod is actually running with
+// can be inspected through its admin server.
+func NewConfigHandler(config Config) (http.Handler, error) {
+	view := configJSON{
+		ControllerNS:                 config.ControllerNS,
+		IdentityTrustDomain:          config.IdentityTrustDomain,
+		ClusterDomain:                config.ClusterDomain,
+		EnableH2Upgrade:              config.EnableH2Upgrade,
+		EnableEndpointSlices:         config.EnableEndpointSlices,
+		EndpointsSource:              watcher.GetEndpointsSource(config.EnableEndpointSlices),
+		EnableIPv6:                   config.EnableIPv6,
+		ExtEndpointZoneWeights:       config.ExtEndpointZoneWeights,
+		NormalizeEndpointZoneWeights: config.NormalizeEndpointZoneWeights,
+		StableEndpointOrder:          config.StableEndpointOrder,
+		DefaultOpaquePorts:           []uint32{},
+		DefaultOpaquePortNames:       append([]string{}, config.DefaultOpaquePorts.Names...),
+		MaxEndpointsPerUpdate:        config.MaxEndpointsPerUpdate,
+		MaxEndpointsPerService:       config.MaxEndpointsPerService,
+		NoEndpointsGracePeriod:       config.NoEndpointsGracePeriod.String(),
+		InitialEmptyAdd:              config.InitialEmptyAdd,
+		ProfileServiceLabels:         append([]string{}, config.ProfileServiceLabels...),
+	}
+
+	if config.MeshedHttp2ClientParams != nil {
+		params, err := protojson.Marshal(config.MeshedHttp2ClientParams)
+		if err != nil {
+			return nil, err
+		}
+		view.MeshedHttp2ClientParams = params
+	}
+
+	for port := range config.DefaultOpaquePorts.Ports {
+		view.DefaultOpaquePorts = append(view.DefaultOpaquePorts, port)
+	}
+	sort.Slice(view.DefaultOpaquePorts, func(i, j int) bool {
+		return view.DefaultOpaquePorts[i] < view.DefaultOpaquePorts[j]
+	})
+
+	body, err := json.MarshalIndent(view, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	body = append(body, '\n')
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(body)
+	}), nil
+}
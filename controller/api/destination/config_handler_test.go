@@ -0,0 +1,87 @@
+package destination
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+	"github.com/linkerd/linkerd2/controller/api/destination/watcher"
+)
+
+func TestConfigHandler(t *testing.T) {
+	config := Config{
+		ControllerNS:         "linkerd",
+		IdentityTrustDomain:  "cluster.local",
+		ClusterDomain:        "cluster.local",
+		EnableH2Upgrade:      true,
+		EnableEndpointSlices: true,
+		EnableIPv6:           false,
+		MeshedHttp2ClientParams: &pb.Http2ClientParams{
+			KeepAlive: &pb.Http2ClientParams_KeepAlive{
+				Timeout:  &duration.Duration{Seconds: 10},
+				Interval: &duration.Duration{Seconds: 20},
+			},
+		},
+		DefaultOpaquePorts: watcher.DefaultOpaquePorts{
+			Ports: map[uint32]struct{}{
+				4444: {},
+				25:   {},
+				3306: {},
+			},
+			Names: []string{"mysql"},
+		},
+	}
+
+	handler, err := NewConfigHandler(config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/config", nil))
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+	if ct := recorder.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Expected JSON content type, got %q", ct)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+		t.Fatalf("Failed to decode response: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"controllerNamespace":          "linkerd",
+		"identityTrustDomain":          "cluster.local",
+		"clusterDomain":                "cluster.local",
+		"enableH2Upgrade":              true,
+		"enableEndpointSlices":         true,
+		"endpointsSource":              "EndpointSlice",
+		"enableIPv6":                   false,
+		"extEndpointZoneWeights":       false,
+		"normalizeEndpointZoneWeights": false,
+		"stableEndpointOrder":          false,
+		"meshedHttp2ClientParams": map[string]interface{}{
+			"keepAlive": map[string]interface{}{
+				"timeout":  "10s",
+				"interval": "20s",
+			},
+		},
+		"defaultOpaquePorts":     []interface{}{25.0, 3306.0, 4444.0},
+		"defaultOpaquePortNames": []interface{}{"mysql"},
+		"maxEndpointsPerUpdate":  0.0,
+		"maxEndpointsPerService": 0.0,
+		"noEndpointsGracePeriod": "0s",
+		"initialEmptyAdd":        false,
+		"profileServiceLabels":   []interface{}{},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+}
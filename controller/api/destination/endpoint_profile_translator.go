@@ -15,7 +15,7 @@ type endpointProfileTranslator struct {
 	enableH2Upgrade     bool
 	controllerNS        string
 	identityTrustDomain string
-	defaultOpaquePorts  map[uint32]struct{}
+	defaultOpaquePorts  watcher.DefaultOpaquePorts
 
 	meshedHttp2ClientParams *pb.Http2ClientParams
 
@@ -47,7 +47,7 @@ func newEndpointProfileTranslator(
 	enableH2Upgrade bool,
 	controllerNS,
 	identityTrustDomain string,
-	defaultOpaquePorts map[uint32]struct{},
+	defaultOpaquePorts watcher.DefaultOpaquePorts,
 	meshedHTTP2ClientParams *pb.Http2ClientParams,
 	stream pb.Destination_GetProfileServer,
 	endStream chan struct{},
@@ -1,9 +1,16 @@
 package destination
 
 import (
+	"cmp"
 	"fmt"
+	"maps"
+	"math"
 	"net/netip"
 	"reflect"
+	"slices"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
 	"github.com/linkerd/linkerd2-proxy-api/go/net"
@@ -16,6 +23,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	logging "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/clock"
 )
 
 const (
@@ -26,6 +34,12 @@ const (
 	envInboundListenAddr = "LINKERD2_PROXY_INBOUND_LISTEN_ADDR"
 
 	updateQueueCapacity = 100
+
+	// The reasons for which endpoints are filtered out of the updates sent
+	// to a client.
+	filterReasonNode          = "node"
+	filterReasonZone          = "zone"
+	filterReasonAddressFamily = "address_family"
 )
 
 // endpointTranslator satisfies EndpointUpdateListener and translates updates
@@ -36,25 +50,89 @@ type (
 		identityTrustDomain string
 		nodeTopologyZone    string
 		nodeName            string
-		defaultOpaquePorts  map[uint32]struct{}
+		defaultOpaquePorts  watcher.DefaultOpaquePorts
 
 		enableH2Upgrade,
 		enableEndpointFiltering,
 		enableIPv6,
 
-		extEndpointZoneWeights bool
+		extEndpointZoneWeights,
+		normalizeEndpointZoneWeights,
+
+		// stableEndpointOrder sorts the addresses of each update by IP and
+		// port, rather than leaving them in map iteration order.
+		stableEndpointOrder,
+
+		// initialEmptyAdd sends an empty Add when the stream starts on a
+		// service that exists but has no endpoints, so that the client can
+		// tell it apart from a service that doesn't exist.
+		initialEmptyAdd bool
 
 		meshedHTTP2ClientParams *pb.Http2ClientParams
 
+		// maxEndpointsPerUpdate bounds the number of addresses sent in a single
+		// Add or Remove update; larger sets are split across several updates.
+		// Zero means no limit.
+		maxEndpointsPerUpdate int
+
+		// noEndpointsGracePeriod delays the removal of all endpoints on
+		// NoEndpoints, so that a service scaling to zero and immediately back
+		// up does not cause clients to drop their connections. Zero disables
+		// the delay.
+		noEndpointsGracePeriod time.Duration
+		noEndpointsTimer       *time.Timer
+
 		availableEndpoints watcher.AddressSet
 		filteredSnapshot   watcher.AddressSet
+		zoneCounts         map[string]int
 		stream             pb.Destination_GetServer
-		endStream          chan struct{}
+		endStream          *streamEnd
 		log                *logging.Entry
 		overflowCounter    prometheus.Counter
+		filteredCounter    *prometheus.CounterVec
+
+		// filteredIDs holds, by reason, the endpoints filtered out of the
+		// set last computed for the client, so that only the endpoints
+		// newly filtered out are counted.
+		filteredIDs map[string]map[watcher.ID]struct{}
+
+		noLocalEndpointsCounter prometheus.Counter
+
+		// noLocalEndpoints is set while a service with internalTrafficPolicy:
+		// Local has endpoints, but none on the client's node.
+		noLocalEndpoints bool
+
+		// started is set once the first endpoint update has been processed.
+		started bool
+
+		// standby withholds all the endpoints from the client while set, as
+		// if there were none. It's used to fail a federated service over to
+		// its remote endpoints only when it has too few local ones.
+		standby bool
+
+		// endpointsObserver, if set, is called from the translator's
+		// goroutine with the number of endpoints in the set computed for the
+		// client, whenever that set is computed.
+		endpointsObserver func(int)
 
 		updates chan interface{}
 		stop    chan struct{}
+
+		// pinned holds back the updates to the client while set, for
+		// debugging. See Streams.
+		pinned atomic.Bool
+
+		// lastUpdate is when the set of endpoints sent to the client last
+		// changed, in Unix nanoseconds, or zero if it never was.
+		lastUpdate atomic.Int64
+
+		// sendTimeout, if positive, is how long a Send to the client may block
+		// before the stream is aborted. It's only set in tests; Sends aren't
+		// timed out otherwise.
+		sendTimeout time.Duration
+
+		// clock times the sends to the client; it's only replaced in tests.
+		clock clock.Clock
 	}
 
 	addUpdate struct {
@@ -68,6 +146,12 @@ type (
 	noEndpointsUpdate struct {
 		exists bool
 	}
+
+	unpinUpdate struct{}
+
+	standbyUpdate struct {
+		standby bool
+	}
 )
 
 var updatesQueueOverflowCounter = promauto.NewCounterVec(
@@ -80,20 +164,49 @@ var updatesQueueOverflowCounter = promauto.NewCounterVec(
 	},
 )
 
+var endpointsFilteredCounter = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "endpoints_filtered",
+		Help: "A counter incremented by the number of endpoints newly filtered out of the set sent to a client",
+	},
+	[]string{
+		"service",
+		"port",
+		"reason",
+	},
+)
+
+var noLocalEndpointsCounter = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "endpoints_no_local",
+		Help: "A counter incremented whenever a client is sent NoEndpoints because a service with internalTrafficPolicy: Local has no endpoints on its node",
+	},
+	[]string{
+		"service",
+		"port",
+	},
+)
+
 func newEndpointTranslator(
 	controllerNS string,
 	identityTrustDomain string,
 	enableH2Upgrade,
 	enableEndpointFiltering,
 	enableIPv6,
-	extEndpointZoneWeights bool,
+	extEndpointZoneWeights,
+	normalizeEndpointZoneWeights,
+	stableEndpointOrder,
+	initialEmptyAdd bool,
 	meshedHTTP2ClientParams *pb.Http2ClientParams,
+	maxEndpointsPerUpdate int,
+	noEndpointsGracePeriod time.Duration,
 	service string,
+	port uint32,
 	srcNodeName string,
-	defaultOpaquePorts map[uint32]struct{},
+	defaultOpaquePorts watcher.DefaultOpaquePorts,
 	k8sAPI *k8s.MetadataAPI,
 	stream pb.Destination_GetServer,
-	endStream chan struct{},
+	endStream *streamEnd,
 	log *logging.Entry,
 ) *endpointTranslator {
 	log = log.WithFields(logging.Fields{
@@ -119,16 +232,40 @@ func newEndpointTranslator(
 		enableEndpointFiltering,
 		enableIPv6,
 		extEndpointZoneWeights,
+		normalizeEndpointZoneWeights,
+		stableEndpointOrder,
+		initialEmptyAdd,
 		meshedHTTP2ClientParams,
+		maxEndpointsPerUpdate,
+		noEndpointsGracePeriod,
+		nil,
 
 		availableEndpoints,
 		filteredSnapshot,
+		map[string]int{},
 		stream,
 		endStream,
 		log,
 		updatesQueueOverflowCounter.With(prometheus.Labels{"service": service}),
+		endpointsFilteredCounter.MustCurryWith(prometheus.Labels{
+			"service": service,
+			"port":    strconv.FormatUint(uint64(port), 10),
+		}),
+		map[string]map[watcher.ID]struct{}{},
+		noLocalEndpointsCounter.With(prometheus.Labels{
+			"service": service,
+			"port":    strconv.FormatUint(uint64(port), 10),
+		}),
+		false,
+		false,
+		false,
+		nil,
 		make(chan interface{}, updateQueueCapacity),
 		make(chan struct{}),
+		atomic.Bool{},
+		atomic.Int64{},
+		0,
+		clock.RealClock{},
 	}
 }
 
@@ -158,17 +295,31 @@ func (et *endpointTranslator) enqueueUpdate(update interface{}) {
 		// We are unable to enqueue because the channel does not have capacity.
 		// The stream has fallen too far behind and should be closed.
 		et.overflowCounter.Inc()
-		select {
-		case <-et.endStream:
-			// The endStream channel has already been closed so no action is
-			// necessary.
-		default:
-			et.log.Error("endpoint update queue full; aborting stream")
-			close(et.endStream)
-		}
+		et.abortStream("endpoint update queue full; aborting stream")
+	}
+}
+
+// abortStream signals to the stream that it should be closed, logging reason
+// unless it already was.
+func (et *endpointTranslator) abortStream(reason string) {
+	if et.endStream.end() {
+		et.log.Error(reason)
 	}
 }
 
+// send sends update to the client. If sendTimeout is set and the Send takes
+// longer than that, the stream is aborted, which also unblocks the Send.
+func (et *endpointTranslator) send(update *pb.Update) error {
+	if et.sendTimeout <= 0 {
+		return et.stream.Send(update)
+	}
+	timer := et.clock.AfterFunc(et.sendTimeout, func() {
+		et.abortStream("timed out sending endpoint update; aborting stream")
+	})
+	defer timer.Stop()
+	return et.stream.Send(update)
+}
+
 // Start initiates a goroutine which processes update events off of the
 // endpointTranslator's internal queue and sends to the grpc stream as
 // appropriate. The goroutine calls several non-thread-safe functions (including
@@ -182,6 +333,9 @@ func (et *endpointTranslator) Start() {
 					return
 				}
 				et.processUpdate(update)
+			case <-et.noEndpointsGraceExpired():
+				et.noEndpointsTimer = nil
+				et.sendFilteredUpdate()
 			case <-et.stop:
 				return
 			}
@@ -207,17 +361,65 @@ func (et *endpointTranslator) processUpdate(update interface{}) {
 	case *removeUpdate:
 		et.remove(update.set)
 	case *noEndpointsUpdate:
+		if update.exists && et.initialEmptyAdd && !et.started {
+			et.sendEmptyAdd()
+		}
 		et.noEndpoints(update.exists)
+	case *unpinUpdate:
+		et.sendFilteredUpdate()
+	case *standbyUpdate:
+		et.standby = update.standby
+		et.sendFilteredUpdate()
+	}
+	et.started = true
+}
+
+// pin stops sending updates to the client, which keeps the endpoints it was
+// last sent. Updates are still processed, so that unpin can catch up.
+func (et *endpointTranslator) pin() {
+	et.pinned.Store(true)
+}
+
+// unpin resumes sending updates to the client, starting with the changes to
+// the endpoints since it was pinned. Like Add and Remove, it doesn't block.
+func (et *endpointTranslator) unpin() {
+	if et.pinned.Swap(false) {
+		et.enqueueUpdate(&unpinUpdate{})
+	}
+}
+
+// setStandby withholds all the endpoints from the client while standby is
+// set, and sends them again once it's cleared. Like Add and Remove, it doesn't
+// block.
+func (et *endpointTranslator) setStandby(standby bool) {
+	et.enqueueUpdate(&standbyUpdate{standby})
+}
+
+// noEndpointsGraceExpired returns the channel fired when a pending NoEndpoints
+// grace period ends, or nil (which blocks forever) if there is none.
+func (et *endpointTranslator) noEndpointsGraceExpired() <-chan time.Time {
+	if et.noEndpointsTimer == nil {
+		return nil
 	}
+	return et.noEndpointsTimer.C
 }
 
 func (et *endpointTranslator) add(set watcher.AddressSet) {
+	if et.noEndpointsTimer != nil {
+		// Endpoints came back within the grace period: the diff against the
+		// snapshot still held by the client only carries what changed.
+		et.log.Debug("Endpoints reappeared within the NoEndpoints grace period")
+		et.noEndpointsTimer.Stop()
+		et.noEndpointsTimer = nil
+	}
+
 	for id, address := range set.Addresses {
 		et.availableEndpoints.Addresses[id] = address
 	}
 
 	et.availableEndpoints.Labels = set.Labels
 	et.availableEndpoints.LocalTrafficPolicy = set.LocalTrafficPolicy
+	et.availableEndpoints.PreferClose = set.PreferClose
 
 	et.sendFilteredUpdate()
 }
@@ -235,14 +437,60 @@ func (et *endpointTranslator) noEndpoints(exists bool) {
 
 	et.availableEndpoints.Addresses = map[watcher.ID]watcher.Address{}
 
+	// Only a service scaling to zero is given a chance to come back; the
+	// endpoints of a deleted service are removed right away.
+	if exists && et.noEndpointsGracePeriod > 0 && len(et.filteredSnapshot.Addresses) > 0 {
+		if et.noEndpointsTimer == nil {
+			et.noEndpointsTimer = time.NewTimer(et.noEndpointsGracePeriod)
+		}
+		return
+	}
+	if et.noEndpointsTimer != nil {
+		et.noEndpointsTimer.Stop()
+		et.noEndpointsTimer = nil
+	}
+
 	et.sendFilteredUpdate()
 }
 
 func (et *endpointTranslator) sendFilteredUpdate() {
+	previouslyFiltered := et.filteredIDs
+	et.filteredIDs = map[string]map[watcher.ID]struct{}{}
 	filtered := et.filterAddresses()
 	filtered = et.selectAddressFamily(filtered)
+	et.countNewlyFiltered(previouslyFiltered)
+	if et.endpointsObserver != nil {
+		et.endpointsObserver(len(filtered.Addresses))
+	}
+
+	// While pinned, the snapshot is left as it was last sent to the client,
+	// so that unpinning sends the changes made in the meantime.
+	if et.pinned.Load() {
+		et.log.Debug("Stream is pinned; holding back endpoint update")
+		return
+	}
+	if et.standby {
+		filtered = watcher.AddressSet{
+			Addresses: make(map[watcher.ID]watcher.Address),
+			Labels:    filtered.Labels,
+		}
+	}
 	diffAdd, diffRemove := et.diffEndpoints(filtered)
 
+	if et.extEndpointZoneWeights && et.normalizeEndpointZoneWeights {
+		// Normalized weights depend on the number of endpoints in each zone,
+		// so whenever those change, the weights of all the endpoints need to
+		// be sent again.
+		zoneCounts := countEndpointsPerZone(filtered)
+		if !maps.Equal(zoneCounts, et.zoneCounts) {
+			diffAdd.Addresses = filtered.Addresses
+		}
+		et.zoneCounts = zoneCounts
+	}
+
+	if len(diffAdd.Addresses) > 0 || len(diffRemove.Addresses) > 0 {
+		et.lastUpdate.Store(time.Now().UnixNano())
+	}
 	if len(diffAdd.Addresses) > 0 {
 		et.sendClientAdd(diffAdd)
 	}
@@ -250,9 +498,52 @@ func (et *endpointTranslator) sendFilteredUpdate() {
 		et.sendClientRemove(diffRemove)
 	}
 
+	// With internalTrafficPolicy: Local, traffic must not leave the node, so
+	// the client is told explicitly that there is nothing to route to rather
+	// than being left with an empty set.
+	noLocalEndpoints := filtered.LocalTrafficPolicy && et.enableEndpointFiltering &&
+		len(filtered.Addresses) == 0 && len(et.availableEndpoints.Addresses) > 0
+	if noLocalEndpoints && !et.noLocalEndpoints {
+		et.log.Infof("Sending NoEndpoints: no endpoints on node %s for a service with internalTrafficPolicy: Local (%d endpoints on other nodes)",
+			et.nodeName, len(et.availableEndpoints.Addresses))
+		et.noLocalEndpointsCounter.Inc()
+		et.lastUpdate.Store(time.Now().UnixNano())
+		et.sendNoEndpoints()
+	}
+	et.noLocalEndpoints = noLocalEndpoints
+
 	et.filteredSnapshot = filtered
 }
 
+// sendEmptyAdd tells the client that the service was resolved, although it
+// has no endpoints yet.
+func (et *endpointTranslator) sendEmptyAdd() {
+	if et.pinned.Load() {
+		return
+	}
+
+	add := &pb.Update{Update: &pb.Update_Add{
+		Add: &pb.WeightedAddrSet{Addrs: []*pb.WeightedAddr{}},
+	}}
+	et.log.Debugf("Sending empty destination add: %+v", add)
+	if err := et.send(add); err != nil {
+		et.log.Debugf("Failed to send address update: %s", err)
+	}
+}
+
+func (et *endpointTranslator) sendNoEndpoints() {
+	noEndpoints := &pb.Update{Update: &pb.Update_NoEndpoints{
+		NoEndpoints: &pb.NoEndpoints{
+			Exists: true,
+		},
+	}}
+
+	et.log.Debugf("Sending destination no endpoints: %+v", noEndpoints)
+	if err := et.send(noEndpoints); err != nil {
+		et.log.Debugf("Failed to send address update: %s", err)
+	}
+}
+
 func (et *endpointTranslator) selectAddressFamily(addresses watcher.AddressSet) watcher.AddressSet {
 	filtered := make(map[watcher.ID]watcher.Address)
 	for id, addr := range addresses.Addresses {
@@ -272,6 +563,7 @@ func (et *endpointTranslator) selectAddressFamily(addresses watcher.AddressSet)
 
 		filtered[id] = addr
 	}
+	et.recordFiltered(filterReasonAddressFamily, addresses.Addresses, filtered)
 
 	return watcher.AddressSet{
 		Addresses:          filtered,
@@ -285,7 +577,8 @@ func (et *endpointTranslator) selectAddressFamily(addresses watcher.AddressSet)
 // consumption zone as the node. An endpoints consumption zone is set
 // by its Hints field and can be different than its actual Topology zone.
 // when service.spec.internalTrafficPolicy is set to local, Topology Aware
-// Hints are not used.
+// Hints are not used. When service.spec.trafficDistribution is PreferClose and
+// hints aren't available, endpoints in the node's own zone are preferred.
 func (et *endpointTranslator) filterAddresses() watcher.AddressSet {
 	filtered := make(map[watcher.ID]watcher.Address)
 
@@ -310,6 +603,7 @@ func (et *endpointTranslator) filterAddresses() watcher.AddressSet {
 			}
 		}
 		et.log.Debugf("Filtered from %d to %d addresses", len(et.availableEndpoints.Addresses), len(filtered))
+		et.recordFiltered(filterReasonNode, et.availableEndpoints.Addresses, filtered)
 		return watcher.AddressSet{
 			Addresses:          filtered,
 			Labels:             et.availableEndpoints.Labels,
@@ -321,6 +615,9 @@ func (et *endpointTranslator) filterAddresses() watcher.AddressSet {
 	// documented in the KEP: https://github.com/kubernetes/enhancements/blob/master/keps/sig-network/2433-topology-aware-hints/README.md#kube-proxy
 	for _, address := range et.availableEndpoints.Addresses {
 		if len(address.ForZones) == 0 {
+			if et.availableEndpoints.PreferClose {
+				return et.filterSameZoneAddresses()
+			}
 			for k, v := range et.availableEndpoints.Addresses {
 				filtered[k] = v
 			}
@@ -345,6 +642,7 @@ func (et *endpointTranslator) filterAddresses() watcher.AddressSet {
 	}
 	if len(filtered) > 0 {
 		et.log.Debugf("Filtered from %d to %d addresses", len(et.availableEndpoints.Addresses), len(filtered))
+		et.recordFiltered(filterReasonZone, et.availableEndpoints.Addresses, filtered)
 		return watcher.AddressSet{
 			Addresses:          filtered,
 			Labels:             et.availableEndpoints.Labels,
@@ -364,6 +662,62 @@ func (et *endpointTranslator) filterAddresses() watcher.AddressSet {
 	}
 }
 
+// filterSameZoneAddresses returns the available addresses in the node's
+// topology zone, falling back to all available addresses if there are none.
+func (et *endpointTranslator) filterSameZoneAddresses() watcher.AddressSet {
+	filtered := make(map[watcher.ID]watcher.Address)
+	et.log.Debugf("Hints not available on endpointslice. Filtering through addresses in zone %s for PreferClose", et.nodeTopologyZone)
+	for id, address := range et.availableEndpoints.Addresses {
+		if address.Zone != nil && *address.Zone == et.nodeTopologyZone {
+			filtered[id] = address
+		}
+	}
+	if len(filtered) == 0 {
+		for k, v := range et.availableEndpoints.Addresses {
+			filtered[k] = v
+		}
+	}
+	et.log.Debugf("Filtered from %d to %d addresses", len(et.availableEndpoints.Addresses), len(filtered))
+	et.recordFiltered(filterReasonZone, et.availableEndpoints.Addresses, filtered)
+	return watcher.AddressSet{
+		Addresses:          filtered,
+		Labels:             et.availableEndpoints.Labels,
+		LocalTrafficPolicy: et.availableEndpoints.LocalTrafficPolicy,
+		PreferClose:        et.availableEndpoints.PreferClose,
+	}
+}
+
+// recordFiltered records the endpoints of all that were filtered out of kept
+// for reason.
+func (et *endpointTranslator) recordFiltered(reason string, all, kept map[watcher.ID]watcher.Address) {
+	for id := range all {
+		if _, ok := kept[id]; ok {
+			continue
+		}
+		if et.filteredIDs[reason] == nil {
+			et.filteredIDs[reason] = make(map[watcher.ID]struct{})
+		}
+		et.filteredIDs[reason][id] = struct{}{}
+	}
+}
+
+// countNewlyFiltered counts the endpoints filtered out of the set just
+// computed that weren't filtered out, for the same reason, of the previous
+// one. Recomputing the same set therefore leaves the counter unchanged.
+func (et *endpointTranslator) countNewlyFiltered(previous map[string]map[watcher.ID]struct{}) {
+	for reason, ids := range et.filteredIDs {
+		n := 0
+		for id := range ids {
+			if _, ok := previous[reason][id]; !ok {
+				n++
+			}
+		}
+		if n > 0 {
+			et.filteredCounter.With(prometheus.Labels{"reason": reason}).Add(float64(n))
+		}
+	}
+}
+
 // diffEndpoints calculates the difference between the filtered set of
 // endpoints in the current (Add/Remove) operation and the snapshot of
 // previously filtered endpoints. This diff allows the client to receive only
@@ -479,19 +833,37 @@ func (et *endpointTranslator) sendClientAdd(set watcher.AddressSet) {
 			wa.MetricLabels["zone_locality"] = "unknown"
 		}
 
+		if et.extEndpointZoneWeights {
+			if reason := et.zoneWeightReason(address); reason != "" {
+				wa.MetricLabels["zone_weight_reason"] = reason
+			}
+		}
+
+		if et.extEndpointZoneWeights && et.normalizeEndpointZoneWeights {
+			wa.Weight = normalizeZoneWeight(wa.Weight, address.Zone, et.zoneCounts)
+		}
+
 		addrs = append(addrs, wa)
 	}
 
-	add := &pb.Update{Update: &pb.Update_Add{
-		Add: &pb.WeightedAddrSet{
-			Addrs:        addrs,
-			MetricLabels: set.Labels,
-		},
-	}}
+	if et.stableEndpointOrder {
+		slices.SortFunc(addrs, func(a, b *pb.WeightedAddr) int {
+			return compareTCPAddrs(a.GetAddr(), b.GetAddr())
+		})
+	}
 
-	et.log.Debugf("Sending destination add: %+v", add)
-	if err := et.stream.Send(add); err != nil {
-		et.log.Debugf("Failed to send address update: %s", err)
+	for _, chunk := range chunkAddrs(addrs, et.maxEndpointsPerUpdate) {
+		add := &pb.Update{Update: &pb.Update_Add{
+			Add: &pb.WeightedAddrSet{
+				Addrs:        chunk,
+				MetricLabels: set.Labels,
+			},
+		}}
+
+		et.log.Debugf("Sending destination add: %+v", add)
+		if err := et.send(add); err != nil {
+			et.log.Debugf("Failed to send address update: %s", err)
+		}
 	}
 }
 
@@ -506,16 +878,121 @@ func (et *endpointTranslator) sendClientRemove(set watcher.AddressSet) {
 		addrs = append(addrs, tcpAddr)
 	}
 
-	remove := &pb.Update{Update: &pb.Update_Remove{
-		Remove: &pb.AddrSet{
-			Addrs: addrs,
-		},
-	}}
+	if et.stableEndpointOrder {
+		slices.SortFunc(addrs, compareTCPAddrs)
+	}
 
-	et.log.Debugf("Sending destination remove: %+v", remove)
-	if err := et.stream.Send(remove); err != nil {
-		et.log.Debugf("Failed to send address update: %s", err)
+	for _, chunk := range chunkAddrs(addrs, et.maxEndpointsPerUpdate) {
+		remove := &pb.Update{Update: &pb.Update_Remove{
+			Remove: &pb.AddrSet{
+				Addrs: chunk,
+			},
+		}}
+
+		et.log.Debugf("Sending destination remove: %+v", remove)
+		if err := et.send(remove); err != nil {
+			et.log.Debugf("Failed to send address update: %s", err)
+		}
+	}
+}
+
+// compareTCPAddrs orders addresses by IP family (IPv4 first), IP and port.
+func compareTCPAddrs(a, b *net.TcpAddress) int {
+	family := func(addr *net.TcpAddress) int {
+		if addr.GetIp().GetIpv6() != nil {
+			return 6
+		}
+		return 4
+	}
+	aIPv6, bIPv6 := a.GetIp().GetIpv6(), b.GetIp().GetIpv6()
+	return cmp.Or(
+		cmp.Compare(family(a), family(b)),
+		cmp.Compare(a.GetIp().GetIpv4(), b.GetIp().GetIpv4()),
+		cmp.Compare(aIPv6.GetFirst(), bIPv6.GetFirst()),
+		cmp.Compare(aIPv6.GetLast(), bIPv6.GetLast()),
+		cmp.Compare(a.GetPort(), b.GetPort()),
+	)
+}
+
+// countEndpointsPerZone returns the number of addresses in set for each zone.
+// Addresses without a zone are counted under the empty zone.
+func countEndpointsPerZone(set watcher.AddressSet) map[string]int {
+	counts := map[string]int{}
+	for _, address := range set.Addresses {
+		zone := ""
+		if address.Zone != nil {
+			zone = *address.Zone
+		}
+		counts[zone]++
+	}
+	return counts
+}
+
+// zoneWeightReason explains the weight given to an address when zone weights
+// are enabled: "same-zone" endpoints are preferred, "topology-hint" endpoints
+// are in another zone but hinted for consumption from the node's zone, and
+// "cross-zone" endpoints are neither. An empty string is returned when the
+// decision can't be made because the node's or the endpoint's zone is unknown.
+func (et *endpointTranslator) zoneWeightReason(address watcher.Address) string {
+	if et.nodeTopologyZone == "" {
+		return ""
+	}
+	if address.Zone != nil && *address.Zone == et.nodeTopologyZone {
+		return "same-zone"
+	}
+	for _, zone := range address.ForZones {
+		if zone.Name == et.nodeTopologyZone {
+			return "topology-hint"
+		}
+	}
+	if address.Zone == nil {
+		return ""
+	}
+	return "cross-zone"
+}
+
+// normalizeZoneWeight scales weight by the size of the endpoint's zone
+// relative to the average zone size, so that every zone carries the same
+// aggregate weight (before any locality preference is applied) regardless of
+// how many endpoints it holds.
+func normalizeZoneWeight(weight uint32, zone *string, zoneCounts map[string]int) uint32 {
+	z := ""
+	if zone != nil {
+		z = *zone
+	}
+	inZone := zoneCounts[z]
+	if inZone == 0 {
+		return weight
+	}
+
+	total := 0
+	for _, count := range zoneCounts {
+		total += count
+	}
+
+	normalized := uint64(weight) * uint64(total) / (uint64(len(zoneCounts)) * uint64(inZone))
+	if normalized == 0 {
+		return 1
+	}
+	if normalized > math.MaxUint32 {
+		return math.MaxUint32
+	}
+	return uint32(normalized)
+}
+
+// chunkAddrs splits addrs into chunks of at most max addresses. A max of zero
+// (or a set that already fits) yields a single chunk holding all of addrs.
+func chunkAddrs[T any](addrs []T, max int) [][]T {
+	if max <= 0 || len(addrs) <= max {
+		return [][]T{addrs}
+	}
+
+	chunks := make([][]T, 0, (len(addrs)+max-1)/max)
+	for len(addrs) > max {
+		chunks = append(chunks, addrs[:max])
+		addrs = addrs[max:]
 	}
+	return append(chunks, addrs)
 }
 
 func toAddr(address watcher.Address) (*net.TcpAddress, error) {
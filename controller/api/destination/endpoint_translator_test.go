@@ -7,18 +7,23 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/go-test/deep"
 	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
 	"github.com/linkerd/linkerd2-proxy-api/go/net"
 	"github.com/linkerd/linkerd2/controller/api/destination/watcher"
+	"github.com/linkerd/linkerd2/controller/api/util"
 	ewv1beta1 "github.com/linkerd/linkerd2/controller/gen/apis/externalworkload/v1beta1"
 	"github.com/linkerd/linkerd2/pkg/addr"
 	"github.com/linkerd/linkerd2/pkg/k8s"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"google.golang.org/protobuf/proto"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clocktesting "k8s.io/utils/clock/testing"
 )
 
 var (
@@ -514,6 +519,47 @@ func TestEndpointTranslatorForPods(t *testing.T) {
 		}
 	})
 
+	t.Run("Sends TlsIdentity in the translator's trust domain", func(t *testing.T) {
+		testCases := []struct {
+			name        string
+			trustDomain string
+			expIdentity string
+		}{
+			{
+				name:        "local",
+				trustDomain: "trust.domain",
+				expIdentity: "serviceaccount-name.ns.serviceaccount.identity.linkerd.trust.domain",
+			},
+			{
+				name:        "remote",
+				trustDomain: "remote.domain",
+				expIdentity: "serviceaccount-name.ns.serviceaccount.identity.linkerd.remote.domain",
+			},
+		}
+
+		for _, tc := range testCases {
+			tc := tc // pin
+			t.Run(tc.name, func(t *testing.T) {
+				mockGetServer, translator := makeEndpointTranslator(t)
+				translator.identityTrustDomain = tc.trustDomain
+				translator.Start()
+				defer translator.Stop()
+
+				translator.Add(mkAddressSetForPods(t, pod1))
+
+				addrs := (<-mockGetServer.updatesReceived).GetAdd().GetAddrs()
+				if len(addrs) != 1 {
+					t.Fatalf("Expected [1] address returned, got %v", addrs)
+				}
+
+				identity := addrs[0].GetTlsIdentity().GetDnsLikeIdentity().GetName()
+				if identity != tc.expIdentity {
+					t.Fatalf("Expected identity %s but got %s", tc.expIdentity, identity)
+				}
+			})
+		}
+	})
+
 	t.Run("Sends Opaque ProtocolHint for opaque ports", func(t *testing.T) {
 		expectedProtocolHint := &pb.ProtocolHint{
 			Protocol: &pb.ProtocolHint_Opaque_{
@@ -744,6 +790,69 @@ func TestEndpointTranslatorTopologyAwareFilter(t *testing.T) {
 	})
 }
 
+func TestEndpointTranslatorPreferClose(t *testing.T) {
+	zoneA := "west-1a"
+	zoneB := "west-1b"
+	addrA := watcher.Address{IP: "7.9.7.9", Port: 7979, Zone: &zoneA}
+	addrB := watcher.Address{IP: "9.7.9.7", Port: 9797, Zone: &zoneB}
+	hintedA := watcher.Address{IP: "7.9.7.9", Port: 7979, Zone: &zoneB, ForZones: []v1.ForZone{{Name: zoneA}}}
+	hintedB := watcher.Address{IP: "9.7.9.7", Port: 9797, Zone: &zoneA, ForZones: []v1.ForZone{{Name: zoneB}}}
+
+	testCases := []struct {
+		name        string
+		addresses   []watcher.Address
+		preferClose bool
+		expected    []string
+	}{
+		{
+			name:        "prefers the same zone without hints",
+			addresses:   []watcher.Address{addrA, addrB},
+			preferClose: true,
+			expected:    []string{"7.9.7.9:7979"},
+		},
+		{
+			name:        "falls back to all zones without a same-zone endpoint",
+			addresses:   []watcher.Address{addrB},
+			preferClose: true,
+			expected:    []string{"9.7.9.7:9797"},
+		},
+		{
+			name:        "follows hints when present",
+			addresses:   []watcher.Address{hintedA, hintedB},
+			preferClose: true,
+			expected:    []string{"7.9.7.9:7979"},
+		},
+		{
+			name:      "sends all zones without PreferClose or hints",
+			addresses: []watcher.Address{addrA, addrB},
+			expected:  []string{"7.9.7.9:7979", "9.7.9.7:9797"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			mockGetServer, translator := makeEndpointTranslator(t)
+			translator.Start()
+			defer translator.Stop()
+
+			set := mkAddressSetForServices(tc.addresses...)
+			set.PreferClose = tc.preferClose
+			translator.Add(set)
+
+			addrs := (<-mockGetServer.updatesReceived).GetAdd().GetAddrs()
+			ipPorts := []string{}
+			for _, a := range addrs {
+				ipPorts = append(ipPorts, addr.ProxyAddressToString(a.GetAddr()))
+			}
+			sort.Strings(ipPorts)
+			if diff := deep.Equal(ipPorts, tc.expected); diff != nil {
+				t.Fatalf("Unexpected addresses: %v", diff)
+			}
+		})
+	}
+}
+
 func TestEndpointTranslatorExperimentalZoneWeights(t *testing.T) {
 	zoneA := "west-1a"
 	zoneB := "west-1b"
@@ -797,6 +906,142 @@ func TestEndpointTranslatorExperimentalZoneWeights(t *testing.T) {
 	})
 }
 
+func TestEndpointTranslatorZoneWeightReason(t *testing.T) {
+	zoneA := "west-1a"
+	zoneB := "west-1b"
+	sameZone := watcher.Address{IP: "7.9.7.9", Port: 7979, Zone: &zoneA}
+	crossZone := watcher.Address{IP: "9.7.9.7", Port: 9797, Zone: &zoneB}
+	hinted := watcher.Address{
+		IP:       "9.7.9.8",
+		Port:     9798,
+		Zone:     &zoneB,
+		ForZones: []v1.ForZone{{Name: zoneA}},
+	}
+	noZone := watcher.Address{IP: "1.2.3.4", Port: 1234}
+
+	reasons := func(update *pb.Update) map[string]string {
+		got := map[string]string{}
+		for _, wa := range update.GetAdd().GetAddrs() {
+			if reason, ok := wa.GetMetricLabels()["zone_weight_reason"]; ok {
+				got[addr.ProxyAddressToString(wa.GetAddr())] = reason
+			}
+		}
+		return got
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.extEndpointZoneWeights = false
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(sameZone, crossZone, hinted))
+
+		if got := reasons(<-mockGetServer.updatesReceived); len(got) != 0 {
+			t.Fatalf("Expected no zone_weight_reason labels, got %v", got)
+		}
+	})
+
+	t.Run("Enabled", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.extEndpointZoneWeights = true
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(sameZone, crossZone, hinted, noZone))
+
+		update := <-mockGetServer.updatesReceived
+		if addrs := update.GetAdd().GetAddrs(); len(addrs) != 4 {
+			t.Fatalf("Expected [4] addresses returned, got %v", addrs)
+		}
+
+		// The address without a zone gets no reason at all.
+		expected := map[string]string{
+			"7.9.7.9:7979": "same-zone",
+			"9.7.9.7:9797": "cross-zone",
+			"9.7.9.8:9798": "topology-hint",
+		}
+		if diff := deep.Equal(reasons(update), expected); diff != nil {
+			t.Fatalf("zone_weight_reason: %v", diff)
+		}
+	})
+}
+
+func TestEndpointTranslatorNormalizedZoneWeights(t *testing.T) {
+	zoneA := "west-1a"
+	zoneB := "west-1b"
+	// One endpoint in the local zone, three in the remote one.
+	addrA := watcher.Address{IP: "7.9.7.9", Port: 7979, Zone: &zoneA}
+	addrB1 := watcher.Address{IP: "9.7.9.1", Port: 9791, Zone: &zoneB}
+	addrB2 := watcher.Address{IP: "9.7.9.2", Port: 9792, Zone: &zoneB}
+	addrB3 := watcher.Address{IP: "9.7.9.3", Port: 9793, Zone: &zoneB}
+
+	sortedAddrs := func(update *pb.Update) []*pb.WeightedAddr {
+		addrs := update.GetAdd().GetAddrs()
+		sort.Slice(addrs, func(i, j int) bool {
+			return addrs[i].GetAddr().Port < addrs[j].GetAddr().Port
+		})
+		return addrs
+	}
+
+	t.Run("Raw", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.extEndpointZoneWeights = true
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(addrA, addrB1, addrB2, addrB3))
+
+		addrs := sortedAddrs(<-mockGetServer.updatesReceived)
+		if len(addrs) != 4 {
+			t.Fatalf("Expected [4] addresses returned, got %v", addrs)
+		}
+		checkAddressAndWeight(t, addrs[0], addrA, defaultWeight*10)
+		checkAddressAndWeight(t, addrs[1], addrB1, defaultWeight)
+		checkAddressAndWeight(t, addrs[2], addrB2, defaultWeight)
+		checkAddressAndWeight(t, addrs[3], addrB3, defaultWeight)
+	})
+
+	t.Run("Normalized", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.extEndpointZoneWeights = true
+		translator.normalizeEndpointZoneWeights = true
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(addrA, addrB1, addrB2, addrB3))
+
+		// Each zone carries the same aggregate weight before the locality
+		// preference: 4 endpoints across 2 zones means the lone local endpoint
+		// is scaled by 2 and each remote endpoint by 2/3.
+		addrs := sortedAddrs(<-mockGetServer.updatesReceived)
+		if len(addrs) != 4 {
+			t.Fatalf("Expected [4] addresses returned, got %v", addrs)
+		}
+		checkAddressAndWeight(t, addrs[0], addrA, defaultWeight*10*2)
+		checkAddressAndWeight(t, addrs[1], addrB1, defaultWeight*2/3)
+		checkAddressAndWeight(t, addrs[2], addrB2, defaultWeight*2/3)
+		checkAddressAndWeight(t, addrs[3], addrB3, defaultWeight*2/3)
+
+		// Removing a remote endpoint changes the zone sizes, so the weights of
+		// the remaining endpoints are sent again.
+		translator.Remove(mkAddressSetForServices(addrB3))
+
+		addrs = sortedAddrs(<-mockGetServer.updatesReceived)
+		if len(addrs) != 3 {
+			t.Fatalf("Expected [3] addresses returned, got %v", addrs)
+		}
+		checkAddressAndWeight(t, addrs[0], addrA, defaultWeight*10*3/2)
+		checkAddressAndWeight(t, addrs[1], addrB1, defaultWeight*3/4)
+		checkAddressAndWeight(t, addrs[2], addrB2, defaultWeight*3/4)
+
+		removed := (<-mockGetServer.updatesReceived).GetRemove().GetAddrs()
+		if len(removed) != 1 {
+			t.Fatalf("Expected [1] address removed, got %v", removed)
+		}
+	})
+}
+
 func TestEndpointTranslatorForLocalTrafficPolicy(t *testing.T) {
 	t.Run("Sends one update for add and none for remove", func(t *testing.T) {
 		mockGetServer, translator := makeEndpointTranslator(t)
@@ -842,6 +1087,356 @@ func TestEndpointTranslatorForLocalTrafficPolicy(t *testing.T) {
 			t.Fatalf("Expecting [%d] updates, got [%d].", expectedNumUpdates, expectedNumUpdates+len(mockGetServer.updatesReceived))
 		}
 	})
+
+	t.Run("Sends all endpoints with Cluster policy", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		noLocalBefore := counterValue(t, translator.noLocalEndpointsCounter)
+		translator.Start()
+		defer translator.Stop()
+		translator.Add(mkAddressSetForServices(AddressOnTest123Node, AddressNotOnTest123Node))
+
+		addrs := (<-mockGetServer.updatesReceived).GetAdd().GetAddrs()
+		if len(addrs) != 2 {
+			t.Fatalf("Expected [2] addresses returned, got %v", addrs)
+		}
+		if noLocal := counterValue(t, translator.noLocalEndpointsCounter) - noLocalBefore; noLocal != 0 {
+			t.Fatalf("Expected no NoEndpoints counted for lack of local endpoints, got %v", noLocal)
+		}
+	})
+
+	t.Run("Sends NoEndpoints when no endpoints are on the node", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		noLocalBefore := counterValue(t, translator.noLocalEndpointsCounter)
+		translator.Start()
+		defer translator.Stop()
+		addressSet := mkAddressSetForServices(AddressNotOnTest123Node)
+		addressSet.LocalTrafficPolicy = true
+		translator.Add(addressSet)
+
+		update := <-mockGetServer.updatesReceived
+		if !update.GetNoEndpoints().GetExists() {
+			t.Fatalf("Expected a NoEndpoints update for an existing service, got %v", update)
+		}
+		if noLocal := counterValue(t, translator.noLocalEndpointsCounter) - noLocalBefore; noLocal != 1 {
+			t.Fatalf("Expected 1 NoEndpoints counted for lack of local endpoints, got %v", noLocal)
+		}
+
+		localSet := mkAddressSetForServices(AddressOnTest123Node)
+		localSet.LocalTrafficPolicy = true
+		translator.Add(localSet)
+		if addrs := (<-mockGetServer.updatesReceived).GetAdd().GetAddrs(); len(addrs) != 1 {
+			t.Fatalf("Expected [1] address added once a local endpoint appears, got %v", addrs)
+		}
+	})
+
+	t.Run("Sends NoEndpoints when the last local endpoint goes away", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.Start()
+		defer translator.Stop()
+		addressSet := mkAddressSetForServices(AddressOnTest123Node, AddressNotOnTest123Node)
+		addressSet.LocalTrafficPolicy = true
+		translator.Add(addressSet)
+
+		if addrs := (<-mockGetServer.updatesReceived).GetAdd().GetAddrs(); len(addrs) != 1 {
+			t.Fatalf("Expected [1] address added, got %v", addrs)
+		}
+
+		translator.Remove(mkAddressSetForServices(AddressOnTest123Node))
+
+		if removed := (<-mockGetServer.updatesReceived).GetRemove().GetAddrs(); len(removed) != 1 {
+			t.Fatalf("Expected [1] address removed, got %v", removed)
+		}
+		update := <-mockGetServer.updatesReceived
+		if !update.GetNoEndpoints().GetExists() {
+			t.Fatalf("Expected a NoEndpoints update for an existing service, got %v", update)
+		}
+	})
+}
+
+func TestEndpointTranslatorStableEndpointOrder(t *testing.T) {
+	addresses := []watcher.Address{
+		{IP: "10.0.0.20", Port: 8080},
+		{IP: "10.0.0.3", Port: 8080},
+		{IP: "10.0.0.3", Port: 80},
+		{IP: "2001:db8::1", Port: 8080},
+		{IP: "10.0.1.1", Port: 8080},
+		{IP: "10.0.0.100", Port: 8080},
+	}
+	expected := []string{
+		"10.0.0.3:80",
+		"10.0.0.3:8080",
+		"10.0.0.20:8080",
+		"10.0.0.100:8080",
+		"10.0.1.1:8080",
+		"[2001:db8::1]:8080",
+	}
+
+	// The addresses set is a map, so translate it several times to make sure
+	// the order doesn't depend on map iteration.
+	for i := 0; i < 10; i++ {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.Start()
+
+		translator.Add(mkAddressSetForServices(addresses...))
+		added := []string{}
+		for _, wa := range (<-mockGetServer.updatesReceived).GetAdd().GetAddrs() {
+			added = append(added, addr.ProxyAddressToString(wa.GetAddr()))
+		}
+
+		translator.Remove(mkAddressSetForServices(addresses...))
+		removed := []string{}
+		for _, tcpAddr := range (<-mockGetServer.updatesReceived).GetRemove().GetAddrs() {
+			removed = append(removed, addr.ProxyAddressToString(tcpAddr))
+		}
+		translator.Stop()
+
+		if diff := deep.Equal(added, expected); diff != nil {
+			t.Fatalf("Unexpected order of added addresses: %v", diff)
+		}
+		if diff := deep.Equal(removed, expected); diff != nil {
+			t.Fatalf("Unexpected order of removed addresses: %v", diff)
+		}
+	}
+}
+
+func TestEndpointTranslatorMaxEndpointsPerUpdate(t *testing.T) {
+	addresses := []watcher.Address{}
+	for i := 0; i < 25; i++ {
+		addresses = append(addresses, watcher.Address{
+			IP:   fmt.Sprintf("10.0.0.%d", i),
+			Port: 8080,
+		})
+	}
+
+	t.Run("Sends a single update when unbounded", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(addresses...))
+
+		addrs := (<-mockGetServer.updatesReceived).GetAdd().GetAddrs()
+		if len(addrs) != len(addresses) {
+			t.Fatalf("Expected [%d] addresses in a single update, got [%d]", len(addresses), len(addrs))
+		}
+	})
+
+	t.Run("Splits large sets across several updates", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.maxEndpointsPerUpdate = 10
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(addresses...))
+
+		added := map[string]struct{}{}
+		for _, expected := range []int{10, 10, 5} {
+			addrs := (<-mockGetServer.updatesReceived).GetAdd().GetAddrs()
+			if len(addrs) != expected {
+				t.Fatalf("Expected [%d] addresses in update, got [%d]", expected, len(addrs))
+			}
+			for _, addr := range addrs {
+				added[addr.String()] = struct{}{}
+			}
+		}
+		if len(added) != len(addresses) {
+			t.Fatalf("Expected [%d] distinct addresses to be added, got [%d]", len(addresses), len(added))
+		}
+
+		translator.Remove(mkAddressSetForServices(addresses...))
+
+		removed := 0
+		for _, expected := range []int{10, 10, 5} {
+			addrs := (<-mockGetServer.updatesReceived).GetRemove().GetAddrs()
+			if len(addrs) != expected {
+				t.Fatalf("Expected [%d] addresses in update, got [%d]", expected, len(addrs))
+			}
+			removed += len(addrs)
+		}
+		if removed != len(addresses) {
+			t.Fatalf("Expected [%d] addresses to be removed, got [%d]", len(addresses), removed)
+		}
+
+		if len(mockGetServer.updatesReceived) != 0 {
+			t.Fatalf("Unexpected extra updates: %d", len(mockGetServer.updatesReceived))
+		}
+	})
+}
+
+func TestEndpointTranslatorNoEndpointsGracePeriod(t *testing.T) {
+	const gracePeriod = 100 * time.Millisecond
+
+	t.Run("Suppresses removal when endpoints reappear", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.noEndpointsGracePeriod = gracePeriod
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(remoteGateway1, remoteGateway2))
+		<-mockGetServer.updatesReceived // Add
+
+		translator.NoEndpoints(true)
+		translator.Add(mkAddressSetForServices(remoteGateway1, remoteGateway2))
+
+		select {
+		case update := <-mockGetServer.updatesReceived:
+			t.Fatalf("Unexpected update: %v", update)
+		case <-time.After(2 * gracePeriod):
+		}
+	})
+
+	t.Run("Only sends what changed when endpoints reappear", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.noEndpointsGracePeriod = gracePeriod
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(remoteGateway1, remoteGateway2))
+		<-mockGetServer.updatesReceived // Add
+
+		translator.NoEndpoints(true)
+		translator.Add(mkAddressSetForServices(remoteGateway1))
+
+		removed := (<-mockGetServer.updatesReceived).GetRemove().GetAddrs()
+		if len(removed) != 1 {
+			t.Fatalf("Expected [1] address to be removed, got %v", removed)
+		}
+		checkAddress(t, removed[0], remoteGateway2)
+	})
+
+	t.Run("Removes endpoints that are gone", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.noEndpointsGracePeriod = gracePeriod
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(remoteGateway1, remoteGateway2))
+		<-mockGetServer.updatesReceived // Add
+
+		start := time.Now()
+		translator.NoEndpoints(true)
+
+		removed := (<-mockGetServer.updatesReceived).GetRemove().GetAddrs()
+		if elapsed := time.Since(start); elapsed < gracePeriod {
+			t.Fatalf("Expected removal after the %s grace period, got it after %s", gracePeriod, elapsed)
+		}
+		if len(removed) != 2 {
+			t.Fatalf("Expected [2] addresses to be removed, got %v", removed)
+		}
+	})
+
+	t.Run("Removes endpoints immediately when the service is deleted", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.noEndpointsGracePeriod = time.Hour
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(remoteGateway1, remoteGateway2))
+		<-mockGetServer.updatesReceived // Add
+
+		translator.NoEndpoints(false)
+
+		select {
+		case update := <-mockGetServer.updatesReceived:
+			removed := update.GetRemove().GetAddrs()
+			if len(removed) != 2 {
+				t.Fatalf("Expected [2] addresses to be removed, got %v", removed)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for the endpoints of the deleted service to be removed")
+		}
+	})
+
+	t.Run("Removes endpoints immediately when disabled", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(remoteGateway1, remoteGateway2))
+		<-mockGetServer.updatesReceived // Add
+
+		translator.NoEndpoints(true)
+
+		removed := (<-mockGetServer.updatesReceived).GetRemove().GetAddrs()
+		if len(removed) != 2 {
+			t.Fatalf("Expected [2] addresses to be removed, got %v", removed)
+		}
+	})
+}
+
+func TestEndpointTranslatorInitialEmptyAdd(t *testing.T) {
+	t.Run("Sends an empty Add for an existing service without endpoints", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.initialEmptyAdd = true
+		translator.Start()
+		defer translator.Stop()
+
+		translator.NoEndpoints(true)
+		translator.Add(mkAddressSetForServices(remoteGateway1))
+
+		update := <-mockGetServer.updatesReceived
+		if update.GetAdd() == nil || len(update.GetAdd().GetAddrs()) != 0 {
+			t.Fatalf("Expected an empty Add, got %v", update)
+		}
+		update = <-mockGetServer.updatesReceived
+		if len(update.GetAdd().GetAddrs()) != 1 {
+			t.Fatalf("Expected an Add of a single address, got %v", update)
+		}
+		checkAddress(t, update.GetAdd().GetAddrs()[0].GetAddr(), remoteGateway1)
+	})
+
+	t.Run("Sends nothing for a service that doesn't exist", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.initialEmptyAdd = true
+		translator.Start()
+		defer translator.Stop()
+
+		translator.NoEndpoints(false)
+		translator.Add(mkAddressSetForServices(remoteGateway1))
+
+		update := <-mockGetServer.updatesReceived
+		if len(update.GetAdd().GetAddrs()) != 1 {
+			t.Fatalf("Expected an Add of a single address, got %v", update)
+		}
+	})
+
+	t.Run("Only sends an empty Add when the stream starts", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.initialEmptyAdd = true
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(remoteGateway1))
+		translator.NoEndpoints(true)
+
+		update := <-mockGetServer.updatesReceived
+		if len(update.GetAdd().GetAddrs()) != 1 {
+			t.Fatalf("Expected an Add of a single address, got %v", update)
+		}
+		update = <-mockGetServer.updatesReceived
+		if len(update.GetRemove().GetAddrs()) != 1 {
+			t.Fatalf("Expected a Remove of a single address, got %v", update)
+		}
+		select {
+		case update := <-mockGetServer.updatesReceived:
+			t.Fatalf("Unexpected update: %v", update)
+		case <-time.After(100 * time.Millisecond):
+		}
+	})
+
+	t.Run("Sends nothing for an existing service without endpoints by default", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.Start()
+		defer translator.Stop()
+
+		translator.NoEndpoints(true)
+		translator.Add(mkAddressSetForServices(remoteGateway1))
+
+		update := <-mockGetServer.updatesReceived
+		if len(update.GetAdd().GetAddrs()) != 1 {
+			t.Fatalf("Expected an Add of a single address, got %v", update)
+		}
+	})
 }
 
 // TestConcurrency, to be triggered with `go test -race`, shouldn't report a race condition
@@ -989,3 +1584,298 @@ func checkAddress(t *testing.T, actual *net.TcpAddress, expected watcher.Address
 		t.Fatalf("Expected port [%+v] but got [%+v]", expectedTCP.Port, actual.Port)
 	}
 }
+
+// slowGetStream is a Get stream standing in for a proxy that reads its
+// updates slower than they're produced: every Send blocks until the stream is
+// unblocked, and then takes latency on clock.
+type slowGetStream struct {
+	util.MockServerStream
+	sending   chan struct{}
+	unblocked chan struct{}
+	clock     *clocktesting.FakeClock
+	latency   time.Duration
+	sent      chan *pb.Update
+}
+
+func (s *slowGetStream) Send(update *pb.Update) error {
+	select {
+	case s.sending <- struct{}{}:
+	default:
+	}
+	<-s.unblocked
+	s.clock.Sleep(s.latency)
+	s.sent <- update
+	return nil
+}
+
+// backpressureHarness produces endpoint updates for a translator whose stream
+// is blocked, to check the backpressure contract of the translator: the
+// informer callbacks producing the updates never block, and a stream falling
+// more than updateQueueCapacity updates behind is aborted rather than left
+// with a partial view of the endpoints.
+//
+// The stream stays blocked until all the updates are produced, and the first
+// update is in flight in Send before the next ones are produced, so exactly
+// updateQueueCapacity+1 updates are accepted regardless of scheduling. Time
+// only passes on the translator's fake clock, when the harness steps it, so
+// whether a Send exceeds the send timeout doesn't depend on scheduling
+// either.
+type backpressureHarness struct {
+	// sendTimeout is the translator's send timeout, if any.
+	sendTimeout time.Duration
+	// sendLatency is how long each Send takes once the stream is unblocked.
+	sendLatency time.Duration
+	// updates is the number of updates produced while the stream is blocked,
+	// alternately adding and removing an endpoint.
+	updates int
+	// updateInterval is the delay between two produced updates.
+	updateInterval time.Duration
+}
+
+type backpressureResult struct {
+	// aborted is true if the translator ended the stream.
+	aborted bool
+	// overflows is the increase of the translator's overflow counter.
+	overflows float64
+	// sent is the number of updates sent once the stream was unblocked, if it
+	// wasn't aborted.
+	sent int
+}
+
+func (h backpressureHarness) run(t *testing.T) backpressureResult {
+	t.Helper()
+
+	clock := clocktesting.NewFakeClock(time.Now())
+	stream := &slowGetStream{
+		MockServerStream: util.NewMockServerStream(),
+		sending:          make(chan struct{}, 1),
+		unblocked:        make(chan struct{}),
+		clock:            clock,
+		latency:          h.sendLatency,
+		sent:             make(chan *pb.Update, h.updates),
+	}
+	endStream := newStreamEnd()
+	translator := newTestEndpointTranslator(t, stream, endStream)
+	translator.sendTimeout = h.sendTimeout
+	translator.clock = clock
+	translator.Start()
+	defer translator.Stop()
+
+	overflowsBefore := counterValue(t, translator.overflowCounter)
+	set := mkAddressSetForServices(west1aAddress)
+	for i := 0; i < h.updates; i++ {
+		if i%2 == 0 {
+			translator.Add(set)
+		} else {
+			translator.Remove(set)
+		}
+		if i == 0 {
+			select {
+			case <-stream.sending:
+			case <-time.After(5 * time.Second):
+				t.Fatal("Timed out waiting for the first update to be sent")
+			}
+		}
+		clock.Step(h.updateInterval)
+	}
+
+	result := backpressureResult{
+		overflows: counterValue(t, translator.overflowCounter) - overflowsBefore,
+	}
+	select {
+	case <-endStream.done():
+		result.aborted = true
+	default:
+	}
+
+	close(stream.unblocked)
+	if result.aborted {
+		return result
+	}
+	for result.sent < h.updates {
+		select {
+		case <-stream.sent:
+			result.sent++
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for updates; got %d of %d", result.sent, h.updates)
+		}
+		// A Send exceeding the send timeout aborts the stream as the clock is
+		// stepped, before the update is reported as sent.
+		select {
+		case <-endStream.done():
+			result.aborted = true
+			return result
+		default:
+		}
+	}
+	return result
+}
+
+func counterValue(t *testing.T, counter prometheus.Counter) float64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := counter.Write(&metric); err != nil {
+		t.Fatalf("Failed to read counter: %s", err)
+	}
+	return metric.GetCounter().GetValue()
+}
+
+func TestEndpointTranslatorBackpressure(t *testing.T) {
+	testCases := []struct {
+		name     string
+		harness  backpressureHarness
+		expected backpressureResult
+	}{
+		{
+			name: "slow stream catching up within the queue capacity",
+			harness: backpressureHarness{
+				sendLatency: time.Millisecond,
+				updates:     updateQueueCapacity + 1,
+			},
+			expected: backpressureResult{sent: updateQueueCapacity + 1},
+		},
+		{
+			name: "paced updates to a slow stream",
+			harness: backpressureHarness{
+				sendLatency:    time.Millisecond,
+				updates:        updateQueueCapacity / 2,
+				updateInterval: time.Millisecond,
+			},
+			expected: backpressureResult{sent: updateQueueCapacity / 2},
+		},
+		{
+			name: "send exceeding the timeout aborts the stream",
+			harness: backpressureHarness{
+				sendTimeout: time.Second,
+				sendLatency: time.Second,
+				updates:     2,
+			},
+			expected: backpressureResult{aborted: true, sent: 1},
+		},
+		{
+			name: "slow sends within the timeout are all delivered",
+			harness: backpressureHarness{
+				sendTimeout: time.Second,
+				sendLatency: time.Second - time.Millisecond,
+				updates:     2,
+			},
+			expected: backpressureResult{sent: 2},
+		},
+		{
+			name: "slow sends aren't timed out by default",
+			harness: backpressureHarness{
+				sendLatency: time.Minute,
+				updates:     2,
+			},
+			expected: backpressureResult{sent: 2},
+		},
+		{
+			name: "stream falling behind the queue capacity is aborted",
+			harness: backpressureHarness{
+				updates: updateQueueCapacity + 2,
+			},
+			expected: backpressureResult{aborted: true, overflows: 1},
+		},
+		{
+			name: "stream is aborted once however far behind it falls",
+			harness: backpressureHarness{
+				updates: 2*updateQueueCapacity + 2,
+			},
+			expected: backpressureResult{aborted: true, overflows: updateQueueCapacity + 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			result := tc.harness.run(t)
+			if result != tc.expected {
+				t.Fatalf("Expected %+v, got %+v", tc.expected, result)
+			}
+		})
+	}
+}
+
+func TestEndpointTranslatorFilteredEndpointsCounter(t *testing.T) {
+	dualStack := mkAddressSetForPods(t, pod1, pod1IPv6)
+	localTraffic := mkAddressSetForServices(AddressOnTest123Node, AddressNotOnTest123Node)
+	localTraffic.LocalTrafficPolicy = true
+
+	testCases := []struct {
+		name     string
+		set      watcher.AddressSet
+		expected map[string]float64
+	}{
+		{
+			name: "endpoints on other nodes with internalTrafficPolicy: Local",
+			set:  localTraffic,
+			expected: map[string]float64{
+				filterReasonNode: 1,
+			},
+		},
+		{
+			name: "endpoints hinted for other zones",
+			set:  mkAddressSetForServices(west1aAddress, west1bAddress),
+			expected: map[string]float64{
+				filterReasonZone: 1,
+			},
+		},
+		{
+			name: "IPv4 endpoints with an IPv6 alternative",
+			set:  dualStack,
+			expected: map[string]float64{
+				filterReasonAddressFamily: 1,
+			},
+		},
+		{
+			name: "no filtered endpoints",
+			set:  mkAddressSetForServices(AddressOnTest123Node, AddressNotOnTest123Node),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			mockGetServer, translator := makeEndpointTranslator(t)
+
+			reasons := []string{filterReasonNode, filterReasonZone, filterReasonAddressFamily}
+			before := make(map[string]float64)
+			for _, reason := range reasons {
+				before[reason] = counterValue(t, translator.filteredCounter.With(prometheus.Labels{"reason": reason}))
+			}
+
+			translator.Start()
+			defer translator.Stop()
+			translator.Add(tc.set)
+			<-mockGetServer.updatesReceived // Add
+
+			for _, reason := range reasons {
+				filtered := counterValue(t, translator.filteredCounter.With(prometheus.Labels{"reason": reason})) - before[reason]
+				if filtered != tc.expected[reason] {
+					t.Fatalf("Expected %v endpoints filtered for reason %s, got %v", tc.expected[reason], reason, filtered)
+				}
+			}
+		})
+	}
+
+	t.Run("endpoints still filtered out aren't counted again", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		counter := endpointsFilteredCounter.With(prometheus.Labels{
+			"service": "service-name.service-ns",
+			"port":    "80",
+			"reason":  filterReasonAddressFamily,
+		})
+		before := counterValue(t, counter)
+
+		translator.Start()
+		defer translator.Stop()
+		translator.Add(dualStack)
+		<-mockGetServer.updatesReceived // Add
+		translator.Add(mkAddressSetForPods(t, pod2))
+		<-mockGetServer.updatesReceived // Add
+
+		if filtered := counterValue(t, counter) - before; filtered != 1 {
+			t.Fatalf("Expected 1 endpoint filtered for reason %s, got %v", filterReasonAddressFamily, filtered)
+		}
+	})
+}
@@ -43,8 +43,11 @@ const (
 	// EndpointSlice objects
 	managedBy = "linkerd-external-workloads-controller"
 
-	// Max number of endpoints per EndpointSlice
-	maxEndpointsQuota = 100
+	// Default max number of endpoints per EndpointSlice
+	DefaultMaxEndpointsPerSlice = 100
+
+	// Max number of endpoints the API Server accepts in an EndpointSlice
+	maxEndpointsPerSliceLimit = 1000
 
 	// Max retries for a service to be reconciled
 	maxRetryBudget = 15
@@ -92,8 +95,14 @@ type informerHandlers struct {
 // different to that of a Pod (e.g. a workload is long lived).
 //
 // NewEndpointsController creates a new controller. The controller must be
-// started with its `Start()` method.
-func NewEndpointsController(k8sAPI *k8s.API, hostname, controllerNs string, stopCh chan struct{}, exportQueueMetrics bool) (*EndpointsController, error) {
+// started with its `Start()` method. Services selecting more than
+// maxEndpointsPerSlice workloads have their endpoints spread across multiple
+// EndpointSlices.
+func NewEndpointsController(k8sAPI *k8s.API, hostname, controllerNs string, stopCh chan struct{}, exportQueueMetrics bool, maxEndpointsPerSlice int) (*EndpointsController, error) {
+	if maxEndpointsPerSlice < 1 || maxEndpointsPerSlice > maxEndpointsPerSliceLimit {
+		return nil, fmt.Errorf("max endpoints per EndpointSlice must be between 1 and %d, got %d", maxEndpointsPerSliceLimit, maxEndpointsPerSlice)
+	}
+
 	queueName := "endpoints_controller_workqueue"
 	workQueueConfig := workqueue.TypedRateLimitingQueueConfig[string]{
 		Name: queueName,
@@ -108,7 +117,7 @@ func NewEndpointsController(k8sAPI *k8s.API, hostname, controllerNs string, stop
 
 	ec := &EndpointsController{
 		k8sAPI:     k8sAPI,
-		reconciler: newEndpointsReconciler(k8sAPI, managedBy, maxEndpointsQuota),
+		reconciler: newEndpointsReconciler(k8sAPI, managedBy, maxEndpointsPerSlice),
 		queue:      workqueue.NewTypedRateLimitingQueueWithConfig[string](workqueue.DefaultTypedControllerRateLimiter[string](), workQueueConfig),
 		stop:       stopCh,
 		log: logging.WithFields(logging.Fields{
@@ -330,6 +339,9 @@ func (ec *EndpointsController) handleError(err error, key string) {
 	}
 
 	if ec.queue.NumRequeues(key) < maxRetryBudget {
+		if isWriteConflict(err) {
+			endpointSliceConflictRetries.Inc()
+		}
 		ec.queue.AddRateLimited(key)
 		return
 	}
@@ -2,6 +2,7 @@ package externalworkload
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"sort"
@@ -10,11 +11,14 @@ import (
 
 	ewv1beta1 "github.com/linkerd/linkerd2/controller/gen/apis/externalworkload/v1beta1"
 	"github.com/linkerd/linkerd2/controller/k8s"
+	dto "github.com/prometheus/client_model/go"
 	v1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
@@ -36,7 +40,7 @@ func newController(t *testing.T) (*k8s.API, func() []k8stesting.Action, *endpoin
 		t.Fatalf("unexpected error %v", err)
 	}
 
-	esController, err := NewEndpointsController(k8sAPI, "hostname", "linkerd", make(chan struct{}), false)
+	esController, err := NewEndpointsController(k8sAPI, "hostname", "linkerd", make(chan struct{}), false, DefaultMaxEndpointsPerSlice)
 	if err != nil {
 		t.Fatalf("unexpected error %v", err)
 	}
@@ -1215,7 +1219,7 @@ func TestWorkloadServicesToUpdate(t *testing.T) {
 				t.Fatalf("unexpected error %v", err)
 			}
 
-			ec, err := NewEndpointsController(k8sAPI, "my-hostname", "controlplane-ns", make(chan struct{}), false)
+			ec, err := NewEndpointsController(k8sAPI, "my-hostname", "controlplane-ns", make(chan struct{}), false, DefaultMaxEndpointsPerSlice)
 			if err != nil {
 				t.Fatalf("unexpected error %v", err)
 			}
@@ -1386,3 +1390,62 @@ func standardSyncService(t *testing.T, esController *endpointSliceController, na
 		t.Error("Expected no error syncing service")
 	}
 }
+
+func TestHandleErrorCountsConflictRetries(t *testing.T) {
+	_, _, esController := newController(t)
+
+	retries := func() float64 {
+		var m dto.Metric
+		if err := endpointSliceConflictRetries.Write(&m); err != nil {
+			t.Fatalf("failed to read metric: %v", err)
+		}
+		return m.GetCounter().GetValue()
+	}
+
+	before := retries()
+	conflict := kerrors.NewConflict(discoveryv1.Resource("endpointslices"), "linkerd-external-svc-abc", errors.New("the object has been modified"))
+	esController.handleError(utilerrors.NewAggregate([]error{conflict}), "ns/svc")
+	if count := retries(); count != before+1 {
+		t.Fatalf("expected %v conflict retries, got %v", before+1, count)
+	}
+
+	esController.handleError(errors.New("connection refused"), "ns/svc")
+	if count := retries(); count != before+1 {
+		t.Fatalf("expected other errors not to be counted as conflict retries, got %v", count)
+	}
+}
+
+func TestNewEndpointsControllerMaxEndpointsPerSlice(t *testing.T) {
+	for _, tc := range []struct {
+		name                 string
+		maxEndpointsPerSlice int
+		expectErr            bool
+	}{
+		{"default", DefaultMaxEndpointsPerSlice, false},
+		{"API Server limit", maxEndpointsPerSliceLimit, false},
+		{"above API Server limit", maxEndpointsPerSliceLimit + 1, true},
+		{"zero", 0, true},
+	} {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			k8sAPI, err := k8s.NewFakeAPI()
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			ec, err := NewEndpointsController(k8sAPI, "hostname", "linkerd", make(chan struct{}), false, tc.maxEndpointsPerSlice)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error for %d max endpoints per slice", tc.maxEndpointsPerSlice)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if ec.reconciler.maxEndpoints != tc.maxEndpointsPerSlice {
+				t.Fatalf("expected the reconciler to write at most %d endpoints per slice, got %d", tc.maxEndpointsPerSlice, ec.reconciler.maxEndpoints)
+			}
+		})
+	}
+}
@@ -108,6 +108,7 @@ func (r *endpointsReconciler) reconcile(svc *corev1.Service, ews []*ewv1beta1.Ex
 	for _, slice := range toDelete {
 		err := r.k8sAPI.Client.DiscoveryV1().EndpointSlices(svc.Namespace).Delete(context.TODO(), slice.Name, metav1.DeleteOptions{})
 		if err != nil {
+			countWriteConflict("delete", err)
 			errs = append(errs, err)
 		}
 	}
@@ -388,6 +389,7 @@ func (r *endpointsReconciler) finalize(svc *corev1.Service, slicesToCreate, slic
 			r.log.Tracef("starting create: %s/%s", slice.Namespace, slice.Name)
 			createdSlice, err := r.k8sAPI.Client.DiscoveryV1().EndpointSlices(svc.Namespace).Create(context.TODO(), slice, metav1.CreateOptions{})
 			if err != nil {
+				countWriteConflict("create", err)
 				// If the namespace  is terminating, operations will not
 				// succeed. Drop the entire reconiliation effort
 				if errors.HasStatusCause(err, corev1.NamespaceTerminatingCause) {
@@ -405,6 +407,7 @@ func (r *endpointsReconciler) finalize(svc *corev1.Service, slicesToCreate, slic
 		r.log.Tracef("starting update: %s/%s", slice.Namespace, slice.Name)
 		updatedSlice, err := r.k8sAPI.Client.DiscoveryV1().EndpointSlices(svc.Namespace).Update(context.TODO(), slice, metav1.UpdateOptions{})
 		if err != nil {
+			countWriteConflict("update", err)
 			return err
 		}
 		r.endpointTracker.Update(updatedSlice)
@@ -415,6 +418,7 @@ func (r *endpointsReconciler) finalize(svc *corev1.Service, slicesToCreate, slic
 		r.log.Tracef("starting delete: %s/%s", slice.Namespace, slice.Name)
 		err := r.k8sAPI.Client.DiscoveryV1().EndpointSlices(svc.Namespace).Delete(context.TODO(), slice.Name, metav1.DeleteOptions{})
 		if err != nil {
+			countWriteConflict("delete", err)
 			return err
 		}
 		r.endpointTracker.ExpectDeletion(slice)
@@ -2,6 +2,7 @@ package externalworkload
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -9,13 +10,16 @@ import (
 
 	ewv1beta1 "github.com/linkerd/linkerd2/controller/gen/apis/externalworkload/v1beta1"
 	"github.com/linkerd/linkerd2/controller/k8s"
+	dto "github.com/prometheus/client_model/go"
 
 	corev1 "k8s.io/api/core/v1"
 	discoveryv1 "k8s.io/api/discovery/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
 	k8stesting "k8s.io/client-go/testing"
 	epsliceutil "k8s.io/endpointslice/util"
 
@@ -337,6 +341,44 @@ func TestReconcilerUpdatesEndpointSliceInPlace(t *testing.T) {
 	}
 }
 
+// Test that a conflict when writing an endpointslice is surfaced and counted
+func TestReconcilerCountsWriteConflicts(t *testing.T) {
+	svc := makeService("test-svc", []corev1.IPFamily{corev1.IPv4Protocol}, map[string]string{"app": "test"}, []corev1.ServicePort{httpUnnamedPort}, "")
+	ew := makeExternalWorkload("1", "wlkd-1", map[string]string{"app": "test"}, map[int32]string{8080: ""}, []string{"192.0.2.1"})
+
+	port := int32(8080)
+	es := makeEndpointSlice(svc, discoveryv1.AddressTypeIPv4, []discoveryv1.EndpointPort{{Port: &port}})
+	es.Generation = 1
+
+	k8sAPI, err := k8s.NewFakeAPI(endpointSliceAsYaml(t, es))
+	if err != nil {
+		t.Fatalf("unexpected error when creating Kubernetes clientset: %v", err)
+	}
+	// Simulate another writer having updated the slice since it was read
+	k8sAPI.Client.(*fake.Clientset).PrependReactor("update", "endpointslices", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, kerrors.NewConflict(discoveryv1.Resource("endpointslices"), es.Name, errors.New("the object has been modified"))
+	})
+
+	before := writeConflictsCount(t, "update")
+	r := newEndpointsReconciler(k8sAPI, testControllerName, defaultTestEndpointsQuota)
+	err = r.reconcile(svc, []*ewv1beta1.ExternalWorkload{ew}, []*discoveryv1.EndpointSlice{es})
+	if !isWriteConflict(err) {
+		t.Fatalf("expected a conflict error when reconciling endpoints, got %v", err)
+	}
+	if count := writeConflictsCount(t, "update"); count != before+1 {
+		t.Fatalf("expected %v update conflicts, got %v", before+1, count)
+	}
+}
+
+func writeConflictsCount(t *testing.T, operation string) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := endpointSliceWriteConflicts.WithLabelValues(operation).Write(&m); err != nil {
+		t.Fatalf("failed to read metric: %v", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
 // === Test ports ===
 
 // A named port on a service can target a different port on a workload
@@ -415,6 +457,46 @@ func TestReconcileManyWorkloads(t *testing.T) {
 	expectSlicesWithLengths(t, []int{100, 100, 50}, slices)
 }
 
+// Test that workloads beyond the API Server's limit of endpoints per slice are
+// sharded across multiple endpointslices, and that every workload is written
+// exactly once as the set grows.
+func TestReconcileWorkloadsAboveSliceLimit(t *testing.T) {
+	svc := makeService("test-svc", []corev1.IPFamily{corev1.IPv4Protocol}, map[string]string{"app": "test"}, []corev1.ServicePort{httpUnnamedPort}, "10.0.2.1")
+	ews := []*ewv1beta1.ExternalWorkload{}
+	for i := 0; i < 2500; i++ {
+		ews = append(ews, makeExternalWorkload("1", fmt.Sprintf("wlkd-%d", i), map[string]string{"app": "test"}, map[int32]string{8080: ""}, []string{fmt.Sprintf("10.1.%d.%d", i/250, i%250)}))
+	}
+
+	k8sAPI, actions := newClientset(t, []string{})
+	r := newEndpointsReconciler(k8sAPI, testControllerName, maxEndpointsPerSliceLimit)
+	if err := r.reconcile(svc, ews, []*discoveryv1.EndpointSlice{}); err != nil {
+		t.Fatalf("unexpected error when reconciling endpoints: %v", err)
+	}
+	expectActions(t, actions(), 3, "create", "endpointslices")
+
+	slices := fetchEndpointSlices(t, k8sAPI, svc)
+	expectSlicesWithLengths(t, []int{1000, 1000, 500}, slices)
+	expectWorkloadsInSlices(t, ews, slices)
+
+	// Add 600 workloads; they do not fit in the partially filled slice so a
+	// new one is created, leaving the existing ones untouched
+	for i := 2500; i < 3100; i++ {
+		ews = append(ews, makeExternalWorkload("1", fmt.Sprintf("wlkd-%d", i), map[string]string{"app": "test"}, map[int32]string{8080: ""}, []string{fmt.Sprintf("10.1.%d.%d", i/250, i%250)}))
+	}
+	existingSlices := []*discoveryv1.EndpointSlice{}
+	for i := range slices {
+		existingSlices = append(existingSlices, &slices[i])
+	}
+	if err := r.reconcile(svc, ews, existingSlices); err != nil {
+		t.Fatalf("unexpected error when reconciling endpoints: %v", err)
+	}
+	expectActions(t, actions(), 1, "create", "endpointslices")
+
+	slices = fetchEndpointSlices(t, k8sAPI, svc)
+	expectSlicesWithLengths(t, []int{1000, 1000, 500, 600}, slices)
+	expectWorkloadsInSlices(t, ews, slices)
+}
+
 // Test with preexisting slices. 250 pods matching a service:
 // * First es: 62 endpoints (all desired)
 // * Second es: 61 endpoints (all desired)
@@ -727,6 +809,30 @@ func expectSlicesWithLengths(t *testing.T, expectedLengths []int, es []discovery
 	}
 }
 
+// expectWorkloadsInSlices checks that each workload's address is found in
+// exactly one endpoint across all the slices, and that there are no others.
+func expectWorkloadsInSlices(t *testing.T, ews []*ewv1beta1.ExternalWorkload, es []discoveryv1.EndpointSlice) {
+	t.Helper()
+	seen := map[string]int{}
+	for _, slice := range es {
+		for _, ep := range slice.Endpoints {
+			for _, addr := range ep.Addresses {
+				seen[addr]++
+			}
+		}
+	}
+
+	if len(seen) != len(ews) {
+		t.Fatalf("expected %d addresses across endpointslices, got %d", len(ews), len(seen))
+	}
+	for _, ew := range ews {
+		addr := ew.Spec.WorkloadIPs[0].Ip
+		if seen[addr] != 1 {
+			t.Fatalf("expected address %s of workload %s to be found once across endpointslices, found %d times", addr, ew.Name, seen[addr])
+		}
+	}
+}
+
 func diffEndpointSlicePorts(t *testing.T, expected, actual []discoveryv1.EndpointSlice) {
 	t.Helper()
 	if len(expected) != len(actual) {
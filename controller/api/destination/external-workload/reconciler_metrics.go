@@ -0,0 +1,47 @@
+package externalworkload
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+var (
+	endpointSliceWriteConflicts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "external_workload_endpointslice_write_conflicts_total",
+		Help: "Total number of EndpointSlice writes by the external workload controller rejected by the API Server with a conflict (409).",
+	}, []string{"operation"})
+
+	endpointSliceConflictRetries = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "external_workload_endpointslice_conflict_retries_total",
+		Help: "Total number of Services requeued by the external workload controller after their EndpointSlices couldn't be written due to a conflict.",
+	})
+)
+
+// countWriteConflict increments the conflicts metric for operation if err
+// means the API Server rejected the write with a conflict, typically because
+// the slice was written by someone else (e.g. another replica that considers
+// itself the leader) since it was read.
+func countWriteConflict(operation string, err error) {
+	if isWriteConflict(err) {
+		endpointSliceWriteConflicts.WithLabelValues(operation).Inc()
+	}
+}
+
+// isWriteConflict returns true if err, or any of the errors it aggregates, is
+// a 409 returned by the API Server.
+func isWriteConflict(err error) bool {
+	var agg utilerrors.Aggregate
+	if errors.As(err, &agg) {
+		for _, err := range agg.Errors() {
+			if isWriteConflict(err) {
+				return true
+			}
+		}
+		return false
+	}
+	return kerrors.IsConflict(err) || kerrors.IsAlreadyExists(err)
+}
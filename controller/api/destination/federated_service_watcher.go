@@ -3,6 +3,7 @@ package destination
 import (
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
 
@@ -46,6 +47,10 @@ type federatedService struct {
 	remoteDiscovery []remoteDiscoveryID
 	subscribers     []federatedServiceSubscriber
 
+	// failoverThreshold is the number of local endpoints below which the
+	// remote endpoints are included. Zero always includes them.
+	failoverThreshold int
+
 	metadataAPI    *k8s.MetadataAPI
 	config         *Config
 	localEndpoints *watcher.EndpointsWatcher
@@ -66,7 +71,20 @@ type federatedServiceSubscriber struct {
 	remoteTranslators map[remoteDiscoveryID]*endpointTranslator
 
 	stream    *synchronizedGetStream
-	endStream chan struct{}
+	endStream *streamEnd
+
+	failover *federatedFailover
+}
+
+// federatedFailover tracks whether a subscriber's remote endpoints are held
+// back in favor of its local ones. It's guarded by the federatedService lock.
+type federatedFailover struct {
+	// localEndpoints is the number of local endpoints last sent to the
+	// subscriber, which is unknown until the local translator reports it.
+	localEndpoints int
+	localKnown     bool
+
+	standby bool
 }
 
 func newFederatedServiceWatcher(
@@ -108,7 +126,7 @@ func (fsw *federatedServiceWatcher) Subscribe(
 	nodeName string,
 	instanceID string,
 	stream pb.Destination_GetServer,
-	endStream chan struct{},
+	endStream *streamEnd,
 ) error {
 	id := watcher.ServiceID{Namespace: namespace, Name: service}
 	fsw.RLock()
@@ -214,6 +232,8 @@ func (fsw *federatedServiceWatcher) newFederatedService(service *corev1.Service)
 		remoteDiscovery: remoteDiscoveryIDs(service, fsw.log),
 		subscribers:     []federatedServiceSubscriber{},
 
+		failoverThreshold: failoverThreshold(service, fsw.log),
+
 		metadataAPI:    fsw.metadataAPI,
 		config:         fsw.config,
 		localEndpoints: fsw.localEndpoints,
@@ -259,6 +279,11 @@ func (fs *federatedService) update(service *corev1.Service) {
 		}
 	}
 	fs.localDiscovery = newLocalDiscovery
+
+	fs.failoverThreshold = failoverThreshold(service, fs.log)
+	for i := range fs.subscribers {
+		fs.updateFailover(&fs.subscribers[i])
+	}
 }
 
 func (fs *federatedService) delete() {
@@ -279,7 +304,7 @@ func (fs *federatedService) delete() {
 			fs.localEndpoints.Unsubscribe(watcher.ServiceID{Namespace: fs.namespace, Name: localDiscovery}, subscriber.port, subscriber.instanceID, translator)
 			translator.Stop()
 		}
-		close(subscriber.endStream)
+		subscriber.endStream.end()
 	}
 }
 
@@ -288,7 +313,7 @@ func (fs *federatedService) subscribe(
 	nodeName string,
 	instanceID string,
 	stream pb.Destination_GetServer,
-	endStream chan struct{},
+	endStream *streamEnd,
 ) {
 	fs.Lock()
 	defer fs.Unlock()
@@ -304,7 +329,11 @@ func (fs *federatedService) subscribe(
 		port:              port,
 		nodeName:          nodeName,
 		instanceID:        instanceID,
+		failover:          &federatedFailover{},
 	}
+	// Until the local endpoints are known, the remote ones are held back if
+	// they may turn out not to be needed, so that they don't flap.
+	subscriber.failover.standby = fs.failoverThreshold > 0 && fs.localDiscovery != ""
 	for _, id := range fs.remoteDiscovery {
 		fs.remoteDiscoverySubscribe(&subscriber, id)
 	}
@@ -355,8 +384,14 @@ func (fs *federatedService) remoteDiscoverySubscribe(
 		false, // Disable endpoint filtering for remote discovery.
 		fs.config.EnableIPv6,
 		fs.config.ExtEndpointZoneWeights,
+		fs.config.NormalizeEndpointZoneWeights,
+		fs.config.StableEndpointOrder,
+		fs.config.InitialEmptyAdd,
 		fs.config.MeshedHttp2ClientParams,
+		fs.config.MaxEndpointsPerUpdate,
+		fs.config.NoEndpointsGracePeriod,
 		fmt.Sprintf("%s.%s.svc.%s:%d", id.service, fs.namespace, remoteConfig.ClusterDomain, subscriber.port),
+		subscriber.port,
 		subscriber.nodeName,
 		fs.config.DefaultOpaquePorts,
 		fs.metadataAPI,
@@ -364,6 +399,7 @@ func (fs *federatedService) remoteDiscoverySubscribe(
 		subscriber.endStream,
 		fs.log,
 	)
+	translator.standby = subscriber.failover.standby
 	translator.Start()
 	subscriber.remoteTranslators[id] = translator
 
@@ -403,8 +439,14 @@ func (fs *federatedService) localDiscoverySubscribe(
 		true,
 		fs.config.EnableIPv6,
 		fs.config.ExtEndpointZoneWeights,
+		fs.config.NormalizeEndpointZoneWeights,
+		fs.config.StableEndpointOrder,
+		fs.config.InitialEmptyAdd,
 		fs.config.MeshedHttp2ClientParams,
+		fs.config.MaxEndpointsPerUpdate,
+		fs.config.NoEndpointsGracePeriod,
 		localDiscovery,
+		subscriber.port,
 		subscriber.nodeName,
 		fs.config.DefaultOpaquePorts,
 		fs.metadataAPI,
@@ -412,6 +454,10 @@ func (fs *federatedService) localDiscoverySubscribe(
 		subscriber.endStream,
 		fs.log,
 	)
+	failover := subscriber.failover
+	translator.endpointsObserver = func(count int) {
+		fs.localEndpointsChanged(failover, translator, count)
+	}
 	translator.Start()
 	subscriber.localTranslators[localDiscovery] = translator
 
@@ -419,6 +465,10 @@ func (fs *federatedService) localDiscoverySubscribe(
 	err := fs.localEndpoints.Subscribe(watcher.ServiceID{Namespace: fs.namespace, Name: localDiscovery}, subscriber.port, subscriber.instanceID, translator)
 	if err != nil {
 		fs.log.Errorf("Failed to subscribe to %s: %s", localDiscovery, err)
+		// There won't be any local endpoints to prefer.
+		subscriber.failover.localEndpoints = 0
+		subscriber.failover.localKnown = true
+		fs.updateFailover(subscriber)
 	}
 }
 
@@ -433,6 +483,66 @@ func (fs *federatedService) localDiscoveryUnsubscribe(
 		translator.NoEndpoints(true)
 		translator.DrainAndStop()
 		delete(subscriber.localTranslators, localDiscovery)
+		subscriber.failover.localEndpoints = 0
+		subscriber.failover.localKnown = false
+	}
+}
+
+// localEndpointsChanged is called by the local translator of the subscriber
+// tracked by failover, with the number of local endpoints sent to it.
+func (fs *federatedService) localEndpointsChanged(
+	failover *federatedFailover,
+	translator *endpointTranslator,
+	count int,
+) {
+	fs.Lock()
+	defer fs.Unlock()
+
+	for i := range fs.subscribers {
+		subscriber := &fs.subscribers[i]
+		if subscriber.failover != failover {
+			continue
+		}
+		for _, localTranslator := range subscriber.localTranslators {
+			// Reports from translators being drained are ignored.
+			if localTranslator == translator {
+				failover.localEndpoints = count
+				failover.localKnown = true
+				fs.updateFailover(subscriber)
+			}
+		}
+		return
+	}
+}
+
+// wantsStandby returns whether the subscriber's remote endpoints should be
+// held back, because it has enough local endpoints.
+func (fs *federatedService) wantsStandby(subscriber *federatedServiceSubscriber) bool {
+	if fs.failoverThreshold == 0 || fs.localDiscovery == "" {
+		return false
+	}
+	if !subscriber.failover.localKnown {
+		// Keep things as they are until the local endpoints are known.
+		return subscriber.failover.standby
+	}
+	return subscriber.failover.localEndpoints >= fs.failoverThreshold
+}
+
+// updateFailover includes or holds back the subscriber's remote endpoints,
+// according to its number of local endpoints.
+func (fs *federatedService) updateFailover(subscriber *federatedServiceSubscriber) {
+	standby := fs.wantsStandby(subscriber)
+	if standby == subscriber.failover.standby {
+		return
+	}
+	if standby {
+		fs.log.Debugf("%d local endpoints; holding back remote endpoints", subscriber.failover.localEndpoints)
+	} else {
+		fs.log.Debugf("%d local endpoints (threshold %d); including remote endpoints", subscriber.failover.localEndpoints, fs.failoverThreshold)
+	}
+	subscriber.failover.standby = standby
+	for _, translator := range subscriber.remoteTranslators {
+		translator.setStandby(standby)
 	}
 }
 
@@ -463,6 +573,21 @@ func remoteDiscoveryIDs(service *corev1.Service, log *logging.Entry) []remoteDis
 	return ids
 }
 
+// failoverThreshold returns the remote discovery failover threshold set on
+// the service, or 0 if there is none.
+func failoverThreshold(service *corev1.Service, log *logging.Entry) int {
+	value, ok := service.Annotations[labels.RemoteDiscoveryFailoverThresholdAnnotation]
+	if !ok {
+		return 0
+	}
+	threshold, err := strconv.Atoi(value)
+	if err != nil || threshold < 0 {
+		log.Errorf("Invalid remote discovery failover threshold '%s'; remote endpoints will always be included", value)
+		return 0
+	}
+	return threshold
+}
+
 func isFederatedService(service *corev1.Service) bool {
 	_, localDiscoveryFound := service.Annotations[labels.LocalDiscoveryAnnotation]
 	_, remoteDiscoveryFound := service.Annotations[labels.RemoteDiscoveryAnnotation]
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"slices"
 	"testing"
+	"time"
 
 	logging "github.com/sirupsen/logrus"
 
@@ -122,6 +123,108 @@ func TestLocalLeaveFederatedService(t *testing.T) {
 	assertUpdatesContains(t, updates, "bb-west-1", "172.17.0.1:8080")
 }
 
+func TestFederatedServiceFailover(t *testing.T) {
+	t.Run("Holds back remote endpoints while local endpoints are healthy", func(t *testing.T) {
+		fsw, err := mockFederatedServiceWatcher(t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		annotateFederatedService(t, fsw, map[string]string{
+			"multicluster.linkerd.io/remote-discovery-failover-threshold": "1",
+		})
+
+		mockGetServer := &mockDestinationGetServer{updatesReceived: make(chan *pb.Update, 50)}
+
+		fsw.Subscribe("bb-federated", "test", 8080, "node", "", mockGetServer, nil)
+
+		updates := []*pb.Update{<-mockGetServer.updatesReceived}
+		assertUpdatesContains(t, updates, "bb-west-1", "172.17.0.1:8080")
+		assertNoUpdate(t, mockGetServer)
+
+		// Removing the threshold includes the remote endpoints.
+		annotateFederatedService(t, fsw, map[string]string{
+			"multicluster.linkerd.io/remote-discovery-failover-threshold": "0",
+		})
+		updates = append(updates, <-mockGetServer.updatesReceived)
+		assertUpdatesContains(t, updates, "bb-east-1", "172.17.1.1:8080")
+	})
+
+	t.Run("Includes remote endpoints while local endpoints are degraded", func(t *testing.T) {
+		fsw, err := mockFederatedServiceWatcher(t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		annotateFederatedService(t, fsw, map[string]string{
+			"multicluster.linkerd.io/remote-discovery-failover-threshold": "2",
+		})
+
+		mockGetServer := &mockDestinationGetServer{updatesReceived: make(chan *pb.Update, 50)}
+
+		fsw.Subscribe("bb-federated", "test", 8080, "node", "", mockGetServer, nil)
+
+		updates := []*pb.Update{}
+		updates = append(updates, <-mockGetServer.updatesReceived)
+		updates = append(updates, <-mockGetServer.updatesReceived)
+		assertUpdatesContains(t, updates, "bb-west-1", "172.17.0.1:8080")
+		assertUpdatesContains(t, updates, "bb-east-1", "172.17.1.1:8080")
+		assertNoUpdate(t, mockGetServer)
+	})
+
+	t.Run("Fails over to remote endpoints when local endpoints are gone", func(t *testing.T) {
+		fsw, err := mockFederatedServiceWatcher(t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		annotateFederatedService(t, fsw, map[string]string{
+			"multicluster.linkerd.io/remote-discovery-failover-threshold": "1",
+		})
+
+		mockGetServer := &mockDestinationGetServer{updatesReceived: make(chan *pb.Update, 50)}
+
+		fsw.Subscribe("bb-federated", "test", 8080, "node", "", mockGetServer, nil)
+
+		updates := []*pb.Update{<-mockGetServer.updatesReceived}
+		assertUpdatesContains(t, updates, "bb-west-1", "172.17.0.1:8080")
+		assertNoUpdate(t, mockGetServer)
+
+		// There is no bb-empty service, so it has no endpoints.
+		annotateFederatedService(t, fsw, map[string]string{
+			"multicluster.linkerd.io/local-discovery":                     "bb-empty",
+			"multicluster.linkerd.io/remote-discovery-failover-threshold": "1",
+		})
+		updates = append(updates, <-mockGetServer.updatesReceived)
+		updates = append(updates, <-mockGetServer.updatesReceived)
+		assertUpdatesRemoves(t, updates, "172.17.0.1:8080")
+		assertUpdatesContains(t, updates, "bb-east-1", "172.17.1.1:8080")
+		assertNoUpdate(t, mockGetServer)
+	})
+}
+
+// annotateFederatedService adds the given annotations to those of the
+// bb-federated service in the lister, and passes the result to fsw.
+func annotateFederatedService(t *testing.T, fsw *federatedServiceWatcher, annotations map[string]string) {
+	t.Helper()
+	id := watcher.ServiceID{Namespace: "test", Name: "bb-federated"}
+	federatedSvc, err := fsw.k8sAPI.Svc().Lister().Services(id.Namespace).Get(id.Name)
+	if err != nil {
+		t.Fatalf("error getting federated service: %s", err)
+	}
+	newFederatedSvc := federatedSvc.DeepCopy()
+	for k, v := range annotations {
+		newFederatedSvc.Annotations[k] = v
+	}
+	fsw.updateService(federatedSvc, newFederatedSvc)
+}
+
+func assertNoUpdate(t *testing.T, server *mockDestinationGetServer) {
+	t.Helper()
+	select {
+	case update := <-server.updatesReceived:
+		t.Fatalf("Unexpected update: %v", update)
+	case <-time.After(time.Second):
+	}
+}
+
 func mockFederatedServiceWatcher(t *testing.T) (*federatedServiceWatcher, error) {
 	k8sAPI, err := k8s.NewFakeAPI(westConfigs...)
 	if err != nil {
@@ -131,7 +234,7 @@ func mockFederatedServiceWatcher(t *testing.T) (*federatedServiceWatcher, error)
 	if err != nil {
 		return nil, fmt.Errorf("NewFakeMetadataAPI returned an error: %w", err)
 	}
-	localEndpoints, err := watcher.NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), false, "local")
+	localEndpoints, err := watcher.NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), false, "local", 0)
 	if err != nil {
 		return nil, fmt.Errorf("NewEndpointsWatcher returned an error: %w", err)
 	}
@@ -7,6 +7,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 
 	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
 	"github.com/linkerd/linkerd2/controller/api/destination/watcher"
@@ -16,6 +17,7 @@ import (
 	"github.com/linkerd/linkerd2/pkg/prometheus"
 	"github.com/linkerd/linkerd2/pkg/util"
 	logging "github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/peer"
@@ -33,11 +35,36 @@ type (
 		EnableH2Upgrade,
 		EnableEndpointSlices,
 		EnableIPv6,
-		ExtEndpointZoneWeights bool
+		ExtEndpointZoneWeights,
+		NormalizeEndpointZoneWeights,
+		StableEndpointOrder bool
 
 		MeshedHttp2ClientParams *pb.Http2ClientParams
 
-		DefaultOpaquePorts map[uint32]struct{}
+		DefaultOpaquePorts watcher.DefaultOpaquePorts
+
+		// MaxEndpointsPerUpdate bounds the number of addresses sent in a single
+		// Get update. Zero means no limit.
+		MaxEndpointsPerUpdate int
+
+		// MaxEndpointsPerService caps the number of endpoints watched for each
+		// service port, to protect against selectors matching huge numbers of
+		// pods. Zero means no limit.
+		MaxEndpointsPerService int
+
+		// NoEndpointsGracePeriod delays the removal of all of a service's
+		// endpoints when it scales to zero, in case they quickly reappear.
+		// Zero disables the delay.
+		NoEndpointsGracePeriod time.Duration
+
+		// InitialEmptyAdd sends an empty Add when a Get stream starts on a
+		// service that exists but has no endpoints, so that clients can tell
+		// it apart from a service that doesn't exist.
+		InitialEmptyAdd bool
+
+		// ProfileServiceLabels lists the Service labels included in the
+		// headers of GetProfile responses. See MaxProfileServiceLabels.
+		ProfileServiceLabels []string
 	}
 
 	server struct {
@@ -56,6 +83,9 @@ type (
 		metadataAPI *k8s.MetadataAPI
 		log         *logging.Entry
 		shutdown    <-chan struct{}
+
+		// streams is nil if the endpoint streams aren't tracked.
+		streams *Streams
 	}
 )
 
@@ -77,7 +107,9 @@ func NewServer(
 	k8sAPI *k8s.API,
 	metadataAPI *k8s.MetadataAPI,
 	clusterStore *watcher.ClusterStore,
+	streams *Streams,
 	shutdown <-chan struct{},
+	opts ...grpc.ServerOption,
 ) (*grpc.Server, error) {
 	log := logging.WithFields(logging.Fields{
 		"addr":      addr,
@@ -94,7 +126,7 @@ func NewServer(
 	if err != nil {
 		return nil, err
 	}
-	endpoints, err := watcher.NewEndpointsWatcher(k8sAPI, metadataAPI, log, config.EnableEndpointSlices, "local")
+	endpoints, err := watcher.NewEndpointsWatcher(k8sAPI, metadataAPI, log, config.EnableEndpointSlices, "local", config.MaxEndpointsPerService)
 	if err != nil {
 		return nil, err
 	}
@@ -124,9 +156,10 @@ func NewServer(
 		metadataAPI,
 		log,
 		shutdown,
+		streams,
 	}
 
-	s := prometheus.NewGrpcServer(grpc.MaxConcurrentStreams(0))
+	s := prometheus.NewGrpcServer(append([]grpc.ServerOption{grpc.MaxConcurrentStreams(0)}, opts...)...)
 	// linkerd2-proxy-api/destination.Destination (proxy-facing)
 	pb.RegisterDestinationServer(s, &srv)
 	return s, nil
@@ -149,7 +182,7 @@ func (s *server) Get(dest *pb.GetDestination, stream pb.Destination_GetServer) e
 
 	log.Debugf("Get %s", dest.GetPath())
 
-	streamEnd := make(chan struct{})
+	endStream := newStreamEnd()
 	// The host must be fully-qualified or be an IP address.
 	host, port, err := getHostAndPort(dest.GetPath())
 	if err != nil {
@@ -168,7 +201,14 @@ func (s *server) Get(dest *pb.GetDestination, stream pb.Destination_GetServer) e
 		return status.Errorf(codes.InvalidArgument, "Invalid authority: %s", dest.GetPath())
 	}
 
+	_, span := trace.StartSpan(stream.Context(), lookupServiceSpan)
+	span.AddAttributes(
+		trace.StringAttribute("path", dest.GetPath()),
+		trace.StringAttribute("namespace", service.Namespace),
+		trace.StringAttribute("service", service.Name),
+	)
 	svc, err := s.k8sAPI.Svc().Lister().Services(service.Namespace).Get(service.Name)
+	endSpan(span, err)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
 			log.Debugf("Service not found %s", service)
@@ -183,7 +223,10 @@ func (s *server) Get(dest *pb.GetDestination, stream pb.Destination_GetServer) e
 		remoteDiscovery := svc.Annotations[labels.RemoteDiscoveryAnnotation]
 		localDiscovery := svc.Annotations[labels.LocalDiscoveryAnnotation]
 		log.Debugf("Federated service discovery, remote:[%s] local:[%s]", remoteDiscovery, localDiscovery)
-		err := s.federatedServices.Subscribe(svc.Name, svc.Namespace, port, token.NodeName, instanceID, stream, streamEnd)
+		_, span := trace.StartSpan(stream.Context(), subscribeSpan)
+		span.AddAttributes(trace.StringAttribute("watch", "federatedService"))
+		err := s.federatedServices.Subscribe(svc.Name, svc.Namespace, port, token.NodeName, instanceID, stream, endStream)
+		endSpan(span, err)
 		if err != nil {
 			log.Errorf("Failed to subscribe to federated service %q: %s", dest.GetPath(), err)
 			return err
@@ -209,19 +252,34 @@ func (s *server) Get(dest *pb.GetDestination, stream pb.Destination_GetServer) e
 			false, // Disable endpoint filtering for remote discovery.
 			s.config.EnableIPv6,
 			s.config.ExtEndpointZoneWeights,
+			s.config.NormalizeEndpointZoneWeights,
+			s.config.StableEndpointOrder,
+			s.config.InitialEmptyAdd,
 			s.config.MeshedHttp2ClientParams,
+			s.config.MaxEndpointsPerUpdate,
+			s.config.NoEndpointsGracePeriod,
 			fmt.Sprintf("%s.%s.svc.%s:%d", remoteSvc, service.Namespace, remoteConfig.ClusterDomain, port),
+			port,
 			token.NodeName,
 			s.config.DefaultOpaquePorts,
 			s.metadataAPI,
 			stream,
-			streamEnd,
+			endStream,
 			log,
 		)
 		translator.Start()
 		defer translator.Stop()
+		if s.streams != nil {
+			defer s.streams.unregister(s.streams.register(dest.GetPath(), translator))
+		}
 
+		_, span := trace.StartSpan(stream.Context(), subscribeSpan)
+		span.AddAttributes(
+			trace.StringAttribute("watch", "remoteEndpoints"),
+			trace.StringAttribute("cluster", cluster),
+		)
 		err = remoteWatcher.Subscribe(watcher.ServiceID{Namespace: service.Namespace, Name: remoteSvc}, port, instanceID, translator)
+		endSpan(span, err)
 		if err != nil {
 			var ise watcher.InvalidService
 			if errors.As(err, &ise) {
@@ -243,19 +301,31 @@ func (s *server) Get(dest *pb.GetDestination, stream pb.Destination_GetServer) e
 			true,
 			s.config.EnableIPv6,
 			s.config.ExtEndpointZoneWeights,
+			s.config.NormalizeEndpointZoneWeights,
+			s.config.StableEndpointOrder,
+			s.config.InitialEmptyAdd,
 			s.config.MeshedHttp2ClientParams,
+			s.config.MaxEndpointsPerUpdate,
+			s.config.NoEndpointsGracePeriod,
 			dest.GetPath(),
+			port,
 			token.NodeName,
 			s.config.DefaultOpaquePorts,
 			s.metadataAPI,
 			stream,
-			streamEnd,
+			endStream,
 			log,
 		)
 		translator.Start()
 		defer translator.Stop()
+		if s.streams != nil {
+			defer s.streams.unregister(s.streams.register(dest.GetPath(), translator))
+		}
 
+		_, span := trace.StartSpan(stream.Context(), subscribeSpan)
+		span.AddAttributes(trace.StringAttribute("watch", "endpoints"))
 		err = s.endpoints.Subscribe(service, port, instanceID, translator)
+		endSpan(span, err)
 		if err != nil {
 			var ise watcher.InvalidService
 			if errors.As(err, &ise) {
@@ -272,7 +342,7 @@ func (s *server) Get(dest *pb.GetDestination, stream pb.Destination_GetServer) e
 	case <-s.shutdown:
 	case <-stream.Context().Done():
 		log.Debugf("Get %s cancelled", dest.GetPath())
-	case <-streamEnd:
+	case <-endStream.done():
 		log.Errorf("Get %s stream aborted", dest.GetPath())
 	}
 
@@ -336,7 +406,10 @@ func (s *server) getProfileByIP(
 	stream pb.Destination_GetProfileServer,
 ) error {
 	// Get the service that the IP currently maps to.
+	_, span := trace.StartSpan(stream.Context(), lookupClusterIPSpan)
+	span.AddAttributes(trace.StringAttribute("ip", ip.String()))
 	svcID, err := getSvcID(s.k8sAPI, ip.String(), s.log)
+	endSpan(span, err)
 	if err != nil {
 		return err
 	}
@@ -391,6 +464,8 @@ func (s *server) subscribeToServiceProfile(
 	canceled := stream.Context().Done()
 	streamEnd := make(chan struct{})
 
+	s.setServiceLabelsHeader(service, stream, log)
+
 	// We build up the pipeline of profile updaters backwards, starting from
 	// the translator which takes profile updates, translates them to protobuf
 	// and pushes them onto the gRPC stream.
@@ -405,7 +480,10 @@ func (s *server) subscribeToServiceProfile(
 
 	// Create an adaptor that merges service-level opaque port configurations
 	// onto profile updates.
+	_, span := trace.StartSpan(stream.Context(), subscribeSpan)
+	span.AddAttributes(trace.StringAttribute("watch", "opaquePorts"))
 	err := s.opaquePorts.Subscribe(service, opaquePortsAdaptor)
+	endSpan(span, err)
 	if err != nil {
 		log.Warnf("Failed to subscribe to service updates for %s: %s", service, err)
 		return err
@@ -543,8 +621,11 @@ func (s *server) subscribeToEndpointProfile(
 	translator.Start()
 	defer translator.Stop()
 
+	_, span := trace.StartSpan(stream.Context(), subscribeSpan)
+	span.AddAttributes(trace.StringAttribute("watch", "workload"))
 	var err error
 	ip, err = s.workloads.Subscribe(service, hostname, ip, port, translator)
+	endSpan(span, err)
 	if err != nil {
 		return err
 	}
@@ -644,6 +725,18 @@ func getHostAndPort(authority string) (string, watcher.Port, error) {
 		return authority, watcher.Port(80), nil
 	}
 
+	// IPv6 literals without a port, either bare or bracketed
+	if ip := net.ParseIP(authority); ip != nil {
+		return authority, watcher.Port(80), nil
+	}
+	if strings.HasPrefix(authority, "[") && strings.HasSuffix(authority, "]") {
+		host := authority[1 : len(authority)-1]
+		if ip := net.ParseIP(host); ip == nil || ip.To4() != nil {
+			return "", 0, fmt.Errorf("invalid destination: %s is not an IPv6 address", authority)
+		}
+		return host, watcher.Port(80), nil
+	}
+
 	host, sport, err := net.SplitHostPort(authority)
 	if err != nil {
 		return "", 0, fmt.Errorf("invalid destination: %w", err)
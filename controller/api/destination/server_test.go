@@ -6,6 +6,7 @@ import (
 	gonet "net"
 	"net/netip"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -86,6 +87,26 @@ func TestGet(t *testing.T) {
 		}
 	})
 
+	t.Run("Returns InvalidArgument for IPv6 literals", func(t *testing.T) {
+		server := makeServer(t)
+		defer server.clusterStore.UnregisterGauges()
+
+		for _, path := range []string{"[2001:db8::88]:8989", "[2001:db8::88]", "2001:db8::88"} {
+			stream := &bufferingGetStream{
+				updates:          make(chan *pb.Update, 50),
+				MockServerStream: util.NewMockServerStream(),
+			}
+
+			err := server.Get(&pb.GetDestination{Scheme: "k8s", Path: path}, stream)
+			if code := status.Code(err); code != codes.InvalidArgument {
+				t.Fatalf("Expected InvalidArgument for %s, got %s", path, code)
+			}
+			if !strings.Contains(err.Error(), "IP queries not supported") {
+				t.Fatalf("Expected %s to be parsed as an IP query, got: %s", path, err)
+			}
+		}
+	})
+
 	t.Run("Returns endpoints (IPv4)", func(t *testing.T) {
 		testReturnEndpoints(t, fullyQualifiedName, podIP1, port)
 	})
@@ -263,6 +284,14 @@ func TestGet(t *testing.T) {
 				t.Fatalf("Expected %s but got %s", fmt.Sprintf("%s:%d", podIP1, port), updateAddAddress(t, update)[0])
 			}
 
+			// Remote endpoints must carry an identity in the remote cluster's
+			// trust domain, not the local one.
+			identity := update.GetAdd().GetAddrs()[0].GetTlsIdentity().GetDnsLikeIdentity().GetName()
+			expectedIdentity := "default.ns.serviceaccount.identity.linkerd.cluster.local"
+			if identity != expectedIdentity {
+				t.Fatalf("Expected identity %s but got %s", expectedIdentity, identity)
+			}
+
 			if len(stream.updates) != 0 {
 				t.Fatalf("Expected 1 update but got %d: %v", 1+len(stream.updates), stream.updates)
 			}
@@ -1344,3 +1373,43 @@ func profileStream(t *testing.T, server *server, host string, port uint32, token
 
 	return stream
 }
+
+func TestGetHostAndPort(t *testing.T) {
+	testCases := []struct {
+		authority string
+		host      string
+		port      watcher.Port
+		err       bool
+	}{
+		{authority: "name1.ns.svc.mycluster.local", host: "name1.ns.svc.mycluster.local", port: 80},
+		{authority: "name1.ns.svc.mycluster.local:8989", host: "name1.ns.svc.mycluster.local", port: 8989},
+		{authority: "172.17.0.12:8989", host: "172.17.0.12", port: 8989},
+		{authority: "[2001:db8::88]:8989", host: "2001:db8::88", port: 8989},
+		{authority: "[2001:db8::88]", host: "2001:db8::88", port: 80},
+		{authority: "2001:db8::88", host: "2001:db8::88", port: 80},
+		{authority: "[::1]:65535", host: "::1", port: 65535},
+		{authority: "[172.17.0.12]", err: true},
+		{authority: "[2001:db8::88]:0", err: true},
+		{authority: "[2001:db8::88]:http", err: true},
+		{authority: "2001:db8::88:8989:", err: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.authority, func(t *testing.T) {
+			host, port, err := getHostAndPort(tc.authority)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("Expected error, got host=%s port=%d", host, port)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if host != tc.host || port != tc.port {
+				t.Fatalf("Expected %s:%d, got %s:%d", tc.host, tc.port, host, port)
+			}
+		})
+	}
+}
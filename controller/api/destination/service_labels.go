@@ -0,0 +1,63 @@
+package destination
+
+import (
+	"fmt"
+
+	"github.com/linkerd/linkerd2/controller/api/destination/watcher"
+	logging "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// serviceLabelsHeader is the GetProfile response header carrying the
+	// allow-listed labels of the resolved Service, as key=value pairs.
+	serviceLabelsHeader = "l5d-service-labels"
+
+	// MaxProfileServiceLabels bounds the number of Service labels that can be
+	// allow-listed for GetProfile responses. As label keys and values are
+	// themselves bounded, so is the size of the header.
+	MaxProfileServiceLabels = 16
+)
+
+// serviceLabelsMetadata returns the response metadata holding the labels that
+// are allow-listed, in the order of the allow-list, or nil if there are none.
+func serviceLabelsMetadata(labels map[string]string, allowed []string) metadata.MD {
+	pairs := []string{}
+	seen := make(map[string]struct{}, len(allowed))
+	for _, key := range allowed {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if value, ok := labels[key]; ok {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+	return metadata.MD{serviceLabelsHeader: pairs}
+}
+
+// setServiceLabelsHeader sets the allow-listed labels of the service as a
+// header of the GetProfile response. It must be called before any profile is
+// sent; the labels aren't updated afterwards.
+func (s *server) setServiceLabelsHeader(service watcher.ID, stream grpc.ServerStream, log *logging.Entry) {
+	if len(s.config.ProfileServiceLabels) == 0 {
+		return
+	}
+
+	svc, err := s.k8sAPI.Svc().Lister().Services(service.Namespace).Get(service.Name)
+	if err != nil {
+		log.Debugf("Failed to get service labels: %s", err)
+		return
+	}
+	md := serviceLabelsMetadata(svc.Labels, s.config.ProfileServiceLabels)
+	if md == nil {
+		return
+	}
+	if err := stream.SetHeader(md); err != nil {
+		log.Debugf("Failed to set the service labels header: %s", err)
+	}
+}
@@ -0,0 +1,138 @@
+package destination
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+	"github.com/linkerd/linkerd2/controller/api/util"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestServiceLabelsMetadata(t *testing.T) {
+	labels := map[string]string{
+		"app":                    "web",
+		"version":                "v2",
+		"pod-template-hash":      "5d4f8b9c7",
+		"app.kubernetes.io/name": "web",
+	}
+
+	testCases := []struct {
+		name     string
+		allowed  []string
+		expected metadata.MD
+	}{
+		{
+			name:     "no allow-listed labels",
+			expected: nil,
+		},
+		{
+			name:     "allow-listed labels in order",
+			allowed:  []string{"version", "app"},
+			expected: metadata.MD{serviceLabelsHeader: {"version=v2", "app=web"}},
+		},
+		{
+			name:     "allow-listed labels the service doesn't have",
+			allowed:  []string{"team", "app.kubernetes.io/name"},
+			expected: metadata.MD{serviceLabelsHeader: {"app.kubernetes.io/name=web"}},
+		},
+		{
+			name:     "duplicate allow-listed labels",
+			allowed:  []string{"app", "app"},
+			expected: metadata.MD{serviceLabelsHeader: {"app=web"}},
+		},
+		{
+			name:     "only allow-listed labels the service doesn't have",
+			allowed:  []string{"team"},
+			expected: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			md := serviceLabelsMetadata(labels, tc.allowed)
+			if !reflect.DeepEqual(md, tc.expected) {
+				t.Fatalf("Expected %v, got %v", tc.expected, md)
+			}
+		})
+	}
+}
+
+// headerCapturingGetProfileStream records the header set by GetProfile.
+type headerCapturingGetProfileStream struct {
+	*bufferingGetProfileStream
+
+	mu     sync.Mutex
+	header metadata.MD
+}
+
+func (s *headerCapturingGetProfileStream) SetHeader(md metadata.MD) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *headerCapturingGetProfileStream) Header() metadata.MD {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.header
+}
+
+func TestGetProfileServiceLabels(t *testing.T) {
+	testCases := []struct {
+		name     string
+		allowed  []string
+		expected metadata.MD
+	}{
+		{
+			name:     "no labels by default",
+			expected: nil,
+		},
+		{
+			name:     "only allow-listed labels",
+			allowed:  []string{"app", "version", "tier"},
+			expected: metadata.MD{serviceLabelsHeader: {"app=name1", "version=v1"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			server := makeServer(t)
+			defer server.clusterStore.UnregisterGauges()
+			server.config.ProfileServiceLabels = tc.allowed
+
+			stream := &headerCapturingGetProfileStream{
+				bufferingGetProfileStream: &bufferingGetProfileStream{
+					updates:          []*pb.DestinationProfile{},
+					MockServerStream: util.NewMockServerStream(),
+				},
+			}
+			defer stream.Cancel()
+
+			errs := make(chan error, 1)
+			go func() {
+				errs <- server.GetProfile(&pb.GetDestination{Scheme: "k8s", Path: "name1.ns.svc.mycluster.local:8989"}, stream)
+			}()
+
+			deadline := time.After(5 * time.Second)
+			for len(stream.Updates()) == 0 {
+				select {
+				case err := <-errs:
+					t.Fatalf("Got error: %s", err)
+				case <-deadline:
+					t.Fatal("Timed out waiting for a profile")
+				case <-time.After(10 * time.Millisecond):
+				}
+			}
+
+			if header := stream.Header(); !reflect.DeepEqual(header, tc.expected) {
+				t.Fatalf("Expected header %v, got %v", tc.expected, header)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package destination
+
+import "sync"
+
+// streamEnd signals that a Get stream should be ended. It's shared by the
+// server and all the translators feeding the stream, any of which may end it
+// from its own goroutine, so its channel is closed at most once.
+type streamEnd struct {
+	ch   chan struct{}
+	once sync.Once
+}
+
+func newStreamEnd() *streamEnd {
+	return &streamEnd{ch: make(chan struct{})}
+}
+
+// done returns a channel that's closed once the stream is ended.
+func (e *streamEnd) done() <-chan struct{} {
+	return e.ch
+}
+
+// end ends the stream. It returns true if the stream was ended by this call,
+// and false if it already was.
+func (e *streamEnd) end() bool {
+	ended := false
+	e.once.Do(func() {
+		close(e.ch)
+		ended = true
+	})
+	return ended
+}
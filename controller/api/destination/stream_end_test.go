@@ -0,0 +1,41 @@
+package destination
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestStreamEnd(t *testing.T) {
+	end := newStreamEnd()
+
+	select {
+	case <-end.done():
+		t.Fatal("Expected the stream not to be ended yet")
+	default:
+	}
+
+	// Ending the stream concurrently must neither panic nor report more
+	// than one of the calls as the one that ended it.
+	var ended atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if end.end() {
+				ended.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ended.Load() != 1 {
+		t.Fatalf("Expected the stream to be ended once, got %d", ended.Load())
+	}
+	select {
+	case <-end.done():
+	default:
+		t.Fatal("Expected the stream to be ended")
+	}
+}
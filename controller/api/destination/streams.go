@@ -0,0 +1,158 @@
+package destination
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type (
+	// Streams tracks the endpoint streams served by the destination server, so
+	// that they can be listed from its admin server. If pinning is enabled,
+	// they can also be pinned for debugging: a pinned stream stops receiving
+	// endpoint updates, and keeps the endpoints it was last sent, until it's
+	// unpinned; it's then sent the changes it missed.
+	Streams struct {
+		sync.Mutex
+		enablePinning bool
+		nextID        uint64
+		streams       map[uint64]trackedStream
+	}
+
+	trackedStream struct {
+		authority   string
+		translators []*endpointTranslator
+	}
+
+	// StreamInfo describes an endpoint stream, as listed by Streams.
+	StreamInfo struct {
+		ID        uint64 `json:"id"`
+		Authority string `json:"authority"`
+		// Views is the number of endpoint translators feeding the stream.
+		Views  int  `json:"views"`
+		Pinned bool `json:"pinned"`
+		// SnapshotAge is how long ago the endpoints sent on the stream last
+		// changed, or empty if none were ever sent.
+		SnapshotAge string `json:"snapshotAge,omitempty"`
+	}
+)
+
+const (
+	pinAction   = "pin"
+	unpinAction = "unpin"
+)
+
+// NewStreams returns a Streams that tracks no streams. Streams can only be
+// pinned if enablePinning is set.
+func NewStreams(enablePinning bool) *Streams {
+	return &Streams{
+		enablePinning: enablePinning,
+		streams:       make(map[uint64]trackedStream),
+	}
+}
+
+func (s *Streams) register(authority string, translators ...*endpointTranslator) uint64 {
+	s.Lock()
+	defer s.Unlock()
+
+	s.nextID++
+	s.streams[s.nextID] = trackedStream{authority, translators}
+	return s.nextID
+}
+
+// unregister stops tracking the stream, which must no longer be used by then.
+func (s *Streams) unregister(id uint64) {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.streams, id)
+}
+
+// Count returns the number of tracked streams.
+func (s *Streams) Count() int {
+	s.Lock()
+	defer s.Unlock()
+
+	return len(s.streams)
+}
+
+// ServeHTTP lists the tracked streams on GET, and pins or unpins one of them
+// on POST, given its "id" and an "action" of "pin" or "unpin".
+func (s *Streams) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		s.list(w)
+	case http.MethodPost:
+		if !s.enablePinning {
+			http.Error(w, "stream pinning is disabled", http.StatusForbidden)
+			return
+		}
+		s.update(w, req)
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Streams) list(w http.ResponseWriter) {
+	now := time.Now()
+
+	s.Lock()
+	streams := make([]StreamInfo, 0, len(s.streams))
+	for id, stream := range s.streams {
+		info := StreamInfo{ID: id, Authority: stream.authority, Views: len(stream.translators), Pinned: len(stream.translators) > 0}
+		var lastUpdate int64
+		for _, translator := range stream.translators {
+			info.Pinned = info.Pinned && translator.pinned.Load()
+			lastUpdate = max(lastUpdate, translator.lastUpdate.Load())
+		}
+		if lastUpdate != 0 {
+			info.SnapshotAge = now.Sub(time.Unix(0, lastUpdate)).Round(time.Millisecond).String()
+		}
+		streams = append(streams, info)
+	}
+	s.Unlock()
+
+	sort.Slice(streams, func(i, j int) bool { return streams[i].ID < streams[j].ID })
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(streams); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Streams) update(w http.ResponseWriter, req *http.Request) {
+	id, err := strconv.ParseUint(req.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid stream id %q", req.FormValue("id")), http.StatusBadRequest)
+		return
+	}
+	action := req.FormValue("action")
+	if action != pinAction && action != unpinAction {
+		http.Error(w, fmt.Sprintf("invalid action %q: must be %q or %q", action, pinAction, unpinAction), http.StatusBadRequest)
+		return
+	}
+
+	// The lock is held while pinning, so that the stream can't be stopped in
+	// the meantime. Neither pin nor unpin block.
+	s.Lock()
+	defer s.Unlock()
+
+	stream, ok := s.streams[id]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown stream %d", id), http.StatusNotFound)
+		return
+	}
+	for _, translator := range stream.translators {
+		if action == pinAction {
+			translator.pin()
+		} else {
+			translator.unpin()
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
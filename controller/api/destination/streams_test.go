@@ -0,0 +1,237 @@
+package destination
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEndpointTranslatorPinning(t *testing.T) {
+	t.Run("Holds back updates while pinned and sends the net changes when unpinned", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(remoteGateway1))
+		<-mockGetServer.updatesReceived // Add
+
+		translator.pin()
+		translator.Add(mkAddressSetForServices(remoteGateway2))
+		translator.Remove(mkAddressSetForServices(remoteGateway2))
+		translator.Remove(mkAddressSetForServices(remoteGateway1))
+		translator.unpin()
+
+		// The updates are processed in order, so the unpinned update is the
+		// first one sent after the pinned ones.
+		update := <-mockGetServer.updatesReceived
+		removed := update.GetRemove().GetAddrs()
+		if len(removed) != 1 {
+			t.Fatalf("Expected an update removing a single address, got %v", update)
+		}
+		checkAddress(t, removed[0], remoteGateway1)
+		if len(mockGetServer.updatesReceived) != 0 {
+			t.Fatalf("Expected a single update, got %d more", len(mockGetServer.updatesReceived))
+		}
+	})
+
+	t.Run("Sends nothing when unpinned without changes", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(remoteGateway1))
+		<-mockGetServer.updatesReceived // Add
+
+		translator.pin()
+		translator.Add(mkAddressSetForServices(remoteGateway2))
+		translator.Remove(mkAddressSetForServices(remoteGateway2))
+		translator.unpin()
+		translator.unpin()
+
+		// Without a pin, the next update is sent right away.
+		translator.Remove(mkAddressSetForServices(remoteGateway1))
+		update := <-mockGetServer.updatesReceived
+		if update.GetRemove() == nil {
+			t.Fatalf("Expected a remove update, got %v", update)
+		}
+		if len(mockGetServer.updatesReceived) != 0 {
+			t.Fatalf("Expected a single update, got %d more", len(mockGetServer.updatesReceived))
+		}
+	})
+
+	t.Run("Keeps observing the endpoints while pinned", func(t *testing.T) {
+		mockGetServer, translator := makeEndpointTranslator(t)
+		observed := make(chan int, 10)
+		translator.endpointsObserver = func(count int) { observed <- count }
+		translator.Start()
+		defer translator.Stop()
+
+		translator.Add(mkAddressSetForServices(remoteGateway1))
+		<-mockGetServer.updatesReceived // Add
+		if count := <-observed; count != 1 {
+			t.Fatalf("Expected 1 endpoint to be observed, got %d", count)
+		}
+
+		translator.pin()
+		translator.Add(mkAddressSetForServices(remoteGateway2))
+		select {
+		case count := <-observed:
+			if count != 2 {
+				t.Fatalf("Expected 2 endpoints to be observed, got %d", count)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for the pinned stream's endpoints to be observed")
+		}
+		if len(mockGetServer.updatesReceived) != 0 {
+			t.Fatalf("Expected no update to be sent while pinned, got %d", len(mockGetServer.updatesReceived))
+		}
+	})
+}
+
+func listStreams(t *testing.T, streams *Streams) []StreamInfo {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	streams.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/streams", nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+	var infos []StreamInfo
+	if err := json.Unmarshal(recorder.Body.Bytes(), &infos); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	return infos
+}
+
+func postStreamAction(streams *Streams, id, action string) int {
+	form := url.Values{"id": {id}, "action": {action}}
+	req := httptest.NewRequest(http.MethodPost, "/debug/streams", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	streams.ServeHTTP(recorder, req)
+	return recorder.Code
+}
+
+func TestStreamsList(t *testing.T) {
+	mockGetServer, translator := makeEndpointTranslator(t)
+	translator.Start()
+	defer translator.Stop()
+
+	streams := NewStreams(false)
+	id := streams.register("name1.ns.svc.mycluster.local:8989", translator)
+
+	expected := []StreamInfo{{ID: id, Authority: "name1.ns.svc.mycluster.local:8989", Views: 1}}
+	if infos := listStreams(t, streams); !reflect.DeepEqual(infos, expected) {
+		t.Fatalf("Expected %v, got %v", expected, infos)
+	}
+	if count := streams.Count(); count != 1 {
+		t.Fatalf("Expected 1 stream, got %d", count)
+	}
+
+	translator.Add(mkAddressSetForServices(remoteGateway1))
+	<-mockGetServer.updatesReceived // Add
+
+	infos := listStreams(t, streams)
+	if len(infos) != 1 || infos[0].SnapshotAge == "" {
+		t.Fatalf("Expected the snapshot age of the stream, got %v", infos)
+	}
+	if _, err := time.ParseDuration(infos[0].SnapshotAge); err != nil {
+		t.Fatalf("Invalid snapshot age: %s", err)
+	}
+
+	if code := postStreamAction(streams, "1", pinAction); code != http.StatusForbidden {
+		t.Fatalf("Expected status %d, got %d", http.StatusForbidden, code)
+	}
+	if translator.pinned.Load() {
+		t.Fatal("Expected the stream not to be pinned")
+	}
+}
+
+func TestStreamsPinning(t *testing.T) {
+	_, translator := makeEndpointTranslator(t)
+	streams := NewStreams(true)
+	id := streams.register("name1.ns.svc.mycluster.local:8989", translator)
+
+	list := func(t *testing.T) []StreamInfo {
+		t.Helper()
+		return listStreams(t, streams)
+	}
+	post := func(id, action string) int {
+		return postStreamAction(streams, id, action)
+	}
+
+	expected := []StreamInfo{{ID: id, Authority: "name1.ns.svc.mycluster.local:8989", Views: 1}}
+	if streams := list(t); !reflect.DeepEqual(streams, expected) {
+		t.Fatalf("Expected %v, got %v", expected, streams)
+	}
+
+	if code := post("1", pinAction); code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d", http.StatusNoContent, code)
+	}
+	if !translator.pinned.Load() {
+		t.Fatal("Expected the stream to be pinned")
+	}
+	expected[0].Pinned = true
+	if streams := list(t); !reflect.DeepEqual(streams, expected) {
+		t.Fatalf("Expected %v, got %v", expected, streams)
+	}
+
+	if code := post("1", unpinAction); code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, got %d", http.StatusNoContent, code)
+	}
+	if translator.pinned.Load() {
+		t.Fatal("Expected the stream to be unpinned")
+	}
+
+	testCases := []struct {
+		name         string
+		id           string
+		action       string
+		expectedCode int
+	}{
+		{
+			name:         "unknown stream",
+			id:           "2",
+			action:       pinAction,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid stream id",
+			id:           "first",
+			action:       pinAction,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid action",
+			id:           "1",
+			action:       "freeze",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			if code := post(tc.id, tc.action); code != tc.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tc.expectedCode, code)
+			}
+		})
+	}
+
+	recorder := httptest.NewRecorder()
+	streams.ServeHTTP(recorder, httptest.NewRequest(http.MethodDelete, "/debug/streams", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected status %d, got %d", http.StatusMethodNotAllowed, recorder.Code)
+	}
+
+	streams.unregister(id)
+	if streams := list(t); len(streams) != 0 {
+		t.Fatalf("Expected no streams once unregistered, got %v", streams)
+	}
+	if code := post("1", pinAction); code != http.StatusNotFound {
+		t.Fatalf("Expected status %d, got %d", http.StatusNotFound, code)
+	}
+}
@@ -30,6 +30,10 @@ kind: Service
 metadata:
   name: name1
   namespace: ns
+  labels:
+    app: name1
+    version: v1
+    team: a-team
 spec:
   type: LoadBalancer
   ipFamilies:
@@ -925,13 +929,15 @@ spec:
 	}
 	log := logging.WithField("test", t.Name())
 	// logging.SetLevel(logging.TraceLevel)
-	defaultOpaquePorts := map[uint32]struct{}{
-		25:    {},
-		443:   {},
-		587:   {},
-		3306:  {},
-		5432:  {},
-		11211: {},
+	defaultOpaquePorts := watcher.DefaultOpaquePorts{
+		Ports: map[uint32]struct{}{
+			25:    {},
+			443:   {},
+			587:   {},
+			3306:  {},
+			5432:  {},
+			11211: {},
+		},
 	}
 
 	err = watcher.InitializeIndexers(k8sAPI)
@@ -943,7 +949,7 @@ spec:
 	if err != nil {
 		t.Fatalf("can't create Workloads watcher: %s", err)
 	}
-	endpoints, err := watcher.NewEndpointsWatcher(k8sAPI, metadataAPI, log, true, "local")
+	endpoints, err := watcher.NewEndpointsWatcher(k8sAPI, metadataAPI, log, true, "local", 0)
 	if err != nil {
 		t.Fatalf("can't create Endpoints watcher: %s", err)
 	}
@@ -992,6 +998,7 @@ spec:
 		metadataAPI,
 		log,
 		make(<-chan struct{}),
+		nil,
 	}, l5dClient
 }
 
@@ -1045,6 +1052,14 @@ func (m *mockDestinationGetProfileServer) Send(profile *pb.DestinationProfile) e
 }
 
 func makeEndpointTranslator(t *testing.T) (*mockDestinationGetServer, *endpointTranslator) {
+	t.Helper()
+	mockGetServer := &mockDestinationGetServer{updatesReceived: make(chan *pb.Update, 50)}
+	return mockGetServer, newTestEndpointTranslator(t, mockGetServer, nil)
+}
+
+// newTestEndpointTranslator returns an endpointTranslator sending its updates
+// to stream, for a client on a node of the west-1a zone.
+func newTestEndpointTranslator(t *testing.T, stream pb.Destination_GetServer, endStream *streamEnd) *endpointTranslator {
 	t.Helper()
 	node := `apiVersion: v1
 kind: Node
@@ -1068,22 +1083,26 @@ metadata:
 	}
 	metadataAPI.Sync(nil)
 
-	mockGetServer := &mockDestinationGetServer{updatesReceived: make(chan *pb.Update, 50)}
-	translator := newEndpointTranslator(
+	return newEndpointTranslator(
 		"linkerd",
 		"trust.domain",
 		true,
 		true,
 		true,  // enableEndpointFiltering
 		false, // extEndpointZoneWeights
+		false, // normalizeEndpointZoneWeights
+		true,  // stableEndpointOrder
+		false, // initialEmptyAdd
 		nil,   // meshedHttp2ClientParams
+		0,     // maxEndpointsPerUpdate
+		0,     // noEndpointsGracePeriod
 		"service-name.service-ns",
+		80,
 		"test-123",
-		map[uint32]struct{}{},
+		watcher.DefaultOpaquePorts{},
 		metadataAPI,
-		mockGetServer,
-		nil,
+		stream,
+		endStream,
 		logging.WithField("test", t.Name()),
 	)
-	return mockGetServer, translator
 }
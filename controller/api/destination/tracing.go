@@ -0,0 +1,24 @@
+package destination
+
+import (
+	"go.opencensus.io/trace"
+	"google.golang.org/grpc/status"
+)
+
+// Spans for the steps of resolving a destination. They are children of the
+// RPC's span, which is started by the gRPC server's ocgrpc stats handler from
+// the trace context propagated by the proxy, so they're only exported when
+// tracing is enabled.
+const (
+	lookupServiceSpan   = "destination.lookupService"
+	lookupClusterIPSpan = "destination.lookupClusterIP"
+	subscribeSpan       = "destination.subscribe"
+)
+
+// endSpan ends span, recording err as its status.
+func endSpan(span *trace.Span, err error) {
+	if err != nil {
+		span.SetStatus(trace.Status{Code: int32(status.Code(err)), Message: err.Error()})
+	}
+	span.End()
+}
@@ -0,0 +1,140 @@
+package destination
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+	"github.com/linkerd/linkerd2/controller/api/util"
+	"go.opencensus.io/trace"
+	"google.golang.org/grpc/codes"
+)
+
+type spanRecorder struct {
+	sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(span *trace.SpanData) {
+	r.Lock()
+	defer r.Unlock()
+	r.spans = append(r.spans, span)
+}
+
+func (r *spanRecorder) get(name string) *trace.SpanData {
+	r.Lock()
+	defer r.Unlock()
+	for _, span := range r.spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	return nil
+}
+
+// tracedGetStream carries the span of the RPC in its context, as the gRPC
+// server's stats handler does.
+type tracedGetStream struct {
+	*bufferingGetStream
+	ctx context.Context
+}
+
+func (s tracedGetStream) Context() context.Context { return s.ctx }
+
+func recordSpans(t *testing.T) *spanRecorder {
+	t.Helper()
+
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	t.Cleanup(func() {
+		trace.UnregisterExporter(recorder)
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+	})
+	return recorder
+}
+
+func TestGetTracing(t *testing.T) {
+	t.Run("Records the steps of a resolved Get", func(t *testing.T) {
+		recorder := recordSpans(t)
+
+		server := makeServer(t)
+		defer server.clusterStore.UnregisterGauges()
+
+		stream := &bufferingGetStream{
+			updates:          make(chan *pb.Update, 50),
+			MockServerStream: util.NewMockServerStream(),
+		}
+		defer stream.Cancel()
+		ctx, rpcSpan := trace.StartSpan(stream.Context(), "io.linkerd.proxy.destination.Destination.Get")
+		defer rpcSpan.End()
+
+		errs := make(chan error, 1)
+		go func() {
+			errs <- server.Get(&pb.GetDestination{Scheme: "k8s", Path: fmt.Sprintf("%s:%d", fullyQualifiedName, port)}, tracedGetStream{stream, ctx})
+		}()
+
+		select {
+		case <-stream.updates:
+		case err := <-errs:
+			t.Fatalf("Got error: %s", err)
+		}
+
+		rpcSpanID := rpcSpan.SpanContext().SpanID
+		lookup := recorder.get(lookupServiceSpan)
+		if lookup == nil {
+			t.Fatalf("Expected a %s span", lookupServiceSpan)
+		}
+		if lookup.ParentSpanID != rpcSpanID {
+			t.Fatalf("Expected the %s span to be a child of the RPC span", lookupServiceSpan)
+		}
+		if lookup.Attributes["service"] != "name1" || lookup.Attributes["namespace"] != "ns" {
+			t.Fatalf("Unexpected %s span attributes: %v", lookupServiceSpan, lookup.Attributes)
+		}
+
+		subscribe := recorder.get(subscribeSpan)
+		if subscribe == nil {
+			t.Fatalf("Expected a %s span", subscribeSpan)
+		}
+		if subscribe.ParentSpanID != rpcSpanID {
+			t.Fatalf("Expected the %s span to be a child of the RPC span", subscribeSpan)
+		}
+		if subscribe.Attributes["watch"] != "endpoints" {
+			t.Fatalf("Unexpected %s span attributes: %v", subscribeSpan, subscribe.Attributes)
+		}
+		if subscribe.Status.Code != int32(codes.OK) {
+			t.Fatalf("Expected the %s span to succeed, got %v", subscribeSpan, subscribe.Status)
+		}
+	})
+
+	t.Run("Records the status of a failed service lookup", func(t *testing.T) {
+		recorder := recordSpans(t)
+
+		server := makeServer(t)
+		defer server.clusterStore.UnregisterGauges()
+
+		stream := &bufferingGetStream{
+			updates:          make(chan *pb.Update, 50),
+			MockServerStream: util.NewMockServerStream(),
+		}
+		defer stream.Cancel()
+
+		err := server.Get(&pb.GetDestination{Scheme: "k8s", Path: "missing.ns.svc.mycluster.local:80"}, stream)
+		if err == nil {
+			t.Fatal("Expecting error, got nothing")
+		}
+
+		lookup := recorder.get(lookupServiceSpan)
+		if lookup == nil {
+			t.Fatalf("Expected a %s span", lookupServiceSpan)
+		}
+		if lookup.Status.Code != int32(codes.Unknown) {
+			t.Fatalf("Expected the %s span to record the lookup error, got %v", lookupServiceSpan, lookup.Status)
+		}
+		if recorder.get(subscribeSpan) != nil {
+			t.Fatalf("Expected no %s span", subscribeSpan)
+		}
+	})
+}
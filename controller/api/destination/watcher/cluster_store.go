@@ -225,6 +225,7 @@ func (cs *ClusterStore) addCluster(clusterName string, secret *v1.Secret) error
 		}),
 		cs.enableEndpointSlices,
 		clusterName,
+		0, // maxEndpointsPerService
 	)
 	if err != nil {
 		return err
@@ -0,0 +1,91 @@
+package watcher
+
+import (
+	ext "github.com/linkerd/linkerd2/controller/gen/apis/externalworkload/v1beta1"
+	"github.com/linkerd/linkerd2/pkg/util"
+	logging "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// DefaultOpaquePorts are the ports that are opaque for the workloads and
+// Services that don't have an opaque ports annotation. Names are resolved
+// against the ports of each workload or Service, so that a name can map to a
+// different port number for each of them; names that a workload or Service
+// doesn't define are ignored for it.
+type DefaultOpaquePorts struct {
+	Ports map[uint32]struct{}
+	Names []string
+}
+
+// ParseDefaultOpaquePorts parses a comma-separated list of ports, port ranges
+// and port names into DefaultOpaquePorts.
+func ParseDefaultOpaquePorts(s string) DefaultOpaquePorts {
+	defaults := DefaultOpaquePorts{Ports: make(map[uint32]struct{})}
+	if s == "" {
+		return defaults
+	}
+	for _, pr := range util.GetPortRanges(s) {
+		portRange, err := util.ParsePortRange(pr)
+		if err == nil {
+			for _, port := range portRange.Ports() {
+				defaults.Ports[uint32(port)] = struct{}{}
+			}
+			continue
+		}
+		if len(validation.IsValidPortName(pr)) != 0 {
+			logging.Warnf("Invalid port range or name [%v]: %s", pr, err)
+			continue
+		}
+		defaults.Names = append(defaults.Names, pr)
+	}
+	return defaults
+}
+
+// forPod returns the default opaque ports of pod, resolving names against
+// the ports of its containers.
+func (d DefaultOpaquePorts) forPod(pod *corev1.Pod) map[uint32]struct{} {
+	if len(d.Names) == 0 || pod == nil {
+		return d.Ports
+	}
+	namedPorts := util.GetNamedPorts(pod.Spec.Containers)
+	return d.resolve(func(name string) (int32, bool) {
+		port, ok := namedPorts[name]
+		return port, ok
+	})
+}
+
+// forExternalWorkload returns the default opaque ports of ew, resolving names
+// against its ports.
+func (d DefaultOpaquePorts) forExternalWorkload(ew *ext.ExternalWorkload) map[uint32]struct{} {
+	if len(d.Names) == 0 || ew == nil {
+		return d.Ports
+	}
+	return d.resolve(func(name string) (int32, bool) {
+		return isNamedInExternalWorkload(name, ew)
+	})
+}
+
+// forService returns the default opaque ports of svc, resolving names against
+// its ports.
+func (d DefaultOpaquePorts) forService(svc *corev1.Service) map[uint32]struct{} {
+	if len(d.Names) == 0 || svc == nil {
+		return d.Ports
+	}
+	return d.resolve(func(name string) (int32, bool) {
+		return isNamed(name, svc.Spec.Ports)
+	})
+}
+
+func (d DefaultOpaquePorts) resolve(lookup func(string) (int32, bool)) map[uint32]struct{} {
+	ports := make(map[uint32]struct{}, len(d.Ports)+len(d.Names))
+	for port := range d.Ports {
+		ports[port] = struct{}{}
+	}
+	for _, name := range d.Names {
+		if port, ok := lookup(name); ok {
+			ports[uint32(port)] = struct{}{}
+		}
+	}
+	return ports
+}
@@ -0,0 +1,141 @@
+package watcher
+
+import (
+	"reflect"
+	"testing"
+
+	ext "github.com/linkerd/linkerd2/controller/gen/apis/externalworkload/v1beta1"
+	consts "github.com/linkerd/linkerd2/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseDefaultOpaquePorts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		ports    string
+		expected DefaultOpaquePorts
+	}{
+		{
+			name:     "empty",
+			ports:    "",
+			expected: DefaultOpaquePorts{Ports: map[uint32]struct{}{}},
+		},
+		{
+			name:  "ports and ranges",
+			ports: "25,3306,4444-4446",
+			expected: DefaultOpaquePorts{
+				Ports: map[uint32]struct{}{25: {}, 3306: {}, 4444: {}, 4445: {}, 4446: {}},
+			},
+		},
+		{
+			name:  "ports and names",
+			ports: "25, mysql,redis-tls ,",
+			expected: DefaultOpaquePorts{
+				Ports: map[uint32]struct{}{25: {}},
+				Names: []string{"mysql", "redis-tls"},
+			},
+		},
+		{
+			name:  "invalid entries",
+			ports: "70000,not_a_name,-1,db",
+			expected: DefaultOpaquePorts{
+				Ports: map[uint32]struct{}{},
+				Names: []string{"db"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			defaults := ParseDefaultOpaquePorts(tc.ports)
+			if !reflect.DeepEqual(defaults, tc.expected) {
+				t.Fatalf("Expected %+v, got %+v", tc.expected, defaults)
+			}
+		})
+	}
+}
+
+func TestDefaultOpaquePortsNamedPorts(t *testing.T) {
+	defaults := ParseDefaultOpaquePorts("25,db")
+
+	podWithPorts := func(annotations map[string]string, ports ...corev1.ContainerPort) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Annotations: annotations},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{{Name: "app", Ports: ports}},
+			},
+		}
+	}
+
+	t.Run("pods", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			pod      *corev1.Pod
+			expected map[uint32]struct{}
+		}{
+			{
+				name:     "no pod",
+				expected: map[uint32]struct{}{25: {}},
+			},
+			{
+				name:     "name resolved to the pod's port",
+				pod:      podWithPorts(nil, corev1.ContainerPort{Name: "db", ContainerPort: 5432}),
+				expected: map[uint32]struct{}{25: {}, 5432: {}},
+			},
+			{
+				name:     "same name resolved to another pod's port",
+				pod:      podWithPorts(nil, corev1.ContainerPort{Name: "db", ContainerPort: 3306}),
+				expected: map[uint32]struct{}{25: {}, 3306: {}},
+			},
+			{
+				name:     "pod without the named port",
+				pod:      podWithPorts(nil, corev1.ContainerPort{Name: "http", ContainerPort: 8080}),
+				expected: map[uint32]struct{}{25: {}},
+			},
+			{
+				name: "annotated pod",
+				pod: podWithPorts(
+					map[string]string{consts.ProxyOpaquePortsAnnotation: "4444"},
+					corev1.ContainerPort{Name: "db", ContainerPort: 5432},
+				),
+				expected: map[uint32]struct{}{4444: {}},
+			},
+		}
+
+		for _, tc := range testCases {
+			tc := tc // pin
+			t.Run(tc.name, func(t *testing.T) {
+				ports := GetAnnotatedOpaquePorts(tc.pod, defaults)
+				if !reflect.DeepEqual(ports, tc.expected) {
+					t.Fatalf("Expected %v, got %v", tc.expected, ports)
+				}
+			})
+		}
+	})
+
+	t.Run("external workloads", func(t *testing.T) {
+		ew := &ext.ExternalWorkload{
+			Spec: ext.ExternalWorkloadSpec{
+				Ports: []ext.PortSpec{{Name: "db", Port: 6379}},
+			},
+		}
+		expected := map[uint32]struct{}{25: {}, 6379: {}}
+		if ports := GetAnnotatedOpaquePortsForExternalWorkload(ew, defaults); !reflect.DeepEqual(ports, expected) {
+			t.Fatalf("Expected %v, got %v", expected, ports)
+		}
+	})
+
+	t.Run("services", func(t *testing.T) {
+		svc := &corev1.Service{
+			Spec: corev1.ServiceSpec{
+				Ports: []corev1.ServicePort{{Name: "db", Port: 1521}},
+			},
+		}
+		expected := map[uint32]struct{}{25: {}, 1521: {}}
+		if ports := defaults.forService(svc); !reflect.DeepEqual(ports, expected) {
+			t.Fatalf("Expected %v, got %v", expected, ports)
+		}
+	})
+}
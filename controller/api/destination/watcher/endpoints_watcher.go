@@ -39,6 +39,14 @@ const (
 const endpointTargetRefPod = "Pod"
 const endpointTargetRefExternalWorkload = "ExternalWorkload"
 
+const (
+	// EndpointSliceSource means endpoints are read from EndpointSlice
+	// resources.
+	EndpointSliceSource = "EndpointSlice"
+	// EndpointsSource means endpoints are read from Endpoints resources.
+	EndpointsSource = "Endpoints"
+)
+
 type (
 	// Address represents an individual port on a specific endpoint.
 	// This endpoint might be the result of a the existence of a pod
@@ -70,6 +78,7 @@ type (
 		Addresses          map[ID]Address
 		Labels             map[string]string
 		LocalTrafficPolicy bool
+		PreferClose        bool
 	}
 
 	portAndHostname struct {
@@ -86,10 +95,11 @@ type (
 		k8sAPI      *k8s.API
 		metadataAPI *k8s.MetadataAPI
 
-		cluster              string
-		log                  *logging.Entry
-		enableEndpointSlices bool
-		sync.RWMutex         // This mutex protects modification of the map itself.
+		cluster                string
+		log                    *logging.Entry
+		enableEndpointSlices   bool
+		maxEndpointsPerService int
+		sync.RWMutex           // This mutex protects modification of the map itself.
 
 		informerHandlers
 	}
@@ -114,17 +124,24 @@ type (
 	// requested, the address set will be filtered to only include addresses
 	// with the requested hostname.
 	servicePublisher struct {
-		id                   ServiceID
-		log                  *logging.Entry
-		k8sAPI               *k8s.API
-		metadataAPI          *k8s.MetadataAPI
-		enableEndpointSlices bool
-		localTrafficPolicy   bool
-		cluster              string
-		ports                map[portAndHostname]*portPublisher
+		id                     ServiceID
+		log                    *logging.Entry
+		k8sAPI                 *k8s.API
+		metadataAPI            *k8s.MetadataAPI
+		enableEndpointSlices   bool
+		maxEndpointsPerService int
+		localTrafficPolicy     bool
+		preferClose            bool
+		cluster                string
+		ports                  map[portAndHostname]*portPublisher
 		// All access to the servicePublisher and its portPublishers is explicitly synchronized by
 		// this mutex.
 		sync.Mutex
+
+		// subscribing is the number of Subscribe calls adding a listener to
+		// this publisher. It's guarded by the EndpointsWatcher's lock, and
+		// the publisher isn't removed while it's non-zero.
+		subscribing int
 	}
 
 	// portPublisher represents a service along with a port and optionally a
@@ -146,6 +163,16 @@ type (
 		listeners            []EndpointUpdateListener
 		metrics              endpointsMetrics
 		localTrafficPolicy   bool
+		preferClose          bool
+		maxAddresses         int
+		// published is the subset of addresses that was sent to the
+		// listeners, which is smaller than addresses when some of them share
+		// an IP or when there are more than maxAddresses of them.
+		published AddressSet
+		// duplicates holds the IDs of the addresses left out of published for
+		// sharing their IP with another one, so that each is only reported
+		// once.
+		duplicates map[ID]struct{}
 	}
 
 	// EndpointUpdateListener is the interface that subscribers must implement.
@@ -178,19 +205,23 @@ func (addr AddressSet) shallowCopy() AddressSet {
 		Addresses:          addresses,
 		Labels:             labels,
 		LocalTrafficPolicy: addr.LocalTrafficPolicy,
+		PreferClose:        addr.PreferClose,
 	}
 }
 
 // NewEndpointsWatcher creates an EndpointsWatcher and begins watching the
 // k8sAPI for pod, service, and endpoint changes. An EndpointsWatcher will
 // watch on Endpoints or EndpointSlice resources, depending on cluster configuration.
-func NewEndpointsWatcher(k8sAPI *k8s.API, metadataAPI *k8s.MetadataAPI, log *logging.Entry, enableEndpointSlices bool, cluster string) (*EndpointsWatcher, error) {
+// When maxEndpointsPerService is greater than zero, the addresses published for
+// each service port are truncated to that many.
+func NewEndpointsWatcher(k8sAPI *k8s.API, metadataAPI *k8s.MetadataAPI, log *logging.Entry, enableEndpointSlices bool, cluster string, maxEndpointsPerService int) (*EndpointsWatcher, error) {
 	ew := &EndpointsWatcher{
-		publishers:           make(map[ServiceID]*servicePublisher),
-		k8sAPI:               k8sAPI,
-		metadataAPI:          metadataAPI,
-		enableEndpointSlices: enableEndpointSlices,
-		cluster:              cluster,
+		publishers:             make(map[ServiceID]*servicePublisher),
+		k8sAPI:                 k8sAPI,
+		metadataAPI:            metadataAPI,
+		enableEndpointSlices:   enableEndpointSlices,
+		maxEndpointsPerService: maxEndpointsPerService,
+		cluster:                cluster,
 		log: log.WithFields(logging.Fields{
 			"component": "endpoints-watcher",
 		}),
@@ -215,8 +246,12 @@ func NewEndpointsWatcher(k8sAPI *k8s.API, metadataAPI *k8s.MetadataAPI, log *log
 		return nil, err
 	}
 
+	// Only one of EndpointSlice or Endpoints resources is ever watched, so
+	// that an endpoint present in both while a cluster migrates between them
+	// is never published twice.
+	ew.log.Infof("Using %s resources as the source of endpoints; %s resources are ignored",
+		GetEndpointsSource(enableEndpointSlices), GetEndpointsSource(!enableEndpointSlices))
 	if ew.enableEndpointSlices {
-		ew.log.Debugf("Watching EndpointSlice resources")
 		ew.epHandle, err = k8sAPI.ES().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 			AddFunc:    ew.addEndpointSlice,
 			DeleteFunc: ew.deleteEndpointSlice,
@@ -227,7 +262,6 @@ func NewEndpointsWatcher(k8sAPI *k8s.API, metadataAPI *k8s.MetadataAPI, log *log
 		}
 
 	} else {
-		ew.log.Debugf("Watching Endpoints resources")
 		ew.epHandle, err = k8sAPI.Endpoint().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 			AddFunc:    ew.addEndpoints,
 			DeleteFunc: ew.deleteEndpoints,
@@ -240,6 +274,15 @@ func NewEndpointsWatcher(k8sAPI *k8s.API, metadataAPI *k8s.MetadataAPI, log *log
 	return ew, nil
 }
 
+// GetEndpointsSource returns the kind of resource endpoints are read from,
+// depending on whether EndpointSlices are enabled.
+func GetEndpointsSource(enableEndpointSlices bool) string {
+	if enableEndpointSlices {
+		return EndpointSliceSource
+	}
+	return EndpointsSource
+}
+
 ////////////////////////
 /// EndpointsWatcher ///
 ////////////////////////
@@ -259,9 +302,20 @@ func (ew *EndpointsWatcher) Subscribe(id ServiceID, port Port, hostname string,
 		ew.log.Debugf("Establishing watch on endpoint [%s.%s:%d]", hostname, id, port)
 	}
 
-	sp := ew.getOrNewServicePublisher(id)
+	// The publisher is marked as being subscribed to, so that it isn't removed
+	// before the listener is added, in case the service is being deleted. The
+	// watcher's lock isn't held while subscribing, as that blocks the informer
+	// callbacks of all services.
+	ew.Lock()
+	sp := ew.getOrNewServicePublisherLocked(id)
+	sp.subscribing++
+	ew.Unlock()
 
 	sp.subscribe(port, hostname, listener)
+
+	ew.Lock()
+	sp.subscribing--
+	ew.Unlock()
 	return nil
 }
 
@@ -279,6 +333,7 @@ func (ew *EndpointsWatcher) Unsubscribe(id ServiceID, port Port, hostname string
 		return
 	}
 	sp.unsubscribe(port, hostname, listener)
+	ew.removeServicePublisherIfDeleted(id)
 }
 
 // removeHandlers will de-register any event handlers used by the
@@ -361,6 +416,7 @@ func (ew *EndpointsWatcher) deleteService(obj interface{}) {
 	sp, ok := ew.getServicePublisher(id)
 	if ok {
 		sp.deleteEndpoints()
+		ew.removeServicePublisherIfDeleted(id)
 	}
 }
 
@@ -503,7 +559,12 @@ func (ew *EndpointsWatcher) deleteEndpointSlice(obj interface{}) {
 func (ew *EndpointsWatcher) getOrNewServicePublisher(id ServiceID) *servicePublisher {
 	ew.Lock()
 	defer ew.Unlock()
+	return ew.getOrNewServicePublisherLocked(id)
+}
 
+// getOrNewServicePublisherLocked is getOrNewServicePublisher for callers
+// already holding the watcher's lock.
+func (ew *EndpointsWatcher) getOrNewServicePublisherLocked(id ServiceID) *servicePublisher {
 	// If the service doesn't yet exist, create a stub for it so the listener can
 	// be registered.
 	sp, ok := ew.publishers[id]
@@ -515,11 +576,12 @@ func (ew *EndpointsWatcher) getOrNewServicePublisher(id ServiceID) *servicePubli
 				"ns":        id.Namespace,
 				"svc":       id.Name,
 			}),
-			k8sAPI:               ew.k8sAPI,
-			metadataAPI:          ew.metadataAPI,
-			cluster:              ew.cluster,
-			ports:                make(map[portAndHostname]*portPublisher),
-			enableEndpointSlices: ew.enableEndpointSlices,
+			k8sAPI:                 ew.k8sAPI,
+			metadataAPI:            ew.metadataAPI,
+			cluster:                ew.cluster,
+			ports:                  make(map[portAndHostname]*portPublisher),
+			enableEndpointSlices:   ew.enableEndpointSlices,
+			maxEndpointsPerService: ew.maxEndpointsPerService,
 		}
 		ew.publishers[id] = sp
 	}
@@ -533,6 +595,32 @@ func (ew *EndpointsWatcher) getServicePublisher(id ServiceID) (sp *servicePublis
 	return
 }
 
+// removeServicePublisherIfDeleted removes the servicePublisher for the given
+// id once its service has been deleted and it has no more subscribers, so
+// that the publishers of deleted services don't accumulate. Publishers of
+// existing services are kept, as they hold the service's traffic policy.
+func (ew *EndpointsWatcher) removeServicePublisherIfDeleted(id ServiceID) {
+	ew.Lock()
+	defer ew.Unlock()
+
+	sp, ok := ew.publishers[id]
+	if !ok || sp.subscribing > 0 {
+		return
+	}
+	_, err := ew.k8sAPI.Svc().Lister().Services(id.Namespace).Get(id.Name)
+	if !apierrors.IsNotFound(err) {
+		return
+	}
+
+	sp.Lock()
+	defer sp.Unlock()
+	if len(sp.ports) > 0 {
+		return
+	}
+	ew.log.Debugf("Removing publisher of deleted service %s", id)
+	delete(ew.publishers, id)
+}
+
 func (ew *EndpointsWatcher) addServer(obj interface{}) {
 	ew.Lock()
 	defer ew.Unlock()
@@ -646,14 +734,18 @@ func (sp *servicePublisher) updateService(newService *corev1.Service) {
 		sp.localTrafficPolicy = false
 	}
 
+	// set preferClose to true if TrafficDistribution is set to PreferClose
+	sp.preferClose = newService.Spec.TrafficDistribution != nil &&
+		*newService.Spec.TrafficDistribution == corev1.ServiceTrafficDistributionPreferClose
+
 	for key, port := range sp.ports {
 		newTargetPort := getTargetPort(newService, key.port)
 		if newTargetPort != port.targetPort {
 			port.updatePort(newTargetPort)
 		}
-		// update service endpoints with new localTrafficPolicy
-		if port.localTrafficPolicy != sp.localTrafficPolicy {
-			port.updateLocalTrafficPolicy(sp.localTrafficPolicy)
+		// update service endpoints with new localTrafficPolicy or preferClose
+		if port.localTrafficPolicy != sp.localTrafficPolicy || port.preferClose != sp.preferClose {
+			port.updateTrafficPolicy(sp.localTrafficPolicy, sp.preferClose)
 		}
 	}
 
@@ -719,6 +811,8 @@ func (sp *servicePublisher) newPortPublisher(srcPort Port, hostname string) *por
 		metrics:              endpointsVecs.newEndpointsMetrics(sp.metricsLabels(srcPort, hostname)),
 		enableEndpointSlices: sp.enableEndpointSlices,
 		localTrafficPolicy:   sp.localTrafficPolicy,
+		preferClose:          sp.preferClose,
+		maxAddresses:         sp.maxEndpointsPerService,
 	}
 
 	if port.enableEndpointSlices {
@@ -774,21 +868,15 @@ func (pp *portPublisher) updateEndpoints(endpoints *corev1.Endpoints) {
 		for _, listener := range pp.listeners {
 			listener.NoEndpoints(true)
 		}
+		pp.addresses = newAddressSet
+		pp.published = newAddressSet
+		pp.duplicates = nil
 	} else {
-		add, remove := diffAddresses(pp.addresses, newAddressSet)
-		for _, listener := range pp.listeners {
-			if len(remove.Addresses) > 0 {
-				listener.Remove(remove)
-			}
-			if len(add.Addresses) > 0 {
-				listener.Add(add)
-			}
-		}
+		pp.publish(newAddressSet)
 	}
-	pp.addresses = newAddressSet
 	pp.exists = true
 	pp.metrics.incUpdates()
-	pp.metrics.setPods(len(pp.addresses.Addresses))
+	pp.metrics.setPods(len(pp.published.Addresses))
 	pp.metrics.setExists(true)
 }
 
@@ -800,22 +888,15 @@ func (pp *portPublisher) addEndpointSlice(slice *discovery.EndpointSlice) {
 		}
 	}
 
-	add, _ := diffAddresses(pp.addresses, newAddressSet)
-	if len(add.Addresses) > 0 {
-		for _, listener := range pp.listeners {
-			listener.Add(add)
-		}
-	}
-
 	// even if the ES doesn't have addresses yet we need to create a new
 	// pp.addresses entry with the appropriate Labels and LocalTrafficPolicy,
 	// which isn't going to be captured during the ES update event when
 	// addresses get added
 
-	pp.addresses = newAddressSet
+	pp.publish(newAddressSet)
 	pp.exists = true
 	pp.metrics.incUpdates()
-	pp.metrics.setPods(len(pp.addresses.Addresses))
+	pp.metrics.setPods(len(pp.published.Addresses))
 	pp.metrics.setExists(true)
 }
 
@@ -824,6 +905,7 @@ func (pp *portPublisher) updateEndpointSlice(oldSlice *discovery.EndpointSlice,
 		Addresses:          make(map[ID]Address),
 		Labels:             pp.addresses.Labels,
 		LocalTrafficPolicy: pp.localTrafficPolicy,
+		PreferClose:        pp.preferClose,
 	}
 
 	for id, address := range pp.addresses.Addresses {
@@ -839,7 +921,21 @@ func (pp *portPublisher) updateEndpointSlice(oldSlice *discovery.EndpointSlice,
 		updatedAddressSet.Addresses[id] = address
 	}
 
-	add, remove := diffAddresses(pp.addresses, updatedAddressSet)
+	pp.publish(updatedAddressSet)
+	pp.exists = true
+	pp.metrics.incUpdates()
+	pp.metrics.setPods(len(pp.published.Addresses))
+	pp.metrics.setExists(true)
+}
+
+// publish makes set the current address set of the publisher and sends the
+// listeners the difference between what was last published and set, once
+// set is deduped and truncated to the maximum number of addresses. The
+// addresses left out are kept in set, so that they can be published later.
+func (pp *portPublisher) publish(set AddressSet) {
+	published := pp.truncateAddresses(pp.dedupeAddresses(set))
+
+	add, remove := diffAddresses(pp.published, published)
 	for _, listener := range pp.listeners {
 		if len(remove.Addresses) > 0 {
 			listener.Remove(remove)
@@ -849,11 +945,8 @@ func (pp *portPublisher) updateEndpointSlice(oldSlice *discovery.EndpointSlice,
 		}
 	}
 
-	pp.addresses = updatedAddressSet
-	pp.exists = true
-	pp.metrics.incUpdates()
-	pp.metrics.setPods(len(pp.addresses.Addresses))
-	pp.metrics.setExists(true)
+	pp.addresses = set
+	pp.published = published
 }
 
 func metricLabels(resource interface{}) map[string]string {
@@ -899,6 +992,7 @@ func (pp *portPublisher) endpointSliceToAddresses(es *discovery.EndpointSlice) A
 			Labels:             metricLabels(es),
 			Addresses:          make(map[ID]Address),
 			LocalTrafficPolicy: pp.localTrafficPolicy,
+			PreferClose:        pp.preferClose,
 		}
 	}
 
@@ -999,6 +1093,7 @@ func (pp *portPublisher) endpointSliceToAddresses(es *discovery.EndpointSlice) A
 		Addresses:          addresses,
 		Labels:             metricLabels(es),
 		LocalTrafficPolicy: pp.localTrafficPolicy,
+		PreferClose:        pp.preferClose,
 	}
 }
 
@@ -1217,11 +1312,15 @@ func (pp *portPublisher) resolveTargetPort(subset corev1.EndpointSubset) Port {
 	return undefinedEndpointPort
 }
 
-func (pp *portPublisher) updateLocalTrafficPolicy(localTrafficPolicy bool) {
+func (pp *portPublisher) updateTrafficPolicy(localTrafficPolicy, preferClose bool) {
 	pp.localTrafficPolicy = localTrafficPolicy
 	pp.addresses.LocalTrafficPolicy = localTrafficPolicy
+	pp.preferClose = preferClose
+	pp.addresses.PreferClose = preferClose
+	pp.published.LocalTrafficPolicy = localTrafficPolicy
+	pp.published.PreferClose = preferClose
 	for _, listener := range pp.listeners {
-		listener.Add(pp.addresses.shallowCopy())
+		listener.Add(pp.published.shallowCopy())
 	}
 }
 
@@ -1235,6 +1334,7 @@ func (pp *portPublisher) updatePort(targetPort namedPort) {
 		endpointSlices, err := pp.k8sAPI.ES().Lister().EndpointSlices(pp.id.Namespace).List(selector)
 		if err == nil {
 			pp.addresses = AddressSet{}
+			pp.published = AddressSet{}
 			for _, slice := range endpointSlices {
 				pp.addEndpointSlice(slice)
 			}
@@ -1252,28 +1352,128 @@ func (pp *portPublisher) updatePort(targetPort namedPort) {
 }
 
 func (pp *portPublisher) deleteEndpointSlice(es *discovery.EndpointSlice) {
-	addrSet := pp.endpointSliceToAddresses(es)
-	for id := range addrSet.Addresses {
-		delete(pp.addresses.Addresses, id)
-	}
-
-	for _, listener := range pp.listeners {
-		listener.Remove(addrSet)
+	// Only the addresses still known are removed: the slices of a deleted
+	// service are deleted after the service itself, once its listeners have
+	// already been told that it doesn't exist anymore.
+	updatedAddressSet := pp.addresses.shallowCopy()
+	for _, id := range pp.endpointSliceToIDs(es) {
+		delete(updatedAddressSet.Addresses, id)
 	}
 
-	if len(pp.addresses.Addresses) == 0 {
+	if len(updatedAddressSet.Addresses) == 0 {
+		if !pp.exists {
+			// The listeners already got a NoEndpoints(false)
+			return
+		}
+		if len(pp.published.Addresses) > 0 {
+			for _, listener := range pp.listeners {
+				listener.Remove(pp.published.shallowCopy())
+			}
+		}
 		pp.noEndpoints(false)
 	} else {
+		// Addresses that were cut from a truncated set take the place of
+		// the removed ones.
+		pp.publish(updatedAddressSet)
 		pp.exists = true
 		pp.metrics.incUpdates()
-		pp.metrics.setPods(len(pp.addresses.Addresses))
+		pp.metrics.setPods(len(pp.published.Addresses))
 		pp.metrics.setExists(true)
 	}
 }
 
+// truncateAddresses caps set to the publisher's maximum number of addresses,
+// keeping those with the lowest IDs so that the same endpoints are always
+// kept for a given set.
+func (pp *portPublisher) truncateAddresses(set AddressSet) AddressSet {
+	if pp.maxAddresses <= 0 || len(set.Addresses) <= pp.maxAddresses {
+		return set
+	}
+
+	ids := make([]ID, 0, len(set.Addresses))
+	for id := range set.Addresses {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return lessID(ids[i], ids[j]) })
+
+	pp.log.Warnf("Service %s has %d endpoints, more than the maximum of %d; only the first %d will be published",
+		pp.id, len(set.Addresses), pp.maxAddresses, pp.maxAddresses)
+	pp.metrics.incTruncated()
+
+	truncated := set.shallowCopy()
+	for _, id := range ids[pp.maxAddresses:] {
+		delete(truncated.Addresses, id)
+	}
+	return truncated
+}
+
+// dedupeAddresses drops the addresses of set that share their IP and port
+// with another address, which happens when pods transiently share an IP
+// because of a CNI misconfiguration. Of the addresses sharing an IP, the one
+// with the lowest ID is kept so that the same one is always published. Only
+// the addresses that weren't already dropped the last time are reported.
+func (pp *portPublisher) dedupeAddresses(set AddressSet) AddressSet {
+	kept := make(map[string]ID, len(set.Addresses))
+	var dropped []ID
+	for id, address := range set.Addresses {
+		if address.IP == "" {
+			continue
+		}
+		hostPort := net.JoinHostPort(address.IP, strconv.FormatUint(uint64(address.Port), 10))
+		other, ok := kept[hostPort]
+		if !ok {
+			kept[hostPort] = id
+			continue
+		}
+		if lessID(id, other) {
+			kept[hostPort] = id
+			id, other = other, id
+		}
+		dropped = append(dropped, id)
+	}
+	if len(dropped) == 0 {
+		pp.duplicates = nil
+		return set
+	}
+
+	deduped := set.shallowCopy()
+	duplicates := make(map[ID]struct{}, len(dropped))
+	newDuplicates := false
+	for _, id := range dropped {
+		delete(deduped.Addresses, id)
+		duplicates[id] = struct{}{}
+		if _, ok := pp.duplicates[id]; ok {
+			continue
+		}
+		newDuplicates = true
+		address := set.Addresses[id]
+		hostPort := net.JoinHostPort(address.IP, strconv.FormatUint(uint64(address.Port), 10))
+		pp.log.Warnf("Endpoints %s and %s of service %s share the address %s; only %s will be published",
+			kept[hostPort], id, pp.id, hostPort, kept[hostPort])
+	}
+	pp.duplicates = duplicates
+	if newDuplicates {
+		pp.metrics.incDuplicateIPs()
+	}
+	return deduped
+}
+
+// lessID orders IDs by namespace, name and then IP family.
+func lessID(a, b ID) bool {
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+	return a.IPFamily < b.IPFamily
+}
+
 func (pp *portPublisher) noEndpoints(exists bool) {
 	pp.exists = exists
 	pp.addresses = AddressSet{}
+	pp.published = AddressSet{}
+	pp.duplicates = nil
 	for _, listener := range pp.listeners {
 		listener.NoEndpoints(exists)
 	}
@@ -1285,8 +1485,8 @@ func (pp *portPublisher) noEndpoints(exists bool) {
 
 func (pp *portPublisher) subscribe(listener EndpointUpdateListener) {
 	if pp.exists {
-		if len(pp.addresses.Addresses) > 0 {
-			listener.Add(pp.addresses.shallowCopy())
+		if len(pp.published.Addresses) > 0 {
+			listener.Add(pp.published.shallowCopy())
 		} else {
 			listener.NoEndpoints(true)
 		}
@@ -1323,13 +1523,16 @@ func (pp *portPublisher) updateServer(oldServer, newServer *v1beta3.Server) {
 			}
 			if pp.addresses.Addresses[id].OpaqueProtocol != address.OpaqueProtocol {
 				pp.addresses.Addresses[id] = address
-				updated = true
+				if _, ok := pp.published.Addresses[id]; ok {
+					pp.published.Addresses[id] = address
+					updated = true
+				}
 			}
 		}
 	}
 	if updated {
 		for _, listener := range pp.listeners {
-			listener.Add(pp.addresses.shallowCopy())
+			listener.Add(pp.published.shallowCopy())
 		}
 		pp.metrics.incUpdates()
 	}
@@ -1484,6 +1687,7 @@ func diffAddresses(oldAddresses, newAddresses AddressSet) (add, remove AddressSe
 		Addresses:          addAddresses,
 		Labels:             newAddresses.Labels,
 		LocalTrafficPolicy: newAddresses.LocalTrafficPolicy,
+		PreferClose:        newAddresses.PreferClose,
 	}
 	remove = AddressSet{
 		Addresses: removeAddresses,
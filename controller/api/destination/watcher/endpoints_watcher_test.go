@@ -12,6 +12,7 @@ import (
 	"github.com/linkerd/linkerd2/controller/k8s"
 	consts "github.com/linkerd/linkerd2/pkg/k8s"
 	"github.com/linkerd/linkerd2/testutil"
+	dto "github.com/prometheus/client_model/go"
 	logging "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
 	dv1 "k8s.io/api/discovery/v1"
@@ -23,6 +24,7 @@ type bufferingEndpointListener struct {
 	added              []string
 	removed            []string
 	localTrafficPolicy bool
+	preferClose        bool
 	noEndpointsCalled  bool
 	noEndpointsExist   bool
 	sync.Mutex
@@ -82,6 +84,7 @@ func (bel *bufferingEndpointListener) Add(set AddressSet) {
 		bel.added = append(bel.added, addressString(address))
 	}
 	bel.localTrafficPolicy = set.LocalTrafficPolicy
+	bel.preferClose = set.PreferClose
 }
 
 func (bel *bufferingEndpointListener) Remove(set AddressSet) {
@@ -675,7 +678,7 @@ status:
 				t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 			}
 
-			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), false, "local")
+			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), false, "local", 0)
 			if err != nil {
 				t.Fatalf("can't create Endpoints watcher: %s", err)
 			}
@@ -708,6 +711,159 @@ status:
 	}
 }
 
+// Test that when both Endpoints and EndpointSlice resources exist for a
+// service, as they do while a cluster migrates between them, only the
+// configured source is used and no endpoint is counted from both
+func TestEndpointsWatcherSourceOfTruth(t *testing.T) {
+	k8sConfigs := []string{`
+kind: APIResourceList
+apiVersion: v1
+groupVersion: discovery.k8s.io/v1
+resources:
+- name: endpointslices
+  singularName: endpointslice
+  namespaced: true
+  kind: EndpointSlice
+  verbs:
+    - delete
+    - deletecollection
+    - get
+    - list
+    - patch
+    - create
+    - update
+    - watch
+`, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: name1
+  namespace: ns
+spec:
+  type: LoadBalancer
+  ports:
+  - port: 8989`, `
+apiVersion: v1
+kind: Endpoints
+metadata:
+  name: name1
+  namespace: ns
+subsets:
+- addresses:
+  - ip: 172.17.0.12
+    targetRef:
+      kind: Pod
+      name: name1-1
+      namespace: ns
+  - ip: 172.17.0.19
+    targetRef:
+      kind: Pod
+      name: name1-2
+      namespace: ns
+  ports:
+  - port: 8989`, `
+addressType: IPv4
+apiVersion: discovery.k8s.io/v1
+endpoints:
+- addresses:
+  - 172.17.0.12
+  conditions:
+    ready: true
+  targetRef:
+    kind: Pod
+    name: name1-1
+    namespace: ns
+- addresses:
+  - 172.17.0.20
+  conditions:
+    ready: true
+  targetRef:
+    kind: Pod
+    name: name1-3
+    namespace: ns
+kind: EndpointSlice
+metadata:
+  labels:
+    kubernetes.io/service-name: name1
+  name: name1-es
+  namespace: ns
+ports:
+- name: ""
+  port: 8989`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: name1-1
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.12`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: name1-2
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.19`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: name1-3
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.20`,
+	}
+
+	for _, tt := range []struct {
+		name                 string
+		enableEndpointSlices bool
+		expectedAddresses    []string
+	}{
+		{
+			name:                 "EndpointSlice",
+			enableEndpointSlices: true,
+			expectedAddresses:    []string{"172.17.0.12:8989", "172.17.0.20:8989"},
+		},
+		{
+			name:                 "Endpoints",
+			enableEndpointSlices: false,
+			expectedAddresses:    []string{"172.17.0.12:8989", "172.17.0.19:8989"},
+		},
+	} {
+		tt := tt // pin
+		t.Run("uses "+tt.name+" resources", func(t *testing.T) {
+			k8sAPI, err := k8s.NewFakeAPI(k8sConfigs...)
+			if err != nil {
+				t.Fatalf("NewFakeAPI returned an error: %s", err)
+			}
+
+			metadataAPI, err := k8s.NewFakeMetadataAPI(nil)
+			if err != nil {
+				t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
+			}
+
+			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), tt.enableEndpointSlices, "local", 0)
+			if err != nil {
+				t.Fatalf("can't create Endpoints watcher: %s", err)
+			}
+
+			k8sAPI.Sync(nil)
+			metadataAPI.Sync(nil)
+
+			listener := newBufferingEndpointListener()
+
+			err = watcher.Subscribe(ServiceID{Name: "name1", Namespace: "ns"}, 8989, "", listener)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			listener.ExpectAdded(tt.expectedAddresses, t)
+		})
+	}
+}
+
 func TestEndpointsWatcherWithEndpointSlices(t *testing.T) {
 	for _, tt := range []struct {
 		serviceType                      string
@@ -720,6 +876,7 @@ func TestEndpointsWatcherWithEndpointSlices(t *testing.T) {
 		expectedNoEndpointsServiceExists bool
 		expectedError                    bool
 		expectedLocalTrafficPolicy       bool
+		expectedPreferClose              bool
 	}{
 		{
 			serviceType: "local services with EndpointSlice",
@@ -851,6 +1008,82 @@ status:
 			expectedError:                    false,
 			expectedLocalTrafficPolicy:       true,
 		},
+		{
+			serviceType: "PreferClose services with EndpointSlice",
+			k8sConfigs: []string{`
+kind: APIResourceList
+apiVersion: v1
+groupVersion: discovery.k8s.io/v1
+resources:
+  - name: endpointslices
+    singularName: endpointslice
+    namespaced: true
+    kind: EndpointSlice
+    verbs:
+      - delete
+      - deletecollection
+      - get
+      - list
+      - patch
+      - create
+      - update
+      - watch
+`, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: name-1
+  namespace: ns
+spec:
+  type: LoadBalancer
+  ports:
+  - port: 8989
+  trafficDistribution: PreferClose`,
+				`
+addressType: IPv4
+apiVersion: discovery.k8s.io/v1
+endpoints:
+- addresses:
+  - 172.17.0.12
+  conditions:
+    ready: true
+  targetRef:
+    kind: Pod
+    name: name-1-1
+    namespace: ns
+  zone: west-1a
+kind: EndpointSlice
+metadata:
+  labels:
+    kubernetes.io/service-name: name-1
+  name: name-1-bhnqh
+  namespace: ns
+ports:
+- name: ""
+  port: 8989`,
+				`
+apiVersion: v1
+kind: Pod
+metadata:
+  name: name-1-1
+  namespace: ns
+  ownerReferences:
+  - kind: ReplicaSet
+    name: rs-1
+status:
+  phase: Running
+  podIP: 172.17.0.12`,
+			},
+			id:   ServiceID{Name: "name-1", Namespace: "ns"},
+			port: 8989,
+			expectedAddresses: []string{
+				"172.17.0.12:8989",
+			},
+			expectedNoEndpoints:              false,
+			expectedNoEndpointsServiceExists: false,
+			expectedError:                    false,
+			expectedPreferClose:              true,
+		},
 		{
 			serviceType: "local services with missing addresses and EndpointSlice",
 			k8sConfigs: []string{`
@@ -1303,7 +1536,7 @@ status:
 				t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 			}
 
-			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local")
+			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 0)
 			if err != nil {
 				t.Fatalf("can't create Endpoints watcher: %s", err)
 			}
@@ -1325,6 +1558,10 @@ status:
 				t.Fatalf("Expected localTrafficPolicy [%v], got [%v]", tt.expectedLocalTrafficPolicy, listener.localTrafficPolicy)
 			}
 
+			if listener.preferClose != tt.expectedPreferClose {
+				t.Fatalf("Expected preferClose [%v], got [%v]", tt.expectedPreferClose, listener.preferClose)
+			}
+
 			listener.ExpectAdded(tt.expectedAddresses, t)
 
 			if listener.endpointsAreNotCalled() != tt.expectedNoEndpoints {
@@ -1715,7 +1952,7 @@ status:
 				t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 			}
 
-			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local")
+			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 0)
 			if err != nil {
 				t.Fatalf("can't create Endpoints watcher: %s", err)
 			}
@@ -1845,7 +2082,7 @@ status:
 				t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 			}
 
-			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), false, "local")
+			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), false, "local", 0)
 			if err != nil {
 				t.Fatalf("can't create Endpoints watcher: %s", err)
 			}
@@ -2019,7 +2256,7 @@ status:
 				t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 			}
 
-			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local")
+			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 0)
 			if err != nil {
 				t.Fatalf("can't create Endpoints watcher: %s", err)
 			}
@@ -2189,7 +2426,7 @@ status:
 				t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 			}
 
-			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local")
+			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 0)
 			if err != nil {
 				t.Fatalf("can't create Endpoints watcher: %s", err)
 			}
@@ -2426,7 +2663,7 @@ subsets:
 				t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 			}
 
-			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), tt.enableEndpointSlices, "local")
+			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), tt.enableEndpointSlices, "local", 0)
 			if err != nil {
 				t.Fatalf("can't create Endpoints watcher: %s", err)
 			}
@@ -2595,7 +2832,7 @@ subsets:
 				t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 			}
 
-			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), false, "local")
+			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), false, "local", 0)
 			if err != nil {
 				t.Fatalf("can't create Endpoints watcher: %s", err)
 			}
@@ -2727,7 +2964,7 @@ status:
 				t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 			}
 
-			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), false, "local")
+			watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), false, "local", 0)
 			if err != nil {
 				t.Fatalf("can't create Endpoints watcher: %s", err)
 			}
@@ -2829,7 +3066,7 @@ status:
 		t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 	}
 
-	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local")
+	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 0)
 	if err != nil {
 		t.Fatalf("can't create Endpoints watcher: %s", err)
 	}
@@ -2992,7 +3229,7 @@ status:
 		t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 	}
 
-	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local")
+	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 0)
 	if err != nil {
 		t.Fatalf("can't create Endpoints watcher: %s", err)
 	}
@@ -3179,7 +3416,7 @@ status:
 		t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 	}
 
-	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local")
+	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 0)
 	if err != nil {
 		t.Fatalf("can't create Endpoints watcher: %s", err)
 	}
@@ -3226,6 +3463,891 @@ status:
 
 }
 
+// Test that a pod held back by a readiness gate is only added once it becomes
+// ready, and that the slice updates it goes through on the way don't cause
+// any churn for the subscribers
+func TestEndpointSliceReadinessGate(t *testing.T) {
+	k8sConfigsWithES := []string{`
+kind: APIResourceList
+apiVersion: v1
+groupVersion: discovery.k8s.io/v1
+resources:
+- name: endpointslices
+  singularName: endpointslice
+  namespaced: true
+  kind: EndpointSlice
+  verbs:
+    - delete
+    - deletecollection
+    - get
+    - list
+    - patch
+    - create
+    - update
+    - watch
+`, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: name1
+  namespace: ns
+spec:
+  type: LoadBalancer
+  ports:
+  - port: 8989`, `
+addressType: IPv4
+apiVersion: discovery.k8s.io/v1
+endpoints:
+- addresses:
+  - 172.17.0.12
+  conditions:
+    ready: true
+  targetRef:
+    kind: Pod
+    name: name1-1
+    namespace: ns
+- addresses:
+  - 172.17.0.13
+  conditions:
+    ready: false
+    serving: false
+  targetRef:
+    kind: Pod
+    name: name1-2
+    namespace: ns
+kind: EndpointSlice
+metadata:
+  labels:
+    kubernetes.io/service-name: name1
+  name: name1-es
+  namespace: ns
+ports:
+- name: ""
+  port: 8989`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: name1-1
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.12`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: name1-2
+  namespace: ns
+spec:
+  readinessGates:
+  - conditionType: example.com/warmed-up
+status:
+  phase: Running
+  podIP: 172.17.0.13
+  conditions:
+  - type: ContainersReady
+    status: "True"
+  - type: example.com/warmed-up
+    status: "False"
+  - type: Ready
+    status: "False"`,
+	}
+
+	k8sAPI, err := k8s.NewFakeAPI(k8sConfigsWithES...)
+	if err != nil {
+		t.Fatalf("NewFakeAPI returned an error: %s", err)
+	}
+
+	metadataAPI, err := k8s.NewFakeMetadataAPI(nil)
+	if err != nil {
+		t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
+	}
+
+	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 0)
+	if err != nil {
+		t.Fatalf("can't create Endpoints watcher: %s", err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	listener := newBufferingEndpointListener()
+
+	err = watcher.Subscribe(ServiceID{Name: "name1", Namespace: "ns"}, 8989, "", listener)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	// The pod whose readiness gate is pending isn't added
+	listener.ExpectAdded([]string{"172.17.0.12:8989"}, t)
+
+	updateSlice := func(update func(es *dv1.EndpointSlice)) {
+		t.Helper()
+		es, err := k8sAPI.Client.DiscoveryV1().EndpointSlices("ns").Get(context.Background(), "name1-es", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		update(es)
+		_, err = k8sAPI.Client.DiscoveryV1().EndpointSlices("ns").Update(context.Background(), es, metav1.UpdateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		k8sAPI.Sync(nil)
+		metadataAPI.Sync(nil)
+
+		// Wait for the update to be processed because there is no blocking call currently in k8s that we can wait on
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	// The pod's containers become ready and it starts serving, but the
+	// readiness gate is still pending so the endpoint isn't ready yet
+	serving := true
+	updateSlice(func(es *dv1.EndpointSlice) {
+		es.Endpoints[1].Conditions.Serving = &serving
+	})
+	listener.ExpectAdded([]string{"172.17.0.12:8989"}, t)
+	listener.ExpectRemoved([]string{}, t)
+
+	// The readiness gate passes and the endpoint becomes ready
+	ready := true
+	updateSlice(func(es *dv1.EndpointSlice) {
+		es.Endpoints[1].Conditions.Ready = &ready
+	})
+	listener.ExpectAdded([]string{"172.17.0.12:8989", "172.17.0.13:8989"}, t)
+
+	// Further updates to the slice that don't change the endpoints don't add
+	// it again
+	updateSlice(func(es *dv1.EndpointSlice) {
+		es.Annotations = map[string]string{"endpoints.kubernetes.io/last-change-trigger-time": "2024-01-01T00:00:00Z"}
+	})
+	listener.ExpectAdded([]string{"172.17.0.12:8989", "172.17.0.13:8989"}, t)
+	listener.ExpectRemoved([]string{}, t)
+}
+
+// Test that the endpoints of a service exceeding the maximum number of
+// endpoints per service are truncated, always keeping the same ones
+func TestEndpointsWatcherMaxEndpointsPerService(t *testing.T) {
+	k8sConfigsWithES := []string{`
+kind: APIResourceList
+apiVersion: v1
+groupVersion: discovery.k8s.io/v1
+resources:
+- name: endpointslices
+  singularName: endpointslice
+  namespaced: true
+  kind: EndpointSlice
+  verbs:
+    - delete
+    - deletecollection
+    - get
+    - list
+    - patch
+    - create
+    - update
+    - watch
+`, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: big
+  namespace: ns
+spec:
+  type: LoadBalancer
+  ports:
+  - port: 8989`, `
+addressType: IPv4
+apiVersion: discovery.k8s.io/v1
+endpoints:
+- addresses:
+  - 172.17.0.11
+  conditions:
+    ready: true
+  targetRef:
+    kind: Pod
+    name: big-1
+    namespace: ns
+- addresses:
+  - 172.17.0.12
+  conditions:
+    ready: true
+  targetRef:
+    kind: Pod
+    name: big-2
+    namespace: ns
+- addresses:
+  - 172.17.0.13
+  conditions:
+    ready: true
+  targetRef:
+    kind: Pod
+    name: big-3
+    namespace: ns
+kind: EndpointSlice
+metadata:
+  labels:
+    kubernetes.io/service-name: big
+  name: big-es
+  namespace: ns
+ports:
+- name: ""
+  port: 8989`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: big-1
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.11`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: big-2
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.12`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: big-3
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.13`,
+	}
+
+	k8sAPI, err := k8s.NewFakeAPI(k8sConfigsWithES...)
+	if err != nil {
+		t.Fatalf("NewFakeAPI returned an error: %s", err)
+	}
+
+	metadataAPI, err := k8s.NewFakeMetadataAPI(nil)
+	if err != nil {
+		t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
+	}
+
+	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 2)
+	if err != nil {
+		t.Fatalf("can't create Endpoints watcher: %s", err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	listener := newBufferingEndpointListener()
+
+	err = watcher.Subscribe(ServiceID{Name: "big", Namespace: "ns"}, 8989, "", listener)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	// Only the endpoints of the first two pods are kept
+	listener.ExpectAdded([]string{"172.17.0.11:8989", "172.17.0.12:8989"}, t)
+
+	var metric dto.Metric
+	if err := endpointsVecs.truncated.With(endpointsLabels("local", "ns", "big", "8989", "")).Write(&metric); err != nil {
+		t.Fatal(err)
+	}
+	if metric.GetCounter().GetValue() < 1 {
+		t.Fatalf("Expected the truncation to be counted, got %v", metric.GetCounter().GetValue())
+	}
+
+	es, err := k8sAPI.Client.DiscoveryV1().EndpointSlices("ns").Get(context.Background(), "big-es", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Once the first pod goes away, the third one takes its place
+	es.Endpoints = es.Endpoints[1:]
+	_, err = k8sAPI.Client.DiscoveryV1().EndpointSlices("ns").Update(context.Background(), es, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	// Wait for the update to be processed because there is no blocking call currently in k8s that we can wait on
+	time.Sleep(50 * time.Millisecond)
+
+	listener.ExpectRemoved([]string{"172.17.0.11:8989"}, t)
+	listener.ExpectAdded([]string{"172.17.0.11:8989", "172.17.0.12:8989", "172.17.0.13:8989"}, t)
+}
+
+// Test that the endpoints cut from a truncated service are published once the
+// slice holding the kept ones is deleted
+func TestEndpointsWatcherMaxEndpointsPerServiceMultipleSlices(t *testing.T) {
+	k8sConfigsWithES := []string{`
+kind: APIResourceList
+apiVersion: v1
+groupVersion: discovery.k8s.io/v1
+resources:
+- name: endpointslices
+  singularName: endpointslice
+  namespaced: true
+  kind: EndpointSlice
+  verbs:
+    - delete
+    - deletecollection
+    - get
+    - list
+    - patch
+    - create
+    - update
+    - watch
+`, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: big
+  namespace: ns
+spec:
+  type: LoadBalancer
+  ports:
+  - port: 8989`, `
+addressType: IPv4
+apiVersion: discovery.k8s.io/v1
+endpoints:
+- addresses:
+  - 172.17.0.11
+  conditions:
+    ready: true
+  targetRef:
+    kind: Pod
+    name: big-1
+    namespace: ns
+- addresses:
+  - 172.17.0.12
+  conditions:
+    ready: true
+  targetRef:
+    kind: Pod
+    name: big-2
+    namespace: ns
+kind: EndpointSlice
+metadata:
+  labels:
+    kubernetes.io/service-name: big
+  name: big-es-1
+  namespace: ns
+ports:
+- name: ""
+  port: 8989`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: big-1
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.11`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: big-2
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.12`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: big-3
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.13`,
+	}
+
+	k8sAPI, err := k8s.NewFakeAPI(k8sConfigsWithES...)
+	if err != nil {
+		t.Fatalf("NewFakeAPI returned an error: %s", err)
+	}
+
+	metadataAPI, err := k8s.NewFakeMetadataAPI(nil)
+	if err != nil {
+		t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
+	}
+
+	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 2)
+	if err != nil {
+		t.Fatalf("can't create Endpoints watcher: %s", err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	listener := newBufferingEndpointListener()
+
+	err = watcher.Subscribe(ServiceID{Name: "big", Namespace: "ns"}, 8989, "", listener)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	listener.ExpectAdded([]string{"172.17.0.11:8989", "172.17.0.12:8989"}, t)
+
+	es := &dv1.EndpointSlice{
+		AddressType: "IPv4",
+		ObjectMeta:  metav1.ObjectMeta{Name: "big-es-2", Namespace: "ns", Labels: map[string]string{dv1.LabelServiceName: "big"}},
+		Endpoints: []dv1.Endpoint{
+			{
+				Addresses:  []string{"172.17.0.13"},
+				Conditions: dv1.EndpointConditions{Ready: func(b bool) *bool { return &b }(true)},
+				TargetRef:  &corev1.ObjectReference{Name: "big-3", Namespace: "ns", Kind: "Pod"},
+			},
+		},
+		Ports: []dv1.EndpointPort{
+			{
+				Name: func(s string) *string { return &s }(""),
+				Port: func(i int32) *int32 { return &i }(8989),
+			},
+		},
+	}
+	_, err = k8sAPI.Client.DiscoveryV1().EndpointSlices("ns").Create(context.Background(), es, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	// Wait for the update to be processed because there is no blocking call currently in k8s that we can wait on
+	time.Sleep(50 * time.Millisecond)
+
+	// The new slice's endpoint is cut
+	listener.ExpectAdded([]string{"172.17.0.11:8989", "172.17.0.12:8989"}, t)
+
+	err = k8sAPI.Client.DiscoveryV1().EndpointSlices("ns").Delete(context.Background(), "big-es-1", metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	time.Sleep(50 * time.Millisecond)
+
+	// Once the kept endpoints are gone, the cut one is published
+	listener.ExpectRemoved([]string{"172.17.0.11:8989", "172.17.0.12:8989"}, t)
+	listener.ExpectAdded([]string{"172.17.0.11:8989", "172.17.0.12:8989", "172.17.0.13:8989"}, t)
+	if listener.endpointsAreNotCalled() {
+		t.Fatal("Expected no NoEndpoints to be sent while a slice still has endpoints")
+	}
+}
+
+// Test that when several endpoints share an IP, only the one with the lowest
+// ID is published
+func TestEndpointsWatcherDuplicateIPs(t *testing.T) {
+	k8sConfigsWithES := []string{`
+kind: APIResourceList
+apiVersion: v1
+groupVersion: discovery.k8s.io/v1
+resources:
+- name: endpointslices
+  singularName: endpointslice
+  namespaced: true
+  kind: EndpointSlice
+  verbs:
+    - delete
+    - deletecollection
+    - get
+    - list
+    - patch
+    - create
+    - update
+    - watch
+`, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: dup
+  namespace: ns
+spec:
+  type: LoadBalancer
+  ports:
+  - port: 8989`, `
+addressType: IPv4
+apiVersion: discovery.k8s.io/v1
+endpoints:
+- addresses:
+  - 172.17.0.21
+  conditions:
+    ready: true
+  targetRef:
+    kind: Pod
+    name: dup-b
+    namespace: ns
+- addresses:
+  - 172.17.0.21
+  conditions:
+    ready: true
+  targetRef:
+    kind: Pod
+    name: dup-a
+    namespace: ns
+- addresses:
+  - 172.17.0.22
+  conditions:
+    ready: true
+  targetRef:
+    kind: Pod
+    name: dup-c
+    namespace: ns
+kind: EndpointSlice
+metadata:
+  labels:
+    kubernetes.io/service-name: dup
+  name: dup-es
+  namespace: ns
+ports:
+- name: ""
+  port: 8989`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: dup-a
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.21`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: dup-b
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.21`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: dup-c
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.22`,
+	}
+
+	k8sAPI, err := k8s.NewFakeAPI(k8sConfigsWithES...)
+	if err != nil {
+		t.Fatalf("NewFakeAPI returned an error: %s", err)
+	}
+
+	metadataAPI, err := k8s.NewFakeMetadataAPI(nil)
+	if err != nil {
+		t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
+	}
+
+	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 0)
+	if err != nil {
+		t.Fatalf("can't create Endpoints watcher: %s", err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	listener := newBufferingEndpointListener()
+
+	err = watcher.Subscribe(ServiceID{Name: "dup", Namespace: "ns"}, 8989, "", listener)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	// The shared IP is only published once
+	listener.ExpectAdded([]string{"172.17.0.21:8989", "172.17.0.22:8989"}, t)
+
+	sp := watcher.publishers[ServiceID{Name: "dup", Namespace: "ns"}]
+	podNames := func(set AddressSet) []string {
+		pods := []string{}
+		for id := range set.Addresses {
+			pods = append(pods, id.Name)
+		}
+		sort.Strings(pods)
+		return pods
+	}
+	// The duplicate is left out of the published addresses only
+	sp.Lock()
+	testCompare(t, []string{"dup-a", "dup-b", "dup-c"}, podNames(sp.ports[portAndHostname{port: 8989}].addresses))
+	testCompare(t, []string{"dup-a", "dup-c"}, podNames(sp.ports[portAndHostname{port: 8989}].published))
+	sp.Unlock()
+
+	duplicateIPs := func() float64 {
+		var metric dto.Metric
+		if err := endpointsVecs.duplicateIPs.With(endpointsLabels("local", "ns", "dup", "8989", "")).Write(&metric); err != nil {
+			t.Fatal(err)
+		}
+		return metric.GetCounter().GetValue()
+	}
+	counted := duplicateIPs()
+	if counted < 1 {
+		t.Fatalf("Expected the duplicate IP to be counted, got %v", counted)
+	}
+
+	es, err := k8sAPI.Client.DiscoveryV1().EndpointSlices("ns").Get(context.Background(), "dup-es", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An update still holding the same duplicate doesn't count it again
+	es.Endpoints = es.Endpoints[:2]
+	_, err = k8sAPI.Client.DiscoveryV1().EndpointSlices("ns").Update(context.Background(), es, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	// Wait for the update to be processed because there is no blocking call currently in k8s that we can wait on
+	time.Sleep(50 * time.Millisecond)
+
+	listener.ExpectRemoved([]string{"172.17.0.22:8989"}, t)
+	if value := duplicateIPs(); value != counted {
+		t.Fatalf("Expected the duplicate IP not to be counted again, got %v instead of %v", value, counted)
+	}
+
+	// Once the kept endpoint goes away, the duplicate takes its place
+	es.Endpoints = es.Endpoints[:1]
+	_, err = k8sAPI.Client.DiscoveryV1().EndpointSlices("ns").Update(context.Background(), es, metav1.UpdateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	time.Sleep(50 * time.Millisecond)
+
+	listener.ExpectRemoved([]string{"172.17.0.21:8989", "172.17.0.22:8989"}, t)
+	listener.ExpectAdded([]string{"172.17.0.21:8989", "172.17.0.21:8989", "172.17.0.22:8989"}, t)
+	sp.Lock()
+	testCompare(t, []string{"dup-b"}, podNames(sp.ports[portAndHostname{port: 8989}].published))
+	sp.Unlock()
+}
+
+// Test that deleting a service with active subscribers tells them it doesn't
+// exist anymore, without removing addresses afterwards when its slices are
+// deleted, and that its publisher is removed once they unsubscribe
+func TestEndpointsWatcherServiceDeletedWithSubscribers(t *testing.T) {
+	k8sConfigsWithES := []string{`
+kind: APIResourceList
+apiVersion: v1
+groupVersion: discovery.k8s.io/v1
+resources:
+- name: endpointslices
+  singularName: endpointslice
+  namespaced: true
+  kind: EndpointSlice
+  verbs:
+    - delete
+    - deletecollection
+    - get
+    - list
+    - patch
+    - create
+    - update
+    - watch
+`, `
+apiVersion: v1
+kind: Service
+metadata:
+  name: gone
+  namespace: ns
+spec:
+  type: LoadBalancer
+  ports:
+  - port: 8989`, `
+addressType: IPv4
+apiVersion: discovery.k8s.io/v1
+endpoints:
+- addresses:
+  - 172.17.0.31
+  conditions:
+    ready: true
+  targetRef:
+    kind: Pod
+    name: gone-1
+    namespace: ns
+kind: EndpointSlice
+metadata:
+  labels:
+    kubernetes.io/service-name: gone
+  name: gone-es
+  namespace: ns
+ports:
+- name: ""
+  port: 8989`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: gone-1
+  namespace: ns
+status:
+  phase: Running
+  podIP: 172.17.0.31`,
+	}
+
+	k8sAPI, err := k8s.NewFakeAPI(k8sConfigsWithES...)
+	if err != nil {
+		t.Fatalf("NewFakeAPI returned an error: %s", err)
+	}
+
+	metadataAPI, err := k8s.NewFakeMetadataAPI(nil)
+	if err != nil {
+		t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
+	}
+
+	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 0)
+	if err != nil {
+		t.Fatalf("can't create Endpoints watcher: %s", err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	id := ServiceID{Name: "gone", Namespace: "ns"}
+	listeners := []*bufferingEndpointListener{newBufferingEndpointListener(), newBufferingEndpointListener()}
+	for _, listener := range listeners {
+		if err := watcher.Subscribe(id, 8989, "", listener); err != nil {
+			t.Fatal(err)
+		}
+		listener.ExpectAdded([]string{"172.17.0.31:8989"}, t)
+	}
+
+	err = k8sAPI.Client.CoreV1().Services("ns").Delete(context.Background(), "gone", metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the deletion to be processed because there is no blocking call currently in k8s that we can wait on
+	time.Sleep(50 * time.Millisecond)
+
+	for _, listener := range listeners {
+		if !listener.endpointsAreNotCalled() || listener.endpointsDoNotExist() {
+			t.Fatal("Expected NoEndpoints(false) to be called")
+		}
+	}
+
+	// The slices of the service are deleted afterwards
+	err = k8sAPI.Client.DiscoveryV1().EndpointSlices("ns").Delete(context.Background(), "gone-es", metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	for _, listener := range listeners {
+		listener.ExpectRemoved([]string{}, t)
+	}
+
+	// The publisher is kept as long as there are subscribers
+	watcher.Unsubscribe(id, 8989, "", listeners[0])
+	if _, ok := watcher.getServicePublisher(id); !ok {
+		t.Fatal("Expected the publisher to be kept while it has subscribers")
+	}
+
+	watcher.Unsubscribe(id, 8989, "", listeners[1])
+	if _, ok := watcher.getServicePublisher(id); ok {
+		t.Fatal("Expected the publisher of the deleted service to be removed")
+	}
+}
+
+// blockingNoEndpointsListener is a listener whose NoEndpoints blocks until
+// it's released, holding up the Subscribe it's called from.
+type blockingNoEndpointsListener struct {
+	*bufferingEndpointListener
+	called  chan struct{}
+	release chan struct{}
+}
+
+func (bl *blockingNoEndpointsListener) NoEndpoints(exists bool) {
+	close(bl.called)
+	<-bl.release
+	bl.bufferingEndpointListener.NoEndpoints(exists)
+}
+
+func TestEndpointsWatcherSubscribeDoesNotHoldWatcherLock(t *testing.T) {
+	k8sAPI, err := k8s.NewFakeAPI()
+	if err != nil {
+		t.Fatalf("NewFakeAPI returned an error: %s", err)
+	}
+
+	metadataAPI, err := k8s.NewFakeMetadataAPI(nil)
+	if err != nil {
+		t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
+	}
+
+	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), false, "local", 0)
+	if err != nil {
+		t.Fatalf("can't create Endpoints watcher: %s", err)
+	}
+
+	k8sAPI.Sync(nil)
+	metadataAPI.Sync(nil)
+
+	// Neither service exists, so subscribing calls NoEndpoints(false)
+	blockedID := ServiceID{Name: "blocked", Namespace: "ns"}
+	blocking := &blockingNoEndpointsListener{
+		bufferingEndpointListener: newBufferingEndpointListener(),
+		called:                    make(chan struct{}),
+		release:                   make(chan struct{}),
+	}
+	subscribed := make(chan error)
+	go func() {
+		subscribed <- watcher.Subscribe(blockedID, 8989, "", blocking)
+	}()
+	<-blocking.called
+
+	// The publisher being subscribed to isn't removed, although its service
+	// doesn't exist and it has no listener yet
+	watcher.removeServicePublisherIfDeleted(blockedID)
+	if _, ok := watcher.getServicePublisher(blockedID); !ok {
+		t.Fatal("Expected the publisher to be kept while it's being subscribed to")
+	}
+
+	// Other services can be subscribed to in the meantime
+	otherSubscribed := make(chan error)
+	go func() {
+		otherSubscribed <- watcher.Subscribe(ServiceID{Name: "other", Namespace: "ns"}, 8989, "", newBufferingEndpointListener())
+	}()
+	select {
+	case err := <-otherSubscribed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out subscribing to another service while a Subscribe was blocked")
+	}
+
+	close(blocking.release)
+	if err := <-subscribed; err != nil {
+		t.Fatal(err)
+	}
+	if !blocking.endpointsAreNotCalled() || blocking.endpointsDoNotExist() {
+		t.Fatal("Expected NoEndpoints(false) to be called")
+	}
+
+	// Once the subscriber is gone, the publisher of the missing service is
+	// removed
+	watcher.Unsubscribe(blockedID, 8989, "", blocking)
+	if _, ok := watcher.getServicePublisher(blockedID); ok {
+		t.Fatal("Expected the publisher of the missing service to be removed")
+	}
+}
+
 // Test that when an endpointslice gets a hint added, then mark it as a change
 func TestEndpointSliceAddHints(t *testing.T) {
 	k8sConfigsWithES := []string{`
@@ -3299,7 +4421,7 @@ status:
 		t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 	}
 
-	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local")
+	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 0)
 	if err != nil {
 		t.Fatalf("can't create Endpoints watcher: %s", err)
 	}
@@ -3419,7 +4541,7 @@ status:
 		t.Fatalf("NewFakeMetadataAPI returned an error: %s", err)
 	}
 
-	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local")
+	watcher, err := NewEndpointsWatcher(k8sAPI, metadataAPI, logging.WithField("test", t.Name()), true, "local", 0)
 	if err != nil {
 		t.Fatalf("can't create Endpoints watcher: %s", err)
 	}
@@ -24,7 +24,7 @@ type (
 		k8sAPI             *k8s.API
 		subscribersGauge   *prometheus.GaugeVec
 		log                *logging.Entry
-		defaultOpaquePorts map[uint32]struct{}
+		defaultOpaquePorts DefaultOpaquePorts
 		sync.RWMutex
 	}
 
@@ -49,7 +49,7 @@ var opaquePortsMetrics = promauto.NewGaugeVec(
 
 // NewOpaquePortsWatcher creates a OpaquePortsWatcher and begins watching for
 // k8sAPI for service changes.
-func NewOpaquePortsWatcher(k8sAPI *k8s.API, log *logging.Entry, opaquePorts map[uint32]struct{}) (*OpaquePortsWatcher, error) {
+func NewOpaquePortsWatcher(k8sAPI *k8s.API, log *logging.Entry, opaquePorts DefaultOpaquePorts) (*OpaquePortsWatcher, error) {
 	opw := &OpaquePortsWatcher{
 		subscriptions:      make(map[ServiceID]*svcSubscriptions),
 		k8sAPI:             k8sAPI,
@@ -86,7 +86,7 @@ func (opw *OpaquePortsWatcher) Subscribe(id ServiceID, listener OpaquePortsUpdat
 		// If there is no watched service, create a subscription for the service
 		// and no opaque ports
 		opw.subscriptions[id] = &svcSubscriptions{
-			opaquePorts: opw.defaultOpaquePorts,
+			opaquePorts: opw.defaultOpaquePorts.forService(svc),
 			listeners:   []OpaquePortsUpdateListener{listener},
 		}
 		numListeners = 1
@@ -163,7 +163,7 @@ func (opw *OpaquePortsWatcher) addService(obj interface{}) {
 	// If the opaque ports annotation was not set, then set the service's
 	// opaque ports to the default value.
 	if !ok {
-		opaquePorts = opw.defaultOpaquePorts
+		opaquePorts = opw.defaultOpaquePorts.forService(svc)
 	}
 	ss, ok := opw.subscriptions[id]
 	// If there are no subscriptions for this service, create one with the
@@ -211,7 +211,7 @@ func (opw *OpaquePortsWatcher) deleteService(obj interface{}) {
 		return
 	}
 	old := ss.opaquePorts
-	ss.opaquePorts = opw.defaultOpaquePorts
+	ss.opaquePorts = opw.defaultOpaquePorts.Ports
 	// Do not send an update if the service already had the default opaque ports
 	if portsEqual(old, ss.opaquePorts) {
 		return
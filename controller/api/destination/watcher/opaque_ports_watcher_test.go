@@ -106,13 +106,15 @@ func (bopl *testOpaquePortsListener) UpdateService(ports map[uint32]struct{}) {
 }
 
 func TestOpaquePortsWatcher(t *testing.T) {
-	defaultOpaquePorts := map[uint32]struct{}{
-		25:    {},
-		443:   {},
-		587:   {},
-		3306:  {},
-		5432:  {},
-		11211: {},
+	defaultOpaquePorts := DefaultOpaquePorts{
+		Ports: map[uint32]struct{}{
+			25:    {},
+			443:   {},
+			587:   {},
+			3306:  {},
+			5432:  {},
+			11211: {},
+		},
 	}
 
 	for _, tt := range []struct {
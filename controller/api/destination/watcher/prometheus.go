@@ -23,14 +23,18 @@ type (
 
 	endpointsMetricsVecs struct {
 		metricsVecs
-		pods   *prometheus.GaugeVec
-		exists *prometheus.GaugeVec
+		pods         *prometheus.GaugeVec
+		exists       *prometheus.GaugeVec
+		truncated    *prometheus.CounterVec
+		duplicateIPs *prometheus.CounterVec
 	}
 
 	endpointsMetrics struct {
 		metrics
-		pods   prometheus.Gauge
-		exists prometheus.Gauge
+		pods         prometheus.Gauge
+		exists       prometheus.Gauge
+		truncated    prometheus.Counter
+		duplicateIPs prometheus.Counter
 	}
 )
 
@@ -166,10 +170,28 @@ func newEndpointsMetricsVecs() endpointsMetricsVecs {
 		labels,
 	)
 
+	truncated := promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "endpoints_truncated",
+			Help: "A counter for number of updates to a endpoints that exceeded the maximum number of endpoints per service and were truncated.",
+		},
+		labels,
+	)
+
+	duplicateIPs := promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "endpoints_duplicate_ips",
+			Help: "A counter for number of updates to a endpoints in which endpoints were newly found to share an IP, and only one of them was kept.",
+		},
+		labels,
+	)
+
 	return endpointsMetricsVecs{
-		metricsVecs: vecs,
-		pods:        pods,
-		exists:      exists,
+		metricsVecs:  vecs,
+		pods:         pods,
+		exists:       exists,
+		truncated:    truncated,
+		duplicateIPs: duplicateIPs,
 	}
 }
 
@@ -184,9 +206,11 @@ func (mv metricsVecs) newMetrics(labels prometheus.Labels) metrics {
 func (emv endpointsMetricsVecs) newEndpointsMetrics(labels prometheus.Labels) endpointsMetrics {
 	metrics := emv.newMetrics(labels)
 	return endpointsMetrics{
-		metrics: metrics,
-		pods:    emv.pods.With(labels),
-		exists:  emv.exists.With(labels),
+		metrics:      metrics,
+		pods:         emv.pods.With(labels),
+		exists:       emv.exists.With(labels),
+		truncated:    emv.truncated.With(labels),
+		duplicateIPs: emv.duplicateIPs.With(labels),
 	}
 }
 
@@ -203,6 +227,12 @@ func (emv endpointsMetricsVecs) unregister(labels prometheus.Labels) {
 	if !emv.exists.Delete(labels) {
 		log.Warnf("unable to delete endpoints_exists metric with labels %s", labels)
 	}
+	if !emv.truncated.Delete(labels) {
+		log.Warnf("unable to delete endpoints_truncated metric with labels %s", labels)
+	}
+	if !emv.duplicateIPs.Delete(labels) {
+		log.Warnf("unable to delete endpoints_duplicate_ips metric with labels %s", labels)
+	}
 }
 
 func (m metrics) setSubscribers(n int) {
@@ -217,6 +247,14 @@ func (em endpointsMetrics) setPods(n int) {
 	em.pods.Set(float64(n))
 }
 
+func (em endpointsMetrics) incTruncated() {
+	em.truncated.Inc()
+}
+
+func (em endpointsMetrics) incDuplicateIPs() {
+	em.duplicateIPs.Inc()
+}
+
 func (em endpointsMetrics) setExists(exists bool) {
 	if exists {
 		em.exists.Set(1.0)
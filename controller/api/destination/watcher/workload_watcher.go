@@ -29,7 +29,7 @@ type (
 	// WorkloadWatcher watches all pods and externalworkloads in the cluster.
 	// It keeps a map of publishers keyed by IP and port.
 	WorkloadWatcher struct {
-		defaultOpaquePorts   map[uint32]struct{}
+		defaultOpaquePorts   DefaultOpaquePorts
 		k8sAPI               *k8s.API
 		metadataAPI          *k8s.MetadataAPI
 		publishers           map[IPPort]*workloadPublisher
@@ -44,7 +44,7 @@ type (
 	// a list of listeners to be notified whenever the workload or the
 	// associated opaque protocol config changes.
 	workloadPublisher struct {
-		defaultOpaquePorts map[uint32]struct{}
+		defaultOpaquePorts DefaultOpaquePorts
 		k8sAPI             *k8s.API
 		metadataAPI        *k8s.MetadataAPI
 		addr               Address
@@ -63,7 +63,7 @@ type (
 
 var ipPortVecs = newMetricsVecs("ip_port", []string{"ip", "port"})
 
-func NewWorkloadWatcher(k8sAPI *k8s.API, metadataAPI *k8s.MetadataAPI, log *logging.Entry, enableEndpointSlices bool, defaultOpaquePorts map[uint32]struct{}) (*WorkloadWatcher, error) {
+func NewWorkloadWatcher(k8sAPI *k8s.API, metadataAPI *k8s.MetadataAPI, log *logging.Entry, enableEndpointSlices bool, defaultOpaquePorts DefaultOpaquePorts) (*WorkloadWatcher, error) {
 	ww := &WorkloadWatcher{
 		defaultOpaquePorts: defaultOpaquePorts,
 		k8sAPI:             k8sAPI,
@@ -769,13 +769,13 @@ func (wp *workloadPublisher) updateExternalWorkload(externalWorkload *ext.Extern
 
 // GetAnnotatedOpaquePorts returns the opaque ports for the pod given its
 // annotations, or the default opaque ports if it's not annotated
-func GetAnnotatedOpaquePorts(pod *corev1.Pod, defaultPorts map[uint32]struct{}) map[uint32]struct{} {
+func GetAnnotatedOpaquePorts(pod *corev1.Pod, defaultPorts DefaultOpaquePorts) map[uint32]struct{} {
 	if pod == nil {
-		return defaultPorts
+		return defaultPorts.Ports
 	}
 	annotation, ok := pod.Annotations[consts.ProxyOpaquePortsAnnotation]
 	if !ok {
-		return defaultPorts
+		return defaultPorts.forPod(pod)
 	}
 	opaquePorts := make(map[uint32]struct{})
 	namedPorts := util.GetNamedPorts(pod.Spec.Containers)
@@ -791,13 +791,13 @@ func GetAnnotatedOpaquePorts(pod *corev1.Pod, defaultPorts map[uint32]struct{})
 
 // GetAnnotatedOpaquePortsForExternalWorkload returns the opaque ports for the external workload given its
 // annotations, or the default opaque ports if it's not annotated
-func GetAnnotatedOpaquePortsForExternalWorkload(ew *ext.ExternalWorkload, defaultPorts map[uint32]struct{}) map[uint32]struct{} {
+func GetAnnotatedOpaquePortsForExternalWorkload(ew *ext.ExternalWorkload, defaultPorts DefaultOpaquePorts) map[uint32]struct{} {
 	if ew == nil {
-		return defaultPorts
+		return defaultPorts.Ports
 	}
 	annotation, ok := ew.Annotations[consts.ProxyOpaquePortsAnnotation]
 	if !ok {
-		return defaultPorts
+		return defaultPorts.forExternalWorkload(ew)
 	}
 	opaquePorts := make(map[uint32]struct{})
 	if annotation != "" {
@@ -45,8 +45,9 @@ func NewMockServerStream() MockServerStream {
 
 // MockAPIClient satisfies the destination API's interfaces
 type MockAPIClient struct {
-	ErrorToReturn                error
-	DestinationGetClientToReturn destinationPb.Destination_GetClient
+	ErrorToReturn                       error
+	DestinationGetClientToReturn        destinationPb.Destination_GetClient
+	DestinationGetProfileClientToReturn destinationPb.Destination_GetProfileClient
 }
 
 // Get provides a mock of a destination API method.
@@ -56,8 +57,11 @@ func (c *MockAPIClient) Get(ctx context.Context, in *destinationPb.GetDestinatio
 
 // GetProfile provides a mock of a destination API method
 func (c *MockAPIClient) GetProfile(ctx context.Context, _ *destinationPb.GetDestination, _ ...grpc.CallOption) (destinationPb.Destination_GetProfileClient, error) {
-	// Not implemented through this client. The proxies use the gRPC server directly instead.
-	return nil, errors.New("not implemented")
+	if c.DestinationGetProfileClientToReturn == nil {
+		// Not implemented through this client. The proxies use the gRPC server directly instead.
+		return nil, errors.New("not implemented")
+	}
+	return c.DestinationGetProfileClientToReturn, c.ErrorToReturn
 }
 
 // MockDestinationGetClient satisfies the Destination_GetClient gRPC interface.
@@ -87,6 +91,26 @@ func (a *MockDestinationGetClient) Recv() (*destinationPb.Update, error) {
 	return updatePopped, errorPopped
 }
 
+// MockDestinationGetProfileClient satisfies the
+// Destination_GetProfileClient gRPC interface.
+type MockDestinationGetProfileClient struct {
+	ProfilesToReturn []*destinationPb.DestinationProfile
+	grpc.ClientStream
+	sync.Mutex
+}
+
+// Recv satisfies the Destination_GetProfileClient.Recv() gRPC method.
+func (a *MockDestinationGetProfileClient) Recv() (*destinationPb.DestinationProfile, error) {
+	a.Lock()
+	defer a.Unlock()
+	if len(a.ProfilesToReturn) == 0 {
+		return nil, io.EOF
+	}
+	var profile *destinationPb.DestinationProfile
+	profile, a.ProfilesToReturn = a.ProfilesToReturn[0], a.ProfilesToReturn[1:]
+	return profile, nil
+}
+
 // AuthorityEndpoints holds the details for the Endpoints associated to an authority
 type AuthorityEndpoints struct {
 	Namespace string
@@ -96,9 +120,12 @@ type AuthorityEndpoints struct {
 
 // PodDetails holds the details for pod associated to an Endpoint
 type PodDetails struct {
-	Name string
-	IP   uint32
-	Port uint32
+	Name     string
+	IP       uint32
+	Port     uint32
+	Weight   uint32
+	Identity string
+	H2       bool
 }
 
 // BuildAddrSet converts AuthorityEndpoints into its protobuf representation
@@ -110,7 +137,23 @@ func BuildAddrSet(endpoint AuthorityEndpoints) *destinationPb.WeightedAddrSet {
 			Port: pod.Port,
 		}
 		labels := map[string]string{"pod": pod.Name}
-		weightedAddr := &destinationPb.WeightedAddr{Addr: addr, MetricLabels: labels}
+		weightedAddr := &destinationPb.WeightedAddr{Addr: addr, Weight: pod.Weight, MetricLabels: labels}
+		if pod.Identity != "" {
+			weightedAddr.TlsIdentity = &destinationPb.TlsIdentity{
+				Strategy: &destinationPb.TlsIdentity_DnsLikeIdentity_{
+					DnsLikeIdentity: &destinationPb.TlsIdentity_DnsLikeIdentity{
+						Name: pod.Identity,
+					},
+				},
+			}
+		}
+		if pod.H2 {
+			weightedAddr.ProtocolHint = &destinationPb.ProtocolHint{
+				Protocol: &destinationPb.ProtocolHint_H2_{
+					H2: &destinationPb.ProtocolHint_H2{},
+				},
+			}
+		}
 		addrs = append(addrs, weightedAddr)
 	}
 	labels := map[string]string{"namespace": endpoint.Namespace, "service": endpoint.ServiceID}
@@ -0,0 +1,83 @@
+package destination
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+)
+
+// Bounds imposed by HTTP/2 (RFC 9113) on the settings the proxy advertises.
+const (
+	http2DefaultWindowSize = 65535
+	http2MaxWindowSize     = math.MaxInt32
+	http2MinFrameSize      = 16384
+	http2MaxFrameSize      = 16777215
+)
+
+// parseMeshedHTTP2ClientParams parses the JSON value of the
+// --meshed-http2-client-params flag, returning nil if it's empty, or an error
+// naming each field holding an invalid value.
+func parseMeshedHTTP2ClientParams(s string) (*pb.Http2ClientParams, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	params := &pb.Http2ClientParams{}
+	if err := json.Unmarshal([]byte(s), params); err != nil {
+		return nil, err
+	}
+	if err := validateHTTP2ClientParams(params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// validateHTTP2ClientParams checks that the parameters can be applied by the
+// proxy. Unset (zero) values are always valid, as the proxy falls back to its
+// defaults for them.
+func validateHTTP2ClientParams(params *pb.Http2ClientParams) error {
+	var errs []error
+
+	if fc := params.GetFlowControl(); fc != nil {
+		if size := fc.GetInitialConnectionWindowSize(); size != 0 && (size < http2DefaultWindowSize || size > http2MaxWindowSize) {
+			errs = append(errs, fmt.Errorf("flow_control.initial_connection_window_size: must be between %d and %d, got %d", http2DefaultWindowSize, http2MaxWindowSize, size))
+		}
+		if size := fc.GetInitialStreamWindowSize(); size > http2MaxWindowSize {
+			errs = append(errs, fmt.Errorf("flow_control.initial_stream_window_size: must be at most %d, got %d", http2MaxWindowSize, size))
+		}
+	}
+
+	if ka := params.GetKeepAlive(); ka != nil {
+		if err := validatePositiveDuration(ka.GetInterval()); err != nil {
+			errs = append(errs, fmt.Errorf("keep_alive.interval: %w", err))
+		}
+		if err := validatePositiveDuration(ka.GetTimeout()); err != nil {
+			errs = append(errs, fmt.Errorf("keep_alive.timeout: %w", err))
+		}
+	}
+
+	if internals := params.GetInternals(); internals != nil {
+		if size := internals.GetMaxFrameSize(); size != 0 && (size < http2MinFrameSize || size > http2MaxFrameSize) {
+			errs = append(errs, fmt.Errorf("internals.max_frame_size: must be between %d and %d, got %d", http2MinFrameSize, http2MaxFrameSize, size))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validatePositiveDuration(d *duration.Duration) error {
+	if d == nil {
+		return errors.New("must be set")
+	}
+	if err := d.CheckValid(); err != nil {
+		return err
+	}
+	if d.AsDuration() <= 0 {
+		return fmt.Errorf("must be positive, got %s", d.AsDuration())
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package destination
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMeshedHTTP2ClientParams(t *testing.T) {
+	testCases := []struct {
+		name   string
+		json   string
+		errors []string
+	}{
+		{
+			name: "unset",
+			json: "",
+		},
+		{
+			name: "valid",
+			json: `{"flow_control":{"initial_connection_window_size":1048576,"initial_stream_window_size":65535},"keep_alive":{"interval":{"seconds":10},"timeout":{"seconds":3},"while_idle":true},"internals":{"max_frame_size":16384}}`,
+		},
+		{
+			name:   "malformed JSON",
+			json:   `{"keep_alive":`,
+			errors: []string{"unexpected end of JSON input"},
+		},
+		{
+			name:   "negative window size",
+			json:   `{"flow_control":{"initial_stream_window_size":-1}}`,
+			errors: []string{"initial_stream_window_size"},
+		},
+		{
+			name: "window sizes out of bounds",
+			json: `{"flow_control":{"initial_connection_window_size":1024,"initial_stream_window_size":4294967295}}`,
+			errors: []string{
+				"flow_control.initial_connection_window_size: must be between 65535 and 2147483647, got 1024",
+				"flow_control.initial_stream_window_size: must be at most 2147483647, got 4294967295",
+			},
+		},
+		{
+			name: "zero keepalive",
+			json: `{"keep_alive":{"interval":{},"while_idle":true}}`,
+			errors: []string{
+				"keep_alive.interval: must be positive, got 0s",
+				"keep_alive.timeout: must be set",
+			},
+		},
+		{
+			name:   "negative keepalive timeout",
+			json:   `{"keep_alive":{"interval":{"seconds":10},"timeout":{"seconds":-5}}}`,
+			errors: []string{"keep_alive.timeout: must be positive, got -5s"},
+		},
+		{
+			name:   "frame size out of bounds",
+			json:   `{"internals":{"max_frame_size":1024}}`,
+			errors: []string{"internals.max_frame_size: must be between 16384 and 16777215, got 1024"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			params, err := parseMeshedHTTP2ClientParams(tc.json)
+			if len(tc.errors) == 0 {
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				if (tc.json == "") != (params == nil) {
+					t.Fatalf("Unexpected parameters: %v", params)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("Expected an error, got %v", params)
+			}
+			for _, expected := range tc.errors {
+				if !strings.Contains(err.Error(), expected) {
+					t.Fatalf("Expected error to contain %q, got %q", expected, err)
+				}
+			}
+		})
+	}
+}
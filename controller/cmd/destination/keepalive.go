@@ -0,0 +1,45 @@
+package destination
+
+import (
+	"errors"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// The defaults of the keepalive flags, which are those of gRPC, so that the
+// server behaves as it always has unless they're set.
+const (
+	defaultKeepaliveTime    = 2 * time.Hour
+	defaultKeepaliveTimeout = 20 * time.Second
+	defaultKeepaliveMinTime = 5 * time.Minute
+)
+
+// serverKeepaliveOptions returns the grpc.ServerOptions that make the server
+// ping clients after pingTime without activity, closing the connection if
+// they don't answer within pingTimeout, and that close the connection of
+// clients pinging more often than every minPingTime, or at all without an
+// active stream unless permitWithoutStream is set.
+func serverKeepaliveOptions(pingTime, pingTimeout, minPingTime time.Duration, permitWithoutStream bool) ([]grpc.ServerOption, error) {
+	if pingTime <= 0 {
+		return nil, errors.New("--keepalive-time must be positive")
+	}
+	if pingTimeout <= 0 {
+		return nil, errors.New("--keepalive-timeout must be positive")
+	}
+	if minPingTime <= 0 {
+		return nil, errors.New("--keepalive-min-time must be positive")
+	}
+
+	return []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    pingTime,
+			Timeout: pingTimeout,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             minPingTime,
+			PermitWithoutStream: permitWithoutStream,
+		}),
+	}, nil
+}
@@ -0,0 +1,151 @@
+package destination
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+	"golang.org/x/net/http2"
+	"google.golang.org/grpc"
+)
+
+func TestServerKeepaliveOptionsValidation(t *testing.T) {
+	testCases := []struct {
+		name        string
+		pingTime    time.Duration
+		pingTimeout time.Duration
+		minPingTime time.Duration
+		expectErr   bool
+	}{
+		{"defaults", defaultKeepaliveTime, defaultKeepaliveTimeout, defaultKeepaliveMinTime, false},
+		{"short intervals", 30 * time.Second, 5 * time.Second, 10 * time.Second, false},
+		{"zero time", 0, defaultKeepaliveTimeout, defaultKeepaliveMinTime, true},
+		{"negative timeout", defaultKeepaliveTime, -time.Second, defaultKeepaliveMinTime, true},
+		{"zero min time", defaultKeepaliveTime, defaultKeepaliveTimeout, 0, true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			opts, err := serverKeepaliveOptions(tc.pingTime, tc.pingTimeout, tc.minPingTime, false)
+			if tc.expectErr && err == nil {
+				t.Fatalf("Expected an error, got %d options", len(opts))
+			}
+			if !tc.expectErr && err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestServerKeepaliveEnforcement(t *testing.T) {
+	testCases := []struct {
+		name                string
+		minPingTime         time.Duration
+		permitWithoutStream bool
+		expectGoAway        bool
+	}{
+		{
+			name:                "pings within the minimum time",
+			minPingTime:         time.Hour,
+			permitWithoutStream: true,
+			expectGoAway:        true,
+		},
+		{
+			name:                "pings past the minimum time",
+			minPingTime:         time.Nanosecond,
+			permitWithoutStream: true,
+			expectGoAway:        false,
+		},
+		{
+			name:                "pings without an active stream",
+			minPingTime:         time.Nanosecond,
+			permitWithoutStream: false,
+			expectGoAway:        true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			opts, err := serverKeepaliveOptions(defaultKeepaliveTime, defaultKeepaliveTimeout, tc.minPingTime, tc.permitWithoutStream)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Failed to listen: %s", err)
+			}
+			server := grpc.NewServer(opts...)
+			pb.RegisterDestinationServer(server, noEndpointsServer{})
+			go server.Serve(lis)
+			defer server.Stop()
+
+			goAway := pingServer(t, lis.Addr().String(), 5)
+			if tc.expectGoAway {
+				if goAway == nil {
+					t.Fatal("Expected the server to close the connection")
+				}
+				if goAway.ErrCode != http2.ErrCodeEnhanceYourCalm || string(goAway.DebugData()) != "too_many_pings" {
+					t.Fatalf("Unexpected GOAWAY: %s %q", goAway.ErrCode, goAway.DebugData())
+				}
+			} else if goAway != nil {
+				t.Fatalf("Unexpected GOAWAY: %s %q", goAway.ErrCode, goAway.DebugData())
+			}
+		})
+	}
+}
+
+// pingServer sends count pings to the HTTP/2 server at addr, without opening
+// any stream. It returns the GOAWAY frame sent by the server, or nil if all
+// the pings were acknowledged.
+func pingServer(t *testing.T, addr string, count int) *http2.GoAwayFrame {
+	t.Helper()
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Failed to connect: %s", err)
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(10 * time.Second)); err != nil {
+		t.Fatalf("Failed to set deadline: %s", err)
+	}
+
+	if _, err := conn.Write([]byte(http2.ClientPreface)); err != nil {
+		t.Fatalf("Failed to write preface: %s", err)
+	}
+	framer := http2.NewFramer(conn, conn)
+	if err := framer.WriteSettings(); err != nil {
+		t.Fatalf("Failed to write settings: %s", err)
+	}
+	for i := 0; i < count; i++ {
+		if err := framer.WritePing(false, [8]byte{byte(i)}); err != nil {
+			t.Fatalf("Failed to write ping: %s", err)
+		}
+	}
+
+	acks := 0
+	for acks < count {
+		frame, err := framer.ReadFrame()
+		if err != nil {
+			t.Fatalf("Failed to read frame: %s", err)
+		}
+		switch frame := frame.(type) {
+		case *http2.SettingsFrame:
+			if !frame.IsAck() {
+				if err := framer.WriteSettingsAck(); err != nil {
+					t.Fatalf("Failed to acknowledge settings: %s", err)
+				}
+			}
+		case *http2.PingFrame:
+			if frame.IsAck() {
+				acks++
+			}
+		case *http2.GoAwayFrame:
+			return frame
+		}
+	}
+	return nil
+}
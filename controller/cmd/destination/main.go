@@ -2,16 +2,18 @@ package destination
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strings"
 	"syscall"
+	"time"
 
-	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
 	"github.com/linkerd/linkerd2/controller/api/destination"
 	externalworkload "github.com/linkerd/linkerd2/controller/api/destination/external-workload"
 	"github.com/linkerd/linkerd2/controller/api/destination/watcher"
@@ -20,15 +22,18 @@ import (
 	"github.com/linkerd/linkerd2/pkg/flags"
 	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
 	"github.com/linkerd/linkerd2/pkg/trace"
-	"github.com/linkerd/linkerd2/pkg/util"
+	"github.com/linkerd/linkerd2/pkg/version"
 	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
 )
 
+const unixSocketScheme = "unix://"
+
 // Main executes the destination subcommand
 func Main(args []string) {
 	cmd := flag.NewFlagSet("destination", flag.ExitOnError)
 
-	addr := cmd.String("addr", ":8086", "address to serve on")
+	addr := cmd.String("addr", ":8086", "address to serve on; use unix:///path/to/socket to serve on a Unix domain socket")
 	metricsAddr := cmd.String("metrics-addr", ":9996", "address to serve scrapable metrics on")
 	kubeConfigPath := cmd.String("kubeconfig", "", "path to kube config")
 	controllerNamespace := cmd.String("controller-namespace", "linkerd", "namespace in which Linkerd is installed")
@@ -40,19 +45,42 @@ func Main(args []string) {
 		"Set to true to allow discovering IPv6 endpoints and preferring IPv6 when both IPv4 and IPv6 are available")
 	trustDomain := cmd.String("identity-trust-domain", "", "configures the name suffix used for identities")
 	clusterDomain := cmd.String("cluster-domain", "", "kubernetes cluster domain")
-	defaultOpaquePorts := cmd.String("default-opaque-ports", "", "configures the default opaque ports")
+	strictDomainCheck := cmd.Bool("strict-domain-check", false,
+		"Fail at startup, rather than warn, if only one of the identity trust domain and the cluster domain is set and they don't match")
+	defaultOpaquePorts := cmd.String("default-opaque-ports", "",
+		"configures the default opaque ports; port names are resolved against the ports of each workload or Service")
 	enablePprof := cmd.Bool("enable-pprof", false, "Enable pprof endpoints on the admin server")
+	tlsCert := cmd.String("tls-cert", "", "path to the certificate to serve gRPC over TLS with; reloaded when it changes")
+	tlsKey := cmd.String("tls-key", "", "path to the private key of --tls-cert")
+	clientCA := cmd.String("client-ca", "", "path to a CA bundle that client certificates must be signed by, requiring mTLS (requires --tls-cert)")
+	keepaliveTime := cmd.Duration("keepalive-time", defaultKeepaliveTime,
+		"How long a gRPC connection must be idle before the server pings the client to check that it's still alive")
+	keepaliveTimeout := cmd.Duration("keepalive-timeout", defaultKeepaliveTimeout,
+		"How long the server waits for a ping to be answered before closing the connection")
+	keepaliveMinTime := cmd.Duration("keepalive-min-time", defaultKeepaliveMinTime,
+		"Minimum time between the pings of a client; the connections of clients pinging more often are closed")
+	keepalivePermitWithoutStream := cmd.Bool("keepalive-permit-without-stream", false,
+		"Allow clients to ping when they have no active streams")
+	informerSyncTimeout := cmd.Duration("informer-sync-timeout", 60*time.Second,
+		"Maximum time to wait for the informer caches to sync at startup")
+	shutdownGracePeriod := cmd.Duration("shutdown-grace-period", 20*time.Second,
+		"Maximum time to wait for in-flight streams to drain on shutdown before closing them")
+	allowDegradedStart := cmd.Bool("allow-degraded-start", false,
+		"Start serving even if informers for non-critical resources (e.g. Servers, ServiceProfiles) failed to sync in time")
 	// This will default to true. It can be overridden with experimental CLI
 	// flags. Currently not exposed as a configuration value through Helm.
 	exportControllerQueueMetrics := cmd.Bool("export-queue-metrics", true, "Exports queue metrics for the external workload controller")
 
 	traceCollector := flags.AddTraceFlags(cmd)
+	watchNamespaces := flags.AddWatchNamespaceFlag(cmd)
 
 	// Zone weighting is disabled by default because it is not consumed by
 	// proxies. This feature exists to support experimentation on top of the
 	// Linkerd control plane API.
 	extEndpointZoneWeights := cmd.Bool("ext-endpoint-zone-weights", false,
 		"Enable setting endpoint weighting based on zone locality")
+	normalizeEndpointZoneWeights := cmd.Bool("ext-endpoint-zone-weights-normalize", false,
+		"When zone weighting is enabled, scale endpoint weights by the number of endpoints in their zone, so that zones of different sizes carry the same aggregate weight")
 
 	// Cluster-wide defaults for meshed HTTP/2 client parameters.. These only
 	// apply to meshed connections, as we don't want to conflict with HTTP/2
@@ -62,22 +90,101 @@ func Main(args []string) {
 	meshedHTTP2ClientParamsJSON := cmd.String("meshed-http2-client-params", "",
 		"HTTP/2 client parameters for meshed connections in JSON format")
 
+	maxEndpointsPerUpdate := cmd.Int("max-endpoints-per-update", 0,
+		"Maximum number of addresses sent in a single endpoint update; larger sets are split across several updates (0 means no limit)")
+	maxEndpointsPerService := cmd.Int("max-endpoints-per-service", 0,
+		"Maximum number of endpoints watched for each service port; larger sets are truncated with a warning (0 means no limit)")
+	noEndpointsGracePeriod := cmd.Duration("no-endpoints-grace-period", 0,
+		"How long to keep sending a service's endpoints after it scales to zero, in case they reappear (0 removes them immediately)")
+	stableEndpointOrder := cmd.Bool("stable-endpoint-order", true,
+		"Sort the addresses of each endpoint update by IP and port, so that the same set of endpoints always yields the same update")
+	profileServiceLabels := cmd.String("profile-service-labels", "",
+		fmt.Sprintf("Comma-separated list of Service labels included in the headers of GetProfile responses (at most %d)", destination.MaxProfileServiceLabels))
+	initialEmptyAdd := cmd.Bool("initial-empty-add", false,
+		"Send an empty Add when a Get stream starts on a service that exists but has no endpoints, to tell it apart from a service that doesn't exist")
+	enableStreamPinning := cmd.Bool("enable-debug-stream-pinning", false,
+		"Allow pinning endpoint streams to the endpoints they were last sent through /debug/streams on the admin server (for debugging only)")
+	extWorkloadLabelSelector := cmd.String("external-workload-label-selector", "",
+		"Label selector restricting the ExternalWorkloads watched by the destination service and the external workload controller")
+	extWorkloadFieldSelector := cmd.String("external-workload-field-selector", "",
+		"Field selector restricting the ExternalWorkloads watched by the destination service and the external workload controller")
+	extWorkloadMaxEndpointsPerSlice := cmd.Int("external-workload-max-endpoints-per-slice", externalworkload.DefaultMaxEndpointsPerSlice,
+		"Maximum number of endpoints in each EndpointSlice written by the external workload controller (at most 1000)")
+
 	flags.ConfigureAndParse(cmd, args)
 
 	if *enableIPv6 && !*enableEndpointSlices {
 		log.Fatal("If --enable-ipv6=true then --enable-endpoint-slices needs to be true")
 	}
 
-	var meshedHTTP2ClientParams *pb.Http2ClientParams
-	if meshedHTTP2ClientParamsJSON != nil && *meshedHTTP2ClientParamsJSON != "" {
-		meshedHTTP2ClientParams = &pb.Http2ClientParams{}
-		if err := json.Unmarshal([]byte(*meshedHTTP2ClientParamsJSON), meshedHTTP2ClientParams); err != nil {
-			log.Fatalf("Failed to parse meshed HTTP/2 client parameters: %s", err)
+	if *normalizeEndpointZoneWeights && !*extEndpointZoneWeights {
+		log.Fatal("If --ext-endpoint-zone-weights-normalize=true then --ext-endpoint-zone-weights needs to be true")
+	}
+
+	meshedHTTP2ClientParams, err := parseMeshedHTTP2ClientParams(*meshedHTTP2ClientParamsJSON)
+	if err != nil {
+		log.Fatalf("Invalid meshed HTTP/2 client parameters: %s", err)
+	}
+
+	if err := checkDomains(*trustDomain, *clusterDomain); err != nil {
+		if *strictDomainCheck {
+			log.Fatal(err)
 		}
+		log.Warn(err)
+	}
+
+	if *trustDomain == "" {
+		*trustDomain = "cluster.local"
+		log.Warnf(" expected trust domain through args (falling back to %s)", *trustDomain)
+	}
+
+	if *clusterDomain == "" {
+		*clusterDomain = "cluster.local"
+		log.Warnf("expected cluster domain through args (falling back to %s)", *clusterDomain)
+	}
+
+	opaquePorts := watcher.ParseDefaultOpaquePorts(*defaultOpaquePorts)
+
+	log.Infof("Using default opaque ports: %v (named: %v)", opaquePorts.Ports, opaquePorts.Names)
+
+	config := destination.Config{
+		ControllerNS:                 *controllerNamespace,
+		IdentityTrustDomain:          *trustDomain,
+		ClusterDomain:                *clusterDomain,
+		DefaultOpaquePorts:           opaquePorts,
+		EnableH2Upgrade:              *enableH2Upgrade,
+		EnableEndpointSlices:         *enableEndpointSlices,
+		EnableIPv6:                   *enableIPv6,
+		ExtEndpointZoneWeights:       *extEndpointZoneWeights,
+		NormalizeEndpointZoneWeights: *normalizeEndpointZoneWeights,
+		StableEndpointOrder:          *stableEndpointOrder,
+		MeshedHttp2ClientParams:      meshedHTTP2ClientParams,
+		MaxEndpointsPerUpdate:        *maxEndpointsPerUpdate,
+		MaxEndpointsPerService:       *maxEndpointsPerService,
+		NoEndpointsGracePeriod:       *noEndpointsGracePeriod,
+		InitialEmptyAdd:              *initialEmptyAdd,
+		ProfileServiceLabels:         parseLabelKeys(*profileServiceLabels),
+	}
+	if len(config.ProfileServiceLabels) > destination.MaxProfileServiceLabels {
+		log.Fatalf("At most %d --profile-service-labels can be set, got %d", destination.MaxProfileServiceLabels, len(config.ProfileServiceLabels))
+	}
+
+	configHandler, err := destination.NewConfigHandler(config)
+	if err != nil {
+		log.Fatalf("Failed to initialize config handler: %s", err)
 	}
 
+	if *enableStreamPinning {
+		log.Warn("Endpoint stream pinning is enabled; it should only be used for debugging")
+	}
+	endpointStreams := destination.NewStreams(*enableStreamPinning)
+
 	ready := false
-	adminServer := admin.NewServer(*metricsAddr, *enablePprof, &ready)
+	adminServer := admin.NewServer(*metricsAddr, *enablePprof, &ready,
+		admin.Route{Path: "/debug/config", Handler: configHandler},
+		admin.Route{Path: "/debug/streams", Handler: endpointStreams},
+		admin.Route{Path: "/version", Handler: version.BuildInfoHandler()},
+	)
 
 	go func() {
 		log.Infof("starting admin server on %s", *metricsAddr)
@@ -95,25 +202,11 @@ func Main(args []string) {
 
 	done := make(chan struct{})
 
-	lis, err := net.Listen("tcp", *addr)
+	lis, err := listen(*addr)
 	if err != nil {
 		log.Fatalf("Failed to listen on %s: %s", *addr, err)
 	}
 
-	if *trustDomain == "" {
-		*trustDomain = "cluster.local"
-		log.Warnf(" expected trust domain through args (falling back to %s)", *trustDomain)
-	}
-
-	if *clusterDomain == "" {
-		*clusterDomain = "cluster.local"
-		log.Warnf("expected cluster domain through args (falling back to %s)", *clusterDomain)
-	}
-
-	opaquePorts := util.ParsePorts(*defaultOpaquePorts)
-
-	log.Infof("Using default opaque ports: %v", opaquePorts)
-
 	if *traceCollector != "" {
 		if err := trace.InitializeTracing("linkerd-destination", *traceCollector); err != nil {
 			log.Warnf("failed to initialize tracing: %s", err)
@@ -129,26 +222,49 @@ func Main(args []string) {
 
 	ctx := context.Background()
 
+	var serverOpts []grpc.ServerOption
+	tlsOpt, err := serverTLSOption(ctx, *tlsCert, *tlsKey, *clientCA)
+	if err != nil {
+		log.Fatalf("Failed to configure TLS: %s", err)
+	}
+	if tlsOpt != nil {
+		log.Info("Serving gRPC over TLS")
+		serverOpts = append(serverOpts, tlsOpt)
+	}
+	keepaliveOpts, err := serverKeepaliveOptions(*keepaliveTime, *keepaliveTimeout, *keepaliveMinTime, *keepalivePermitWithoutStream)
+	if err != nil {
+		log.Fatalf("Failed to configure keepalive: %s", err)
+	}
+	serverOpts = append(serverOpts, keepaliveOpts...)
+
 	err = pkgK8s.EndpointSliceAccess(ctx, k8Client)
 	if *enableEndpointSlices && err != nil {
 		log.Fatalf("Failed to start with EndpointSlices enabled: %s", err)
 	}
 
+	ewSelector := k8s.ExtWorkloadSelector{
+		Label: *extWorkloadLabelSelector,
+		Field: *extWorkloadFieldSelector,
+	}
 	var k8sAPI *k8s.API
 	if *enableEndpointSlices {
-		k8sAPI, err = k8s.InitializeAPI(
+		k8sAPI, err = k8s.InitializeAPIForNamespaces(
 			ctx,
 			*kubeConfigPath,
 			true,
 			"local",
+			*watchNamespaces,
+			ewSelector,
 			k8s.Endpoint, k8s.ES, k8s.Pod, k8s.Svc, k8s.SP, k8s.Job, k8s.Srv, k8s.ExtWorkload,
 		)
 	} else {
-		k8sAPI, err = k8s.InitializeAPI(
+		k8sAPI, err = k8s.InitializeAPIForNamespaces(
 			ctx,
 			*kubeConfigPath,
 			true,
 			"local",
+			*watchNamespaces,
+			ewSelector,
 			k8s.Endpoint, k8s.Pod, k8s.Svc, k8s.SP, k8s.Job, k8s.Srv, k8s.ExtWorkload,
 		)
 	}
@@ -166,33 +282,39 @@ func Main(args []string) {
 		log.Fatalf("Failed to initialize Cluster Store: %s", err)
 	}
 
-	config := destination.Config{
-		ControllerNS:            *controllerNamespace,
-		IdentityTrustDomain:     *trustDomain,
-		ClusterDomain:           *clusterDomain,
-		DefaultOpaquePorts:      opaquePorts,
-		EnableH2Upgrade:         *enableH2Upgrade,
-		EnableEndpointSlices:    *enableEndpointSlices,
-		EnableIPv6:              *enableIPv6,
-		ExtEndpointZoneWeights:  *extEndpointZoneWeights,
-		MeshedHttp2ClientParams: meshedHTTP2ClientParams,
-	}
 	server, err := destination.NewServer(
 		*addr,
 		config,
 		k8sAPI,
 		metadataAPI,
 		clusterStore,
+		endpointStreams,
 		done,
+		serverOpts...,
 	)
 
 	if err != nil {
 		log.Fatalf("Failed to initialize destination server: %s", err)
 	}
 
-	// blocks until caches are synced
-	k8sAPI.Sync(nil)
-	metadataAPI.Sync(nil)
+	// blocks until caches are synced, or the timeout elapses; the metadata
+	// informers sync concurrently, within the same timeout
+	var metadataUnsynced []string
+	metadataSynced := make(chan struct{})
+	go func() {
+		metadataUnsynced = metadataAPI.SyncWithTimeout(nil, *informerSyncTimeout)
+		close(metadataSynced)
+	}()
+	unsynced := k8sAPI.SyncWithTimeout(nil, *informerSyncTimeout)
+	<-metadataSynced
+	unsynced = append(unsynced, metadataUnsynced...)
+	sort.Strings(unsynced)
+	if len(unsynced) > 0 {
+		if critical := criticalResources(unsynced); !*allowDegradedStart || len(critical) > 0 {
+			log.Fatalf("Failed to sync caches for: %s", strings.Join(unsynced, ", "))
+		}
+		log.Errorf("Starting in degraded mode; caches failed to sync for: %s", strings.Join(unsynced, ", "))
+	}
 	clusterStore.Sync(nil)
 
 	// Start mesh expansion external workload controller to write endpointslices
@@ -202,7 +324,7 @@ func Main(args []string) {
 		if !ok {
 			log.Fatal("Failed to initialize External Workload Endpoints Controller, \"HOSTNAME\" value not found")
 		}
-		externalWorkloadController, err := externalworkload.NewEndpointsController(k8sAPI, hostname, *controllerNamespace, done, *exportControllerQueueMetrics)
+		externalWorkloadController, err := externalworkload.NewEndpointsController(k8sAPI, hostname, *controllerNamespace, done, *exportControllerQueueMetrics, *extWorkloadMaxEndpointsPerSlice)
 		if err != nil {
 			log.Fatalf("Failed to initialize External Workload Endpoints Controller: %v", err)
 		}
@@ -222,7 +344,76 @@ func Main(args []string) {
 	<-stop
 
 	log.Infof("shutting down gRPC server on %s", *addr)
-	close(done)
-	server.GracefulStop()
+	summary := gracefulStop(server, endpointStreams, func() { close(done) }, *shutdownGracePeriod)
+	log.WithFields(summary.fields()).Info("gRPC server shut down")
 	adminServer.Shutdown(ctx)
 }
+
+// listen returns a listener on addr, which is either a TCP address or a
+// unix:// URL. Stale socket files left behind by a previous run are removed,
+// and the socket file is removed once the listener is closed.
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixSocketScheme)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if path == "" {
+		return nil, errors.New("missing Unix socket path")
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+
+	lis, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Closing the listener unlinks the socket file.
+	lis.(*net.UnixListener).SetUnlinkOnClose(true)
+	return lis, nil
+}
+
+// checkDomains returns an error if exactly one of the trust domain and the
+// cluster domain was set and it doesn't match the default the other one falls
+// back to, which usually means one of them was overridden by mistake.
+func checkDomains(trustDomain, clusterDomain string) error {
+	const defaultDomain = "cluster.local"
+	switch {
+	case trustDomain != "" && clusterDomain == "" && trustDomain != defaultDomain:
+		return fmt.Errorf("identity trust domain is set to %q but the cluster domain falls back to %q", trustDomain, defaultDomain)
+	case trustDomain == "" && clusterDomain != "" && clusterDomain != defaultDomain:
+		return fmt.Errorf("cluster domain is set to %q but the identity trust domain falls back to %q", clusterDomain, defaultDomain)
+	}
+	return nil
+}
+
+// criticalResources returns the resources in resources the destination
+// service can't serve discovery without.
+func criticalResources(resources []string) []string {
+	critical := []string{}
+	for _, res := range resources {
+		switch res {
+		case pkgK8s.Endpoints, pkgK8s.EndpointSlices, pkgK8s.Pod, pkgK8s.Service:
+			critical = append(critical, res)
+		}
+	}
+	return critical
+}
+
+// parseLabelKeys splits a comma-separated list of label keys, ignoring
+// whitespace and empty entries.
+func parseLabelKeys(list string) []string {
+	keys := []string{}
+	for _, key := range strings.Split(list, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,126 @@
+package destination
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestCheckDomains(t *testing.T) {
+	testCases := []struct {
+		name          string
+		trustDomain   string
+		clusterDomain string
+		expectErr     bool
+	}{
+		{"both defaulted", "", "", false},
+		{"both set to the same value", "example.com", "example.com", false},
+		{"both set to different values", "example.com", "cluster.local", false},
+		{"only trust domain set to the default", "cluster.local", "", false},
+		{"only cluster domain set to the default", "", "cluster.local", false},
+		{"only trust domain overridden", "example.com", "", true},
+		{"only cluster domain overridden", "", "example.com", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkDomains(tc.trustDomain, tc.clusterDomain)
+			if tc.expectErr && err == nil {
+				t.Fatalf("Expected an error for trust domain %q and cluster domain %q", tc.trustDomain, tc.clusterDomain)
+			}
+			if !tc.expectErr && err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestCriticalResources(t *testing.T) {
+	critical := criticalResources([]string{"job", "pod", "server", "serviceprofile", "endpointslices"})
+	if len(critical) != 2 || critical[0] != "pod" || critical[1] != "endpointslices" {
+		t.Fatalf("Expected [pod endpointslices], got %v", critical)
+	}
+
+	critical = criticalResources([]string{"job", "server"})
+	if len(critical) != 0 {
+		t.Fatalf("Expected no critical resources, got %v", critical)
+	}
+}
+
+// noEndpointsServer answers every Get with a single NoEndpoints update.
+type noEndpointsServer struct {
+	pb.UnimplementedDestinationServer
+}
+
+func (noEndpointsServer) Get(_ *pb.GetDestination, stream pb.Destination_GetServer) error {
+	return stream.Send(&pb.Update{
+		Update: &pb.Update_NoEndpoints{NoEndpoints: &pb.NoEndpoints{Exists: true}},
+	})
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "destination.sock")
+
+	// A stale socket file from a previous run must not prevent listening.
+	stale, err := listen(unixSocketScheme + path)
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %s", path, err)
+	}
+	stale.(interface{ SetUnlinkOnClose(bool) }).SetUnlinkOnClose(false)
+	stale.Close()
+
+	lis, err := listen(unixSocketScheme + path)
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %s", path, err)
+	}
+
+	server := grpc.NewServer()
+	pb.RegisterDestinationServer(server, noEndpointsServer{})
+	go server.Serve(lis)
+
+	conn, err := grpc.NewClient(unixSocketScheme+path, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %s", err)
+	}
+	defer conn.Close()
+
+	stream, err := pb.NewDestinationClient(conn).Get(context.Background(), &pb.GetDestination{
+		Scheme: "k8s",
+		Path:   "foo.ns.svc.cluster.local:80",
+	})
+	if err != nil {
+		t.Fatalf("Get failed: %s", err)
+	}
+	update, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv failed: %s", err)
+	}
+	if update.GetNoEndpoints() == nil {
+		t.Fatalf("Expected a NoEndpoints update, got %v", update)
+	}
+
+	server.Stop()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected socket file to be removed on shutdown, got %v", err)
+	}
+}
+
+func TestListenRejectsNonSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, []byte("data"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %s", err)
+	}
+
+	if _, err := listen(unixSocketScheme + path); err == nil {
+		t.Fatalf("Expected an error when listening on a regular file")
+	}
+	if _, err := listen(unixSocketScheme); err == nil {
+		t.Fatalf("Expected an error for a missing socket path")
+	}
+}
@@ -0,0 +1,71 @@
+package destination
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// streamCounter counts the streams being served, e.g. destination.Streams.
+type streamCounter interface {
+	Count() int
+}
+
+// shutdownSummary describes how the endpoint streams in flight when the
+// destination server began shutting down were handled.
+type shutdownSummary struct {
+	activeStreams      int
+	drainedStreams     int
+	forceClosedStreams int
+	duration           time.Duration
+}
+
+// summarizeShutdown builds the summary of a shutdown that started with active
+// streams in flight, of which remaining were still open when the grace period
+// ran out and had to be force-closed.
+func summarizeShutdown(active, remaining int, duration time.Duration) shutdownSummary {
+	forceClosed := min(max(remaining, 0), active)
+	return shutdownSummary{
+		activeStreams:      active,
+		drainedStreams:     active - forceClosed,
+		forceClosedStreams: forceClosed,
+		duration:           duration,
+	}
+}
+
+func (s shutdownSummary) fields() log.Fields {
+	return log.Fields{
+		"active_streams":       s.activeStreams,
+		"drained_streams":      s.drainedStreams,
+		"force_closed_streams": s.forceClosedStreams,
+		"duration":             s.duration.String(),
+	}
+}
+
+// gracefulStop stops server, giving in-flight streams up to gracePeriod to
+// drain before force-closing the rest, and returns a summary of the shutdown
+// of the streams counted by streams. The streams are counted before drain is
+// called to tell them to end.
+func gracefulStop(server *grpc.Server, streams streamCounter, drain func(), gracePeriod time.Duration) shutdownSummary {
+	start := time.Now()
+	active := streams.Count()
+
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	drain()
+
+	remaining := 0
+	select {
+	case <-stopped:
+	case <-time.After(gracePeriod):
+		remaining = streams.Count()
+		server.Stop()
+		<-stopped
+	}
+
+	return summarizeShutdown(active, remaining, time.Since(start))
+}
@@ -0,0 +1,131 @@
+package destination
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestSummarizeShutdown(t *testing.T) {
+	testCases := []struct {
+		name      string
+		active    int
+		remaining int
+		expected  shutdownSummary
+	}{
+		{
+			name:     "no streams",
+			expected: shutdownSummary{duration: time.Second},
+		},
+		{
+			name:     "all streams drained",
+			active:   5,
+			expected: shutdownSummary{activeStreams: 5, drainedStreams: 5, duration: time.Second},
+		},
+		{
+			name:      "some streams force-closed",
+			active:    5,
+			remaining: 2,
+			expected:  shutdownSummary{activeStreams: 5, drainedStreams: 3, forceClosedStreams: 2, duration: time.Second},
+		},
+		{
+			name:      "streams opened during shutdown are not counted",
+			active:    2,
+			remaining: 4,
+			expected:  shutdownSummary{activeStreams: 2, forceClosedStreams: 2, duration: time.Second},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			summary := summarizeShutdown(tc.active, tc.remaining, time.Second)
+			if summary != tc.expected {
+				t.Fatalf("Expected %+v, got %+v", tc.expected, summary)
+			}
+		})
+	}
+}
+
+// blockingServer holds every Get stream open until its context ends or
+// shutdown is closed, and counts the streams it's serving, standing in for
+// the endpoint streams registered by the destination server.
+type blockingServer struct {
+	pb.UnimplementedDestinationServer
+	started  chan struct{}
+	shutdown chan struct{}
+	streams  atomic.Int32
+}
+
+func (s *blockingServer) Get(_ *pb.GetDestination, stream pb.Destination_GetServer) error {
+	s.streams.Add(1)
+	defer s.streams.Add(-1)
+	s.started <- struct{}{}
+	select {
+	case <-stream.Context().Done():
+	case <-s.shutdown:
+	}
+	return nil
+}
+
+func (s *blockingServer) Count() int {
+	return int(s.streams.Load())
+}
+
+// serveBlocking starts a gRPC server for srv and opens a Get stream to it,
+// returning once the stream is being served.
+func serveBlocking(t *testing.T, srv *blockingServer) *grpc.Server {
+	t.Helper()
+	server := grpc.NewServer()
+	pb.RegisterDestinationServer(server, srv)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	go server.Serve(lis)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	if _, err := pb.NewDestinationClient(conn).Get(context.Background(), &pb.GetDestination{}); err != nil {
+		t.Fatalf("Get failed: %s", err)
+	}
+	<-srv.started
+	return server
+}
+
+func TestGracefulStopDrainsStreams(t *testing.T) {
+	srv := &blockingServer{started: make(chan struct{}, 1), shutdown: make(chan struct{})}
+	server := serveBlocking(t, srv)
+
+	summary := gracefulStop(server, srv, func() { close(srv.shutdown) }, 5*time.Second)
+	expected := shutdownSummary{activeStreams: 1, drainedStreams: 1, duration: summary.duration}
+	if summary != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, summary)
+	}
+}
+
+func TestGracefulStopForceClosesStreams(t *testing.T) {
+	// The stream ignores the drain, as shutdown is never closed.
+	srv := &blockingServer{started: make(chan struct{}, 1)}
+	server := serveBlocking(t, srv)
+
+	summary := gracefulStop(server, srv, func() {}, 50*time.Millisecond)
+	expected := shutdownSummary{activeStreams: 1, forceClosedStreams: 1, duration: summary.duration}
+	if summary != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, summary)
+	}
+	if summary.duration < 50*time.Millisecond {
+		t.Fatalf("Expected shutdown to wait for the grace period, took %s", summary.duration)
+	}
+}
@@ -0,0 +1,68 @@
+package destination
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	pkgTls "github.com/linkerd/linkerd2/pkg/tls"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// serverTLSOption returns a grpc.ServerOption that serves TLS with the given
+// certificate and key, reloading them whenever they change on disk. When
+// clientCAPath is set, clients must present a certificate signed by it. No
+// option is returned if TLS isn't configured.
+func serverTLSOption(ctx context.Context, certPath, keyPath, clientCAPath string) (grpc.ServerOption, error) {
+	if certPath == "" && keyPath == "" && clientCAPath == "" {
+		return nil, nil
+	}
+	if certPath == "" || keyPath == "" {
+		return nil, errors.New("both --tls-cert and --tls-key must be set to enable TLS")
+	}
+
+	updateEvent := make(chan struct{})
+	errEvent := make(chan error)
+	watcher := pkgTls.NewFsCredsWatcher(filepath.Dir(certPath), updateEvent, errEvent).
+		WithFilePaths(certPath, keyPath)
+
+	var certVal atomic.Value
+	if err := watcher.UpdateCert(&certVal); err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	go func() {
+		if err := watcher.StartWatching(ctx); err != nil {
+			log.Errorf("Failed to watch TLS certificate for changes: %s", err)
+		}
+	}()
+	go watcher.ProcessEvents(log.WithField("component", "tls"), &certVal, updateEvent, errEvent)
+
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return certVal.Load().(*tls.Certificate), nil
+		},
+	}
+
+	if clientCAPath != "" {
+		caPEM, err := os.ReadFile(clientCAPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+		pool, err := pkgTls.DecodePEMCertPool(string(caPEM))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode client CA: %w", err)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return grpc.Creds(credentials.NewTLS(config)), nil
+}
@@ -0,0 +1,138 @@
+package destination
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+	pkgTls "github.com/linkerd/linkerd2/pkg/tls"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func writeFile(t *testing.T, dir, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("Failed to write %s: %s", path, err)
+	}
+	return path
+}
+
+func TestServerTLSOption(t *testing.T) {
+	ca, err := pkgTls.GenerateRootCAWithDefaults("destination-test-ca")
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %s", err)
+	}
+	serverCred, err := ca.GenerateEndEntityCred("localhost")
+	if err != nil {
+		t.Fatalf("Failed to generate server credentials: %s", err)
+	}
+	clientCred, err := ca.GenerateEndEntityCred("client.linkerd.cluster.local")
+	if err != nil {
+		t.Fatalf("Failed to generate client credentials: %s", err)
+	}
+
+	dir := t.TempDir()
+	certPath := writeFile(t, dir, "tls.crt", serverCred.Crt.EncodePEM())
+	keyPath := writeFile(t, dir, "tls.key", serverCred.EncodePrivateKeyPEM())
+	caPath := writeFile(t, dir, "ca.crt", ca.Cred.Crt.EncodeCertificatePEM())
+
+	t.Run("Is disabled by default", func(t *testing.T) {
+		opt, err := serverTLSOption(context.Background(), "", "", "")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if opt != nil {
+			t.Fatalf("Expected no TLS option when TLS isn't configured")
+		}
+	})
+
+	t.Run("Requires both a certificate and a key", func(t *testing.T) {
+		if _, err := serverTLSOption(context.Background(), certPath, "", ""); err == nil {
+			t.Fatalf("Expected an error without a key")
+		}
+		if _, err := serverTLSOption(context.Background(), "", "", caPath); err == nil {
+			t.Fatalf("Expected an error with only a client CA")
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opt, err := serverTLSOption(ctx, certPath, keyPath, caPath)
+	if err != nil {
+		t.Fatalf("Failed to configure TLS: %s", err)
+	}
+
+	server := grpc.NewServer(opt)
+	pb.RegisterDestinationServer(server, noEndpointsServer{})
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %s", err)
+	}
+	go server.Serve(lis)
+	defer server.Stop()
+
+	clientCert, err := tls.X509KeyPair([]byte(clientCred.Crt.EncodePEM()), []byte(clientCred.EncodePrivateKeyPEM()))
+	if err != nil {
+		t.Fatalf("Failed to load client certificate: %s", err)
+	}
+
+	testCases := []struct {
+		name      string
+		creds     credentials.TransportCredentials
+		expectErr bool
+	}{
+		{
+			name:      "rejects plaintext clients",
+			creds:     insecure.NewCredentials(),
+			expectErr: true,
+		},
+		{
+			name: "rejects clients without a certificate",
+			creds: credentials.NewTLS(&tls.Config{
+				RootCAs:    ca.Cred.Crt.CertPool(),
+				ServerName: "localhost",
+			}),
+			expectErr: true,
+		},
+		{
+			name: "accepts clients with a valid certificate",
+			creds: credentials.NewTLS(&tls.Config{
+				RootCAs:      ca.Cred.Crt.CertPool(),
+				ServerName:   "localhost",
+				Certificates: []tls.Certificate{clientCert},
+			}),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(tc.creds))
+			if err != nil {
+				t.Fatalf("Failed to create client: %s", err)
+			}
+			defer conn.Close()
+
+			stream, err := pb.NewDestinationClient(conn).Get(context.Background(), &pb.GetDestination{
+				Scheme: "k8s",
+				Path:   "foo.ns.svc.cluster.local:80",
+			})
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			if tc.expectErr && err == nil {
+				t.Fatalf("Expected the request to fail")
+			}
+			if !tc.expectErr && err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+		})
+	}
+}
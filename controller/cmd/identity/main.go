@@ -41,6 +41,9 @@ func Main(args []string) {
 	identityIssuanceLifeTime := cmd.String("identity-issuance-lifetime", "", "the amount of time for which the Identity issuer should certify identity")
 	identityClockSkewAllowance := cmd.String("identity-clock-skew-allowance", "", "the amount of time to allow for clock skew within a Linkerd cluster")
 	enablePprof := cmd.Bool("enable-pprof", false, "Enable pprof endpoints on the admin server")
+	namespaceMetrics := cmd.Bool("enable-namespace-issuance-metrics", false, "Label the issued certificates metric with the namespace of each identity")
+	certifyRateLimit := cmd.Int("certify-rate-limit", 0, "Maximum number of certificates issued for each identity per --certify-rate-limit-window (0 means no limit)")
+	certifyRateLimitWindow := cmd.Duration("certify-rate-limit-window", time.Minute, "Window over which --certify-rate-limit is applied")
 	qps := cmd.Float64("kube-apiclient-qps", 100, "Maximum QPS sent to the kube-apiserver before throttling")
 	burst := cmd.Int("kube-apiclient-burst", 200, "Burst value over kube-apiclient-qps")
 
@@ -55,6 +58,10 @@ func Main(args []string) {
 
 	flags.ConfigureAndParse(cmd, args)
 
+	if *certifyRateLimit > 0 && *certifyRateLimitWindow <= 0 {
+		log.Fatalf("--certify-rate-limit-window must be positive when --certify-rate-limit is set, got %s", *certifyRateLimitWindow)
+	}
+
 	ready := false
 	adminServer := admin.NewServer(*adminAddr, *enablePprof, &ready)
 
@@ -181,7 +188,7 @@ func Main(args []string) {
 	//
 	// Create, initialize and run service
 	//
-	svc := identity.NewService(v, trustAnchors, &validity, recordEventFunc, expectedName, issuerPathCrt, issuerPathKey)
+	svc := identity.NewService(v, trustAnchors, &validity, recordEventFunc, expectedName, issuerPathCrt, issuerPathKey, *namespaceMetrics, *certifyRateLimit, *certifyRateLimitWindow)
 	if err = svc.Initialize(); err != nil {
 		//nolint:gocritic
 		log.Fatalf("Failed to initialize identity service: %s", err)
@@ -9,6 +9,9 @@ import (
 	injector "github.com/linkerd/linkerd2/controller/proxy-injector"
 	"github.com/linkerd/linkerd2/controller/webhook"
 	"github.com/linkerd/linkerd2/pkg/flags"
+	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
+	"github.com/linkerd/linkerd2/pkg/trace"
+	log "github.com/sirupsen/logrus"
 )
 
 // Main executes the proxy-injector subcommand
@@ -19,12 +22,50 @@ func Main(args []string) {
 	kubeconfig := cmd.String("kubeconfig", "", "path to kubeconfig")
 	linkerdNamespace := cmd.String("linkerd-namespace", "linkerd", "control plane namespace")
 	enablePprof := cmd.Bool("enable-pprof", false, "Enable pprof endpoints on the admin server")
+	configProvenance := cmd.String("config-provenance", injector.ConfigProvenanceNone,
+		fmt.Sprintf("How to report whether the proxy configuration values of injected pods come from the workload, its namespace or the defaults (%s, %s or %s)",
+			injector.ConfigProvenanceNone, injector.ConfigProvenanceLog, injector.ConfigProvenanceAnnotation))
+	deprecatedAnnotations := cmd.String("deprecated-annotations", "",
+		"Comma-separated list of annotations, each optionally followed by =<replacement>, whose use on workloads or namespaces is returned as an admission warning, in addition to the ones deprecated by this release")
+	requirePodOptIn := cmd.Bool("require-pod-opt-in", false,
+		fmt.Sprintf("Only inject pods that have the %s annotation themselves, ignoring it on their namespace", pkgK8s.ProxyInjectAnnotation))
+	allowNamespaces := cmd.String("allow-namespaces", "",
+		"Comma-separated list of namespaces whose resources can be injected; if this or --allow-namespace-selector is set, resources in other namespaces are admitted unmutated")
+	allowNamespaceSelector := cmd.String("allow-namespace-selector", "",
+		"Label selector of the namespaces whose resources can be injected")
+	denyNamespaces := cmd.String("deny-namespaces", "",
+		"Comma-separated list of namespaces whose resources are admitted unmutated; takes precedence over the allow-list")
+	denyNamespaceSelector := cmd.String("deny-namespace-selector", "",
+		"Label selector of the namespaces whose resources are admitted unmutated; takes precedence over the allow-list")
+	traceCollector := flags.AddTraceFlags(cmd)
 	flags.ConfigureAndParse(cmd, args)
 
+	switch *configProvenance {
+	case injector.ConfigProvenanceNone, injector.ConfigProvenanceLog, injector.ConfigProvenanceAnnotation:
+	default:
+		log.Fatalf("invalid --config-provenance %q", *configProvenance)
+	}
+
+	deprecated, err := injector.ParseDeprecatedAnnotations(*deprecatedAnnotations)
+	if err != nil {
+		log.Fatalf("invalid --deprecated-annotations: %s", err)
+	}
+
+	namespaces, err := injector.NewNamespaceFilter(*allowNamespaces, *allowNamespaceSelector, *denyNamespaces, *denyNamespaceSelector)
+	if err != nil {
+		log.Fatalf("invalid namespace filter: %s", err)
+	}
+
+	if *traceCollector != "" {
+		if err := trace.InitializeTracing("linkerd-proxy-injector", *traceCollector); err != nil {
+			log.Warnf("failed to initialize tracing: %s", err)
+		}
+	}
+
 	webhook.Launch(
 		context.Background(),
 		[]k8s.APIResource{k8s.NS, k8s.Deploy, k8s.RC, k8s.RS, k8s.Job, k8s.DS, k8s.SS, k8s.Pod, k8s.CJ},
-		injector.Inject(*linkerdNamespace),
+		injector.Inject(*linkerdNamespace, *configProvenance, deprecated, *requirePodOptIn, namespaces),
 		"linkerd-proxy-injector",
 		*metricsAddr,
 		*addr,
@@ -32,5 +32,11 @@ func (d *TrustDomain) Identity(typ, nm, ns string) (string, error) {
 	}
 
 	id := fmt.Sprintf("%s.%s.%s.identity.%s.%s", nm, ns, typ, d.controlNS, d.domain)
+
+	// Each part is valid on its own, but with a long multi-label trust domain
+	// the assembled name may still exceed what a DNS name allows.
+	if errs := validation.IsDNS1123Subdomain(id); len(errs) > 0 {
+		return "", fmt.Errorf("invalid identity '%s': %s", id, errs[0])
+	}
 	return id, nil
 }
@@ -0,0 +1,129 @@
+package identity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/linkerd/linkerd2/pkg/tls"
+)
+
+func TestNewTrustDomain(t *testing.T) {
+	testCases := []struct {
+		name      string
+		controlNS string
+		domain    string
+		expectErr bool
+	}{
+		{"default domain", "linkerd", "cluster.local", false},
+		{"multi-label domain", "linkerd", "mesh.example.com", false},
+		{"single-label domain", "linkerd", "local", false},
+		{"trailing dot", "linkerd", "mesh.example.com.", true},
+		{"uppercase domain", "linkerd", "Mesh.Example.com", true},
+		{"empty label", "linkerd", "mesh..example.com", true},
+		{"invalid control namespace", "linkerd.io", "cluster.local", true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewTrustDomain(tc.controlNS, tc.domain)
+			if tc.expectErr && err == nil {
+				t.Fatalf("Expected an error for domain %q", tc.domain)
+			}
+			if !tc.expectErr && err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+		})
+	}
+}
+
+func TestTrustDomainIdentity(t *testing.T) {
+	testCases := []struct {
+		name      string
+		domain    string
+		typ       string
+		nm        string
+		ns        string
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:     "default domain",
+			domain:   "cluster.local",
+			typ:      "serviceaccount",
+			nm:       "default",
+			ns:       "emojivoto",
+			expected: "default.emojivoto.serviceaccount.identity.linkerd.cluster.local",
+		},
+		{
+			name:     "multi-label domain",
+			domain:   "mesh.example.com",
+			typ:      "serviceaccount",
+			nm:       "web",
+			ns:       "emojivoto",
+			expected: "web.emojivoto.serviceaccount.identity.linkerd.mesh.example.com",
+		},
+		{
+			name:     "deeply nested domain",
+			domain:   "a.b.c.mesh.example.com",
+			typ:      "serviceaccount",
+			nm:       "web",
+			ns:       "emojivoto",
+			expected: "web.emojivoto.serviceaccount.identity.linkerd.a.b.c.mesh.example.com",
+		},
+		{
+			name:      "invalid name",
+			domain:    "mesh.example.com",
+			typ:       "serviceaccount",
+			nm:        "web.app",
+			ns:        "emojivoto",
+			expectErr: true,
+		},
+		{
+			name:      "identity longer than a DNS name",
+			domain:    strings.Repeat("mesh.", 40) + "example.com",
+			typ:       "serviceaccount",
+			nm:        "web",
+			ns:        "emojivoto",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			domain, err := NewTrustDomain("linkerd", tc.domain)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			id, err := domain.Identity(tc.typ, tc.nm, tc.ns)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got identity %q", id)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if id != tc.expected {
+				t.Fatalf("Expected identity %q, got %q", tc.expected, id)
+			}
+
+			// The identity must be usable as the name of the certificate
+			// issued for it.
+			ca, err := tls.GenerateRootCAWithDefaults("identity.linkerd." + tc.domain)
+			if err != nil {
+				t.Fatalf("Failed to generate CA: %s", err)
+			}
+			cred, err := ca.GenerateEndEntityCred(id)
+			if err != nil {
+				t.Fatalf("Failed to issue certificate for %q: %s", id, err)
+			}
+			if err := cred.Crt.Certificate.VerifyHostname(id); err != nil {
+				t.Fatalf("Certificate doesn't match identity %q: %s", id, err)
+			}
+		})
+	}
+}
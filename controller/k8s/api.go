@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 
+	ewv1beta1 "github.com/linkerd/linkerd2/controller/gen/apis/externalworkload/v1beta1"
 	spv1alpha2 "github.com/linkerd/linkerd2/controller/gen/apis/serviceprofile/v1alpha2"
 	l5dcrdclient "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned"
 	l5dcrdinformer "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions"
@@ -66,7 +68,7 @@ type API struct {
 	secret   coreinformers.SecretInformer
 	srv      srvinformers.ServerInformer
 
-	syncChecks            []cache.InformerSynced
+	syncChecks            map[string]cache.InformerSynced
 	sharedInformers       informers.SharedInformerFactory
 	l5dCrdSharedInformers l5dcrdinformer.SharedInformerFactory
 }
@@ -76,6 +78,25 @@ type API struct {
 // metrics on each one; don't forget to call UnregisterGauges() on the returned
 // API reference to clean them up!
 func InitializeAPI(ctx context.Context, kubeConfig string, ensureClusterWideAccess bool, cluster string, resources ...APIResource) (*API, error) {
+	return InitializeAPIWithExtWorkloadSelector(ctx, kubeConfig, ensureClusterWideAccess, cluster, ExtWorkloadSelector{}, resources...)
+}
+
+// InitializeAPIWithExtWorkloadSelector is like InitializeAPI, but the
+// ExternalWorkload informer only watches the ExternalWorkloads matching
+// ewSelector.
+func InitializeAPIWithExtWorkloadSelector(ctx context.Context, kubeConfig string, ensureClusterWideAccess bool, cluster string, ewSelector ExtWorkloadSelector, resources ...APIResource) (*API, error) {
+	return InitializeAPIForNamespaces(ctx, kubeConfig, ensureClusterWideAccess, cluster, nil, ewSelector, resources...)
+}
+
+// InitializeAPIForNamespaces is like InitializeAPIWithExtWorkloadSelector, but
+// when namespaces isn't empty the informers of namespaced resources only watch
+// those namespaces, and cluster-wide access isn't required for them.
+// Cluster-scoped resources are still watched cluster-wide.
+func InitializeAPIForNamespaces(ctx context.Context, kubeConfig string, ensureClusterWideAccess bool, cluster string, namespaces []string, ewSelector ExtWorkloadSelector, resources ...APIResource) (*API, error) {
+	if err := ewSelector.validate(); err != nil {
+		return nil, err
+	}
+
 	config, err := k8s.GetConfig(kubeConfig, "")
 	if err != nil {
 		return nil, fmt.Errorf("error configuring Kubernetes API client: %w", err)
@@ -91,7 +112,7 @@ func InitializeAPI(ctx context.Context, kubeConfig string, ensureClusterWideAcce
 		return nil, err
 	}
 
-	return initAPI(ctx, k8sClient, dynamicClient, config, ensureClusterWideAccess, cluster, resources...)
+	return initAPI(ctx, k8sClient, dynamicClient, config, ensureClusterWideAccess, cluster, namespaces, ewSelector, resources...)
 }
 
 // InitializeAPIForConfig creates Kubernetes clients and returns an initialized
@@ -104,14 +125,14 @@ func InitializeAPIForConfig(ctx context.Context, kubeConfig *rest.Config, ensure
 		return nil, err
 	}
 
-	return initAPI(ctx, k8sClient, nil, kubeConfig, ensureClusterWideAccess, cluster, resources...)
+	return initAPI(ctx, k8sClient, nil, kubeConfig, ensureClusterWideAccess, cluster, nil, ExtWorkloadSelector{}, resources...)
 }
 
-func initAPI(ctx context.Context, k8sClient *k8s.KubernetesAPI, dynamicClient dynamic.Interface, kubeConfig *rest.Config, ensureClusterWideAccess bool, cluster string, resources ...APIResource) (*API, error) {
+func initAPI(ctx context.Context, k8sClient *k8s.KubernetesAPI, dynamicClient dynamic.Interface, kubeConfig *rest.Config, ensureClusterWideAccess bool, cluster string, namespaces []string, ewSelector ExtWorkloadSelector, resources ...APIResource) (*API, error) {
 	// check for cluster-wide access
 	var err error
 
-	if ensureClusterWideAccess {
+	if ensureClusterWideAccess && len(namespaces) == 0 {
 		err := k8s.ClusterAccess(ctx, k8sClient)
 		if err != nil {
 			return nil, err
@@ -147,7 +168,8 @@ func initAPI(ctx context.Context, k8sClient *k8s.KubernetesAPI, dynamicClient dy
 		break
 	}
 
-	api := NewClusterScopedAPI(k8sClient, dynamicClient, l5dCrdClient, cluster, resources...)
+	sharedInformers := informers.NewSharedInformerFactory(k8sClient, ResyncTime)
+	api := newAPI(k8sClient, dynamicClient, l5dCrdClient, sharedInformers, cluster, namespaces, ewSelector, resources...)
 	for _, gauge := range api.gauges {
 		if err := prometheus.Register(gauge); err != nil {
 			log.Warnf("failed to register Prometheus gauge %s: %s", gauge.Desc().String(), err)
@@ -168,7 +190,24 @@ func NewClusterScopedAPI(
 	resources ...APIResource,
 ) *API {
 	sharedInformers := informers.NewSharedInformerFactory(k8sClient, ResyncTime)
-	return newAPI(k8sClient, dynamicClient, l5dCrdClient, sharedInformers, cluster, resources...)
+	return newAPI(k8sClient, dynamicClient, l5dCrdClient, sharedInformers, cluster, nil, ExtWorkloadSelector{}, resources...)
+}
+
+// NewMultiNamespacedAPI takes a Kubernetes client and returns an initialized
+// API whose namespaced resources are only watched in namespaces, through one
+// informer per namespace. This creates informers on each one of resources
+// passed, registering metrics on each one; don't forget to call
+// UnregisterGauges() on the returned API reference to clean them up!
+func NewMultiNamespacedAPI(
+	k8sClient kubernetes.Interface,
+	dynamicClient dynamic.Interface,
+	l5dCrdClient l5dcrdclient.Interface,
+	namespaces []string,
+	cluster string,
+	resources ...APIResource,
+) *API {
+	sharedInformers := informers.NewSharedInformerFactory(k8sClient, ResyncTime)
+	return newAPI(k8sClient, dynamicClient, l5dCrdClient, sharedInformers, cluster, namespaces, ExtWorkloadSelector{}, resources...)
 }
 
 // NewNamespacedAPI takes a Kubernetes client and returns an initialized API
@@ -184,7 +223,7 @@ func NewNamespacedAPI(
 	resources ...APIResource,
 ) *API {
 	sharedInformers := informers.NewSharedInformerFactoryWithOptions(k8sClient, ResyncTime, informers.WithNamespace(namespace))
-	return newAPI(k8sClient, dynamicClient, l5dCrdClient, sharedInformers, cluster, resources...)
+	return newAPI(k8sClient, dynamicClient, l5dCrdClient, sharedInformers, cluster, nil, ExtWorkloadSelector{}, resources...)
 }
 
 // newAPI takes a Kubernetes client and returns an initialized API.
@@ -194,6 +233,8 @@ func newAPI(
 	l5dCrdClient l5dcrdclient.Interface,
 	sharedInformers informers.SharedInformerFactory,
 	cluster string,
+	namespaces []string,
+	ewSelector ExtWorkloadSelector,
 	resources ...APIResource,
 ) *API {
 	var l5dCrdSharedInformers l5dcrdinformer.SharedInformerFactory
@@ -201,10 +242,14 @@ func newAPI(
 		l5dCrdSharedInformers = l5dcrdinformer.NewSharedInformerFactory(l5dCrdClient, ResyncTime)
 	}
 
+	if len(namespaces) > 0 {
+		registerMultiNamespaceInformers(k8sClient, l5dCrdClient, sharedInformers, l5dCrdSharedInformers, namespaces, ewSelector, resources...)
+	}
+
 	api := &API{
 		Client:                k8sClient,
 		DynamicClient:         dynamicClient,
-		syncChecks:            make([]cache.InformerSynced, 0),
+		syncChecks:            make(map[string]cache.InformerSynced),
 		sharedInformers:       sharedInformers,
 		l5dCrdSharedInformers: l5dCrdSharedInformers,
 	}
@@ -217,95 +262,102 @@ func newAPI(
 		switch resource {
 		case CJ:
 			api.cj = sharedInformers.Batch().V1().CronJobs()
-			api.syncChecks = append(api.syncChecks, api.cj.Informer().HasSynced)
+			api.syncChecks[k8s.CronJob] = api.cj.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.CronJob, informerLabels, api.cj.Informer())
 		case CM:
 			api.cm = sharedInformers.Core().V1().ConfigMaps()
-			api.syncChecks = append(api.syncChecks, api.cm.Informer().HasSynced)
+			api.syncChecks[k8s.ConfigMap] = api.cm.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.ConfigMap, informerLabels, api.cm.Informer())
 		case Deploy:
 			api.deploy = sharedInformers.Apps().V1().Deployments()
-			api.syncChecks = append(api.syncChecks, api.deploy.Informer().HasSynced)
+			api.syncChecks[k8s.Deployment] = api.deploy.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.Deployment, informerLabels, api.deploy.Informer())
 		case DS:
 			api.ds = sharedInformers.Apps().V1().DaemonSets()
-			api.syncChecks = append(api.syncChecks, api.ds.Informer().HasSynced)
+			api.syncChecks[k8s.DaemonSet] = api.ds.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.DaemonSet, informerLabels, api.ds.Informer())
 		case Endpoint:
 			api.endpoint = sharedInformers.Core().V1().Endpoints()
-			api.syncChecks = append(api.syncChecks, api.endpoint.Informer().HasSynced)
+			api.syncChecks[k8s.Endpoints] = api.endpoint.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.Endpoints, informerLabels, api.endpoint.Informer())
 		case ES:
 			api.es = sharedInformers.Discovery().V1().EndpointSlices()
-			api.syncChecks = append(api.syncChecks, api.es.Informer().HasSynced)
+			api.syncChecks[k8s.EndpointSlices] = api.es.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.EndpointSlices, informerLabels, api.es.Informer())
 		case ExtWorkload:
 			if l5dCrdSharedInformers == nil {
 				panic("Linkerd CRD shared informer not configured")
 			}
+			if !ewSelector.isEmpty() {
+				// Registering the filtered informer first makes the factory
+				// return it instead of its default, cluster-wide one
+				l5dCrdSharedInformers.InformerFor(&ewv1beta1.ExternalWorkload{}, func(client l5dcrdclient.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+					return ewinformers.NewFilteredExternalWorkloadInformer(client, metav1.NamespaceAll, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, ewSelector.tweakListOptions)
+				})
+			}
 			api.ew = l5dCrdSharedInformers.Externalworkload().V1beta1().ExternalWorkloads()
-			api.syncChecks = append(api.syncChecks, api.ew.Informer().HasSynced)
+			api.syncChecks[k8s.ExtWorkload] = api.ew.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.ExtWorkload, informerLabels, api.ew.Informer())
 		case Job:
 			api.job = sharedInformers.Batch().V1().Jobs()
-			api.syncChecks = append(api.syncChecks, api.job.Informer().HasSynced)
+			api.syncChecks[k8s.Job] = api.job.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.Job, informerLabels, api.job.Informer())
 		case Link:
 			if l5dCrdSharedInformers == nil {
 				panic("Linkerd CRD shared informer not configured")
 			}
 			api.link = l5dCrdSharedInformers.Link().V1alpha2().Links()
-			api.syncChecks = append(api.syncChecks, api.link.Informer().HasSynced)
+			api.syncChecks[k8s.Link] = api.link.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.Link, informerLabels, api.link.Informer())
 		case MWC:
 			api.mwc = sharedInformers.Admissionregistration().V1().MutatingWebhookConfigurations()
-			api.syncChecks = append(api.syncChecks, api.mwc.Informer().HasSynced)
+			api.syncChecks[k8s.MutatingWebhookConfig] = api.mwc.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.MutatingWebhookConfig, informerLabels, api.mwc.Informer())
 		case NS:
 			api.ns = sharedInformers.Core().V1().Namespaces()
-			api.syncChecks = append(api.syncChecks, api.ns.Informer().HasSynced)
+			api.syncChecks[k8s.Namespace] = api.ns.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.Namespace, informerLabels, api.ns.Informer())
 		case Pod:
 			api.pod = sharedInformers.Core().V1().Pods()
-			api.syncChecks = append(api.syncChecks, api.pod.Informer().HasSynced)
+			api.syncChecks[k8s.Pod] = api.pod.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.Pod, informerLabels, api.pod.Informer())
 		case RC:
 			api.rc = sharedInformers.Core().V1().ReplicationControllers()
-			api.syncChecks = append(api.syncChecks, api.rc.Informer().HasSynced)
+			api.syncChecks[k8s.ReplicationController] = api.rc.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.ReplicationController, informerLabels, api.rc.Informer())
 		case RS:
 			api.rs = sharedInformers.Apps().V1().ReplicaSets()
-			api.syncChecks = append(api.syncChecks, api.rs.Informer().HasSynced)
+			api.syncChecks[k8s.ReplicaSet] = api.rs.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.ReplicaSet, informerLabels, api.rs.Informer())
 		case SP:
 			if l5dCrdSharedInformers == nil {
 				panic("Linkerd CRD shared informer not configured")
 			}
 			api.sp = l5dCrdSharedInformers.Linkerd().V1alpha2().ServiceProfiles()
-			api.syncChecks = append(api.syncChecks, api.sp.Informer().HasSynced)
+			api.syncChecks[k8s.ServiceProfile] = api.sp.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.ServiceProfile, informerLabels, api.sp.Informer())
 		case Srv:
 			if l5dCrdSharedInformers == nil {
 				panic("Linkerd CRD shared informer not configured")
 			}
 			api.srv = l5dCrdSharedInformers.Server().V1beta3().Servers()
-			api.syncChecks = append(api.syncChecks, api.srv.Informer().HasSynced)
+			api.syncChecks[k8s.Server] = api.srv.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.Server, informerLabels, api.srv.Informer())
 		case SS:
 			api.ss = sharedInformers.Apps().V1().StatefulSets()
-			api.syncChecks = append(api.syncChecks, api.ss.Informer().HasSynced)
+			api.syncChecks[k8s.StatefulSet] = api.ss.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.StatefulSet, informerLabels, api.ss.Informer())
 		case Svc:
 			api.svc = sharedInformers.Core().V1().Services()
-			api.syncChecks = append(api.syncChecks, api.svc.Informer().HasSynced)
+			api.syncChecks[k8s.Service] = api.svc.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.Service, informerLabels, api.svc.Informer())
 		case Node:
 			api.node = sharedInformers.Core().V1().Nodes()
-			api.syncChecks = append(api.syncChecks, api.node.Informer().HasSynced)
+			api.syncChecks[k8s.Node] = api.node.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.Node, informerLabels, api.node.Informer())
 		case Secret:
 			api.secret = sharedInformers.Core().V1().Secrets()
-			api.syncChecks = append(api.syncChecks, api.secret.Informer().HasSynced)
+			api.syncChecks[k8s.Secret] = api.secret.Informer().HasSynced
 			api.promGauges.addInformerSize(k8s.Secret, informerLabels, api.secret.Informer())
 		}
 	}
@@ -323,6 +375,20 @@ func (api *API) Sync(stopCh <-chan struct{}) {
 	waitForCacheSync(api.syncChecks)
 }
 
+// SyncWithTimeout starts all informers and waits up to timeout for them to be
+// synced. Rather than exiting when some of them fail to sync in time, it
+// returns the resources whose informers didn't sync, so callers can decide
+// whether they can proceed without them.
+func (api *API) SyncWithTimeout(stopCh <-chan struct{}, timeout time.Duration) []string {
+	api.sharedInformers.Start(stopCh)
+
+	if api.l5dCrdSharedInformers != nil {
+		api.l5dCrdSharedInformers.Start(stopCh)
+	}
+
+	return waitForCacheSyncWithTimeout(api.syncChecks, timeout)
+}
+
 // UnregisterGauges unregisters all the prometheus cache gauges associated to this API
 func (api *API) UnregisterGauges() {
 	api.promGauges.unregister()
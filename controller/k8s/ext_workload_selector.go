@@ -0,0 +1,36 @@
+package k8s
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// ExtWorkloadSelector scopes the ExternalWorkload informer to the
+// ExternalWorkloads matching its label and field selectors. Empty selectors
+// match everything.
+type ExtWorkloadSelector struct {
+	Label string
+	Field string
+}
+
+func (s ExtWorkloadSelector) isEmpty() bool {
+	return s.Label == "" && s.Field == ""
+}
+
+func (s ExtWorkloadSelector) validate() error {
+	if _, err := labels.Parse(s.Label); err != nil {
+		return fmt.Errorf("invalid ExternalWorkload label selector %q: %w", s.Label, err)
+	}
+	if _, err := fields.ParseSelector(s.Field); err != nil {
+		return fmt.Errorf("invalid ExternalWorkload field selector %q: %w", s.Field, err)
+	}
+	return nil
+}
+
+func (s ExtWorkloadSelector) tweakListOptions(options *metav1.ListOptions) {
+	options.LabelSelector = s.Label
+	options.FieldSelector = s.Field
+}
@@ -0,0 +1,107 @@
+package k8s
+
+import (
+	"sync"
+	"testing"
+
+	ewv1beta1 "github.com/linkerd/linkerd2/controller/gen/apis/externalworkload/v1beta1"
+	l5dfake "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/fake"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestExtWorkloadSelector(t *testing.T) {
+	newExtWorkload := func(name string, workloadLabels map[string]string) *ewv1beta1.ExternalWorkload {
+		return &ewv1beta1.ExternalWorkload{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns", Labels: workloadLabels},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		selector      ExtWorkloadSelector
+		expectedNames []string
+	}{
+		{
+			name:          "no selector",
+			expectedNames: []string{"vm-a", "vm-b"},
+		},
+		{
+			name: "label and field selectors",
+			selector: ExtWorkloadSelector{
+				Label: "team=a",
+				Field: "metadata.namespace=ns",
+			},
+			expectedNames: []string{"vm-a"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			l5dClient := l5dfake.NewSimpleClientset(
+				newExtWorkload("vm-a", map[string]string{"team": "a"}),
+				newExtWorkload("vm-b", map[string]string{"team": "b"}),
+			)
+			var mu sync.Mutex
+			var restrictions []k8stesting.ListRestrictions
+			l5dClient.PrependReactor("list", "externalworkloads", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				mu.Lock()
+				defer mu.Unlock()
+				restrictions = append(restrictions, action.(k8stesting.ListAction).GetListRestrictions())
+				return false, nil, nil
+			})
+
+			clientSet := fake.NewSimpleClientset()
+			api := newAPI(clientSet, nil, l5dClient, informers.NewSharedInformerFactory(clientSet, ResyncTime), "fake", nil, tc.selector, ExtWorkload)
+			api.Sync(nil)
+
+			mu.Lock()
+			if len(restrictions) == 0 {
+				mu.Unlock()
+				t.Fatal("Expected the ExternalWorkloads to be listed")
+			}
+			for _, r := range restrictions {
+				if r.Labels.String() != tc.selector.Label {
+					t.Errorf("Expected label selector %q, got %q", tc.selector.Label, r.Labels.String())
+				}
+				if r.Fields.String() != tc.selector.Field {
+					t.Errorf("Expected field selector %q, got %q", tc.selector.Field, r.Fields.String())
+				}
+			}
+			mu.Unlock()
+
+			ews, err := api.ExtWorkload().Lister().List(labels.Everything())
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			names := map[string]struct{}{}
+			for _, ew := range ews {
+				names[ew.Name] = struct{}{}
+			}
+			if len(names) != len(tc.expectedNames) {
+				t.Fatalf("Expected ExternalWorkloads %v, got %v", tc.expectedNames, names)
+			}
+			for _, name := range tc.expectedNames {
+				if _, ok := names[name]; !ok {
+					t.Fatalf("Expected ExternalWorkloads %v, got %v", tc.expectedNames, names)
+				}
+			}
+		})
+	}
+
+	t.Run("invalid selectors", func(t *testing.T) {
+		for _, selector := range []ExtWorkloadSelector{
+			{Label: "team in (a"},
+			{Field: "metadata.name"},
+		} {
+			if err := selector.validate(); err == nil {
+				t.Fatalf("Expected an error for %+v", selector)
+			}
+		}
+	})
+}
@@ -2,6 +2,7 @@ package k8s
 
 import (
 	"context"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,16 +14,42 @@ import (
 
 const ResyncTime = 10 * time.Minute
 
-func waitForCacheSync(syncChecks []cache.InformerSynced) {
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+const cacheSyncTimeout = 60 * time.Second
+
+func waitForCacheSync(syncChecks map[string]cache.InformerSynced) {
+	if unsynced := waitForCacheSyncWithTimeout(syncChecks, cacheSyncTimeout); len(unsynced) > 0 {
+		//nolint:gocritic
+		log.Fatalf("failed to sync caches for: %s", strings.Join(unsynced, ", "))
+	}
+}
+
+// waitForCacheSyncWithTimeout waits up to timeout for all the informers in
+// syncChecks to be synced, and returns the sorted names of the resources whose
+// informers didn't sync in time.
+func waitForCacheSyncWithTimeout(syncChecks map[string]cache.InformerSynced, timeout time.Duration) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
+	checks := make([]cache.InformerSynced, 0, len(syncChecks))
+	for _, check := range syncChecks {
+		checks = append(checks, check)
+	}
+
 	log.Infof("waiting for caches to sync")
-	if !cache.WaitForCacheSync(ctx.Done(), syncChecks...) {
-		//nolint:gocritic
-		log.Fatal("failed to sync caches")
+	if cache.WaitForCacheSync(ctx.Done(), checks...) {
+		log.Infof("caches synced")
+		return nil
+	}
+
+	unsynced := []string{}
+	for resource, check := range syncChecks {
+		if !check() {
+			log.Errorf("failed to sync %s cache", resource)
+			unsynced = append(unsynced, resource)
+		}
 	}
-	log.Infof("caches synced")
+	sort.Strings(unsynced)
+	return unsynced
 }
 
 func isValidRSParent(rs metav1.Object) bool {
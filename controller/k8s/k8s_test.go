@@ -0,0 +1,41 @@
+package k8s
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestWaitForCacheSyncWithTimeout(t *testing.T) {
+	synced := func() bool { return true }
+	neverSynced := func() bool { return false }
+
+	t.Run("Returns nothing when all caches sync", func(t *testing.T) {
+		syncChecks := map[string]cache.InformerSynced{
+			"pod":     synced,
+			"service": synced,
+		}
+
+		unsynced := waitForCacheSyncWithTimeout(syncChecks, time.Second)
+		if len(unsynced) != 0 {
+			t.Fatalf("Expected all caches to sync, got unsynced: %v", unsynced)
+		}
+	})
+
+	t.Run("Reports the caches that never sync", func(t *testing.T) {
+		syncChecks := map[string]cache.InformerSynced{
+			"pod":     synced,
+			"server":  neverSynced,
+			"service": synced,
+			"job":     neverSynced,
+		}
+
+		unsynced := waitForCacheSyncWithTimeout(syncChecks, 200*time.Millisecond)
+		expected := []string{"job", "server"}
+		if !reflect.DeepEqual(expected, unsynced) {
+			t.Fatalf("Expected unsynced caches %v, got %v", expected, unsynced)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/linkerd/linkerd2/pkg/k8s"
 	"github.com/prometheus/client_golang/prometheus"
@@ -28,7 +29,7 @@ type MetadataAPI struct {
 
 	client          metadata.Interface
 	inf             map[APIResource]informers.GenericInformer
-	syncChecks      []cache.InformerSynced
+	syncChecks      map[string]cache.InformerSynced
 	sharedInformers metadatainformer.SharedInformerFactory
 }
 
@@ -77,7 +78,7 @@ func newClusterScopedMetadataAPI(
 	api := &MetadataAPI{
 		client:          metadataClient,
 		inf:             make(map[APIResource]informers.GenericInformer),
-		syncChecks:      make([]cache.InformerSynced, 0),
+		syncChecks:      make(map[string]cache.InformerSynced),
 		sharedInformers: sharedInformers,
 	}
 
@@ -100,6 +101,15 @@ func (api *MetadataAPI) Sync(stopCh <-chan struct{}) {
 	waitForCacheSync(api.syncChecks)
 }
 
+// SyncWithTimeout starts all informers and waits up to timeout for them to be
+// synced, returning the resources whose informers didn't sync in time. See
+// API.SyncWithTimeout.
+func (api *MetadataAPI) SyncWithTimeout(stopCh <-chan struct{}, timeout time.Duration) []string {
+	api.sharedInformers.Start(stopCh)
+
+	return waitForCacheSyncWithTimeout(api.syncChecks, timeout)
+}
+
 // UnregisterGauges unregisters all the prometheus cache gauges associated to this API
 func (api *MetadataAPI) UnregisterGauges() {
 	api.promGauges.unregister()
@@ -279,7 +289,7 @@ func (api *MetadataAPI) addInformer(res APIResource, informerLabels prometheus.L
 	}
 	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
 	inf := api.sharedInformers.ForResource(gvr)
-	api.syncChecks = append(api.syncChecks, inf.Informer().HasSynced)
+	api.syncChecks[strings.ToLower(gvk.Kind)] = inf.Informer().HasSynced
 	api.promGauges.addInformerSize(strings.ToLower(gvk.Kind), informerLabels, inf.Informer())
 	api.inf[res] = inf
 
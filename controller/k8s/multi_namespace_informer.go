@@ -0,0 +1,481 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	ewv1beta1 "github.com/linkerd/linkerd2/controller/gen/apis/externalworkload/v1beta1"
+	linkv1alpha2 "github.com/linkerd/linkerd2/controller/gen/apis/link/v1alpha2"
+	srvv1beta3 "github.com/linkerd/linkerd2/controller/gen/apis/server/v1beta3"
+	spv1alpha2 "github.com/linkerd/linkerd2/controller/gen/apis/serviceprofile/v1alpha2"
+	l5dcrdclient "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned"
+	l5dcrdinformer "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions"
+	ewinformers "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions/externalworkload/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+type namespacedInformer struct {
+	obj      runtime.Object
+	informer func(informers.SharedInformerFactory) cache.SharedIndexInformer
+}
+
+type namespacedL5dInformer struct {
+	obj      runtime.Object
+	informer func(l5dcrdinformer.SharedInformerFactory) cache.SharedIndexInformer
+}
+
+// namespacedInformers holds, for each namespaced resource, its object type
+// and how to get its informer from a namespace-scoped factory. Resources
+// missing from here and from namespacedL5dInformers are cluster-scoped.
+var namespacedInformers = map[APIResource]namespacedInformer{
+	CJ: {&batchv1.CronJob{}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Batch().V1().CronJobs().Informer()
+	}},
+	CM: {&corev1.ConfigMap{}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().ConfigMaps().Informer()
+	}},
+	Deploy: {&appsv1.Deployment{}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().Deployments().Informer()
+	}},
+	DS: {&appsv1.DaemonSet{}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().DaemonSets().Informer()
+	}},
+	Endpoint: {&corev1.Endpoints{}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Endpoints().Informer()
+	}},
+	ES: {&discoveryv1.EndpointSlice{}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Discovery().V1().EndpointSlices().Informer()
+	}},
+	Job: {&batchv1.Job{}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Batch().V1().Jobs().Informer()
+	}},
+	Pod: {&corev1.Pod{}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Pods().Informer()
+	}},
+	RC: {&corev1.ReplicationController{}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().ReplicationControllers().Informer()
+	}},
+	RS: {&appsv1.ReplicaSet{}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().ReplicaSets().Informer()
+	}},
+	SS: {&appsv1.StatefulSet{}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().StatefulSets().Informer()
+	}},
+	Svc: {&corev1.Service{}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Services().Informer()
+	}},
+	Secret: {&corev1.Secret{}, func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Secrets().Informer()
+	}},
+}
+
+// namespacedL5dInformers is like namespacedInformers, for the Linkerd CRDs.
+// ExternalWorkloads are handled separately, as they can be filtered by an
+// ExtWorkloadSelector.
+var namespacedL5dInformers = map[APIResource]namespacedL5dInformer{
+	Link: {&linkv1alpha2.Link{}, func(f l5dcrdinformer.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Link().V1alpha2().Links().Informer()
+	}},
+	SP: {&spv1alpha2.ServiceProfile{}, func(f l5dcrdinformer.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Linkerd().V1alpha2().ServiceProfiles().Informer()
+	}},
+	Srv: {&srvv1beta3.Server{}, func(f l5dcrdinformer.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Server().V1beta3().Servers().Informer()
+	}},
+}
+
+// registerMultiNamespaceInformers makes sharedInformers and
+// l5dCrdSharedInformers return, for each of the namespaced resources, an
+// informer only watching the given namespaces. It must be called before any
+// informer is requested from the factories.
+func registerMultiNamespaceInformers(
+	k8sClient kubernetes.Interface,
+	l5dCrdClient l5dcrdclient.Interface,
+	sharedInformers informers.SharedInformerFactory,
+	l5dCrdSharedInformers l5dcrdinformer.SharedInformerFactory,
+	namespaces []string,
+	ewSelector ExtWorkloadSelector,
+	resources ...APIResource,
+) {
+	factories := make(map[string]informers.SharedInformerFactory, len(namespaces))
+	l5dFactories := make(map[string]l5dcrdinformer.SharedInformerFactory, len(namespaces))
+	for _, ns := range namespaces {
+		factories[ns] = informers.NewSharedInformerFactoryWithOptions(k8sClient, ResyncTime, informers.WithNamespace(ns))
+		if l5dCrdClient != nil {
+			l5dFactories[ns] = l5dcrdinformer.NewSharedInformerFactoryWithOptions(l5dCrdClient, ResyncTime, l5dcrdinformer.WithNamespace(ns))
+		}
+	}
+
+	for _, resource := range resources {
+		if ni, ok := namespacedInformers[resource]; ok {
+			sharedInformers.InformerFor(ni.obj, func(kubernetes.Interface, time.Duration) cache.SharedIndexInformer {
+				children := make(map[string]cache.SharedIndexInformer, len(namespaces))
+				for ns, factory := range factories {
+					children[ns] = ni.informer(factory)
+				}
+				return newMultiNamespaceInformer(namespaces, children)
+			})
+			continue
+		}
+
+		if l5dCrdSharedInformers == nil {
+			continue
+		}
+		if resource == ExtWorkload {
+			l5dCrdSharedInformers.InformerFor(&ewv1beta1.ExternalWorkload{}, func(client l5dcrdclient.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+				children := make(map[string]cache.SharedIndexInformer, len(namespaces))
+				for _, ns := range namespaces {
+					children[ns] = ewinformers.NewFilteredExternalWorkloadInformer(client, ns, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, ewSelector.tweakListOptions)
+				}
+				return newMultiNamespaceInformer(namespaces, children)
+			})
+			continue
+		}
+		if ni, ok := namespacedL5dInformers[resource]; ok {
+			l5dCrdSharedInformers.InformerFor(ni.obj, func(l5dcrdclient.Interface, time.Duration) cache.SharedIndexInformer {
+				children := make(map[string]cache.SharedIndexInformer, len(namespaces))
+				for ns, factory := range l5dFactories {
+					children[ns] = ni.informer(factory)
+				}
+				return newMultiNamespaceInformer(namespaces, children)
+			})
+		}
+	}
+}
+
+// multiNamespaceInformer is a SharedIndexInformer watching a resource in a
+// set of namespaces, through one namespace-scoped informer per namespace.
+// Event handlers are added to each of them, and its indexer merges theirs.
+type multiNamespaceInformer struct {
+	namespaces []string
+	informers  map[string]cache.SharedIndexInformer
+}
+
+// multiNamespaceRegistration holds the registration of an event handler with
+// each of the namespace-scoped informers.
+type multiNamespaceRegistration map[string]cache.ResourceEventHandlerRegistration
+
+func newMultiNamespaceInformer(namespaces []string, informers map[string]cache.SharedIndexInformer) *multiNamespaceInformer {
+	return &multiNamespaceInformer{namespaces, informers}
+}
+
+func (r multiNamespaceRegistration) HasSynced() bool {
+	for _, registration := range r {
+		if !registration.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *multiNamespaceInformer) AddEventHandler(handler cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error) {
+	registrations := make(multiNamespaceRegistration, len(m.informers))
+	for ns, informer := range m.informers {
+		registration, err := informer.AddEventHandler(handler)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add event handler in namespace %s: %w", ns, err)
+		}
+		registrations[ns] = registration
+	}
+	return registrations, nil
+}
+
+func (m *multiNamespaceInformer) AddEventHandlerWithResyncPeriod(handler cache.ResourceEventHandler, resyncPeriod time.Duration) (cache.ResourceEventHandlerRegistration, error) {
+	registrations := make(multiNamespaceRegistration, len(m.informers))
+	for ns, informer := range m.informers {
+		registration, err := informer.AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add event handler in namespace %s: %w", ns, err)
+		}
+		registrations[ns] = registration
+	}
+	return registrations, nil
+}
+
+func (m *multiNamespaceInformer) RemoveEventHandler(handle cache.ResourceEventHandlerRegistration) error {
+	registrations, ok := handle.(multiNamespaceRegistration)
+	if !ok {
+		return errors.New("event handler was not added to this informer")
+	}
+	for ns, informer := range m.informers {
+		if err := informer.RemoveEventHandler(registrations[ns]); err != nil {
+			return fmt.Errorf("failed to remove event handler in namespace %s: %w", ns, err)
+		}
+	}
+	return nil
+}
+
+func (m *multiNamespaceInformer) GetStore() cache.Store {
+	return m.GetIndexer()
+}
+
+// GetController returns the informer itself, which runs all the
+// namespace-scoped informers.
+func (m *multiNamespaceInformer) GetController() cache.Controller {
+	return m
+}
+
+func (m *multiNamespaceInformer) Run(stopCh <-chan struct{}) {
+	var wg sync.WaitGroup
+	for _, informer := range m.informers {
+		wg.Add(1)
+		go func(informer cache.SharedIndexInformer) {
+			defer wg.Done()
+			informer.Run(stopCh)
+		}(informer)
+	}
+	wg.Wait()
+}
+
+func (m *multiNamespaceInformer) HasSynced() bool {
+	for _, informer := range m.informers {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// LastSyncResourceVersion is always empty, as there is no single resource
+// version the namespace-scoped informers have all synced to.
+func (m *multiNamespaceInformer) LastSyncResourceVersion() string {
+	return ""
+}
+
+func (m *multiNamespaceInformer) SetWatchErrorHandler(handler cache.WatchErrorHandler) error {
+	for _, informer := range m.informers {
+		if err := informer.SetWatchErrorHandler(handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *multiNamespaceInformer) SetTransform(handler cache.TransformFunc) error {
+	for _, informer := range m.informers {
+		if err := informer.SetTransform(handler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *multiNamespaceInformer) IsStopped() bool {
+	for _, informer := range m.informers {
+		if !informer.IsStopped() {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *multiNamespaceInformer) AddIndexers(indexers cache.Indexers) error {
+	for _, informer := range m.informers {
+		if err := informer.AddIndexers(indexers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *multiNamespaceInformer) GetIndexer() cache.Indexer {
+	indexers := make(map[string]cache.Indexer, len(m.informers))
+	for ns, informer := range m.informers {
+		indexers[ns] = informer.GetIndexer()
+	}
+	return &multiNamespaceIndexer{m.namespaces, indexers}
+}
+
+// multiNamespaceIndexer merges the indexers of the namespace-scoped informers
+// of a multiNamespaceInformer. Objects are looked up in the indexer of their
+// namespace, and lists are the concatenation of those of all the indexers.
+type multiNamespaceIndexer struct {
+	namespaces []string
+	indexers   map[string]cache.Indexer
+}
+
+// indexerFor returns the indexer holding the objects of obj's namespace, or
+// nil if that namespace isn't watched.
+func (m *multiNamespaceIndexer) indexerFor(obj interface{}) (cache.Indexer, error) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return nil, err
+	}
+	return m.indexerForKey(key)
+}
+
+func (m *multiNamespaceIndexer) indexerForKey(key string) (cache.Indexer, error) {
+	ns, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return m.indexers[ns], nil
+}
+
+func (m *multiNamespaceIndexer) Add(obj interface{}) error {
+	indexer, err := m.indexerFor(obj)
+	if err != nil {
+		return err
+	}
+	if indexer == nil {
+		return fmt.Errorf("object %v is not in a watched namespace", obj)
+	}
+	return indexer.Add(obj)
+}
+
+func (m *multiNamespaceIndexer) Update(obj interface{}) error {
+	indexer, err := m.indexerFor(obj)
+	if err != nil {
+		return err
+	}
+	if indexer == nil {
+		return fmt.Errorf("object %v is not in a watched namespace", obj)
+	}
+	return indexer.Update(obj)
+}
+
+func (m *multiNamespaceIndexer) Delete(obj interface{}) error {
+	indexer, err := m.indexerFor(obj)
+	if err != nil || indexer == nil {
+		return err
+	}
+	return indexer.Delete(obj)
+}
+
+func (m *multiNamespaceIndexer) List() []interface{} {
+	var items []interface{}
+	for _, ns := range m.namespaces {
+		items = append(items, m.indexers[ns].List()...)
+	}
+	return items
+}
+
+func (m *multiNamespaceIndexer) ListKeys() []string {
+	var keys []string
+	for _, ns := range m.namespaces {
+		keys = append(keys, m.indexers[ns].ListKeys()...)
+	}
+	return keys
+}
+
+func (m *multiNamespaceIndexer) Get(obj interface{}) (interface{}, bool, error) {
+	indexer, err := m.indexerFor(obj)
+	if err != nil || indexer == nil {
+		return nil, false, err
+	}
+	return indexer.Get(obj)
+}
+
+func (m *multiNamespaceIndexer) GetByKey(key string) (interface{}, bool, error) {
+	indexer, err := m.indexerForKey(key)
+	if err != nil || indexer == nil {
+		return nil, false, err
+	}
+	return indexer.GetByKey(key)
+}
+
+func (m *multiNamespaceIndexer) Replace(items []interface{}, resourceVersion string) error {
+	perNamespace := make(map[string][]interface{}, len(m.namespaces))
+	for _, item := range items {
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(item)
+		if err != nil {
+			return err
+		}
+		ns, _, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			return err
+		}
+		if _, ok := m.indexers[ns]; !ok {
+			return fmt.Errorf("object %v is not in a watched namespace", item)
+		}
+		perNamespace[ns] = append(perNamespace[ns], item)
+	}
+	for _, ns := range m.namespaces {
+		if err := m.indexers[ns].Replace(perNamespace[ns], resourceVersion); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *multiNamespaceIndexer) Resync() error {
+	for _, ns := range m.namespaces {
+		if err := m.indexers[ns].Resync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *multiNamespaceIndexer) Index(indexName string, obj interface{}) ([]interface{}, error) {
+	var items []interface{}
+	for _, ns := range m.namespaces {
+		nsItems, err := m.indexers[ns].Index(indexName, obj)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, nsItems...)
+	}
+	return items, nil
+}
+
+func (m *multiNamespaceIndexer) IndexKeys(indexName, indexedValue string) ([]string, error) {
+	var keys []string
+	for _, ns := range m.namespaces {
+		nsKeys, err := m.indexers[ns].IndexKeys(indexName, indexedValue)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, nsKeys...)
+	}
+	return keys, nil
+}
+
+func (m *multiNamespaceIndexer) ListIndexFuncValues(indexName string) []string {
+	seen := map[string]struct{}{}
+	var values []string
+	for _, ns := range m.namespaces {
+		for _, value := range m.indexers[ns].ListIndexFuncValues(indexName) {
+			if _, ok := seen[value]; ok {
+				continue
+			}
+			seen[value] = struct{}{}
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func (m *multiNamespaceIndexer) ByIndex(indexName, indexedValue string) ([]interface{}, error) {
+	var items []interface{}
+	for _, ns := range m.namespaces {
+		nsItems, err := m.indexers[ns].ByIndex(indexName, indexedValue)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, nsItems...)
+	}
+	return items, nil
+}
+
+func (m *multiNamespaceIndexer) GetIndexers() cache.Indexers {
+	return m.indexers[m.namespaces[0]].GetIndexers()
+}
+
+func (m *multiNamespaceIndexer) AddIndexers(indexers cache.Indexers) error {
+	for _, ns := range m.namespaces {
+		if err := m.indexers[ns].AddIndexers(indexers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
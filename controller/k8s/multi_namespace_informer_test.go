@@ -0,0 +1,101 @@
+package k8s
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	spv1alpha2 "github.com/linkerd/linkerd2/controller/gen/apis/serviceprofile/v1alpha2"
+	l5dfake "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestMultiNamespacedAPI(t *testing.T) {
+	newPod := func(ns, name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns}}
+	}
+
+	clientSet := fake.NewSimpleClientset(
+		newPod("ns-a", "pod-a"),
+		newPod("ns-b", "pod-b"),
+		newPod("ns-c", "pod-c"),
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns-c"}},
+	)
+	l5dClient := l5dfake.NewSimpleClientset(
+		&spv1alpha2.ServiceProfile{ObjectMeta: metav1.ObjectMeta{Name: "sp-a", Namespace: "ns-a"}},
+		&spv1alpha2.ServiceProfile{ObjectMeta: metav1.ObjectMeta{Name: "sp-c", Namespace: "ns-c"}},
+	)
+
+	var mu sync.Mutex
+	listed := map[string]struct{}{}
+	clientSet.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		listed[action.GetNamespace()] = struct{}{}
+		return false, nil, nil
+	})
+
+	api := NewMultiNamespacedAPI(clientSet, nil, l5dClient, []string{"ns-a", "ns-b"}, "fake", NS, Pod, SP)
+
+	var added []string
+	_, err := api.Pod().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			added = append(added, obj.(*corev1.Pod).Name)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	api.Sync(nil)
+
+	mu.Lock()
+	if len(listed) != 2 {
+		t.Errorf("Expected the pods to be listed in ns-a and ns-b only, got %v", listed)
+	}
+	for _, ns := range []string{"ns-a", "ns-b"} {
+		if _, ok := listed[ns]; !ok {
+			t.Errorf("Expected the pods to be listed in %s, got %v", ns, listed)
+		}
+	}
+	sort.Strings(added)
+	if len(added) != 2 || added[0] != "pod-a" || added[1] != "pod-b" {
+		t.Errorf("Expected the handler to be called for pod-a and pod-b, got %v", added)
+	}
+	mu.Unlock()
+
+	pods, err := api.Pod().Lister().List(labels.Everything())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(pods) != 2 {
+		t.Fatalf("Expected 2 pods, got %d", len(pods))
+	}
+
+	if _, err := api.Pod().Lister().Pods("ns-b").Get("pod-b"); err != nil {
+		t.Fatalf("Expected pod-b to be found, got %s", err)
+	}
+	if _, err := api.Pod().Lister().Pods("ns-c").Get("pod-c"); err == nil {
+		t.Fatal("Expected pod-c not to be found, as ns-c isn't watched")
+	}
+
+	sps, err := api.SP().Lister().List(labels.Everything())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(sps) != 1 || sps[0].Name != "sp-a" {
+		t.Fatalf("Expected only sp-a, got %v", sps)
+	}
+
+	// Namespaces are cluster-scoped, and are still watched cluster-wide
+	if _, err := api.NS().Lister().Get("ns-c"); err != nil {
+		t.Fatalf("Expected ns-c to be found, got %s", err)
+	}
+}
@@ -0,0 +1,65 @@
+package injector
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
+)
+
+// ParseDeprecatedAnnotations parses a comma-separated list of deprecated
+// annotations, each optionally followed by `=` and the annotation replacing
+// it, and adds them to the annotations deprecated by this release.
+func ParseDeprecatedAnnotations(s string) (map[string]string, error) {
+	deprecated := make(map[string]string, len(pkgK8s.DeprecatedAnnotations))
+	for annotation, replacement := range pkgK8s.DeprecatedAnnotations {
+		deprecated[annotation] = replacement
+	}
+
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		annotation, replacement, _ := strings.Cut(entry, "=")
+		annotation, replacement = strings.TrimSpace(annotation), strings.TrimSpace(replacement)
+		if annotation == "" {
+			return nil, fmt.Errorf("invalid deprecated annotation %q", entry)
+		}
+		deprecated[annotation] = replacement
+	}
+	return deprecated, nil
+}
+
+// deprecatedAnnotationWarnings returns the admission warnings for the
+// deprecated annotations set on the workload or its namespace. The
+// annotations are still honored; the warnings are only shown to the user
+// creating the workload.
+func deprecatedAnnotationWarnings(workloadAnn, nsAnn map[string]string, deprecated map[string]string) []string {
+	var warnings []string
+	for _, source := range []struct {
+		name        string
+		annotations map[string]string
+	}{
+		{"workload", workloadAnn},
+		{"namespace", nsAnn},
+	} {
+		var found []string
+		for annotation := range source.annotations {
+			if _, ok := deprecated[annotation]; ok {
+				found = append(found, annotation)
+			}
+		}
+		sort.Strings(found)
+
+		for _, annotation := range found {
+			warning := fmt.Sprintf("annotation %s on the %s is deprecated", annotation, source.name)
+			if replacement := deprecated[annotation]; replacement != "" {
+				warning = fmt.Sprintf("%s; use %s instead", warning, replacement)
+			}
+			warnings = append(warnings, warning)
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,109 @@
+package injector
+
+import (
+	"reflect"
+	"testing"
+
+	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
+)
+
+func TestParseDeprecatedAnnotations(t *testing.T) {
+	testCases := []struct {
+		name      string
+		value     string
+		expected  map[string]string
+		expectErr bool
+	}{
+		{
+			name:     "empty",
+			value:    "",
+			expected: map[string]string{},
+		},
+		{
+			name:  "with and without replacement",
+			value: "config.linkerd.io/old-a=config.linkerd.io/new-a, config.linkerd.io/old-b,",
+			expected: map[string]string{
+				"config.linkerd.io/old-a": "config.linkerd.io/new-a",
+				"config.linkerd.io/old-b": "",
+			},
+		},
+		{
+			name:      "missing annotation",
+			value:     "=config.linkerd.io/new-a",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			deprecated, err := ParseDeprecatedAnnotations(tc.value)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got %v", deprecated)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			expected := map[string]string{}
+			for annotation, replacement := range pkgK8s.DeprecatedAnnotations {
+				expected[annotation] = replacement
+			}
+			for annotation, replacement := range tc.expected {
+				expected[annotation] = replacement
+			}
+			if !reflect.DeepEqual(deprecated, expected) {
+				t.Fatalf("Expected %v, got %v", expected, deprecated)
+			}
+		})
+	}
+}
+
+func TestDeprecatedAnnotationWarnings(t *testing.T) {
+	deprecated := map[string]string{
+		"config.linkerd.io/old-a": "config.linkerd.io/new-a",
+		"config.linkerd.io/old-b": "",
+	}
+
+	testCases := []struct {
+		name        string
+		workloadAnn map[string]string
+		nsAnn       map[string]string
+		expected    []string
+	}{
+		{
+			name:        "current annotations",
+			workloadAnn: map[string]string{pkgK8s.ProxyInjectAnnotation: pkgK8s.ProxyInjectEnabled, "config.linkerd.io/new-a": "1"},
+			nsAnn:       map[string]string{pkgK8s.ProxyLogLevelAnnotation: "debug"},
+			expected:    nil,
+		},
+		{
+			name:        "deprecated workload annotations",
+			workloadAnn: map[string]string{"config.linkerd.io/old-b": "1", "config.linkerd.io/old-a": "1", pkgK8s.ProxyInjectAnnotation: pkgK8s.ProxyInjectEnabled},
+			expected: []string{
+				"annotation config.linkerd.io/old-a on the workload is deprecated; use config.linkerd.io/new-a instead",
+				"annotation config.linkerd.io/old-b on the workload is deprecated",
+			},
+		},
+		{
+			name:        "deprecated namespace annotation",
+			workloadAnn: map[string]string{pkgK8s.ProxyInjectAnnotation: pkgK8s.ProxyInjectEnabled},
+			nsAnn:       map[string]string{"config.linkerd.io/old-a": "1"},
+			expected: []string{
+				"annotation config.linkerd.io/old-a on the namespace is deprecated; use config.linkerd.io/new-a instead",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			warnings := deprecatedAnnotationWarnings(tc.workloadAnn, tc.nsAnn, deprecated)
+			if !reflect.DeepEqual(warnings, tc.expected) {
+				t.Fatalf("Expected %q, got %q", tc.expected, warnings)
+			}
+		})
+	}
+}
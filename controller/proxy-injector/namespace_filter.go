@@ -0,0 +1,78 @@
+package injector
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NamespaceFilter restricts the namespaces whose resources the injector
+// mutates, regardless of their annotations. A namespace is excluded if it's
+// in the deny-list, by name or by label selector; otherwise, if an allow-list
+// is set, it's only included if it's in it, by name or by label selector. The
+// zero value includes every namespace.
+type NamespaceFilter struct {
+	allowNames    map[string]struct{}
+	allowSelector labels.Selector
+	denyNames     map[string]struct{}
+	denySelector  labels.Selector
+}
+
+// NewNamespaceFilter builds a NamespaceFilter out of comma-separated lists of
+// namespace names and label selectors. Empty values leave the corresponding
+// list unset.
+func NewNamespaceFilter(allowNames, allowSelector, denyNames, denySelector string) (*NamespaceFilter, error) {
+	filter := &NamespaceFilter{
+		allowNames: parseNamespaceNames(allowNames),
+		denyNames:  parseNamespaceNames(denyNames),
+	}
+
+	var err error
+	if filter.allowSelector, err = parseNamespaceSelector(allowSelector); err != nil {
+		return nil, fmt.Errorf("invalid allow-list selector: %w", err)
+	}
+	if filter.denySelector, err = parseNamespaceSelector(denySelector); err != nil {
+		return nil, fmt.Errorf("invalid deny-list selector: %w", err)
+	}
+	return filter, nil
+}
+
+// Includes returns true if the resources of the namespace with the given name
+// and labels can be injected. A nil filter includes every namespace.
+func (f *NamespaceFilter) Includes(name string, nsLabels map[string]string) bool {
+	if f == nil {
+		return true
+	}
+	if _, ok := f.denyNames[name]; ok {
+		return false
+	}
+	if f.denySelector != nil && f.denySelector.Matches(labels.Set(nsLabels)) {
+		return false
+	}
+
+	if len(f.allowNames) == 0 && f.allowSelector == nil {
+		return true
+	}
+	if _, ok := f.allowNames[name]; ok {
+		return true
+	}
+	return f.allowSelector != nil && f.allowSelector.Matches(labels.Set(nsLabels))
+}
+
+func parseNamespaceNames(s string) map[string]struct{} {
+	names := make(map[string]struct{})
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names[name] = struct{}{}
+		}
+	}
+	return names
+}
+
+func parseNamespaceSelector(s string) (labels.Selector, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	return labels.Parse(s)
+}
@@ -0,0 +1,126 @@
+package injector
+
+import (
+	"testing"
+)
+
+func TestNamespaceFilter(t *testing.T) {
+	testCases := []struct {
+		name           string
+		allowNames     string
+		allowSelector  string
+		denyNames      string
+		denySelector   string
+		namespace      string
+		labels         map[string]string
+		expectedResult bool
+	}{
+		{
+			name:           "no lists",
+			namespace:      "emojivoto",
+			expectedResult: true,
+		},
+		{
+			name:           "allowed by name",
+			allowNames:     "booksapp, emojivoto",
+			namespace:      "emojivoto",
+			expectedResult: true,
+		},
+		{
+			name:           "not in the allow-list",
+			allowNames:     "booksapp",
+			namespace:      "emojivoto",
+			expectedResult: false,
+		},
+		{
+			name:           "allowed by selector",
+			allowNames:     "booksapp",
+			allowSelector:  "team=apps",
+			namespace:      "emojivoto",
+			labels:         map[string]string{"team": "apps"},
+			expectedResult: true,
+		},
+		{
+			name:           "not matching the allow-list selector",
+			allowSelector:  "team=apps",
+			namespace:      "emojivoto",
+			labels:         map[string]string{"team": "infra"},
+			expectedResult: false,
+		},
+		{
+			name:           "denied by name",
+			denyNames:      "kube-system,emojivoto",
+			namespace:      "emojivoto",
+			expectedResult: false,
+		},
+		{
+			name:           "denied by selector",
+			denySelector:   "injection notin (allowed)",
+			namespace:      "emojivoto",
+			expectedResult: false,
+		},
+		{
+			name:           "not in the deny-list",
+			denyNames:      "kube-system",
+			denySelector:   "team=infra",
+			namespace:      "emojivoto",
+			labels:         map[string]string{"team": "apps"},
+			expectedResult: true,
+		},
+		{
+			name:           "deny-list name takes precedence over allow-list name",
+			allowNames:     "emojivoto",
+			denyNames:      "emojivoto",
+			namespace:      "emojivoto",
+			expectedResult: false,
+		},
+		{
+			name:           "deny-list selector takes precedence over allow-list name",
+			allowNames:     "emojivoto",
+			denySelector:   "team=infra",
+			namespace:      "emojivoto",
+			labels:         map[string]string{"team": "infra"},
+			expectedResult: false,
+		},
+		{
+			name:           "deny-list name takes precedence over allow-list selector",
+			allowSelector:  "team=apps",
+			denyNames:      "emojivoto",
+			namespace:      "emojivoto",
+			labels:         map[string]string{"team": "apps"},
+			expectedResult: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := NewNamespaceFilter(tc.allowNames, tc.allowSelector, tc.denyNames, tc.denySelector)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if result := filter.Includes(tc.namespace, tc.labels); result != tc.expectedResult {
+				t.Fatalf("Expected %t, got %t", tc.expectedResult, result)
+			}
+		})
+	}
+
+	t.Run("zero value", func(t *testing.T) {
+		if !(&NamespaceFilter{}).Includes("emojivoto", nil) {
+			t.Fatal("Expected the zero value to include every namespace")
+		}
+	})
+
+	t.Run("nil filter", func(t *testing.T) {
+		var filter *NamespaceFilter
+		if !filter.Includes("emojivoto", map[string]string{"team": "apps"}) {
+			t.Fatal("Expected a nil filter to include every namespace")
+		}
+	})
+
+	t.Run("invalid selector", func(t *testing.T) {
+		if _, err := NewNamespaceFilter("", "team in (apps", "", ""); err == nil {
+			t.Fatal("Expected an error for an invalid selector")
+		}
+	})
+}
@@ -13,6 +13,7 @@ import (
 	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
 	"github.com/linkerd/linkerd2/pkg/version"
 	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -23,12 +24,35 @@ import (
 const (
 	eventTypeSkipped  = "InjectionSkipped"
 	eventTypeInjected = "Injected"
+
+	injectSpan        = "proxyInjector.inject"
+	resolveConfigSpan = "proxyInjector.resolveConfig"
+	buildPatchSpan    = "proxyInjector.buildPatch"
+)
+
+// The ways the injector can report where the proxy configuration values of
+// the resources it injects come from.
+const (
+	ConfigProvenanceNone       = "none"
+	ConfigProvenanceLog        = "log"
+	ConfigProvenanceAnnotation = "annotation"
+)
+
+var (
+	// The paths of the config values and trust roots mounted into the
+	// injector. They're only changed by tests.
+	valuesConfigPath = pkgK8s.MountPathValuesConfig
+	trustRootsPath   = pkgK8s.MountPathTrustRootsPEM
 )
 
 // Inject returns the function that produces an AdmissionResponse containing
 // the patch, if any, to apply to the pod (proxy sidecar and eventually the
-// init container to set it up)
-func Inject(linkerdNamespace string) webhook.Handler {
+// init container to set it up). The source of each proxy configuration value
+// is reported according to configProvenance, and the use of the deprecated
+// annotations is surfaced as admission warnings. If requirePodOptIn is true,
+// only pods that have the inject annotation themselves are injected.
+// Resources in the namespaces excluded by namespaces are admitted unmutated.
+func Inject(linkerdNamespace string, configProvenance string, deprecatedAnnotations map[string]string, requirePodOptIn bool, namespaces *NamespaceFilter) webhook.Handler {
 	return func(
 		ctx context.Context,
 		api *k8s.MetadataAPI,
@@ -37,36 +61,36 @@ func Inject(linkerdNamespace string) webhook.Handler {
 	) (*admissionv1beta1.AdmissionResponse, error) {
 		log.Debugf("request object bytes: %s", request.Object.Raw)
 
-		// Build the resource config based off the request metadata and kind of
-		// object. This is later used to build the injection report and generated
-		// patch.
-		valuesConfig, err := config.Values(pkgK8s.MountPathValuesConfig)
-		if err != nil {
-			return nil, err
-		}
-
-		caPEM, err := os.ReadFile(pkgK8s.MountPathTrustRootsPEM)
-		if err != nil {
-			return nil, err
-		}
-		valuesConfig.IdentityTrustAnchorsPEM = string(caPEM)
+		ctx, span := trace.StartSpan(ctx, injectSpan)
+		defer span.End()
+		span.AddAttributes(
+			trace.StringAttribute("namespace", request.Namespace),
+			trace.StringAttribute("kind", request.Kind.Kind),
+		)
 
 		ns, err := api.Get(k8s.NS, request.Namespace)
 		if err != nil {
 			return nil, err
 		}
-		resourceConfig := inject.NewResourceConfig(valuesConfig, inject.OriginWebhook, linkerdNamespace).
-			WithOwnerRetriever(ownerRetriever(ctx, api, request.Namespace)).
-			WithNsAnnotations(ns.GetAnnotations()).
-			WithKind(request.Kind.Kind)
+		if !namespaces.Includes(ns.GetName(), ns.GetLabels()) {
+			log.Infof("skipped %s %s/%s: namespace excluded from injection", request.Kind.Kind, request.Namespace, request.Name)
+			return &admissionv1beta1.AdmissionResponse{
+				UID:     request.UID,
+				Allowed: true,
+			}, nil
+		}
 
-		// Build the injection report.
-		report, err := resourceConfig.ParseMetaAndYAML(request.Object.Raw)
+		resourceConfig, report, err := resolveConfig(ctx, api, request, ns, linkerdNamespace, requirePodOptIn)
 		if err != nil {
 			return nil, err
 		}
 		log.Infof("received %s", report.ResName())
 
+		warnings := deprecatedAnnotationWarnings(resourceConfig.GetWorkloadAnnotations(), resourceConfig.GetNsAnnotations(), deprecatedAnnotations)
+		for _, warning := range warnings {
+			log.Infof("%s: %s", report.ResName(), warning)
+		}
+
 		// If the resource has an owner, then it should be retrieved for recording
 		// events.
 		var parent *metav1.PartialObjectMetadata
@@ -100,6 +124,7 @@ func Inject(linkerdNamespace string) webhook.Handler {
 			// If namespace has annotations that do not exist on pod then copy them
 			// over to pod's template.
 			inject.AppendNamespaceAnnotations(resourceConfig.GetOverrideAnnotations(), resourceConfig.GetNsAnnotations(), resourceConfig.GetWorkloadAnnotations())
+			reportConfigProvenance(resourceConfig, report.ResName(), configProvenance)
 
 			// If the pod did not inherit the opaque ports annotation from the
 			// namespace, then add the default value from the config values. This
@@ -116,7 +141,10 @@ func Inject(linkerdNamespace string) webhook.Handler {
 				}
 			}
 
+			_, patchSpan := trace.StartSpan(ctx, buildPatchSpan)
+			patchSpan.AddAttributes(trace.StringAttribute("patch", "injection"))
 			patchJSON, err := resourceConfig.GetPodPatch(true)
+			endSpan(patchSpan, err)
 			if err != nil {
 				return nil, err
 			}
@@ -132,6 +160,7 @@ func Inject(linkerdNamespace string) webhook.Handler {
 				Allowed:   true,
 				PatchType: &patchType,
 				Patch:     patchJSON,
+				Warnings:  warnings,
 			}, nil
 		}
 
@@ -149,7 +178,10 @@ func Inject(linkerdNamespace string) webhook.Handler {
 
 		// Create a patch which adds the opaque ports annotation if the workload
 		// doesn't already have it set.
+		_, patchSpan := trace.StartSpan(ctx, buildPatchSpan)
+		patchSpan.AddAttributes(trace.StringAttribute("patch", "annotations"))
 		patchJSON, err := resourceConfig.CreateOpaquePortsPatch()
+		endSpan(patchSpan, err)
 		if err != nil {
 			return nil, err
 		}
@@ -166,6 +198,7 @@ func Inject(linkerdNamespace string) webhook.Handler {
 				Allowed:   true,
 				PatchType: &patchType,
 				Patch:     patchJSON,
+				Warnings:  warnings,
 			}, nil
 		}
 
@@ -176,21 +209,91 @@ func Inject(linkerdNamespace string) webhook.Handler {
 			log.Infof("skipped %s: %s", report.ResName(), readableMsg)
 			proxyInjectionAdmissionResponses.With(admissionResponseLabels(ownerKind, request.Namespace, "true", strings.Join(reasons, ","), report.InjectAnnotationAt, configLabels)).Inc()
 			return &admissionv1beta1.AdmissionResponse{
-				UID:     request.UID,
-				Allowed: true,
+				UID:      request.UID,
+				Allowed:  true,
+				Warnings: warnings,
 			}, nil
 		}
 
 		return &admissionv1beta1.AdmissionResponse{
-			UID:     request.UID,
-			Allowed: true,
+			UID:      request.UID,
+			Allowed:  true,
+			Warnings: warnings,
 		}, nil
 	}
 }
 
+// resolveConfig builds the resource config based off the request metadata and
+// kind of object, along with its injection report. They are later used to
+// generate the patch. ns is the request's namespace, already fetched by the
+// caller.
+func resolveConfig(
+	ctx context.Context,
+	api *k8s.MetadataAPI,
+	request *admissionv1beta1.AdmissionRequest,
+	ns *metav1.PartialObjectMetadata,
+	linkerdNamespace string,
+	requirePodOptIn bool,
+) (resourceConfig *inject.ResourceConfig, report *inject.Report, err error) {
+	ctx, span := trace.StartSpan(ctx, resolveConfigSpan)
+	defer func() { endSpan(span, err) }()
+
+	valuesConfig, err := config.Values(valuesConfigPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	caPEM, err := os.ReadFile(trustRootsPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	valuesConfig.IdentityTrustAnchorsPEM = string(caPEM)
+
+	resourceConfig = inject.NewResourceConfig(valuesConfig, inject.OriginWebhook, linkerdNamespace).
+		WithOwnerRetriever(ownerRetriever(ctx, api, request.Namespace)).
+		WithNsAnnotations(ns.GetAnnotations()).
+		WithRequirePodOptIn(requirePodOptIn).
+		WithKind(request.Kind.Kind)
+
+	// Build the injection report.
+	report, err = resourceConfig.ParseMetaAndYAML(request.Object.Raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resourceConfig, report, nil
+}
+
+// endSpan ends span, marking it as failed if err isn't nil.
+func endSpan(span *trace.Span, err error) {
+	if err != nil {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+	}
+	span.End()
+}
+
 func ownerRetriever(ctx context.Context, api *k8s.MetadataAPI, ns string) inject.OwnerRetrieverFunc {
 	return func(p *v1.Pod) (string, string, error) {
 		p.SetNamespace(ns)
 		return api.GetOwnerKindAndName(ctx, p, true)
 	}
 }
+
+// reportConfigProvenance reports, according to mode, which of the proxy
+// configuration values of the resource come from the workload and which from
+// its namespace; the others use the default values.
+func reportConfigProvenance(conf *inject.ResourceConfig, resName string, mode string) {
+	if mode != ConfigProvenanceLog && mode != ConfigProvenanceAnnotation {
+		return
+	}
+
+	provenance := inject.FormatConfigProvenance(inject.GetConfigProvenance(conf.GetWorkloadAnnotations(), conf.GetNsAnnotations()))
+	if mode == ConfigProvenanceAnnotation {
+		conf.AppendPodAnnotation(pkgK8s.ConfigProvenanceAnnotation, provenance)
+		return
+	}
+	if provenance == "" {
+		log.Infof("proxy config of %s: all values are defaults", resName)
+		return
+	}
+	log.Infof("proxy config of %s: %s; other values are defaults", resName, provenance)
+}
@@ -1,19 +1,26 @@
 package injector
 
 import (
+	"context"
 	"encoding/json"
+	"os"
 	"path/filepath"
+	"sync"
 	"testing"
 
 	"github.com/go-test/deep"
+	"github.com/linkerd/linkerd2/controller/k8s"
 	"github.com/linkerd/linkerd2/controller/proxy-injector/fake"
 	"github.com/linkerd/linkerd2/pkg/charts/linkerd2"
 	"github.com/linkerd/linkerd2/pkg/inject"
 	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
+	"go.opencensus.io/trace"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/yaml"
 )
 
 type unmarshalledPatch []map[string]interface{}
@@ -347,6 +354,149 @@ func TestGetAnnotationPatch(t *testing.T) {
 	})
 }
 
+func TestReportConfigProvenance(t *testing.T) {
+	factory := fake.NewFactory(filepath.Join("fake", "data"))
+
+	testCases := []struct {
+		name     string
+		mode     string
+		expected string
+	}{
+		{
+			name: "not reported",
+			mode: ConfigProvenanceNone,
+		},
+		{
+			name: "reported in the logs",
+			mode: ConfigProvenanceLog,
+		},
+		{
+			name:     "reported as an annotation",
+			mode:     ConfigProvenanceAnnotation,
+			expected: "config.alpha.linkerd.io/proxy-wait-before-exit-seconds=namespace,config.linkerd.io/proxy-log-level=workload,config.linkerd.io/skip-outbound-ports=namespace,linkerd.io/inject=workload",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			req := getFakePodReq(fileContents(factory, t, "pod-inject-enabled-log-level.yaml"))
+			conf := confNsWithConfigAnnotations().
+				WithKind(req.Kind.Kind).
+				WithOwnerRetriever(ownerRetrieverFake)
+			if _, err := conf.ParseMetaAndYAML(req.Object.Raw); err != nil {
+				t.Fatal(err)
+			}
+
+			reportConfigProvenance(conf, "pod/nginx", tc.mode)
+
+			provenance, ok := conf.GetOverrideAnnotations()[pkgK8s.ConfigProvenanceAnnotation]
+			if ok != (tc.mode == ConfigProvenanceAnnotation) {
+				t.Fatalf("Unexpected %s annotation: %q", pkgK8s.ConfigProvenanceAnnotation, provenance)
+			}
+			if provenance != tc.expected {
+				t.Fatalf("Expected %q, got %q", tc.expected, provenance)
+			}
+		})
+	}
+}
+
+type spanRecorder struct {
+	sync.Mutex
+	spans map[string]*trace.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(span *trace.SpanData) {
+	r.Lock()
+	defer r.Unlock()
+	r.spans[span.Name] = span
+}
+
+func (r *spanRecorder) get(name string) *trace.SpanData {
+	r.Lock()
+	defer r.Unlock()
+	return r.spans[name]
+}
+
+func TestInjectTracing(t *testing.T) {
+	dir := t.TempDir()
+	valuesYAML, err := yaml.Marshal(values)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	valuesConfigPath = filepath.Join(dir, "values")
+	trustRootsPath = filepath.Join(dir, "ca-bundle.crt")
+	defer func() {
+		valuesConfigPath = pkgK8s.MountPathValuesConfig
+		trustRootsPath = pkgK8s.MountPathTrustRootsPEM
+	}()
+	if err := os.WriteFile(valuesConfigPath, valuesYAML, 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := os.WriteFile(trustRootsPath, []byte("IdentityTrustAnchorsPEM"), 0600); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	api, err := k8s.NewFakeMetadataAPI([]string{`
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: kube-public
+  annotations:
+    linkerd.io/inject: enabled
+`})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	api.Sync(nil)
+
+	recorder := &spanRecorder{spans: make(map[string]*trace.SpanData)}
+	trace.RegisterExporter(recorder)
+	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
+	defer func() {
+		trace.UnregisterExporter(recorder)
+		trace.ApplyConfig(trace.Config{DefaultSampler: trace.NeverSample()})
+	}()
+
+	factory := fake.NewFactory(filepath.Join("fake", "data"))
+	req := getFakePodReq(fileContents(factory, t, "pod-inject-enabled.yaml"))
+	req.Namespace = "kube-public"
+
+	ctx, parent := trace.StartSpan(context.Background(), "webhook.admissionReview")
+	response, err := Inject("linkerd", ConfigProvenanceNone, nil, false, &NamespaceFilter{})(ctx, api, req, record.NewFakeRecorder(10))
+	parent.End()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(response.Patch) == 0 {
+		t.Fatal("Expected the pod to be injected")
+	}
+
+	injectSpanData := recorder.get(injectSpan)
+	if injectSpanData == nil {
+		t.Fatalf("Expected a %s span", injectSpan)
+	}
+	if injectSpanData.ParentSpanID != parent.SpanContext().SpanID {
+		t.Fatalf("Expected the %s span to be a child of the admission review span", injectSpan)
+	}
+
+	for _, name := range []string{resolveConfigSpan, buildPatchSpan} {
+		span := recorder.get(name)
+		if span == nil {
+			t.Fatalf("Expected a %s span", name)
+		}
+		if span.ParentSpanID != injectSpanData.SpanID {
+			t.Fatalf("Expected the %s span to be a child of the %s span", name, injectSpan)
+		}
+		if span.Status.Code != trace.StatusCodeOK {
+			t.Fatalf("Expected the %s span to succeed, got %v", name, span.Status)
+		}
+	}
+	if patch := recorder.get(buildPatchSpan).Attributes["patch"]; patch != "injection" {
+		t.Fatalf("Expected an injection patch to be built, got %v", patch)
+	}
+}
+
 func getFakePodReq(b []byte) *admissionv1beta1.AdmissionRequest {
 	return &admissionv1beta1.AdmissionRequest{
 		Kind:      metav1.GroupVersionKind{Kind: "Pod"},
@@ -5,18 +5,38 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"sigs.k8s.io/yaml"
 
 	"github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned"
 	"github.com/linkerd/linkerd2/pkg/k8s"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
 )
 
+const (
+	kindServer              = "server"
+	kindServerAuthorization = "serverauthorization"
+
+	// rewatchDelay is how long to wait before re-establishing a watch that
+	// was closed or failed to start.
+	rewatchDelay = time.Second
+)
+
+type watchFunc func(ctx context.Context) (watch.Interface, error)
+
 func main() {
 	namespace := flag.String("namespace", "", "namespace of resource to get")
+	kind := flag.String("kind", "", fmt.Sprintf("only watch resources of this kind (%q or %q); all kinds are watched if empty", kindServer, kindServerAuthorization))
+	duration := flag.Duration("duration", 0, "stop watching and exit after this long; if 0, watch until interrupted")
 	flag.Parse()
 
+	if *kind != "" && *kind != kindServer && *kind != kindServerAuthorization {
+		fmt.Fprintf(os.Stderr, "invalid --kind %q: must be %q or %q\n", *kind, kindServer, kindServerAuthorization)
+		os.Exit(1)
+	}
+
 	config, err := k8s.GetConfig("", "")
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error configuring Kubernetes API client: %v", err)
@@ -24,28 +44,76 @@ func main() {
 	}
 	client := versioned.NewForConfigOrDie(config)
 
-	srvWatch, err := client.ServerV1beta3().Servers(*namespace).Watch(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to watch Servers: %s", err)
-		os.Exit(1)
+	ctx := context.Background()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
 	}
-	sazWatch, err := client.ServerauthorizationV1beta1().ServerAuthorizations(*namespace).Watch(context.Background(), metav1.ListOptions{})
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "failed to watch ServerAuthorizations: %s", err)
-		os.Exit(1)
+
+	updates := make(chan watch.Event)
+	if *kind == "" || *kind == kindServer {
+		go watchEvents(ctx, "Servers", func(ctx context.Context) (watch.Interface, error) {
+			return client.ServerV1beta3().Servers(*namespace).Watch(ctx, metav1.ListOptions{})
+		}, rewatchDelay, updates)
+	}
+	if *kind == "" || *kind == kindServerAuthorization {
+		go watchEvents(ctx, "ServerAuthorizations", func(ctx context.Context) (watch.Interface, error) {
+			return client.ServerauthorizationV1beta1().ServerAuthorizations(*namespace).Watch(ctx, metav1.ListOptions{})
+		}, rewatchDelay, updates)
 	}
-	srvUpdates := srvWatch.ResultChan()
-	sazUpdates := sazWatch.ResultChan()
 
 	for {
-		var update interface{}
 		select {
-		case u := <-srvUpdates:
-			update = u
-		case u := <-sazUpdates:
-			update = u
+		case <-ctx.Done():
+			return
+		case update := <-updates:
+			b, _ := yaml.Marshal(update)
+			fmt.Println(string(b))
+		}
+	}
+}
+
+// watchEvents sends the events of the watch opened by open to updates, until
+// ctx is done. The watch is re-established when it's closed by the server, or
+// if it fails to start.
+func watchEvents(ctx context.Context, name string, open watchFunc, delay time.Duration, updates chan<- watch.Event) {
+	for {
+		w, err := open(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to watch %s: %s\n", name, err)
+		} else {
+			forwardEvents(ctx, w, updates)
+			w.Stop()
+			if ctx.Err() == nil {
+				fmt.Fprintf(os.Stderr, "watch of %s closed; re-establishing it\n", name)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// forwardEvents sends the events of w to updates, until ctx is done or the
+// watch is closed.
+func forwardEvents(ctx context.Context, w watch.Interface, updates chan<- watch.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+			select {
+			case updates <- event:
+			case <-ctx.Done():
+				return
+			}
 		}
-		b, _ := yaml.Marshal(update)
-		fmt.Println(string(b))
 	}
 }
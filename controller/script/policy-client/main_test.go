@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestWatchEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The first attempt fails, the second watch is closed by the server after
+	// an event and the third one stays open.
+	watches := make(chan *watch.FakeWatcher, 3)
+	attempts := 0
+	open := func(context.Context) (watch.Interface, error) {
+		attempts++
+		if attempts == 1 {
+			return nil, errors.New("connection refused")
+		}
+		w := watch.NewFake()
+		watches <- w
+		return w, nil
+	}
+
+	updates := make(chan watch.Event)
+	done := make(chan struct{})
+	go func() {
+		watchEvents(ctx, "Servers", open, time.Millisecond, updates)
+		close(done)
+	}()
+
+	first := <-watches
+	first.Add(pod("first"))
+	expectEvent(t, updates, "first")
+	first.Stop()
+
+	second := <-watches
+	second.Add(pod("second"))
+	expectEvent(t, updates, "second")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the watch to stop")
+	}
+	if attempts != 3 {
+		t.Fatalf("Expected 3 attempts to watch, got %d", attempts)
+	}
+}
+
+func pod(name string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}
+}
+
+func expectEvent(t *testing.T, updates <-chan watch.Event, name string) {
+	t.Helper()
+	select {
+	case event := <-updates:
+		if got := event.Object.(*corev1.Pod).Name; got != name {
+			t.Fatalf("Expected an event for %s, got %s", name, got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timed out waiting for an event for %s", name)
+	}
+}
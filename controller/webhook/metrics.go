@@ -0,0 +1,17 @@
+package webhook
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	admissionReviewSucceeded = "success"
+	admissionReviewFailed    = "failure"
+)
+
+var admissionReviewDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "webhook_admission_review_duration_seconds",
+	Help:    "Time in seconds spent decoding an admission review request and running the webhook handler on it, e.g. resolving the configuration and building the patch.",
+	Buckets: []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+}, []string{"result"})
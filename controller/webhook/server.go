@@ -15,6 +15,7 @@ import (
 	pkgTls "github.com/linkerd/linkerd2/pkg/tls"
 	"github.com/linkerd/linkerd2/pkg/util"
 	log "github.com/sirupsen/logrus"
+	"go.opencensus.io/trace"
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -158,7 +159,18 @@ func (s *Server) serve(res http.ResponseWriter, req *http.Request) {
 }
 
 func (s *Server) processReq(ctx context.Context, data []byte) (*admissionv1beta1.AdmissionReview, error) {
+	ctx, span := trace.StartSpan(ctx, "webhook.admissionReview")
+	defer span.End()
+
+	start := time.Now()
+	result := admissionReviewFailed
+	defer func() {
+		admissionReviewDuration.WithLabelValues(result).Observe(time.Since(start).Seconds())
+	}()
+
+	_, decodeSpan := trace.StartSpan(ctx, "webhook.decode")
 	admissionReview, err := decode(data)
+	decodeSpan.End()
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode admission review request: %w", err)
 	}
@@ -166,6 +178,7 @@ func (s *Server) processReq(ctx context.Context, data []byte) (*admissionv1beta1
 		return nil, fmt.Errorf("invalid admission review request")
 	}
 	log.Infof("received admission review request %q", admissionReview.Request.UID)
+	span.AddAttributes(trace.StringAttribute("uid", string(admissionReview.Request.UID)))
 	log.Debugf("admission request: %+v", admissionReview.Request)
 
 	admissionResponse, err := s.handler(ctx, s.metadataAPI, admissionReview.Request, s.recorder)
@@ -181,6 +194,7 @@ func (s *Server) processReq(ctx context.Context, data []byte) (*admissionv1beta1
 		return admissionReview, nil
 	}
 	admissionReview.Response = admissionResponse
+	result = admissionReviewSucceeded
 
 	return admissionReview, nil
 }
@@ -12,6 +12,10 @@ import (
 	"time"
 
 	"github.com/linkerd/linkerd2/controller/k8s"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/client-go/tools/record"
 )
 
 var mockHTTPServer = &http.Server{
@@ -62,3 +66,55 @@ func TestShutdown(t *testing.T) {
 		t.Fatalf("Unexpected error: %s", err)
 	}
 }
+
+func admissionReviewCount(t *testing.T, result string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := admissionReviewDuration.WithLabelValues(result).(prometheus.Metric).Write(&m); err != nil {
+		t.Fatalf("Failed to read metric: %s", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestProcessReqDuration(t *testing.T) {
+	var handlerErr error
+	handler := func(
+		_ context.Context,
+		_ *k8s.MetadataAPI,
+		request *admissionv1beta1.AdmissionRequest,
+		_ record.EventRecorder,
+	) (*admissionv1beta1.AdmissionResponse, error) {
+		if handlerErr != nil {
+			return nil, handlerErr
+		}
+		return &admissionv1beta1.AdmissionResponse{UID: request.UID, Allowed: true}, nil
+	}
+	testServer := getConfiguredServer(mockHTTPServer, nil, handler, nil)
+	review := []byte(`{"apiVersion":"admission.k8s.io/v1beta1","kind":"AdmissionReview","request":{"uid":"c5b8bd8e-1d4b-4d8f-9f49-e3c2d5b8e5a1"}}`)
+
+	succeeded := admissionReviewCount(t, admissionReviewSucceeded)
+	failed := admissionReviewCount(t, admissionReviewFailed)
+
+	for i := 0; i < 2; i++ {
+		if _, err := testServer.processReq(context.Background(), review); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	if count := admissionReviewCount(t, admissionReviewSucceeded); count != succeeded+2 {
+		t.Fatalf("Expected %d successful observations, got %d", succeeded+2, count)
+	}
+
+	handlerErr = errors.New("handler failed")
+	if _, err := testServer.processReq(context.Background(), review); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if _, err := testServer.processReq(context.Background(), []byte("{")); err == nil {
+		t.Fatal("Expected an error decoding an invalid request")
+	}
+	if count := admissionReviewCount(t, admissionReviewFailed); count != failed+2 {
+		t.Fatalf("Expected %d failed observations, got %d", failed+2, count)
+	}
+	if count := admissionReviewCount(t, admissionReviewSucceeded); count != succeeded+2 {
+		t.Fatalf("Expected %d successful observations, got %d", succeeded+2, count)
+	}
+}
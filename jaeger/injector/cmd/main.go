@@ -10,6 +10,7 @@ import (
 	"github.com/linkerd/linkerd2/controller/webhook"
 	"github.com/linkerd/linkerd2/jaeger/injector/mutator"
 	"github.com/linkerd/linkerd2/pkg/flags"
+	log "github.com/sirupsen/logrus"
 )
 
 func main() {
@@ -29,13 +30,36 @@ func main() {
 	clusterDomain := cmd.String("cluster-domain", "cluster.local", "kubernetes cluster domain")
 	linkerdNamespace := cmd.String("linkerd-namespace", "linkerd", "namespace in which Linkerd control-plane is installed")
 	enablePprof := cmd.Bool("enable-pprof", false, "Enable pprof endpoints on the admin server")
+	requireOptIn := cmd.Bool("require-opt-in", false,
+		"only inject pods that have, or whose namespace has, the jaeger.linkerd.io/inject: enabled annotation")
+	collectorConfigPath := cmd.String("collector-config-path", "",
+		"directory where a ConfigMap overriding the collector settings is mounted; the settings are reloaded whenever it changes")
+	collectorEnvNames := cmd.String("collector-env-names", "",
+		"comma-separated list of NAME=OVERRIDE pairs renaming the environment variables injected into the proxy")
 
 	flags.ConfigureAndParse(cmd, os.Args[1:])
 
+	envNames, err := mutator.ParseEnvNames(*collectorEnvNames)
+	if err != nil {
+		log.Fatalf("invalid -collector-env-names: %s", err)
+	}
+
+	collector := mutator.NewCollectorConfig(mutator.CollectorSettings{
+		SvcAddr:       *collectorSvcAddr,
+		TraceProtocol: *collectorTraceProtocol,
+		TraceSvcName:  *collectorTraceSvcName,
+		SvcAccount:    *collectorSvcAccount,
+	})
+	if *collectorConfigPath != "" {
+		if err := collector.Watch(context.Background(), *collectorConfigPath); err != nil {
+			log.Fatalf("failed to load the collector configuration: %s", err)
+		}
+	}
+
 	webhook.Launch(
 		context.Background(),
 		[]k8s.APIResource{k8s.NS},
-		mutator.Mutate(*collectorSvcAddr, *collectorTraceProtocol, *collectorTraceSvcName, *collectorSvcAccount, *clusterDomain, *linkerdNamespace),
+		mutator.Mutate(collector, *clusterDomain, *linkerdNamespace, *requireOptIn, envNames),
 		"linkerd-jaeger-injector",
 		*metricsAddr,
 		*addr,
@@ -0,0 +1,116 @@
+package mutator
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	pkgTls "github.com/linkerd/linkerd2/pkg/tls"
+	log "github.com/sirupsen/logrus"
+)
+
+// The keys of the ConfigMap the collector settings can be sourced from. They
+// match the names of the chart values.
+const (
+	collectorSvcAddrKey       = "collectorSvcAddr"
+	collectorTraceProtocolKey = "collectorTraceProtocol"
+	collectorTraceSvcNameKey  = "collectorTraceSvcName"
+	collectorSvcAccountKey    = "collectorSvcAccount"
+)
+
+// CollectorSettings are the collector values injected into the proxies,
+// unless overridden by the workload's annotations.
+type CollectorSettings struct {
+	SvcAddr       string
+	TraceProtocol string
+	TraceSvcName  string
+	SvcAccount    string
+}
+
+// CollectorConfig holds the current CollectorSettings, which can be reloaded
+// from a mounted ConfigMap while the injector is running.
+type CollectorConfig struct {
+	sync.RWMutex
+	defaults CollectorSettings
+	settings CollectorSettings
+}
+
+// NewCollectorConfig returns a CollectorConfig holding defaults, which are
+// also used for the keys missing from the ConfigMap.
+func NewCollectorConfig(defaults CollectorSettings) *CollectorConfig {
+	return &CollectorConfig{defaults: defaults, settings: defaults}
+}
+
+// Get returns the current settings.
+func (c *CollectorConfig) Get() CollectorSettings {
+	c.RLock()
+	defer c.RUnlock()
+	return c.settings
+}
+
+// Load replaces the current settings with the ones read from the files in dir,
+// where a ConfigMap is mounted. The settings are left untouched on error.
+func (c *CollectorConfig) Load(dir string) error {
+	settings := c.defaults
+	for key, value := range map[string]*string{
+		collectorSvcAddrKey:       &settings.SvcAddr,
+		collectorTraceProtocolKey: &settings.TraceProtocol,
+		collectorTraceSvcNameKey:  &settings.TraceSvcName,
+		collectorSvcAccountKey:    &settings.SvcAccount,
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, key))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		*value = strings.TrimSpace(string(data))
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	c.settings = settings
+	return nil
+}
+
+// Watch loads the settings from dir and reloads them every time the ConfigMap
+// mounted there is updated, until ctx is done.
+func (c *CollectorConfig) Watch(ctx context.Context, dir string) error {
+	if err := c.Load(dir); err != nil {
+		return err
+	}
+
+	updateEvent := make(chan struct{})
+	// buffered so that the watcher can report ctx being done after the loop
+	// below has returned
+	errEvent := make(chan error, 1)
+	watcher := pkgTls.NewFsCredsWatcher(dir, updateEvent, errEvent)
+	go func() {
+		if err := watcher.StartWatching(ctx); err != nil {
+			log.Errorf("Failed to watch the collector configuration: %s", err)
+		}
+	}()
+
+	go func() {
+		for {
+			select {
+			case <-updateEvent:
+				if err := c.Load(dir); err != nil {
+					log.Warnf("Skipping update as the collector configuration could not be read: %s", err)
+				} else {
+					log.Infof("Updated the collector configuration: %+v", c.Get())
+				}
+			case err := <-errEvent:
+				log.Warnf("Received error from fs watcher: %s", err)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
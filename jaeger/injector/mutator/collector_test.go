@@ -0,0 +1,149 @@
+package mutator
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/linkerd/linkerd2/controller/k8s"
+	"github.com/linkerd/linkerd2/controller/webhook"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestCollectorConfigLoad(t *testing.T) {
+	defaults := CollectorSettings{
+		SvcAddr:       "collector.linkerd-jaeger:55678",
+		TraceProtocol: "opencensus",
+		TraceSvcName:  "linkerd-proxy",
+		SvcAccount:    "collector",
+	}
+	collector := NewCollectorConfig(defaults)
+	dir := t.TempDir()
+
+	writeKey(t, dir, collectorSvcAddrKey, "otel-collector.tracing:4317\n")
+	writeKey(t, dir, collectorTraceProtocolKey, "opentelemetry")
+	if err := collector.Load(dir); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := CollectorSettings{
+		SvcAddr:       "otel-collector.tracing:4317",
+		TraceProtocol: "opentelemetry",
+		TraceSvcName:  "linkerd-proxy",
+		SvcAccount:    "collector",
+	}
+	if settings := collector.Get(); settings != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, settings)
+	}
+
+	// Keys removed from the ConfigMap fall back to the defaults
+	if err := os.Remove(filepath.Join(dir, collectorTraceProtocolKey)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := collector.Load(dir); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected.TraceProtocol = defaults.TraceProtocol
+	if settings := collector.Get(); settings != expected {
+		t.Fatalf("Expected %+v, got %+v", expected, settings)
+	}
+}
+
+func TestCollectorConfigWatch(t *testing.T) {
+	api, err := k8s.NewFakeMetadataAPI([]string{`
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: emojivoto
+`})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	api.Sync(nil)
+
+	dir := t.TempDir()
+	writeKey(t, dir, collectorSvcAddrKey, "collector.linkerd-jaeger:55678")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	collector := NewCollectorConfig(CollectorSettings{SvcAddr: "collector.default:55678"})
+	if err := collector.Watch(ctx, dir); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	mutate := Mutate(collector, "cluster.local", "linkerd", false, nil)
+
+	if addr := injectedCollectorAddr(t, mutate, api); addr != "collector.linkerd-jaeger:55678" {
+		t.Fatalf("Expected the ConfigMap's collector address to be injected, got %q", addr)
+	}
+
+	// The kubelet updates a mounted ConfigMap by swapping its ..data symlink;
+	// creating it is what the watcher reacts to. As the watcher is started
+	// asynchronously, keep updating it until the change is picked up.
+	writeKey(t, dir, collectorSvcAddrKey, "otel-collector.tracing:55678")
+	dataDir := filepath.Join(dir, "..data")
+	deadline := time.Now().Add(10 * time.Second)
+	for collector.Get().SvcAddr != "otel-collector.tracing:55678" {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for the collector configuration to be reloaded")
+		}
+		if err := os.Mkdir(dataDir, 0o700); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		if err := os.Remove(dataDir); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+
+	if addr := injectedCollectorAddr(t, mutate, api); addr != "otel-collector.tracing:55678" {
+		t.Fatalf("Expected the updated collector address to be injected, got %q", addr)
+	}
+}
+
+func writeKey(t *testing.T, dir, key, value string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, key), []byte(value), 0o600); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+}
+
+func injectedCollectorAddr(t *testing.T, mutate webhook.Handler, api *k8s.MetadataAPI) string {
+	t.Helper()
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "emojivoto"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "linkerd-proxy"}},
+		},
+	}
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	response, err := mutate(context.Background(), api, &admissionv1beta1.AdmissionRequest{
+		Namespace: "emojivoto",
+		Object:    runtime.RawExtension{Raw: raw},
+	}, record.NewFakeRecorder(10))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var patch []struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(response.Patch, &patch); err != nil {
+		t.Fatalf("Unexpected error unmarshalling patch %s: %s", response.Patch, err)
+	}
+	for _, op := range patch {
+		var envVar corev1.EnvVar
+		if err := json.Unmarshal(op.Value, &envVar); err == nil && envVar.Name == envCollectorSvcAddr {
+			return envVar.Value
+		}
+	}
+	return ""
+}
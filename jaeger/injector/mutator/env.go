@@ -0,0 +1,62 @@
+package mutator
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// The environment variables injected into the proxy container, whose names
+// can be overridden.
+const (
+	envTraceAttributesPath = "LINKERD2_PROXY_TRACE_ATTRIBUTES_PATH"
+	envCollectorSvcAddr    = "LINKERD2_PROXY_TRACE_COLLECTOR_SVC_ADDR"
+	envTraceProtocol       = "LINKERD2_PROXY_TRACE_PROTOCOL"
+	envTraceSvcName        = "LINKERD2_PROXY_TRACE_SERVICE_NAME"
+	envCollectorSvcName    = "LINKERD2_PROXY_TRACE_COLLECTOR_SVC_NAME"
+	envExtraAttributes     = "LINKERD2_PROXY_TRACE_EXTRA_ATTRIBUTES"
+)
+
+var injectedEnvNames = map[string]struct{}{
+	envTraceAttributesPath: {},
+	envCollectorSvcAddr:    {},
+	envTraceProtocol:       {},
+	envTraceSvcName:        {},
+	envCollectorSvcName:    {},
+	envExtraAttributes:     {},
+}
+
+// ParseEnvNames parses a comma-separated list of NAME=OVERRIDE pairs, each
+// replacing the name of one of the injected environment variables.
+func ParseEnvNames(s string) (map[string]string, error) {
+	names := map[string]string{}
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, override, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected NAME=OVERRIDE, got %q", pair)
+		}
+		name, override = strings.TrimSpace(name), strings.TrimSpace(override)
+		if _, ok := injectedEnvNames[name]; !ok {
+			return nil, fmt.Errorf("%s is not an injected environment variable", name)
+		}
+		if errs := validation.IsEnvVarName(override); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid environment variable name %q: %s", override, strings.Join(errs, ", "))
+		}
+		names[name] = override
+	}
+	return names, nil
+}
+
+// EnvName returns the name under which the environment variable name is
+// injected.
+func (p Params) EnvName(name string) string {
+	if override, ok := p.EnvNames[name]; ok {
+		return override
+	}
+	return name
+}
@@ -10,7 +10,7 @@ const tpl = `[
     "op": "add",
     "path": "/spec/{{.ProxyPath}}/env/-",
     "value": {
-      "name": "LINKERD2_PROXY_TRACE_ATTRIBUTES_PATH",
+      "name": "{{.EnvName "LINKERD2_PROXY_TRACE_ATTRIBUTES_PATH"}}",
       "value": "/var/run/linkerd/podinfo/labels"
     }
   },
@@ -18,7 +18,7 @@ const tpl = `[
     "op": "add",
     "path": "/spec/{{.ProxyPath}}/env/-",
     "value": {
-      "name": "LINKERD2_PROXY_TRACE_COLLECTOR_SVC_ADDR",
+      "name": "{{.EnvName "LINKERD2_PROXY_TRACE_COLLECTOR_SVC_ADDR"}}",
       "value": "{{.CollectorSvcAddr}}"
     }
   },
@@ -26,7 +26,7 @@ const tpl = `[
     "op": "add",
     "path": "/spec/{{.ProxyPath}}/env/-",
     "value": {
-      "name": "LINKERD2_PROXY_TRACE_PROTOCOL",
+      "name": "{{.EnvName "LINKERD2_PROXY_TRACE_PROTOCOL"}}",
       "value": "{{.CollectorTraceProtocol}}"
     }
   },
@@ -34,7 +34,7 @@ const tpl = `[
     "op": "add",
     "path": "/spec/{{.ProxyPath}}/env/-",
     "value": {
-      "name": "LINKERD2_PROXY_TRACE_SERVICE_NAME",
+      "name": "{{.EnvName "LINKERD2_PROXY_TRACE_SERVICE_NAME"}}",
       "value": "{{.CollectorTraceSvcName}}"
     }
   },
@@ -42,7 +42,7 @@ const tpl = `[
     "op": "add",
     "path": "/spec/{{.ProxyPath}}/env/-",
     "value": {
-      "name": "LINKERD2_PROXY_TRACE_COLLECTOR_SVC_NAME",
+      "name": "{{.EnvName "LINKERD2_PROXY_TRACE_COLLECTOR_SVC_NAME"}}",
       "value": "{{.CollectorSvcAccount}}.serviceaccount.identity.{{.LinkerdNamespace}}.{{.ClusterDomain}}"
     }
   },
@@ -50,7 +50,7 @@ const tpl = `[
     "op": "add",
     "path": "/spec/{{.ProxyPath}}/env/-",
     "value": {
-      "name": "LINKERD2_PROXY_TRACE_EXTRA_ATTRIBUTES",
+      "name": "{{.EnvName "LINKERD2_PROXY_TRACE_EXTRA_ATTRIBUTES"}}",
       "value": "k8s.pod.uid=$(_pod_uid)\nk8s.container.name=$(_pod_containerName)"
     }
   },
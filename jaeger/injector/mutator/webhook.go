@@ -25,6 +25,8 @@ const (
 	collectorTraceSvcNameAnnotation  = l5dLabels.ProxyConfigAnnotationsPrefix + "/trace-collector-name"
 	collectorSvcAccountAnnotation    = l5dLabels.ProxyConfigAnnotationsPrefixAlpha +
 		"/trace-collector-service-account"
+	collectorEnvNamesAnnotation = l5dLabels.ProxyConfigAnnotationsPrefixAlpha +
+		"/trace-collector-env-names"
 )
 
 // Params holds the values used in the patch template
@@ -36,11 +38,16 @@ type Params struct {
 	CollectorSvcAccount    string
 	ClusterDomain          string
 	LinkerdNamespace       string
+	// EnvNames overrides the names of the injected environment variables
+	EnvNames map[string]string
 }
 
 // Mutate returns an AdmissionResponse containing the patch, if any, to apply
-// to the proxy
-func Mutate(collectorSvcAddr, collectorTraceProtocol, collectorTraceSvcName, collectorSvcAccount, clusterDomain, linkerdNamespace string) webhook.Handler {
+// to the proxy, using the collector settings current at the time of the
+// request. If requireOptIn is true, only pods that have, or whose namespace
+// has, the inject annotation set to enabled are patched. envNames overrides
+// the names of the injected environment variables.
+func Mutate(collector *CollectorConfig, clusterDomain, linkerdNamespace string, requireOptIn bool, envNames map[string]string) webhook.Handler {
 	return func(
 		_ context.Context,
 		api *k8s.MetadataAPI,
@@ -54,7 +61,8 @@ func Mutate(collectorSvcAddr, collectorTraceProtocol, collectorTraceSvcName, col
 			Allowed: true,
 		}
 
-		if collectorSvcAddr == "" {
+		settings := collector.Get()
+		if settings.SvcAddr == "" {
 			return admissionResponse, nil
 		}
 
@@ -64,12 +72,13 @@ func Mutate(collectorSvcAddr, collectorTraceProtocol, collectorTraceSvcName, col
 		}
 		params := Params{
 			ProxyPath:              webhook.GetProxyContainerPath(pod.Spec),
-			CollectorSvcAddr:       collectorSvcAddr,
-			CollectorTraceProtocol: collectorTraceProtocol,
-			CollectorTraceSvcName:  collectorTraceSvcName,
-			CollectorSvcAccount:    collectorSvcAccount,
+			CollectorSvcAddr:       settings.SvcAddr,
+			CollectorTraceProtocol: settings.TraceProtocol,
+			CollectorTraceSvcName:  settings.TraceSvcName,
+			CollectorSvcAccount:    settings.SvcAccount,
 			ClusterDomain:          clusterDomain,
 			LinkerdNamespace:       linkerdNamespace,
+			EnvNames:               envNames,
 		}
 		if params.ProxyPath == "" || labels.IsTracingEnabled(pod) {
 			return admissionResponse, nil
@@ -79,6 +88,10 @@ func Mutate(collectorSvcAddr, collectorTraceProtocol, collectorTraceSvcName, col
 		if err != nil {
 			return nil, err
 		}
+		if !injectionEnabled(namespace, pod, requireOptIn) {
+			log.Debugf("skipping pod %s/%s: tracing injection not enabled", request.Namespace, pod.GetName())
+			return admissionResponse, nil
+		}
 		applyOverrides(namespace, pod, &params)
 		amendSvcAccount(pod.Namespace, &params)
 
@@ -99,6 +112,25 @@ func Mutate(collectorSvcAddr, collectorTraceProtocol, collectorTraceSvcName, col
 	}
 }
 
+// injectionEnabled returns whether the tracing configuration can be injected
+// into pod, according to the inject annotation on it or, if it doesn't have
+// it, on its namespace. Without either, it's only injected if opting in isn't
+// required.
+func injectionEnabled(ns metav1.Object, pod *corev1.Pod, requireOptIn bool) bool {
+	value, ok := pod.GetAnnotations()[labels.JaegerInjectAnnotation]
+	if !ok {
+		value = ns.GetAnnotations()[labels.JaegerInjectAnnotation]
+	}
+	switch value {
+	case labels.JaegerInjectEnabled:
+		return true
+	case labels.JaegerInjectDisabled:
+		return false
+	default:
+		return !requireOptIn
+	}
+}
+
 func applyOverrides(ns metav1.Object, pod *corev1.Pod, params *Params) {
 	ann := map[string]string{}
 	for k, v := range ns.GetAnnotations() {
@@ -119,6 +151,21 @@ func applyOverrides(ns metav1.Object, pod *corev1.Pod, params *Params) {
 	if override, ok := ann[collectorSvcAccountAnnotation]; ok {
 		params.CollectorSvcAccount = override
 	}
+	if override, ok := ann[collectorEnvNamesAnnotation]; ok {
+		overrides, err := ParseEnvNames(override)
+		if err != nil {
+			log.Warnf("ignoring invalid %s annotation on pod %s/%s: %s", collectorEnvNamesAnnotation, pod.GetNamespace(), pod.GetName(), err)
+			return
+		}
+		envNames := make(map[string]string, len(params.EnvNames)+len(overrides))
+		for name, envName := range params.EnvNames {
+			envNames[name] = envName
+		}
+		for name, envName := range overrides {
+			envNames[name] = envName
+		}
+		params.EnvNames = envNames
+	}
 }
 
 func amendSvcAccount(ns string, params *Params) {
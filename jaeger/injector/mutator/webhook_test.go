@@ -0,0 +1,255 @@
+package mutator
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/linkerd/linkerd2/controller/k8s"
+	"github.com/linkerd/linkerd2/jaeger/pkg/labels"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestInjectionEnabled(t *testing.T) {
+	testCases := []struct {
+		name           string
+		nsAnnotation   string
+		podAnnotation  string
+		requireOptIn   bool
+		expectedResult bool
+	}{
+		{
+			name:           "no annotations by default",
+			expectedResult: true,
+		},
+		{
+			name:           "no annotations when opting in is required",
+			requireOptIn:   true,
+			expectedResult: false,
+		},
+		{
+			name:           "namespace opted in",
+			nsAnnotation:   labels.JaegerInjectEnabled,
+			requireOptIn:   true,
+			expectedResult: true,
+		},
+		{
+			name:           "pod opted in",
+			podAnnotation:  labels.JaegerInjectEnabled,
+			requireOptIn:   true,
+			expectedResult: true,
+		},
+		{
+			name:           "pod opted in within an opted out namespace",
+			nsAnnotation:   labels.JaegerInjectDisabled,
+			podAnnotation:  labels.JaegerInjectEnabled,
+			requireOptIn:   true,
+			expectedResult: true,
+		},
+		{
+			name:           "pod opted out within an opted in namespace",
+			nsAnnotation:   labels.JaegerInjectEnabled,
+			podAnnotation:  labels.JaegerInjectDisabled,
+			requireOptIn:   true,
+			expectedResult: false,
+		},
+		{
+			name:           "namespace opted out by default",
+			nsAnnotation:   labels.JaegerInjectDisabled,
+			expectedResult: false,
+		},
+		{
+			name:           "invalid value when opting in is required",
+			podAnnotation:  "true",
+			requireOptIn:   true,
+			expectedResult: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			ns := &metav1.ObjectMeta{Annotations: map[string]string{}}
+			if tc.nsAnnotation != "" {
+				ns.Annotations[labels.JaegerInjectAnnotation] = tc.nsAnnotation
+			}
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{}}}
+			if tc.podAnnotation != "" {
+				pod.Annotations[labels.JaegerInjectAnnotation] = tc.podAnnotation
+			}
+
+			if result := injectionEnabled(ns, pod, tc.requireOptIn); result != tc.expectedResult {
+				t.Fatalf("Expected %t, got %t", tc.expectedResult, result)
+			}
+		})
+	}
+}
+
+func TestParseEnvNames(t *testing.T) {
+	testCases := []struct {
+		name          string
+		value         string
+		expectedNames map[string]string
+		expectedErr   bool
+	}{
+		{
+			name:          "empty",
+			expectedNames: map[string]string{},
+		},
+		{
+			name:  "overrides",
+			value: "LINKERD2_PROXY_TRACE_PROTOCOL=OTEL_EXPORTER_OTLP_PROTOCOL, LINKERD2_PROXY_TRACE_SERVICE_NAME = OTEL_SERVICE_NAME",
+			expectedNames: map[string]string{
+				envTraceProtocol: "OTEL_EXPORTER_OTLP_PROTOCOL",
+				envTraceSvcName:  "OTEL_SERVICE_NAME",
+			},
+		},
+		{
+			name:        "missing override",
+			value:       "LINKERD2_PROXY_TRACE_PROTOCOL",
+			expectedErr: true,
+		},
+		{
+			name:        "not injected",
+			value:       "LINKERD2_PROXY_LOG=RUST_LOG",
+			expectedErr: true,
+		},
+		{
+			name:        "invalid override",
+			value:       "LINKERD2_PROXY_TRACE_PROTOCOL=1=2",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			names, err := ParseEnvNames(tc.value)
+			if tc.expectedErr {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(names, tc.expectedNames) {
+				t.Fatalf("Expected %v, got %v", tc.expectedNames, names)
+			}
+		})
+	}
+}
+
+func TestMutateEnvNames(t *testing.T) {
+	api, err := k8s.NewFakeMetadataAPI([]string{`
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: emojivoto
+`})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	api.Sync(nil)
+
+	envNames := map[string]string{
+		envTraceProtocol: "OTEL_EXPORTER_OTLP_PROTOCOL",
+		envTraceSvcName:  "OTEL_SERVICE_NAME",
+	}
+	collector := NewCollectorConfig(CollectorSettings{
+		SvcAddr:       "collector.linkerd-jaeger:55678",
+		TraceProtocol: "opentelemetry",
+		TraceSvcName:  "linkerd-proxy",
+		SvcAccount:    "collector",
+	})
+	mutate := Mutate(collector, "cluster.local", "linkerd", false, envNames)
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expectedEnv map[string]string
+	}{
+		{
+			name: "flag overrides",
+			expectedEnv: map[string]string{
+				envTraceAttributesPath:        "/var/run/linkerd/podinfo/labels",
+				envCollectorSvcAddr:           "collector.linkerd-jaeger:55678",
+				"OTEL_EXPORTER_OTLP_PROTOCOL": "opentelemetry",
+				"OTEL_SERVICE_NAME":           "linkerd-proxy",
+				envCollectorSvcName:           "collector.linkerd-jaeger.serviceaccount.identity.linkerd.cluster.local",
+				envExtraAttributes:            "k8s.pod.uid=$(_pod_uid)\nk8s.container.name=$(_pod_containerName)",
+			},
+		},
+		{
+			name: "annotation overrides",
+			annotations: map[string]string{
+				collectorEnvNamesAnnotation: "LINKERD2_PROXY_TRACE_COLLECTOR_SVC_ADDR=OTEL_EXPORTER_OTLP_ENDPOINT,LINKERD2_PROXY_TRACE_SERVICE_NAME=SERVICE_NAME",
+			},
+			expectedEnv: map[string]string{
+				envTraceAttributesPath:        "/var/run/linkerd/podinfo/labels",
+				"OTEL_EXPORTER_OTLP_ENDPOINT": "collector.linkerd-jaeger:55678",
+				"OTEL_EXPORTER_OTLP_PROTOCOL": "opentelemetry",
+				"SERVICE_NAME":                "linkerd-proxy",
+				envCollectorSvcName:           "collector.linkerd-jaeger.serviceaccount.identity.linkerd.cluster.local",
+				envExtraAttributes:            "k8s.pod.uid=$(_pod_uid)\nk8s.container.name=$(_pod_containerName)",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "web",
+					Namespace:   "emojivoto",
+					Annotations: tc.annotations,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "linkerd-proxy"}},
+				},
+			}
+			raw, err := json.Marshal(pod)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			request := &admissionv1beta1.AdmissionRequest{
+				Namespace: "emojivoto",
+				Object:    runtime.RawExtension{Raw: raw},
+			}
+
+			response, err := mutate(context.Background(), api, request, record.NewFakeRecorder(10))
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			var patch []struct {
+				Path  string          `json:"path"`
+				Value json.RawMessage `json:"value"`
+			}
+			if err := json.Unmarshal(response.Patch, &patch); err != nil {
+				t.Fatalf("Unexpected error unmarshalling patch %s: %s", response.Patch, err)
+			}
+			env := map[string]string{}
+			for _, op := range patch {
+				if op.Path != "/spec/containers/0/env/-" {
+					continue
+				}
+				var envVar corev1.EnvVar
+				if err := json.Unmarshal(op.Value, &envVar); err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				env[envVar.Name] = envVar.Value
+			}
+			if !reflect.DeepEqual(env, tc.expectedEnv) {
+				t.Fatalf("Expected env %v, got %v", tc.expectedEnv, env)
+			}
+		})
+	}
+}
@@ -13,6 +13,17 @@ const (
 	// JaegerTracingEnabled is set by the jaeger-injector component when
 	// tracing has been enabled on a pod.
 	JaegerTracingEnabled = JaegerAnnotationsPrefix + "/tracing-enabled"
+
+	// JaegerInjectAnnotation can be set on a namespace or pod to opt in
+	// (enabled) or out (disabled) of having the tracing configuration
+	// injected. The pod's value takes precedence.
+	JaegerInjectAnnotation = JaegerAnnotationsPrefix + "/inject"
+
+	// JaegerInjectEnabled opts a namespace or pod in of tracing injection
+	JaegerInjectEnabled = "enabled"
+
+	// JaegerInjectDisabled opts a namespace or pod out of tracing injection
+	JaegerInjectDisabled = "disabled"
 )
 
 // IsTracingEnabled returns true if a pod has an annotation indicating that
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/linkerd/linkerd2/controller/gen/apis/link/v1alpha2"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	sm "github.com/linkerd/linkerd2/pkg/servicemirror"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+type (
+	linkStatusOptions struct {
+		namespace   string
+		clusterName string
+		output      string
+	}
+
+	// linkStatus is the effective configuration of a Link, as seen by the
+	// service mirror controller that serves it.
+	linkStatus struct {
+		Name                     string               `json:"name"`
+		TargetClusterName        string               `json:"targetClusterName"`
+		TargetClusterDomain      string               `json:"targetClusterDomain"`
+		GatewayAddress           string               `json:"gatewayAddress"`
+		Selector                 string               `json:"selector"`
+		RemoteDiscoverySelector  string               `json:"remoteDiscoverySelector"`
+		FederatedServiceSelector string               `json:"federatedServiceSelector"`
+		Probe                    *v1alpha2.ProbeSpec  `json:"probe,omitempty"`
+		Credentials              linkCredentialStatus `json:"credentials"`
+	}
+
+	linkCredentialStatus struct {
+		Secret string `json:"secret"`
+		Valid  bool   `json:"valid"`
+		Server string `json:"server,omitempty"`
+		Error  string `json:"error,omitempty"`
+	}
+)
+
+func newLinkStatusCommand() *cobra.Command {
+	opts := linkStatusOptions{
+		namespace: defaultMulticlusterNamespace,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "link-status",
+		Short: "Display the effective configuration of a Link",
+		Long: `Display the effective configuration of a Link.
+
+This shows the target cluster, the selectors used to pick the services to
+mirror, the gateway probe configuration and whether the credentials Secret
+referenced by the Link contains a usable kubeconfig.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.clusterName == "" {
+				return errors.New("You need to specify cluster name")
+			}
+
+			k, err := k8s.NewAPI(kubeconfigPath, kubeContext, impersonate, impersonateGroup, 0)
+			if err != nil {
+				return err
+			}
+
+			link, err := k.L5dCrdClient.LinkV1alpha2().Links(opts.namespace).Get(cmd.Context(), opts.clusterName, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			// A missing or unreadable Secret is reported as invalid credentials
+			secret, err := k.CoreV1().Secrets(opts.namespace).Get(cmd.Context(), link.Spec.ClusterCredentialsSecret, metav1.GetOptions{})
+			return renderLinkStatus(newLinkStatus(link, secret, err), stdout, opts.output)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.namespace, "namespace", opts.namespace, "The namespace of the Link")
+	cmd.Flags().StringVar(&opts.clusterName, "cluster-name", "", "The name of the target cluster")
+	cmd.Flags().StringVarP(&opts.output, "output", "o", "", "Output format. One of: json")
+
+	return cmd
+}
+
+// newLinkStatus builds the linkStatus of a Link. secretErr is the error
+// returned when fetching the credentials Secret, if any.
+func newLinkStatus(link *v1alpha2.Link, secret *corev1.Secret, secretErr error) linkStatus {
+	status := linkStatus{
+		Name:                     link.Name,
+		TargetClusterName:        link.Spec.TargetClusterName,
+		TargetClusterDomain:      link.Spec.TargetClusterDomain,
+		GatewayAddress:           fmt.Sprintf("%s:%s", link.Spec.GatewayAddress, link.Spec.GatewayPort),
+		Selector:                 formatSelector(link.Spec.Selector),
+		RemoteDiscoverySelector:  formatSelector(link.Spec.RemoteDiscoverySelector),
+		FederatedServiceSelector: formatSelector(link.Spec.FederatedServiceSelector),
+		Credentials: linkCredentialStatus{
+			Secret: link.Spec.ClusterCredentialsSecret,
+		},
+	}
+	if link.Spec.ProbeSpec.Path != "" {
+		probe := link.Spec.ProbeSpec
+		status.Probe = &probe
+	}
+
+	if secretErr != nil {
+		status.Credentials.Error = secretErr.Error()
+		return status
+	}
+	creds, err := sm.ParseRemoteClusterSecret(secret)
+	if err != nil {
+		status.Credentials.Error = err.Error()
+		return status
+	}
+	// The kubeconfig is checked as the service mirror does, so that
+	// credentials it would reject aren't reported as valid.
+	if err := sm.ValidateKubeconfig(creds); err != nil {
+		status.Credentials.Error = err.Error()
+		return status
+	}
+	cfg, err := clientcmd.RESTConfigFromKubeConfig(creds)
+	if err != nil {
+		status.Credentials.Error = err.Error()
+		return status
+	}
+	status.Credentials.Valid = true
+	status.Credentials.Server = cfg.Host
+
+	return status
+}
+
+func formatSelector(selector *metav1.LabelSelector) string {
+	if selector == nil {
+		return "<none>"
+	}
+	return metav1.FormatLabelSelector(selector)
+}
+
+func renderLinkStatus(status linkStatus, w io.Writer, output string) error {
+	switch output {
+	case "json":
+		out, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", out)
+		return err
+	case "":
+	default:
+		return fmt.Errorf("output format %s not supported", output)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Link:\t%s\n", status.Name)
+	fmt.Fprintf(tw, "Target cluster:\t%s\n", status.TargetClusterName)
+	fmt.Fprintf(tw, "Target cluster domain:\t%s\n", status.TargetClusterDomain)
+	fmt.Fprintf(tw, "Gateway address:\t%s\n", status.GatewayAddress)
+	fmt.Fprintf(tw, "Selector:\t%s\n", status.Selector)
+	fmt.Fprintf(tw, "Remote discovery selector:\t%s\n", status.RemoteDiscoverySelector)
+	fmt.Fprintf(tw, "Federated service selector:\t%s\n", status.FederatedServiceSelector)
+	if status.Probe != nil {
+		fmt.Fprintf(tw, "Probe:\tGET %s on port %s every %s (timeout %s, failure threshold %s)\n",
+			status.Probe.Path, status.Probe.Port, status.Probe.Period, status.Probe.Timeout, status.Probe.FailureThreshold)
+	} else {
+		fmt.Fprintf(tw, "Probe:\t<none>\n")
+	}
+	if status.Credentials.Valid {
+		fmt.Fprintf(tw, "Credentials:\tvalid (secret %s, server %s)\n", status.Credentials.Secret, status.Credentials.Server)
+	} else {
+		fmt.Fprintf(tw, "Credentials:\tinvalid (secret %s): %s\n", status.Credentials.Secret, status.Credentials.Error)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/linkerd/linkerd2/controller/gen/apis/link/v1alpha2"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const sampleKubeconfig = `
+apiVersion: v1
+kind: Config
+clusters:
+- name: east
+  cluster:
+    server: https://east.example.com:6443
+    certificate-authority-data: Y2EK
+contexts:
+- name: east
+  context:
+    cluster: east
+    user: east
+current-context: east
+users:
+- name: east
+  user:
+    token: abc
+`
+
+func sampleLink() *v1alpha2.Link {
+	return &v1alpha2.Link{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "east",
+			Namespace: "linkerd-multicluster",
+		},
+		Spec: v1alpha2.LinkSpec{
+			TargetClusterName:        "east",
+			TargetClusterDomain:      "cluster.local",
+			ClusterCredentialsSecret: "cluster-credentials-east",
+			GatewayAddress:           "10.0.0.1",
+			GatewayPort:              "4143",
+			ProbeSpec: v1alpha2.ProbeSpec{
+				Path:             "/ready",
+				Port:             "4191",
+				Period:           "3s",
+				Timeout:          "30s",
+				FailureThreshold: "3",
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"mirror.linkerd.io/exported": "true"},
+			},
+			RemoteDiscoverySelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"mirror.linkerd.io/exported": "remote-discovery"},
+			},
+		},
+	}
+}
+
+func TestRenderLinkStatus(t *testing.T) {
+	secret := &corev1.Secret{
+		Data: map[string][]byte{k8s.ConfigKeyName: []byte(sampleKubeconfig)},
+	}
+
+	testCases := []struct {
+		name      string
+		secret    *corev1.Secret
+		secretErr error
+		expected  string
+	}{
+		{
+			name:   "valid credentials",
+			secret: secret,
+			expected: `Link:                        east
+Target cluster:              east
+Target cluster domain:       cluster.local
+Gateway address:             10.0.0.1:4143
+Selector:                    mirror.linkerd.io/exported=true
+Remote discovery selector:   mirror.linkerd.io/exported=remote-discovery
+Federated service selector:  <none>
+Probe:                       GET /ready on port 4191 every 3s (timeout 30s, failure threshold 3)
+Credentials:                 valid (secret cluster-credentials-east, server https://east.example.com:6443)
+`,
+		},
+		{
+			name: "kubeconfig rejected by the service mirror",
+			secret: &corev1.Secret{
+				Data: map[string][]byte{k8s.ConfigKeyName: []byte(strings.Replace(sampleKubeconfig, "current-context: east\n", "", 1))},
+			},
+			expected: `Link:                        east
+Target cluster:              east
+Target cluster domain:       cluster.local
+Gateway address:             10.0.0.1:4143
+Selector:                    mirror.linkerd.io/exported=true
+Remote discovery selector:   mirror.linkerd.io/exported=remote-discovery
+Federated service selector:  <none>
+Probe:                       GET /ready on port 4191 every 3s (timeout 30s, failure threshold 3)
+Credentials:                 invalid (secret cluster-credentials-east): invalid remote cluster kubeconfig: no current context set
+`,
+		},
+		{
+			name:      "missing secret",
+			secretErr: errors.New(`secrets "cluster-credentials-east" not found`),
+			expected: `Link:                        east
+Target cluster:              east
+Target cluster domain:       cluster.local
+Gateway address:             10.0.0.1:4143
+Selector:                    mirror.linkerd.io/exported=true
+Remote discovery selector:   mirror.linkerd.io/exported=remote-discovery
+Federated service selector:  <none>
+Probe:                       GET /ready on port 4191 every 3s (timeout 30s, failure threshold 3)
+Credentials:                 invalid (secret cluster-credentials-east): secrets "cluster-credentials-east" not found
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			status := newLinkStatus(sampleLink(), tc.secret, tc.secretErr)
+			if err := renderLinkStatus(status, &buf, ""); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if buf.String() != tc.expected {
+				t.Fatalf("Expected:\n%s\nGot:\n%s", tc.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestRenderLinkStatusJSON(t *testing.T) {
+	status := newLinkStatus(sampleLink(), &corev1.Secret{}, nil)
+
+	var buf bytes.Buffer
+	if err := renderLinkStatus(status, &buf, "json"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var decoded linkStatus
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Failed to decode JSON output: %s", err)
+	}
+	if !reflect.DeepEqual(status, decoded) {
+		t.Fatalf("Expected %+v, got %+v", status, decoded)
+	}
+	if decoded.Credentials.Valid {
+		t.Fatalf("Expected credentials without a kubeconfig to be invalid")
+	}
+}
@@ -30,6 +30,14 @@ func TestServiceMirrorRender(t *testing.T) {
 			},
 			"service_mirror_ha.golden",
 		},
+
+		{
+			linkValues,
+			map[string]interface{}{
+				"linkNamespaces": []interface{}{"team-a", "team-b"},
+			},
+			"service_mirror_link_namespaces.golden",
+		},
 	}
 	for i, tc := range testCases {
 		tc := tc
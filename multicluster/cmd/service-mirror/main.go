@@ -2,16 +2,20 @@ package servicemirror
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	"github.com/linkerd/linkerd2/controller/gen/apis/link/v1alpha2"
 	l5dcrdclient "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned"
+	l5dscheme "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/scheme"
 	l5dcrdinformer "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions"
+	linklisters "github.com/linkerd/linkerd2/controller/gen/client/listers/link/v1alpha2"
 	controllerK8s "github.com/linkerd/linkerd2/controller/k8s"
 	servicemirror "github.com/linkerd/linkerd2/multicluster/service-mirror"
 	"github.com/linkerd/linkerd2/pkg/admin"
@@ -19,15 +23,28 @@ import (
 	"github.com/linkerd/linkerd2/pkg/k8s"
 	sm "github.com/linkerd/linkerd2/pkg/servicemirror"
 	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/leaderelection"
 	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
 	linkWatchRestartAfter = 10 * time.Second
+	// Upper bound for the delay between attempts to load a Link's credentials
+	linkCredentialsMaxBackoff = 5 * time.Minute
+	// Reason of the Event recorded on a Link whose credentials can't be loaded
+	eventReasonCredentialsFailed = "ClusterCredentialsFailed"
+	// Reason of the Event recorded on a Link whose credentials are malformed
+	eventReasonCredentialsInvalid = "ClusterCredentialsInvalid"
+	// Reason of the Event recorded on Links sharing their name across the
+	// watched namespaces
+	eventReasonDuplicateLink = "DuplicateLink"
 	// Duration of the lease
 	LEASE_DURATION = 30 * time.Second
 	// Deadline for the leader to refresh its lease. Defaults to the same value
@@ -57,10 +74,16 @@ func Main(args []string) {
 	enablePprof := cmd.Bool("enable-pprof", false, "Enable pprof endpoints on the admin server")
 	localMirror := cmd.Bool("local-mirror", false, "watch the local cluster for federated service members")
 	federatedServiceSelector := cmd.String("federated-service-selector", k8s.DefaultFederatedServiceSelector, "Selector (label query) for federated service members in the local cluster")
+	linkNamespaces := cmd.String("link-namespaces", "", "comma-separated list of namespaces to watch for the Link, in addition to --namespace; the Link's credentials Secret must live in the Link's namespace, and a Link found in several of them is not mirrored")
+	linkUpdatesBuffer := cmd.Int("link-updates-buffer", 100, "number of Link updates that can be queued before further updates are dropped")
 
 	flags.ConfigureAndParse(cmd, args)
 	linkName := cmd.Arg(0)
 
+	if *linkUpdatesBuffer < 1 {
+		log.Fatalf("--link-updates-buffer must be at least 1, got %d", *linkUpdatesBuffer)
+	}
+
 	ready := false
 	adminServer := admin.NewServer(*metricsAddr, *enablePprof, &ready)
 
@@ -111,6 +134,14 @@ func Main(args []string) {
 		log.Fatalf("Failed to initialize K8s API: %s", err)
 	}
 
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: controllerK8sAPI.Client.CoreV1().Events(""),
+	})
+	recorder := eventBroadcaster.NewRecorder(l5dscheme.Scheme, corev1.EventSource{
+		Component: fmt.Sprintf("linkerd-service-mirror-%s", linkName),
+	})
+
 	metrics := servicemirror.NewProbeMetricVecs()
 	controllerK8sAPI.Sync(nil)
 	ready = true
@@ -138,19 +169,26 @@ func Main(args []string) {
 		run = func(ctx context.Context) {
 			// Use a small buffered channel for Link updates to avoid dropping
 			// updates if there is an update burst.
-			results := make(chan *v1alpha2.Link, 100)
-			informerFactory := l5dcrdinformer.NewSharedInformerFactoryWithOptions(
-				l5dClient,
-				controllerK8s.ResyncTime,
-				l5dcrdinformer.WithNamespace(*namespace),
-			)
-			informer := informerFactory.Link().V1alpha2().Links().Informer()
-			log.Infof("Starting Link informer")
-			informerFactory.Start(ctx.Done())
-
-			_, err := informer.AddEventHandler(servicemirror.GetLinkHandlers(results, linkName))
+			results := make(chan *v1alpha2.Link, *linkUpdatesBuffer)
+			namespaces := watchedLinkNamespaces(*namespace, *linkNamespaces)
+			listers, err := startLinkInformers(ctx, l5dClient, namespaces, linkName, results)
 			if err != nil {
-				log.Fatalf("Failed to add event handler to Link informer: %s", err)
+				log.Fatalf("Failed to start Link informers: %s", err)
+			}
+
+			// Number of consecutive failures to load the Link's credentials,
+			// used to back off between attempts.
+			credsFailures := 0
+
+			// Fires when the Link should be retried after a failure. It's
+			// reset by any newer update of the Link, which is handled right
+			// away instead.
+			var retry *time.Timer
+			retryFired := func() <-chan time.Time {
+				if retry == nil {
+					return nil
+				}
+				return retry.C
 			}
 
 			// Each time the link resource is updated, reload the config and restart the
@@ -165,26 +203,70 @@ func Main(args []string) {
 					// lease is lost, or by a background task handling SIGTERM.
 					// Before terminating the loop, stop the workers and set
 					// them to nil to release memory.
+					if retry != nil {
+						retry.Stop()
+					}
 					cleanupWorkers()
-				case link := <-results:
-					if link != nil {
-						log.Infof("Got updated link %s: %+v", linkName, link)
-						creds, err := loadCredentials(ctx, link, *namespace, controllerK8sAPI.Client)
-						if err != nil {
-							log.Errorf("Failed to load remote cluster credentials: %s", err)
-						}
-						err = restartClusterWatcher(ctx, link, *namespace, creds, controllerK8sAPI, l5dClient, *requeueLimit, *repairPeriod, metrics, *enableHeadlessSvc, *enableNamespaceCreation)
-						if err != nil {
-							// failed to restart cluster watcher; give a bit of slack
-							// and requeue the link to give it another try
-							log.Error(err)
-							time.Sleep(linkWatchRestartAfter)
-							results <- link
-						}
-					} else {
-						log.Infof("Link %s deleted", linkName)
-						cleanupWorkers()
+					return
+				case <-results:
+				case <-retryFired():
+				}
+				if retry != nil {
+					retry.Stop()
+					retry = nil
+				}
+
+				// The update may come from any of the watched namespaces, so
+				// the Link is looked up in all of them. This also ensures the
+				// latest version of the Link is used when retrying.
+				link, err := resolveLink(listers, linkName)
+				var duplicateErr *duplicateLinkError
+				if errors.As(err, &duplicateErr) {
+					// The Links would share the lease and the mirror
+					// resources, so none of them is mirrored until only one
+					// remains.
+					log.Error(err)
+					for _, l := range duplicateErr.links {
+						recorder.Event(l, corev1.EventTypeWarning, eventReasonDuplicateLink, err.Error())
 					}
+					cleanupWorkers()
+					continue
+				}
+				if err != nil {
+					log.Errorf("Failed to get link %s: %s", linkName, err)
+					continue
+				}
+				if link == nil {
+					log.Infof("Link %s deleted", linkName)
+					cleanupWorkers()
+					continue
+				}
+
+				log.Infof("Got updated link %s: %+v", linkName, link)
+				creds, err := loadCredentialsOrRecord(ctx, link, link.Namespace, controllerK8sAPI.Client, recorder)
+				if err != nil {
+					// Without credentials there's no point in starting a
+					// cluster watcher; back off and retry so the Secret can be
+					// fixed in the meantime.
+					credsFailures++
+					delay := credentialsBackoff(credsFailures)
+					log.Errorf("Failed to load remote cluster credentials (retrying in %s): %s", delay, err)
+					retry = time.NewTimer(delay)
+					continue
+				}
+				credsFailures = 0
+				err = restartClusterWatcher(ctx, link, *namespace, creds, controllerK8sAPI, l5dClient, *requeueLimit, *repairPeriod, metrics, *enableHeadlessSvc, *enableNamespaceCreation)
+				var invalidErr *sm.InvalidKubeconfigError
+				if errors.As(err, &invalidErr) {
+					// Retrying won't fix a malformed kubeconfig; wait for the
+					// link to be updated instead.
+					log.Errorf("Not retrying link %s: %s", linkName, err)
+					recordInvalidCredentials(link, invalidErr, recorder)
+				} else if err != nil {
+					// failed to restart cluster watcher; give a bit of slack
+					// and retry the link to give it another try
+					log.Error(err)
+					retry = time.NewTimer(linkWatchRestartAfter)
 				}
 			}
 		}
@@ -289,6 +371,159 @@ func loadCredentials(ctx context.Context, link *v1alpha2.Link, namespace string,
 	return sm.ParseRemoteClusterSecret(secret)
 }
 
+// watchedLinkNamespaces returns the namespaces in which to watch for the
+// Link: the service mirror's own namespace plus the comma-separated
+// extraNamespaces, without duplicates.
+func watchedLinkNamespaces(namespace, extraNamespaces string) []string {
+	namespaces := []string{namespace}
+	seen := map[string]struct{}{namespace: {}}
+	for _, ns := range strings.Split(extraNamespaces, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" {
+			continue
+		}
+		if _, ok := seen[ns]; ok {
+			continue
+		}
+		seen[ns] = struct{}{}
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces
+}
+
+// startLinkInformers starts a Link informer for each of the given namespaces,
+// all of them sending the updates for linkName into results. It returns the
+// listers of the informers, to be used with resolveLink.
+func startLinkInformers(ctx context.Context, l5dClient l5dcrdclient.Interface, namespaces []string, linkName string, results chan<- *v1alpha2.Link) ([]linklisters.LinkNamespaceLister, error) {
+	listers := make([]linklisters.LinkNamespaceLister, 0, len(namespaces))
+	for _, ns := range namespaces {
+		informerFactory := l5dcrdinformer.NewSharedInformerFactoryWithOptions(
+			l5dClient,
+			controllerK8s.ResyncTime,
+			l5dcrdinformer.WithNamespace(ns),
+		)
+		links := informerFactory.Link().V1alpha2().Links()
+		informer := links.Informer()
+		listers = append(listers, links.Lister().Links(ns))
+		log.Infof("Starting Link informer in namespace %s", ns)
+		informerFactory.Start(ctx.Done())
+
+		_, err := informer.AddEventHandler(servicemirror.GetLinkHandlers(results, linkName))
+		if err != nil {
+			return nil, fmt.Errorf("failed to add event handler to Link informer in namespace %s: %w", ns, err)
+		}
+	}
+	return listers, nil
+}
+
+// duplicateLinkError is returned when several of the watched namespaces hold
+// a Link with the same name.
+type duplicateLinkError struct {
+	links []*v1alpha2.Link
+}
+
+func (e *duplicateLinkError) Error() string {
+	namespaces := make([]string, len(e.links))
+	for i, link := range e.links {
+		namespaces[i] = link.Namespace
+	}
+	return fmt.Sprintf("Link %s exists in several namespaces (%s); none of them will be mirrored until only one remains",
+		e.links[0].Name, strings.Join(namespaces, ", "))
+}
+
+// resolveLink returns the Link named linkName from the given listers, or nil
+// if none of them holds it. A duplicateLinkError is returned if more than one
+// of them does.
+func resolveLink(listers []linklisters.LinkNamespaceLister, linkName string) (*v1alpha2.Link, error) {
+	var links []*v1alpha2.Link
+	for _, lister := range listers {
+		link, err := lister.Get(linkName)
+		if kerrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+
+	switch len(links) {
+	case 0:
+		return nil, nil
+	case 1:
+		return links[0], nil
+	default:
+		return nil, &duplicateLinkError{links}
+	}
+}
+
+// loadCredentialsOrRecord loads the Link's credentials, recording a warning
+// Event on the Link when they can't be loaded.
+func loadCredentialsOrRecord(ctx context.Context, link *v1alpha2.Link, namespace string, k8sAPI kubernetes.Interface, recorder record.EventRecorder) ([]byte, error) {
+	creds, err := loadCredentials(ctx, link, namespace, k8sAPI)
+	if err != nil {
+		servicemirror.CredentialsFailures(link.Name, servicemirror.CredentialsFailureLoad).Inc()
+		recorder.Eventf(link, corev1.EventTypeWarning, eventReasonCredentialsFailed, "Failed to load remote cluster credentials: %s", err)
+		return nil, err
+	}
+	return creds, nil
+}
+
+// recordInvalidCredentials records a warning Event on a Link whose
+// credentials were rejected, and counts the failure.
+func recordInvalidCredentials(link *v1alpha2.Link, err *sm.InvalidKubeconfigError, recorder record.EventRecorder) {
+	servicemirror.CredentialsFailures(link.Name, servicemirror.CredentialsFailureInvalid).Inc()
+	recorder.Event(link, corev1.EventTypeWarning, eventReasonCredentialsInvalid, err.Error())
+}
+
+// credentialsBackoff returns the delay before retrying to load a Link's
+// credentials after the given number of consecutive failures. The delay
+// doubles with each failure, starting at linkWatchRestartAfter and capped at
+// linkCredentialsMaxBackoff.
+func credentialsBackoff(failures int) time.Duration {
+	delay := linkWatchRestartAfter
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if delay >= linkCredentialsMaxBackoff {
+			return linkCredentialsMaxBackoff
+		}
+	}
+	return delay
+}
+
+// preflightRemoteCluster checks that the target cluster's API server is
+// reachable and that the Link's credentials allow listing the resources the
+// cluster watcher relies on.
+func preflightRemoteCluster(ctx context.Context, client kubernetes.Interface, clusterName string) error {
+	if _, err := client.Discovery().ServerVersion(); err != nil {
+		return fmt.Errorf("cannot reach the API server of target cluster %s, check the Link's API server address and network connectivity: %w", clusterName, err)
+	}
+
+	for _, resource := range []string{"services", "endpoints"} {
+		if err := k8s.ResourceAuthz(ctx, client, "", "list", "", "v1", resource, ""); err != nil {
+			return fmt.Errorf("cannot list %s in target cluster %s, check the RBAC of the service account used by the Link: %w", resource, clusterName, err)
+		}
+	}
+
+	return nil
+}
+
+// linkRepairPeriod returns the endpoint refresh period for the Link's target
+// cluster, as set through the LinkEndpointRefreshPeriodAnnotation, falling
+// back to defaultPeriod when the annotation is absent or invalid.
+func linkRepairPeriod(link *v1alpha2.Link, defaultPeriod time.Duration) time.Duration {
+	value, ok := link.Annotations[k8s.LinkEndpointRefreshPeriodAnnotation]
+	if !ok {
+		return defaultPeriod
+	}
+	period, err := time.ParseDuration(value)
+	if err != nil || period <= 0 {
+		log.Warnf("Invalid %s annotation on link %s: %q; using %s", k8s.LinkEndpointRefreshPeriodAnnotation, link.Name, value, defaultPeriod)
+		return defaultPeriod
+	}
+	return period
+}
+
 func restartClusterWatcher(
 	ctx context.Context,
 	link *v1alpha2.Link,
@@ -305,6 +540,10 @@ func restartClusterWatcher(
 
 	cleanupWorkers()
 
+	if err := sm.ValidateKubeconfig(creds); err != nil {
+		return err
+	}
+
 	workerMetrics, err := metrics.NewWorkerMetrics(link.Spec.TargetClusterName)
 	if err != nil {
 		return fmt.Errorf("failed to create metrics for cluster watcher: %w", err)
@@ -328,6 +567,10 @@ func restartClusterWatcher(
 	if err != nil {
 		return fmt.Errorf("cannot initialize api for target cluster %s: %w", link.Spec.TargetClusterName, err)
 	}
+	if err := preflightRemoteCluster(ctx, remoteAPI.Client, link.Spec.TargetClusterName); err != nil {
+		remoteAPI.UnregisterGauges()
+		return err
+	}
 	cw, err := servicemirror.NewRemoteClusterServiceWatcher(
 		ctx,
 		namespace,
@@ -336,7 +579,7 @@ func restartClusterWatcher(
 		linkClient,
 		link,
 		requeueLimit,
-		repairPeriod,
+		linkRepairPeriod(link, repairPeriod),
 		ch,
 		enableHeadlessSvc,
 		enableNamespaceCreation,
@@ -0,0 +1,339 @@
+package servicemirror
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/linkerd/linkerd2/controller/gen/apis/link/v1alpha2"
+	l5dfake "github.com/linkerd/linkerd2/controller/gen/client/clientset/versioned/fake"
+	linklisters "github.com/linkerd/linkerd2/controller/gen/client/listers/link/v1alpha2"
+	servicemirror "github.com/linkerd/linkerd2/multicluster/service-mirror"
+	"github.com/linkerd/linkerd2/pkg/k8s"
+	sm "github.com/linkerd/linkerd2/pkg/servicemirror"
+	dto "github.com/prometheus/client_model/go"
+	authV1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestLoadCredentialsOrRecordMissingSecret(t *testing.T) {
+	link := &v1alpha2.Link{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "remote",
+			Namespace: "linkerd-multicluster",
+		},
+		Spec: v1alpha2.LinkSpec{
+			ClusterCredentialsSecret: "cluster-credentials-remote",
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	failuresBefore := credentialsFailureCount(t, link.Name, servicemirror.CredentialsFailureLoad)
+
+	creds, err := loadCredentialsOrRecord(context.Background(), link, "linkerd-multicluster", fake.NewSimpleClientset(), recorder)
+	if err == nil {
+		t.Fatalf("Expected an error for a missing credentials secret")
+	}
+	if creds != nil {
+		t.Fatalf("Expected no credentials, got %q", creds)
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.HasPrefix(event, "Warning "+eventReasonCredentialsFailed) {
+			t.Fatalf("Unexpected event: %s", event)
+		}
+		if !strings.Contains(event, "cluster-credentials-remote") {
+			t.Fatalf("Expected event to mention the secret name, got: %s", event)
+		}
+	default:
+		t.Fatalf("Expected a warning event to be recorded")
+	}
+
+	if failures := credentialsFailureCount(t, link.Name, servicemirror.CredentialsFailureLoad); failures != failuresBefore+1 {
+		t.Fatalf("Expected the credentials failures counter to be incremented, got %v (was %v)", failures, failuresBefore)
+	}
+}
+
+func TestRecordInvalidCredentials(t *testing.T) {
+	link := &v1alpha2.Link{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "remote",
+			Namespace: "linkerd-multicluster",
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	loadFailuresBefore := credentialsFailureCount(t, link.Name, servicemirror.CredentialsFailureLoad)
+	invalidFailuresBefore := credentialsFailureCount(t, link.Name, servicemirror.CredentialsFailureInvalid)
+
+	err := sm.ValidateKubeconfig(nil)
+	var invalidErr *sm.InvalidKubeconfigError
+	if !errors.As(err, &invalidErr) {
+		t.Fatalf("Expected an invalid kubeconfig error, got: %v", err)
+	}
+	recordInvalidCredentials(link, invalidErr, recorder)
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.HasPrefix(event, "Warning "+eventReasonCredentialsInvalid) {
+			t.Fatalf("Unexpected event: %s", event)
+		}
+	default:
+		t.Fatalf("Expected a warning event to be recorded")
+	}
+
+	if failures := credentialsFailureCount(t, link.Name, servicemirror.CredentialsFailureInvalid); failures != invalidFailuresBefore+1 {
+		t.Fatalf("Expected the invalid credentials failures counter to be incremented, got %v (was %v)", failures, invalidFailuresBefore)
+	}
+	if failures := credentialsFailureCount(t, link.Name, servicemirror.CredentialsFailureLoad); failures != loadFailuresBefore {
+		t.Fatalf("Expected the load failures counter to be unchanged, got %v (was %v)", failures, loadFailuresBefore)
+	}
+}
+
+func credentialsFailureCount(t *testing.T, linkName, reason string) float64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := servicemirror.CredentialsFailures(linkName, reason).Write(&metric); err != nil {
+		t.Fatalf("Failed to read credentials failures counter: %s", err)
+	}
+	return metric.GetCounter().GetValue()
+}
+
+func TestCredentialsBackoff(t *testing.T) {
+	testCases := []struct {
+		failures int
+		expected time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{5, 160 * time.Second},
+		{6, linkCredentialsMaxBackoff},
+		{100, linkCredentialsMaxBackoff},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		if got := credentialsBackoff(tc.failures); got != tc.expected {
+			t.Fatalf("Expected backoff %s after %d failures, got %s", tc.expected, tc.failures, got)
+		}
+	}
+}
+
+func TestPreflightRemoteCluster(t *testing.T) {
+	allowed := func(resources ...string) k8stesting.ReactionFunc {
+		return func(action k8stesting.Action) (bool, runtime.Object, error) {
+			ssar := action.(k8stesting.CreateAction).GetObject().(*authV1.SelfSubjectAccessReview)
+			res := ssar.Spec.ResourceAttributes.Resource
+			for _, r := range resources {
+				if r == res {
+					return true, &authV1.SelfSubjectAccessReview{Status: authV1.SubjectAccessReviewStatus{Allowed: true}}, nil
+				}
+			}
+			return true, &authV1.SelfSubjectAccessReview{Status: authV1.SubjectAccessReviewStatus{Allowed: false, Reason: "denied"}}, nil
+		}
+	}
+
+	testCases := []struct {
+		name        string
+		reactions   map[string]k8stesting.ReactionFunc
+		expectedErr string
+	}{
+		{
+			name: "reachable with access",
+			reactions: map[string]k8stesting.ReactionFunc{
+				"selfsubjectaccessreviews": allowed("services", "endpoints"),
+			},
+		},
+		{
+			name: "unreachable",
+			reactions: map[string]k8stesting.ReactionFunc{
+				"version": func(k8stesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("connection refused")
+				},
+			},
+			expectedErr: "cannot reach the API server of target cluster remote",
+		},
+		{
+			name: "no access to services",
+			reactions: map[string]k8stesting.ReactionFunc{
+				"selfsubjectaccessreviews": allowed("endpoints"),
+			},
+			expectedErr: "cannot list services in target cluster remote",
+		},
+		{
+			name: "no access to endpoints",
+			reactions: map[string]k8stesting.ReactionFunc{
+				"selfsubjectaccessreviews": allowed("services"),
+			},
+			expectedErr: "cannot list endpoints in target cluster remote",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			for resource, reaction := range tc.reactions {
+				client.PrependReactor("*", resource, reaction)
+			}
+
+			err := preflightRemoteCluster(context.Background(), client, "remote")
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tc.expectedErr) {
+				t.Fatalf("Expected error starting with %q, got: %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestLinkRepairPeriod(t *testing.T) {
+	defaultPeriod := time.Minute
+
+	testCases := []struct {
+		name        string
+		annotations map[string]string
+		expected    time.Duration
+	}{
+		{
+			name:     "no annotation",
+			expected: defaultPeriod,
+		},
+		{
+			name:        "per-link override",
+			annotations: map[string]string{k8s.LinkEndpointRefreshPeriodAnnotation: "15s"},
+			expected:    15 * time.Second,
+		},
+		{
+			name:        "invalid override",
+			annotations: map[string]string{k8s.LinkEndpointRefreshPeriodAnnotation: "often"},
+			expected:    defaultPeriod,
+		},
+		{
+			name:        "non-positive override",
+			annotations: map[string]string{k8s.LinkEndpointRefreshPeriodAnnotation: "0s"},
+			expected:    defaultPeriod,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			link := &v1alpha2.Link{
+				ObjectMeta: metav1.ObjectMeta{Name: "remote", Annotations: tc.annotations},
+			}
+			if got := linkRepairPeriod(link, defaultPeriod); got != tc.expected {
+				t.Fatalf("Expected %s, got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestWatchedLinkNamespaces(t *testing.T) {
+	testCases := []struct {
+		extra    string
+		expected []string
+	}{
+		{"", []string{"linkerd-multicluster"}},
+		{"team-a", []string{"linkerd-multicluster", "team-a"}},
+		{"team-a, team-b,,team-a,linkerd-multicluster", []string{"linkerd-multicluster", "team-a", "team-b"}},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		got := watchedLinkNamespaces("linkerd-multicluster", tc.extra)
+		if !reflect.DeepEqual(tc.expected, got) {
+			t.Fatalf("Expected %v for %q, got %v", tc.expected, tc.extra, got)
+		}
+	}
+}
+
+func TestStartLinkInformers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l5dClient := l5dfake.NewSimpleClientset()
+	results := make(chan *v1alpha2.Link, 10)
+	namespaces := []string{"team-a", "team-b"}
+	listers, err := startLinkInformers(ctx, l5dClient, namespaces, "remote", results)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(listers) != len(namespaces) {
+		t.Fatalf("Expected %d listers, got %d", len(namespaces), len(listers))
+	}
+
+	for _, ns := range append(namespaces, "team-c") {
+		link := &v1alpha2.Link{ObjectMeta: metav1.ObjectMeta{Name: "remote", Namespace: ns}}
+		if _, err := l5dClient.LinkV1alpha2().Links(ns).Create(ctx, link, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("Failed to create link: %s", err)
+		}
+	}
+
+	observed := map[string]struct{}{}
+	for len(observed) < len(namespaces) {
+		select {
+		case link := <-results:
+			observed[link.Namespace] = struct{}{}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for links, observed %v", observed)
+		}
+	}
+	if _, ok := observed["team-c"]; ok {
+		t.Fatalf("Observed a link in an unwatched namespace")
+	}
+}
+
+func TestResolveLink(t *testing.T) {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	lister := linklisters.NewLinkLister(indexer)
+	listers := []linklisters.LinkNamespaceLister{lister.Links("team-a"), lister.Links("team-b")}
+
+	link, err := resolveLink(listers, "remote")
+	if err != nil || link != nil {
+		t.Fatalf("Expected no link and no error, got %v and %v", link, err)
+	}
+
+	for _, ns := range []string{"team-a", "team-c"} {
+		if err := indexer.Add(&v1alpha2.Link{ObjectMeta: metav1.ObjectMeta{Name: "remote", Namespace: ns}}); err != nil {
+			t.Fatalf("Failed to add link: %s", err)
+		}
+	}
+	link, err = resolveLink(listers, "remote")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if link == nil || link.Namespace != "team-a" {
+		t.Fatalf("Expected the link in team-a, got %v", link)
+	}
+
+	if err := indexer.Add(&v1alpha2.Link{ObjectMeta: metav1.ObjectMeta{Name: "remote", Namespace: "team-b"}}); err != nil {
+		t.Fatalf("Failed to add link: %s", err)
+	}
+	link, err = resolveLink(listers, "remote")
+	var duplicateErr *duplicateLinkError
+	if !errors.As(err, &duplicateErr) {
+		t.Fatalf("Expected a duplicate link error, got %v", err)
+	}
+	if link != nil {
+		t.Fatalf("Expected no link, got %v", link)
+	}
+	if len(duplicateErr.links) != 2 {
+		t.Fatalf("Expected 2 duplicate links, got %d", len(duplicateErr.links))
+	}
+	if !strings.Contains(err.Error(), "team-a, team-b") {
+		t.Fatalf("Expected the error to list the namespaces, got: %s", err)
+	}
+}
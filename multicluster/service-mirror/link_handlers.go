@@ -21,6 +21,7 @@ func GetLinkHandlers(results chan<- *v1alpha2.Link, linkName string) cache.Resou
 				case results <- link:
 				default:
 					log.Errorf("Link update dropped (queue full): %s", link.GetName())
+					linkUpdatesDropped.WithLabelValues(link.GetName()).Inc()
 				}
 			}
 		},
@@ -44,6 +45,7 @@ func GetLinkHandlers(results chan<- *v1alpha2.Link, linkName string) cache.Resou
 				case results <- currentLink:
 				default:
 					log.Errorf("Link update dropped (queue full): %s", currentLink.GetName())
+					linkUpdatesDropped.WithLabelValues(currentLink.GetName()).Inc()
 				}
 			}
 		},
@@ -66,6 +68,7 @@ func GetLinkHandlers(results chan<- *v1alpha2.Link, linkName string) cache.Resou
 				case results <- nil: // nil indicates the link was deleted
 				default:
 					log.Errorf("Link delete dropped (queue full): %s", link.GetName())
+					linkUpdatesDropped.WithLabelValues(link.GetName()).Inc()
 				}
 			}
 		},
@@ -10,6 +10,7 @@ import (
 	"github.com/linkerd/linkerd2/controller/gen/apis/link/v1alpha2"
 	l5dcrdinformer "github.com/linkerd/linkerd2/controller/gen/client/informers/externalversions"
 	"github.com/linkerd/linkerd2/controller/k8s"
+	dto "github.com/prometheus/client_model/go"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -139,3 +140,36 @@ func TestLinkHandlers(t *testing.T) {
 		t.Fatal("Timed out waiting for message")
 	}
 }
+
+func TestLinkHandlersDroppedUpdates(t *testing.T) {
+	droppedCount := func() float64 {
+		var metric dto.Metric
+		if err := linkUpdatesDropped.WithLabelValues(linkName).Write(&metric); err != nil {
+			t.Fatalf("Failed to read dropped updates counter: %s", err)
+		}
+		return metric.GetCounter().GetValue()
+	}
+
+	results := make(chan *v1alpha2.Link, 1)
+	handlers := GetLinkHandlers(results, linkName)
+	link := &v1alpha2.Link{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      linkName,
+			Namespace: nsName,
+		},
+	}
+	before := droppedCount()
+
+	// the first update fills the queue
+	handlers.AddFunc(link)
+	if dropped := droppedCount(); dropped != before {
+		t.Fatalf("Expected no dropped updates, got %v", dropped-before)
+	}
+
+	// the following ones overflow it
+	handlers.AddFunc(link)
+	handlers.DeleteFunc(link)
+	if dropped := droppedCount(); dropped != before+2 {
+		t.Fatalf("Expected 2 dropped updates, got %v", dropped-before)
+	}
+}
@@ -10,6 +10,15 @@ const (
 	gatewayClusterName   = "target_cluster_name"
 	eventTypeLabelName   = "event_type"
 	probeSuccessfulLabel = "probe_successful"
+	linkNameLabel        = "link"
+	reasonLabel          = "reason"
+
+	// CredentialsFailureLoad is the reason of a credentials failure for
+	// credentials that couldn't be loaded from their Secret.
+	CredentialsFailureLoad = "load"
+	// CredentialsFailureInvalid is the reason of a credentials failure for
+	// a kubeconfig that can't be used to connect to the remote cluster.
+	CredentialsFailureInvalid = "invalid"
 )
 
 // ProbeMetricVecs stores metrics about about gateways collected by probe
@@ -35,7 +44,11 @@ type ProbeMetrics struct {
 	unregister     func()
 }
 
-var endpointRepairCounter *prometheus.CounterVec
+var (
+	endpointRepairCounter *prometheus.CounterVec
+	linkUpdatesDropped    *prometheus.CounterVec
+	credentialsFailures   *prometheus.CounterVec
+)
 
 func init() {
 	endpointRepairCounter = promauto.NewCounterVec(
@@ -45,6 +58,29 @@ func init() {
 		},
 		[]string{gatewayClusterName},
 	)
+
+	linkUpdatesDropped = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "service_mirror_link_updates_dropped",
+			Help: "Increments when a Link update is dropped because the updates queue is full",
+		},
+		[]string{linkNameLabel},
+	)
+
+	credentialsFailures = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "service_mirror_credentials_failures",
+			Help: "A counter for the number of times a Link's remote cluster credentials failed to load or were rejected",
+		},
+		[]string{linkNameLabel, reasonLabel},
+	)
+}
+
+// CredentialsFailures returns the counter of the failures of a Link's remote
+// cluster credentials for the given reason, one of CredentialsFailureLoad and
+// CredentialsFailureInvalid.
+func CredentialsFailures(link, reason string) prometheus.Counter {
+	return credentialsFailures.WithLabelValues(link, reason)
 }
 
 // NewProbeMetricVecs creates a new ProbeMetricVecs.
@@ -38,6 +38,7 @@ type Values struct {
 	RemoteMirrorServiceAccount     bool     `json:"remoteMirrorServiceAccount"`
 	RemoteMirrorServiceAccountName string   `json:"remoteMirrorServiceAccountName"`
 	TargetClusterName              string   `json:"targetClusterName"`
+	LinkNamespaces                 []string `json:"linkNamespaces"`
 	EnablePodAntiAffinity          bool     `json:"enablePodAntiAffinity"`
 	RevisionHistoryLimit           uint32   `json:"revisionHistoryLimit"`
 
@@ -10,18 +10,30 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// Route is an additional endpoint served by the admin server.
+type Route struct {
+	Path    string
+	Handler http.Handler
+}
+
 type handler struct {
 	promHandler http.Handler
 	enablePprof bool
 	ready       *bool
+	routes      map[string]http.Handler
 }
 
 // NewServer returns an initialized `http.Server`, configured to listen on an address.
-func NewServer(addr string, enablePprof bool, ready *bool) *http.Server {
+// Additional endpoints can be served by passing them as routes.
+func NewServer(addr string, enablePprof bool, ready *bool, routes ...Route) *http.Server {
 	h := &handler{
 		promHandler: promhttp.Handler(),
 		enablePprof: enablePprof,
 		ready:       ready,
+		routes:      make(map[string]http.Handler, len(routes)),
+	}
+	for _, route := range routes {
+		h.routes[route.Path] = route.Handler
 	}
 
 	return &http.Server{
@@ -56,6 +68,10 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	case "/ready":
 		h.serveReady(w)
 	default:
+		if route, ok := h.routes[req.URL.Path]; ok {
+			route.ServeHTTP(w, req)
+			return
+		}
 		http.NotFound(w, req)
 	}
 }
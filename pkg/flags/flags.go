@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/linkerd/linkerd2/pkg/version"
 
@@ -58,6 +59,35 @@ func AddTraceFlags(cmd *flag.FlagSet) *string {
 	return traceCollector
 }
 
+// AddWatchNamespaceFlag adds the watch-namespace flag to the flagSet, which
+// can be repeated to watch several namespaces, and returns a pointer to the
+// namespaces it was set to. An empty list means all namespaces are watched.
+func AddWatchNamespaceFlag(cmd *flag.FlagSet) *[]string {
+	namespaces := []string{}
+	cmd.Var((*stringSliceValue)(&namespaces), "watch-namespace",
+		"Namespace to watch instead of the whole cluster; can be repeated to watch several namespaces")
+
+	return &namespaces
+}
+
+// stringSliceValue is a flag.Value appending each value it's set to
+type stringSliceValue []string
+
+func (s *stringSliceValue) String() string {
+	if s == nil {
+		return ""
+	}
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSliceValue) Set(value string) error {
+	if value == "" {
+		return fmt.Errorf("namespace can't be empty")
+	}
+	*s = append(*s, value)
+	return nil
+}
+
 func setLogLevel(logLevel string) {
 	level, err := log.ParseLevel(logLevel)
 	if err != nil {
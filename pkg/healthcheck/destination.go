@@ -0,0 +1,76 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+	"github.com/linkerd/linkerd2/controller/api/destination"
+	"github.com/linkerd/linkerd2/pkg/addr"
+)
+
+const (
+	// destinationCheckService is the control plane service resolved to
+	// verify that the destination service is able to discover endpoints.
+	destinationCheckService = "linkerd-dst"
+	destinationCheckPort    = 8086
+)
+
+// checkDestinationResolves port-forwards to the destination service and
+// resolves the control plane's own destination service through it.
+func (hc *HealthChecker) checkDestinationResolves(ctx context.Context) error {
+	client, conn, err := destination.NewExternalClient(ctx, hc.ControlPlaneNamespace, hc.kubeAPI, "")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	clusterDomain := "cluster.local"
+	if hc.linkerdConfig != nil && hc.linkerdConfig.ClusterDomain != "" {
+		clusterDomain = hc.linkerdConfig.ClusterDomain
+	}
+	authority := fmt.Sprintf("%s.%s.svc.%s:%d", destinationCheckService, hc.ControlPlaneNamespace, clusterDomain, destinationCheckPort)
+
+	return CheckDestinationResolves(ctx, client, authority)
+}
+
+// CheckDestinationResolves issues a Get for the given authority and returns an
+// error unless the first update received adds at least one valid address.
+// This catches a destination service that is running but unable to resolve
+// endpoints, e.g. because of missing RBAC or stuck informers.
+func CheckDestinationResolves(ctx context.Context, client pb.DestinationClient, authority string) error {
+	ctx, cancel := context.WithTimeout(ctx, RequestTimeout)
+	defer cancel()
+
+	stream, err := client.Get(ctx, &pb.GetDestination{
+		Scheme: "k8s",
+		Path:   authority,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", authority, err)
+	}
+
+	update, err := stream.Recv()
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", authority, err)
+	}
+
+	switch u := update.GetUpdate().(type) {
+	case *pb.Update_Add:
+		addrs := u.Add.GetAddrs()
+		if len(addrs) == 0 {
+			return fmt.Errorf("destination returned no addresses for %s", authority)
+		}
+		for _, wa := range addrs {
+			if wa.GetAddr().GetIp() == nil || wa.GetAddr().GetPort() == 0 {
+				return fmt.Errorf("destination returned an invalid address for %s: %s", authority, addr.ProxyAddressToString(wa.GetAddr()))
+			}
+		}
+		return nil
+	case *pb.Update_NoEndpoints:
+		return fmt.Errorf("destination found no endpoints for %s", authority)
+	default:
+		return errors.New("destination returned an unexpected first update")
+	}
+}
@@ -0,0 +1,117 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/linkerd/linkerd2-proxy-api/go/destination"
+	"github.com/linkerd/linkerd2-proxy-api/go/net"
+	"github.com/linkerd/linkerd2/controller/api/util"
+)
+
+func TestCheckDestinationResolves(t *testing.T) {
+	authority := "linkerd-dst.linkerd.svc.cluster.local:8086"
+
+	validAddrs := util.BuildAddrSet(util.AuthorityEndpoints{
+		Namespace: "linkerd",
+		ServiceID: "linkerd-dst",
+		Pods: []util.PodDetails{
+			{Name: "linkerd-destination-1", IP: 167772161, Port: 8086},
+		},
+	})
+
+	testCases := []struct {
+		name      string
+		client    *util.MockAPIClient
+		expectErr bool
+	}{
+		{
+			name: "resolves endpoints",
+			client: &util.MockAPIClient{
+				DestinationGetClientToReturn: &util.MockDestinationGetClient{
+					UpdatesToReturn: []pb.Update{
+						{Update: &pb.Update_Add{Add: validAddrs}},
+					},
+				},
+			},
+		},
+		{
+			name: "fails to open the stream",
+			client: &util.MockAPIClient{
+				ErrorToReturn: errors.New("connection refused"),
+			},
+			expectErr: true,
+		},
+		{
+			name: "fails to receive an update",
+			client: &util.MockAPIClient{
+				DestinationGetClientToReturn: &util.MockDestinationGetClient{
+					ErrorsToReturn: []error{errors.New("permission denied")},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "returns no endpoints",
+			client: &util.MockAPIClient{
+				DestinationGetClientToReturn: &util.MockDestinationGetClient{
+					UpdatesToReturn: []pb.Update{
+						{Update: &pb.Update_NoEndpoints{NoEndpoints: &pb.NoEndpoints{Exists: true}}},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "returns an empty address set",
+			client: &util.MockAPIClient{
+				DestinationGetClientToReturn: &util.MockDestinationGetClient{
+					UpdatesToReturn: []pb.Update{
+						{Update: &pb.Update_Add{Add: &pb.WeightedAddrSet{}}},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "returns an address without a port",
+			client: &util.MockAPIClient{
+				DestinationGetClientToReturn: &util.MockDestinationGetClient{
+					UpdatesToReturn: []pb.Update{
+						{Update: &pb.Update_Add{Add: &pb.WeightedAddrSet{
+							Addrs: []*pb.WeightedAddr{
+								{Addr: &net.TcpAddress{Ip: &net.IPAddress{Ip: &net.IPAddress_Ipv4{Ipv4: 167772161}}}},
+							},
+						}}},
+					},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "returns a removal first",
+			client: &util.MockAPIClient{
+				DestinationGetClientToReturn: &util.MockDestinationGetClient{
+					UpdatesToReturn: []pb.Update{
+						{Update: &pb.Update_Remove{Remove: &pb.AddrSet{}}},
+					},
+				},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckDestinationResolves(context.Background(), tc.client, authority)
+			if tc.expectErr && err == nil {
+				t.Fatalf("Expected an error, got none")
+			}
+			if !tc.expectErr && err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+		})
+	}
+}
@@ -125,6 +125,11 @@ const (
 	// control-plane proxies. The checkers include running and version checks
 	LinkerdControlPlaneProxyChecks CategoryID = "linkerd-control-plane-proxy"
 
+	// LinkerdDestinationChecks adds a check that the destination service is
+	// able to resolve the endpoints of a control plane service, which requires
+	// the control plane proxies to be running
+	LinkerdDestinationChecks CategoryID = "linkerd-destination"
+
 	// LinkerdHAChecks adds checks to validate that the HA configuration
 	// is correct. These checks are no ops if linkerd is not in HA mode
 	LinkerdHAChecks CategoryID = "linkerd-ha-checks"
@@ -1204,6 +1209,21 @@ func (hc *HealthChecker) allCategories() []*Category {
 			},
 			false,
 		),
+		NewCategory(
+			LinkerdDestinationChecks,
+			[]Checker{
+				{
+					description:         "destination service resolves endpoints",
+					hintAnchor:          "l5d-destination-resolves",
+					retryDeadline:       hc.RetryDeadline,
+					surfaceErrorOnRetry: true,
+					check: func(ctx context.Context) error {
+						return hc.checkDestinationResolves(ctx)
+					},
+				},
+			},
+			false,
+		),
 		NewCategory(
 			LinkerdControlPlaneVersionChecks,
 			[]Checker{
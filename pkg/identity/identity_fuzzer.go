@@ -18,7 +18,7 @@ func FuzzServiceCertify(data []byte) int {
 		return 0
 	}
 
-	svc := NewService(&fakeValidator{"successful-result", nil}, nil, nil, nil, "", "", "")
+	svc := NewService(&fakeValidator{"successful-result", nil}, nil, nil, nil, "", "", "", false, 0, 0)
 	svc.updateIssuer(&fakeIssuer{tls.Crt{}, nil})
 
 	_, _ = svc.Certify(context.Background(), req)
@@ -0,0 +1,21 @@
+package identity
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var issuedCertificatesCounter = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "identity_issued_certificates_total",
+		Help: "A counter for the number of leaf certificates issued, by the namespace and type of the identity they were issued for. The namespace is only set when namespace issuance metrics are enabled.",
+	},
+	[]string{"namespace", "type"},
+)
+
+var rateLimitedCounter = promauto.NewCounter(
+	prometheus.CounterOpts{
+		Name: "identity_certify_rate_limited_total",
+		Help: "A counter for the number of certify requests rejected because their identity exceeded the rate limit.",
+	},
+)
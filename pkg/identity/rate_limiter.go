@@ -0,0 +1,51 @@
+package identity
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter allows up to limit requests per key in each fixed window of
+// time. A limit of zero allows every request.
+type rateLimiter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	sync.Mutex
+	windowStart time.Time
+	counts      map[string]int
+}
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		window: window,
+		now:    time.Now,
+		counts: make(map[string]int),
+	}
+}
+
+// allow records a request for key and reports whether it's within the limit.
+func (rl *rateLimiter) allow(key string) bool {
+	if rl.limit <= 0 {
+		return true
+	}
+
+	rl.Lock()
+	defer rl.Unlock()
+
+	// All keys share the same window, so that the counts of every key can be
+	// dropped at once instead of tracking each key's expiry.
+	now := rl.now()
+	if now.Sub(rl.windowStart) >= rl.window {
+		rl.windowStart = now
+		rl.counts = make(map[string]int)
+	}
+
+	if rl.counts[key] >= rl.limit {
+		return false
+	}
+	rl.counts[key]++
+	return true
+}
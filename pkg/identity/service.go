@@ -50,8 +50,27 @@ type (
 		recordEvent  func(parent runtime.Object, eventType, reason, message string)
 
 		expectedName, issuerPathCrt, issuerPathKey string
+
+		// namespaceMetrics labels the issued certificates metric with the
+		// namespace of each identity.
+		namespaceMetrics bool
+
+		limiter *rateLimiter
+
+		csrValidators []CSRValidator
+	}
+
+	// CSRValidator implementors check a certificate signing request before a
+	// certificate is issued for it.
+	CSRValidator interface {
+		// ValidateCSR returns an error if a certificate shouldn't be issued for
+		// csr, which requests the given identity.
+		ValidateCSR(csr *x509.CertificateRequest, identity string) error
 	}
 
+	// CSRValidatorFunc adapts a function to a CSRValidator.
+	CSRValidatorFunc func(csr *x509.CertificateRequest, identity string) error
+
 	// Validator implementors accept a bearer token, validates it, and returns a
 	// DNS-form identity.
 	Validator interface {
@@ -135,8 +154,11 @@ func (svc *Service) loadCredentials() (tls.Issuer, error) {
 	return tls.NewCA(*creds, *svc.validity), nil
 }
 
-// NewService creates a new identity service.
-func NewService(validator Validator, trustAnchors *x509.CertPool, validity *tls.Validity, recordEvent func(parent runtime.Object, eventType, reason, message string), expectedName, issuerPathCrt, issuerPathKey string) *Service {
+// NewService creates a new identity service. When namespaceMetrics is set, the
+// certificates it issues are counted by the namespace of their identity. When
+// rateLimit is greater than zero, each identity may only be certified that many
+// times per rateLimitWindow.
+func NewService(validator Validator, trustAnchors *x509.CertPool, validity *tls.Validity, recordEvent func(parent runtime.Object, eventType, reason, message string), expectedName, issuerPathCrt, issuerPathKey string, namespaceMetrics bool, rateLimit int, rateLimitWindow time.Duration) *Service {
 	return &Service{
 		pb.UnimplementedIdentityServer{},
 		validator,
@@ -148,9 +170,24 @@ func NewService(validator Validator, trustAnchors *x509.CertPool, validity *tls.
 		expectedName,
 		issuerPathCrt,
 		issuerPathKey,
+		namespaceMetrics,
+		newRateLimiter(rateLimit, rateLimitWindow),
+		[]CSRValidator{CSRValidatorFunc(checkCSR)},
 	}
 }
 
+// RegisterCSRValidator adds a validator that every CSR must pass before a
+// certificate is issued for it, in addition to the default checks. It must be
+// called before the service starts serving requests.
+func (svc *Service) RegisterCSRValidator(validator CSRValidator) {
+	svc.csrValidators = append(svc.csrValidators, validator)
+}
+
+// ValidateCSR calls f(csr, identity).
+func (f CSRValidatorFunc) ValidateCSR(csr *x509.CertificateRequest, identity string) error {
+	return f(csr, identity)
+}
+
 // Register registers an identity service implementation in the provided gRPC
 // server.
 func Register(g *grpc.Server, s *Service) {
@@ -193,9 +230,11 @@ func (svc *Service) Certify(ctx context.Context, req *pb.CertifyRequest) (*pb.Ce
 		return nil, err
 	}
 
-	if err = checkCSR(csr, reqIdentity); err != nil {
-		log.Debugf("requester sent invalid CSR: %s", err)
-		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	for _, validator := range svc.csrValidators {
+		if err = validator.ValidateCSR(csr, reqIdentity); err != nil {
+			log.Debugf("requester sent invalid CSR: %s", err)
+			return nil, status.Error(codes.FailedPrecondition, err.Error())
+		}
 	}
 
 	// Authenticate the provided token against the Kubernetes API.
@@ -226,6 +265,15 @@ func (svc *Service) Certify(ctx context.Context, req *pb.CertifyRequest) (*pb.Ce
 		return nil, status.Error(codes.FailedPrecondition, msg)
 	}
 
+	// The limit is only applied once the token has been validated, so that
+	// requests for an identity without its token can't use up its quota.
+	if !svc.limiter.allow(tokIdentity) {
+		rateLimitedCounter.Inc()
+		msg := fmt.Sprintf("rate limit exceeded for %s: at most %d certificates per %s", tokIdentity, svc.limiter.limit, svc.limiter.window)
+		log.Info(msg)
+		return nil, status.Error(codes.ResourceExhausted, msg)
+	}
+
 	// Create a certificate
 	issuer := *svc.issuer
 	crt, err := issuer.IssueEndEntityCrt(csr)
@@ -252,6 +300,7 @@ func (svc *Service) Certify(ctx context.Context, req *pb.CertifyRequest) (*pb.Ce
 	}
 	svc.recordEvent(&sa, v1.EventTypeNormal, eventTypeIssuedLeafCert, msg)
 	log.Info(msg)
+	svc.countIssued(identitySegments)
 
 	// Bundle issuer crt with certificate so the trust path to the root can be verified.
 	rsp := &pb.CertifyResponse{
@@ -263,6 +312,19 @@ func (svc *Service) Certify(ctx context.Context, req *pb.CertifyRequest) (*pb.Ce
 	return rsp, nil
 }
 
+// countIssued counts a certificate issued for the identity with the given
+// segments, which are of the form <name>.<namespace>.<type>.identity...
+func (svc *Service) countIssued(identitySegments []string) {
+	namespace, typ := "", ""
+	if len(identitySegments) > 2 {
+		typ = identitySegments[2]
+		if svc.namespaceMetrics {
+			namespace = identitySegments[1]
+		}
+	}
+	issuedCertificatesCounter.WithLabelValues(namespace, typ).Inc()
+}
+
 func checkRequest(req *pb.CertifyRequest) (string, []byte, *x509.CertificateRequest, error) {
 	reqIdentity := req.GetIdentity()
 	if reqIdentity == "" {
@@ -287,6 +349,8 @@ func checkRequest(req *pb.CertifyRequest) (string, []byte, *x509.CertificateRequ
 	return reqIdentity, tok, csr, nil
 }
 
+// checkCSR is the default CSRValidator. It ensures the CSR requests exactly
+// the given identity and nothing else.
 func checkCSR(csr *x509.CertificateRequest, identity string) error {
 	if len(csr.DNSNames) != 1 {
 		return errors.New("CSR must have exactly one DNSName")
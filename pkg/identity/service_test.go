@@ -2,15 +2,25 @@ package identity
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
 	"testing"
+	"time"
 
 	pb "github.com/linkerd/linkerd2-proxy-api/go/identity"
 	"github.com/linkerd/linkerd2/pkg/tls"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func TestServiceNotReady(t *testing.T) {
 	// ch := make(chan tls.Issuer, 1)
-	svc := NewService(&fakeValidator{"successful-result", nil}, nil, nil, nil, "", "", "")
+	svc := NewService(&fakeValidator{"successful-result", nil}, nil, nil, nil, "", "", "", false, 0, 0)
 	req := &pb.CertifyRequest{
 		Identity:                  "some-identity",
 		Token:                     []byte{},
@@ -31,7 +41,7 @@ func TestServiceNotReady(t *testing.T) {
 }
 
 func TestInvalidRequestArguments(t *testing.T) {
-	svc := NewService(&fakeValidator{"successful-result", nil}, nil, nil, nil, "", "", "")
+	svc := NewService(&fakeValidator{"successful-result", nil}, nil, nil, nil, "", "", "", false, 0, 0)
 	svc.updateIssuer(&fakeIssuer{tls.Crt{}, nil})
 	fakeData := "fake-data"
 	invalidCsr := func() *pb.CertifyRequest {
@@ -78,3 +88,187 @@ func TestInvalidRequestArguments(t *testing.T) {
 	}
 
 }
+
+// newTestService returns a Service issuing certificates from a fresh root CA
+// for tokens validated as identity.
+func newTestService(t *testing.T, identity string, namespaceMetrics bool) *Service {
+	t.Helper()
+	ca, err := tls.GenerateRootCAWithDefaults("identity.linkerd.cluster.local")
+	if err != nil {
+		t.Fatalf("Failed to generate CA: %s", err)
+	}
+	recordEvent := func(runtime.Object, string, string, string) {}
+	svc := NewService(&fakeValidator{identity, nil}, ca.Cred.Crt.CertPool(), nil, recordEvent, "", "", "", namespaceMetrics, 0, 0)
+	svc.updateIssuer(ca)
+	return svc
+}
+
+// newTestCertifyRequest returns a valid CertifyRequest for identity.
+func newTestCertifyRequest(t *testing.T, identity string) *pb.CertifyRequest {
+	t.Helper()
+	key, err := tls.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+	return newTestCertifyRequestWithKey(t, identity, key)
+}
+
+func newTestCertifyRequestWithKey(t *testing.T, identity string, key *ecdsa.PrivateKey) *pb.CertifyRequest {
+	t.Helper()
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{DNSNames: []string{identity}}, key)
+	if err != nil {
+		t.Fatalf("Failed to create CSR: %s", err)
+	}
+	return &pb.CertifyRequest{
+		Identity:                  identity,
+		Token:                     []byte("token"),
+		CertificateSigningRequest: csr,
+	}
+}
+
+func issuedCertificates(t *testing.T, namespace, typ string) float64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := issuedCertificatesCounter.WithLabelValues(namespace, typ).Write(&metric); err != nil {
+		t.Fatalf("Failed to read metric: %s", err)
+	}
+	return metric.GetCounter().GetValue()
+}
+
+func TestCertifyCountsIssuedCertificates(t *testing.T) {
+	testCases := []struct {
+		name              string
+		identity          string
+		namespaceMetrics  bool
+		expectedNamespace string
+	}{
+		{
+			name:              "by namespace",
+			identity:          "web.emojivoto.serviceaccount.identity.linkerd.cluster.local",
+			namespaceMetrics:  true,
+			expectedNamespace: "emojivoto",
+		},
+		{
+			name:              "without namespaces",
+			identity:          "web.booksapp.serviceaccount.identity.linkerd.cluster.local",
+			namespaceMetrics:  false,
+			expectedNamespace: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newTestService(t, tc.identity, tc.namespaceMetrics)
+			before := issuedCertificates(t, tc.expectedNamespace, "serviceaccount")
+
+			if _, err := svc.Certify(context.Background(), newTestCertifyRequest(t, tc.identity)); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if after := issuedCertificates(t, tc.expectedNamespace, "serviceaccount"); after != before+1 {
+				t.Fatalf("Expected the %q namespace count to go from %v to %v, got %v", tc.expectedNamespace, before, before+1, after)
+			}
+			if !tc.namespaceMetrics {
+				if count := issuedCertificates(t, "booksapp", "serviceaccount"); count != 0 {
+					t.Fatalf("Expected no certificates counted for the booksapp namespace, got %v", count)
+				}
+			}
+		})
+	}
+}
+
+func TestCertifyRateLimit(t *testing.T) {
+	identity := "web.emojivoto.serviceaccount.identity.linkerd.cluster.local"
+	svc := newTestService(t, identity, false)
+
+	now := time.Now()
+	svc.limiter = newRateLimiter(2, time.Minute)
+	svc.limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := svc.Certify(context.Background(), newTestCertifyRequest(t, identity)); err != nil {
+			t.Fatalf("Unexpected error for request %d: %s", i+1, err)
+		}
+	}
+
+	var before dto.Metric
+	if err := rateLimitedCounter.Write(&before); err != nil {
+		t.Fatalf("Failed to read metric: %s", err)
+	}
+
+	_, err := svc.Certify(context.Background(), newTestCertifyRequest(t, identity))
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("Expected the third request to be rejected with ResourceExhausted, got %v", err)
+	}
+
+	var after dto.Metric
+	if err := rateLimitedCounter.Write(&after); err != nil {
+		t.Fatalf("Failed to read metric: %s", err)
+	}
+	if after.GetCounter().GetValue() != before.GetCounter().GetValue()+1 {
+		t.Fatalf("Expected the rejection to be counted")
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := svc.Certify(context.Background(), newTestCertifyRequest(t, identity)); err != nil {
+		t.Fatalf("Expected requests to be allowed again in the next window, got %s", err)
+	}
+}
+
+func TestCertifyCSRValidators(t *testing.T) {
+	identity := "web.emojivoto.serviceaccount.identity.linkerd.cluster.local"
+
+	// Only allow P-256 keys.
+	requireP256 := CSRValidatorFunc(func(csr *x509.CertificateRequest, _ string) error {
+		key, ok := csr.PublicKey.(*ecdsa.PublicKey)
+		if !ok || key.Curve != elliptic.P256() {
+			return errors.New("CSR key must be an ECDSA P-256 key")
+		}
+		return nil
+	})
+
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %s", err)
+	}
+
+	testCases := []struct {
+		name         string
+		req          *pb.CertifyRequest
+		expectedCode codes.Code
+	}{
+		{
+			name:         "compliant CSR",
+			req:          newTestCertifyRequest(t, identity),
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "CSR rejected by the registered validator",
+			req:          newTestCertifyRequestWithKey(t, identity, p384Key),
+			expectedCode: codes.FailedPrecondition,
+		},
+		{
+			name: "CSR rejected by the default validator",
+			req: func() *pb.CertifyRequest {
+				req := newTestCertifyRequest(t, "other.emojivoto.serviceaccount.identity.linkerd.cluster.local")
+				req.Identity = identity
+				return req
+			}(),
+			expectedCode: codes.FailedPrecondition,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			svc := newTestService(t, identity, false)
+			svc.RegisterCSRValidator(requireP256)
+
+			_, err := svc.Certify(context.Background(), tc.req)
+			if code := status.Code(err); code != tc.expectedCode {
+				t.Fatalf("Expected code %s, got %s (%v)", tc.expectedCode, code, err)
+			}
+		})
+	}
+}
@@ -127,6 +127,10 @@ type ResourceConfig struct {
 	ownerRetriever OwnerRetrieverFunc
 	origin         Origin
 
+	// requirePodOptIn makes the namespace's inject annotation insufficient
+	// for injection: the pod itself must have it.
+	requirePodOptIn bool
+
 	workload struct {
 		obj      runtime.Object
 		metaType metav1.TypeMeta
@@ -539,6 +543,13 @@ func (conf *ResourceConfig) WithNsAnnotations(m map[string]string) *ResourceConf
 	return conf
 }
 
+// WithRequirePodOptIn enriches ResourceConfig with whether the pod must have
+// the inject annotation itself, ignoring the one on its namespace
+func (conf *ResourceConfig) WithRequirePodOptIn(require bool) *ResourceConfig {
+	conf.requirePodOptIn = require
+	return conf
+}
+
 // WithOwnerRetriever enriches ResourceConfig with a function that allows to retrieve
 // the kind and name of the workload's owner reference
 func (conf *ResourceConfig) WithOwnerRetriever(f OwnerRetrieverFunc) *ResourceConfig {
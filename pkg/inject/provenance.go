@@ -0,0 +1,62 @@
+package inject
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/linkerd/linkerd2/pkg/k8s"
+)
+
+// ConfigSource is where the value of a proxy configuration annotation used
+// for injection comes from.
+type ConfigSource string
+
+const (
+	// ConfigSourceDefault is used when neither the workload nor its namespace
+	// set the annotation, so that the default values apply.
+	ConfigSourceDefault ConfigSource = "default"
+	// ConfigSourceNamespace is used when the annotation is inherited from the
+	// workload's namespace.
+	ConfigSourceNamespace ConfigSource = "namespace"
+	// ConfigSourceWorkload is used when the workload sets the annotation,
+	// which takes precedence over its namespace.
+	ConfigSourceWorkload ConfigSource = "workload"
+)
+
+// GetConfigProvenance returns the source of the value used for each of the
+// proxy configuration annotations, following the same precedence as
+// AppendNamespaceAnnotations.
+func GetConfigProvenance(workloadAnn map[string]string, nsAnn map[string]string) map[string]ConfigSource {
+	keys := make([]string, 0, len(ProxyAnnotations)+len(ProxyAlphaConfigAnnotations)+1)
+	keys = append(keys, ProxyAnnotations...)
+	keys = append(keys, ProxyAlphaConfigAnnotations...)
+	keys = append(keys, k8s.ProxyInjectAnnotation)
+
+	provenance := make(map[string]ConfigSource, len(keys))
+	for _, key := range keys {
+		if _, ok := workloadAnn[key]; ok {
+			provenance[key] = ConfigSourceWorkload
+		} else if _, ok := nsAnn[key]; ok {
+			provenance[key] = ConfigSourceNamespace
+		} else {
+			provenance[key] = ConfigSourceDefault
+		}
+	}
+	return provenance
+}
+
+// FormatConfigProvenance renders provenance as a comma-separated list of
+// annotation=source pairs sorted by annotation. The annotations using the
+// default values are left out, as they're the majority.
+func FormatConfigProvenance(provenance map[string]ConfigSource) string {
+	pairs := []string{}
+	for key, source := range provenance {
+		if source == ConfigSourceDefault {
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, source))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
@@ -0,0 +1,74 @@
+package inject
+
+import (
+	"testing"
+
+	"github.com/linkerd/linkerd2/pkg/k8s"
+)
+
+func TestConfigProvenance(t *testing.T) {
+	testCases := []struct {
+		name        string
+		workloadAnn map[string]string
+		nsAnn       map[string]string
+		expected    map[string]ConfigSource
+		formatted   string
+	}{
+		{
+			name: "defaults",
+			expected: map[string]ConfigSource{
+				k8s.ProxyCPULimitAnnotation: ConfigSourceDefault,
+				k8s.ProxyLogLevelAnnotation: ConfigSourceDefault,
+			},
+			formatted: "",
+		},
+		{
+			name:  "namespace",
+			nsAnn: map[string]string{k8s.ProxyInjectAnnotation: k8s.ProxyInjectEnabled, k8s.ProxyLogLevelAnnotation: "debug"},
+			expected: map[string]ConfigSource{
+				k8s.ProxyInjectAnnotation:   ConfigSourceNamespace,
+				k8s.ProxyLogLevelAnnotation: ConfigSourceNamespace,
+				k8s.ProxyCPULimitAnnotation: ConfigSourceDefault,
+			},
+			formatted: "config.linkerd.io/proxy-log-level=namespace,linkerd.io/inject=namespace",
+		},
+		{
+			name:        "workload takes precedence over namespace",
+			workloadAnn: map[string]string{k8s.ProxyLogLevelAnnotation: "trace", k8s.ProxyWaitBeforeExitSecondsAnnotation: "10"},
+			nsAnn:       map[string]string{k8s.ProxyLogLevelAnnotation: "debug", k8s.ProxyCPULimitAnnotation: "1"},
+			expected: map[string]ConfigSource{
+				k8s.ProxyLogLevelAnnotation:              ConfigSourceWorkload,
+				k8s.ProxyWaitBeforeExitSecondsAnnotation: ConfigSourceWorkload,
+				k8s.ProxyCPULimitAnnotation:              ConfigSourceNamespace,
+				k8s.ProxyInjectAnnotation:                ConfigSourceDefault,
+			},
+			formatted: "config.alpha.linkerd.io/proxy-wait-before-exit-seconds=workload,config.linkerd.io/proxy-cpu-limit=namespace,config.linkerd.io/proxy-log-level=workload",
+		},
+		{
+			name:        "unknown annotations are ignored",
+			workloadAnn: map[string]string{"config.linkerd.io/invalid-key": "invalid-value"},
+			expected: map[string]ConfigSource{
+				k8s.ProxyLogLevelAnnotation: ConfigSourceDefault,
+			},
+			formatted: "",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			provenance := GetConfigProvenance(tc.workloadAnn, tc.nsAnn)
+			for key, expected := range tc.expected {
+				if source := provenance[key]; source != expected {
+					t.Fatalf("Expected %s to come from %q, got %q", key, expected, source)
+				}
+			}
+			if _, ok := provenance["config.linkerd.io/invalid-key"]; ok {
+				t.Fatal("Unexpected provenance for an unknown annotation")
+			}
+			if formatted := FormatConfigProvenance(provenance); formatted != tc.formatted {
+				t.Fatalf("Expected %q, got %q", tc.formatted, formatted)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ const (
 	unsupportedResource                  = "unsupported_resource"
 	injectEnableAnnotationAbsent         = "injection_enable_annotation_absent"
 	injectDisableAnnotationPresent       = "injection_disable_annotation_present"
+	injectPodOptInRequired               = "injection_pod_opt_in_required"
 	annotationAtNamespace                = "namespace"
 	annotationAtWorkload                 = "workload"
 	invalidInjectAnnotationWorkload      = "invalid_inject_annotation_at_workload"
@@ -32,6 +33,7 @@ var (
 		unsupportedResource:                  "this resource kind is unsupported",
 		injectEnableAnnotationAbsent:         fmt.Sprintf("neither the namespace nor the pod have the annotation \"%s:%s\"", k8s.ProxyInjectAnnotation, k8s.ProxyInjectEnabled),
 		injectDisableAnnotationPresent:       fmt.Sprintf("pod has the annotation \"%s:%s\"", k8s.ProxyInjectAnnotation, k8s.ProxyInjectDisabled),
+		injectPodOptInRequired:               fmt.Sprintf("the pod doesn't have the annotation \"%s:%s\", and the namespace's annotation is ignored as pods are required to opt in", k8s.ProxyInjectAnnotation, k8s.ProxyInjectEnabled),
 		invalidInjectAnnotationWorkload:      fmt.Sprintf("invalid value for annotation \"%s\" at workload", k8s.ProxyInjectAnnotation),
 		invalidInjectAnnotationNamespace:     fmt.Sprintf("invalid value for annotation \"%s\" at namespace", k8s.ProxyInjectAnnotation),
 		disabledAutomountServiceAccountToken: "automountServiceAccountToken set to \"false\", with Values.identity.serviceAccountTokenProjection set to \"false\"",
@@ -183,6 +185,9 @@ func (r *Report) disabledByAnnotation(conf *ResourceConfig) (bool, string, strin
 	// cli     | n/a       | enabled  | yes      | false
 	// cli     | n/a       | ""       | yes      | false
 	// cli     | n/a       | disabled | no       | true
+	//
+	// When pods are required to opt in, the webhook ignores an enabled
+	// namespace: only the rows where the pod is enabled inject.
 
 	podAnnotation := conf.pod.meta.Annotations[k8s.ProxyInjectAnnotation]
 	nsAnnotation := conf.nsAnnotations[k8s.ProxyInjectAnnotation]
@@ -203,6 +208,9 @@ func (r *Report) disabledByAnnotation(conf *ResourceConfig) (bool, string, strin
 		if podAnnotation == k8s.ProxyInjectDisabled {
 			return true, injectDisableAnnotationPresent, annotationAtWorkload
 		}
+		if conf.requirePodOptIn && podAnnotation == "" {
+			return true, injectPodOptInRequired, ""
+		}
 		return false, "", annotationAtNamespace
 	}
 
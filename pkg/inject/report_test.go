@@ -381,6 +381,86 @@ func TestDisableByAnnotation(t *testing.T) {
 		}
 	})
 
+	t.Run("webhook origin requiring pod opt-in", func(t *testing.T) {
+		var testCases = []struct {
+			name           string
+			podMeta        *metav1.ObjectMeta
+			nsAnnotations  map[string]string
+			expected       bool
+			expectedReason string
+		}{
+			{
+				name: "pod opted in",
+				podMeta: &metav1.ObjectMeta{
+					Annotations: map[string]string{
+						k8s.ProxyInjectAnnotation: k8s.ProxyInjectEnabled,
+					},
+				},
+				expected: false,
+			},
+			{
+				name: "pod opted in within an enabled namespace",
+				podMeta: &metav1.ObjectMeta{
+					Annotations: map[string]string{
+						k8s.ProxyInjectAnnotation: k8s.ProxyInjectEnabled,
+					},
+				},
+				nsAnnotations: map[string]string{
+					k8s.ProxyInjectAnnotation: k8s.ProxyInjectEnabled,
+				},
+				expected: false,
+			},
+			{
+				name:    "enabled inherited from the namespace",
+				podMeta: &metav1.ObjectMeta{},
+				nsAnnotations: map[string]string{
+					k8s.ProxyInjectAnnotation: k8s.ProxyInjectEnabled,
+				},
+				expected:       true,
+				expectedReason: injectPodOptInRequired,
+			},
+			{
+				name: "pod opted out within an enabled namespace",
+				podMeta: &metav1.ObjectMeta{
+					Annotations: map[string]string{
+						k8s.ProxyInjectAnnotation: k8s.ProxyInjectDisabled,
+					},
+				},
+				nsAnnotations: map[string]string{
+					k8s.ProxyInjectAnnotation: k8s.ProxyInjectEnabled,
+				},
+				expected:       true,
+				expectedReason: injectDisableAnnotationPresent,
+			},
+			{
+				name:           "no annotations",
+				podMeta:        &metav1.ObjectMeta{},
+				nsAnnotations:  map[string]string{},
+				expected:       true,
+				expectedReason: injectEnableAnnotationAbsent,
+			},
+		}
+
+		for _, testCase := range testCases {
+			testCase := testCase // pin
+			t.Run(testCase.name, func(t *testing.T) {
+				resourceConfig := &ResourceConfig{origin: OriginWebhook}
+				resourceConfig.WithNsAnnotations(testCase.nsAnnotations).WithRequirePodOptIn(true)
+				resourceConfig.pod.meta = testCase.podMeta
+				resourceConfig.pod.spec = &corev1.PodSpec{} // initialize empty spec to prevent test from failing
+
+				report := newReport(resourceConfig)
+				actual, reason, _ := report.disabledByAnnotation(resourceConfig)
+				if testCase.expected != actual {
+					t.Errorf("Expected %t. Actual %t", testCase.expected, actual)
+				}
+				if testCase.expectedReason != reason {
+					t.Errorf("Expected reason %q. Actual %q", testCase.expectedReason, reason)
+				}
+			})
+		}
+	})
+
 	t.Run("CLI origin", func(t *testing.T) {
 		var testCases = []struct {
 			podMeta  *metav1.ObjectMeta
@@ -1,7 +1,10 @@
 package k8s
 
 import (
+	"crypto/x509"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
@@ -157,6 +160,35 @@ func GetConfig(fpath, kubeContext string) (*rest.Config, error) {
 		ClientConfig()
 }
 
+// AddCAFile makes config trust the PEM-encoded CA certificates in caFile, in
+// addition to the CAs it's already configured with.
+func AddCAFile(config *rest.Config, caFile string) error {
+	extra, err := os.ReadFile(filepath.Clean(caFile))
+	if err != nil {
+		return err
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(extra) {
+		return fmt.Errorf("no valid certificates found in %s", caFile)
+	}
+
+	caData := append([]byte{}, config.CAData...)
+	if len(caData) == 0 && config.CAFile != "" {
+		caData, err = os.ReadFile(filepath.Clean(config.CAFile))
+		if err != nil {
+			return err
+		}
+	}
+	if len(caData) > 0 && caData[len(caData)-1] != '\n' {
+		caData = append(caData, '\n')
+	}
+
+	// CAData takes precedence over CAFile, which is cleared to make it clear
+	// it's no longer used.
+	config.CAData = append(caData, extra...)
+	config.CAFile = ""
+	return nil
+}
+
 // CanonicalResourceNameFromFriendlyName returns a canonical name from common shorthands used in command line tools.
 // This works based on https://github.com/kubernetes/kubernetes/blob/63ffb1995b292be0a1e9ebde6216b83fc79dd988/pkg/kubectl/kubectl.go#L39
 // This also works for non-k8s resources, e.g. authorities
@@ -1,7 +1,13 @@
 package k8s
 
 import (
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/linkerd/linkerd2/pkg/tls"
+	"k8s.io/client-go/rest"
 )
 
 func TestGetConfig(t *testing.T) {
@@ -25,6 +31,102 @@ func TestGetConfig(t *testing.T) {
 	})
 }
 
+func TestAddCAFile(t *testing.T) {
+	dir := t.TempDir()
+	writeCA := func(name string) string {
+		ca, err := tls.GenerateRootCAWithDefaults(name)
+		if err != nil {
+			t.Fatalf("Failed to generate CA: %s", err)
+		}
+		path := filepath.Join(dir, name+".crt")
+		if err := os.WriteFile(path, []byte(ca.Cred.Crt.EncodeCertificatePEM()), 0600); err != nil {
+			t.Fatalf("Failed to write CA: %s", err)
+		}
+		return path
+	}
+	kubeconfigCA := writeCA("kubeconfig-ca")
+	extraCA := writeCA("extra-ca")
+	invalidCA := filepath.Join(dir, "invalid.crt")
+	if err := os.WriteFile(invalidCA, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("Failed to write file: %s", err)
+	}
+
+	kubeconfigCAData, err := os.ReadFile(kubeconfigCA)
+	if err != nil {
+		t.Fatalf("Failed to read CA: %s", err)
+	}
+
+	testCases := []struct {
+		name        string
+		config      *rest.Config
+		caFile      string
+		expectedCAs []string
+		expectErr   bool
+	}{
+		{
+			name:        "CA data in kubeconfig",
+			config:      &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAData: kubeconfigCAData}},
+			caFile:      extraCA,
+			expectedCAs: []string{"kubeconfig-ca", "extra-ca"},
+		},
+		{
+			name:        "CA file in kubeconfig",
+			config:      &rest.Config{TLSClientConfig: rest.TLSClientConfig{CAFile: kubeconfigCA}},
+			caFile:      extraCA,
+			expectedCAs: []string{"kubeconfig-ca", "extra-ca"},
+		},
+		{
+			name:        "no CA in kubeconfig",
+			config:      &rest.Config{},
+			caFile:      extraCA,
+			expectedCAs: []string{"extra-ca"},
+		},
+		{
+			name:      "invalid CA file",
+			config:    &rest.Config{},
+			caFile:    invalidCA,
+			expectErr: true,
+		},
+		{
+			name:      "missing CA file",
+			config:    &rest.Config{},
+			caFile:    filepath.Join(dir, "missing.crt"),
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			err := AddCAFile(tc.config, tc.caFile)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			if tc.config.CAFile != "" {
+				t.Fatalf("Expected CAFile to be cleared, got %s", tc.config.CAFile)
+			}
+			certs, err := tls.DecodePEMCertificates(string(tc.config.CAData))
+			if err != nil {
+				t.Fatalf("Failed to decode CA data: %s", err)
+			}
+			subjects := []string{}
+			for _, cert := range certs {
+				subjects = append(subjects, cert.Subject.CommonName)
+			}
+			if !reflect.DeepEqual(subjects, tc.expectedCAs) {
+				t.Fatalf("Expected CAs %v, got %v", tc.expectedCAs, subjects)
+			}
+		})
+	}
+}
+
 func TestCanonicalResourceNameFromFriendlyName(t *testing.T) {
 	t.Run("Returns canonical name for all known variants", func(t *testing.T) {
 		expectations := map[string]string{
@@ -96,6 +96,11 @@ const (
 	// (e.g. v0.1.3).
 	ProxyVersionAnnotation = Prefix + "/proxy-version"
 
+	// ConfigProvenanceAnnotation is set by the proxy injector, when configured
+	// to report it, to the proxy configuration annotations whose value comes
+	// from the workload or from its namespace, along with their source.
+	ConfigProvenanceAnnotation = Prefix + "/config-provenance"
+
 	// ProxyInjectAnnotation controls whether or not a pod should be injected
 	// when set on a pod spec. When set on a namespace spec, it applies to all
 	// pods in the namespace. Supported values are Enabled or Disabled
@@ -470,6 +475,12 @@ const (
 	// with RemoteDiscoveryAnnotation and the endpoints will be unioned.
 	LocalDiscoveryAnnotation = MulticlusterPrefix + "/local-discovery"
 
+	// RemoteDiscoveryFailoverThresholdAnnotation makes a service using both
+	// LocalDiscoveryAnnotation and RemoteDiscoveryAnnotation prefer its local
+	// endpoints: the remote endpoints are only included while there are fewer
+	// local endpoints than the value of this annotation.
+	RemoteDiscoveryFailoverThresholdAnnotation = MulticlusterPrefix + "/remote-discovery-failover-threshold"
+
 	// RemoteResourceVersionAnnotation is the last observed remote resource
 	// version of a mirrored resource. Useful when doing updates
 	RemoteResourceVersionAnnotation = SvcMirrorPrefix + "/remote-resource-version"
@@ -496,6 +507,10 @@ const (
 	// GatewayProbeTimeout is the probe request timeout
 	GatewayProbeTimeout = SvcMirrorPrefix + "/probe-timeout"
 
+	// LinkEndpointRefreshPeriodAnnotation can be set on a Link to override the
+	// service mirror's endpoint refresh period for that Link's target cluster
+	LinkEndpointRefreshPeriodAnnotation = SvcMirrorPrefix + "/endpoint-refresh-period"
+
 	// ConfigKeyName is the key in the secret that stores the kubeconfig needed to connect
 	// to a remote cluster
 	ConfigKeyName = "kubeconfig"
@@ -507,6 +522,12 @@ const (
 	ProbePortName = "mc-probe"
 )
 
+// DeprecatedAnnotations maps deprecated proxy configuration annotations to
+// the annotation that replaces them, or to an empty string when the
+// annotation has no replacement. None of the annotations supported by this
+// release are deprecated; entries are added here as annotations get replaced.
+var DeprecatedAnnotations = map[string]string{}
+
 // CreatedByAnnotationValue returns the value associated with
 // CreatedByAnnotation.
 func CreatedByAnnotationValue() string {
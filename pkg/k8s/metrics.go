@@ -19,6 +19,19 @@ func GetContainerMetrics(
 	container corev1.Container,
 	emitLogs bool,
 	portName string,
+) ([]byte, error) {
+	return GetContainerPath(k8sAPI, pod, container, emitLogs, portName, "/metrics")
+}
+
+// GetContainerPath returns the response to a GET request for path, made to a
+// container on the passed in portName
+func GetContainerPath(
+	k8sAPI *KubernetesAPI,
+	pod corev1.Pod,
+	container corev1.Container,
+	emitLogs bool,
+	portName string,
+	path string,
 ) ([]byte, error) {
 	portForward, err := NewContainerMetricsForward(k8sAPI, pod, container, emitLogs, portName)
 	if err != nil {
@@ -31,8 +44,7 @@ func GetContainerMetrics(
 		return nil, err
 	}
 
-	metricsURL := portForward.URLFor("/metrics")
-	return getResponse(metricsURL)
+	return getResponse(portForward.URLFor(path))
 }
 
 // getResponse makes a http Get request to the passed url and returns the response/error
@@ -45,5 +57,8 @@ func getResponse(url string) ([]byte, error) {
 		return nil, err
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status from %s: %s", url, resp.Status)
+	}
 	return io.ReadAll(resp.Body)
 }
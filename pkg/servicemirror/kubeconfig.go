@@ -0,0 +1,61 @@
+package servicemirror
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// InvalidKubeconfigError is returned when the remote cluster credentials
+// can't possibly be used to connect to the remote cluster, so that retrying
+// with the same credentials is pointless.
+type InvalidKubeconfigError struct {
+	reason string
+}
+
+func (e *InvalidKubeconfigError) Error() string {
+	return fmt.Sprintf("invalid remote cluster kubeconfig: %s", e.reason)
+}
+
+// ValidateKubeconfig checks that the kubeconfig in creds can be parsed and
+// that its current context references a server URL, a CA and some means of
+// authentication.
+func ValidateKubeconfig(creds []byte) error {
+	config, err := clientcmd.Load(creds)
+	if err != nil {
+		return &InvalidKubeconfigError{fmt.Sprintf("failed to parse: %s", err)}
+	}
+
+	ctxName := config.CurrentContext
+	if ctxName == "" {
+		return &InvalidKubeconfigError{"no current context set"}
+	}
+	kubeCtx, ok := config.Contexts[ctxName]
+	if !ok {
+		return &InvalidKubeconfigError{fmt.Sprintf("context %q not found", ctxName)}
+	}
+
+	cluster, ok := config.Clusters[kubeCtx.Cluster]
+	if !ok {
+		return &InvalidKubeconfigError{fmt.Sprintf("cluster %q not found", kubeCtx.Cluster)}
+	}
+	if cluster.Server == "" {
+		return &InvalidKubeconfigError{fmt.Sprintf("cluster %q has no server URL", kubeCtx.Cluster)}
+	}
+	if len(cluster.CertificateAuthorityData) == 0 && cluster.CertificateAuthority == "" && !cluster.InsecureSkipTLSVerify {
+		return &InvalidKubeconfigError{fmt.Sprintf("cluster %q has no certificate authority", kubeCtx.Cluster)}
+	}
+
+	authInfo, ok := config.AuthInfos[kubeCtx.AuthInfo]
+	if !ok {
+		return &InvalidKubeconfigError{fmt.Sprintf("user %q not found", kubeCtx.AuthInfo)}
+	}
+	hasClientCert := (len(authInfo.ClientCertificateData) > 0 || authInfo.ClientCertificate != "") &&
+		(len(authInfo.ClientKeyData) > 0 || authInfo.ClientKey != "")
+	if authInfo.Token == "" && authInfo.TokenFile == "" && !hasClientCert &&
+		authInfo.Exec == nil && authInfo.AuthProvider == nil && authInfo.Username == "" {
+		return &InvalidKubeconfigError{fmt.Sprintf("user %q has no credentials", kubeCtx.AuthInfo)}
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package servicemirror
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateKubeconfig(t *testing.T) {
+	const (
+		cluster = `
+clusters:
+- name: remote
+  cluster:
+    server: https://remote.example.com:6443
+    certificate-authority-data: Y2EK
+`
+		user = `
+users:
+- name: remote
+  user:
+    token: abc
+`
+		currentContext = `
+contexts:
+- name: remote
+  context:
+    cluster: remote
+    user: remote
+current-context: remote
+`
+	)
+
+	testCases := []struct {
+		name       string
+		kubeconfig string
+		reason     string
+	}{
+		{
+			name:       "valid kubeconfig",
+			kubeconfig: cluster + user + currentContext,
+		},
+		{
+			name:       "unparseable kubeconfig",
+			kubeconfig: "clusters: {",
+			reason:     "failed to parse",
+		},
+		{
+			name:       "empty kubeconfig",
+			kubeconfig: "",
+			reason:     "no current context set",
+		},
+		{
+			name:       "missing context",
+			kubeconfig: cluster + user + "current-context: remote\n",
+			reason:     `context "remote" not found`,
+		},
+		{
+			name:       "missing cluster",
+			kubeconfig: user + currentContext,
+			reason:     `cluster "remote" not found`,
+		},
+		{
+			name: "missing server URL",
+			kubeconfig: `
+clusters:
+- name: remote
+  cluster:
+    certificate-authority-data: Y2EK
+` + user + currentContext,
+			reason: `cluster "remote" has no server URL`,
+		},
+		{
+			name: "missing CA",
+			kubeconfig: `
+clusters:
+- name: remote
+  cluster:
+    server: https://remote.example.com:6443
+` + user + currentContext,
+			reason: `cluster "remote" has no certificate authority`,
+		},
+		{
+			name:       "missing user",
+			kubeconfig: cluster + currentContext,
+			reason:     `user "remote" not found`,
+		},
+		{
+			name: "missing credentials",
+			kubeconfig: cluster + `
+users:
+- name: remote
+  user:
+    client-certificate-data: Y2VydAo=
+` + currentContext,
+			reason: `user "remote" has no credentials`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateKubeconfig([]byte(tc.kubeconfig))
+			if tc.reason == "" {
+				if err != nil {
+					t.Fatalf("Unexpected error: %s", err)
+				}
+				return
+			}
+
+			var invalidErr *InvalidKubeconfigError
+			if !errors.As(err, &invalidErr) {
+				t.Fatalf("Expected an invalid kubeconfig error, got: %v", err)
+			}
+			if !strings.HasPrefix(invalidErr.reason, tc.reason) {
+				t.Fatalf("Expected reason %q, got %q", tc.reason, invalidErr.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+// GitSHA and BuildDate can be set at link time. When they aren't, they're
+// read from the VCS information stamped into the binary by the go tool, if
+// any.
+var (
+	GitSHA    = ""
+	BuildDate = ""
+)
+
+const unknownBuildInfo = "unknown"
+
+// BuildInfo describes the build of the current process.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GitSHA    string `json:"gitSha"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+// GetBuildInfo returns the build information of the current process. Fields
+// that can't be determined are reported as "unknown".
+func GetBuildInfo() BuildInfo {
+	info := BuildInfo{
+		Version:   Version,
+		GitSHA:    GitSHA,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.GitSHA == "" {
+					info.GitSHA = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			}
+		}
+	}
+
+	if info.GitSHA == "" {
+		info.GitSHA = unknownBuildInfo
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = unknownBuildInfo
+	}
+	return info
+}
+
+// BuildInfoHandler serves the build information of the current process as
+// JSON, so that the version of a running component can be audited.
+func BuildInfoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(GetBuildInfo()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
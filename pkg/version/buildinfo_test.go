@@ -0,0 +1,74 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuildInfoHandler(t *testing.T) {
+	defer func(gitSHA, buildDate string) {
+		GitSHA = gitSHA
+		BuildDate = buildDate
+	}(GitSHA, BuildDate)
+
+	testCases := []struct {
+		name      string
+		gitSHA    string
+		buildDate string
+		expected  BuildInfo
+	}{
+		{
+			name:      "set at link time",
+			gitSHA:    "0123abcd",
+			buildDate: "2024-01-02T03:04:05Z",
+			expected:  BuildInfo{Version: Version, GitSHA: "0123abcd", BuildDate: "2024-01-02T03:04:05Z"},
+		},
+		{
+			name:     "unset",
+			expected: BuildInfo{Version: Version, GitSHA: unknownBuildInfo, BuildDate: unknownBuildInfo},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			GitSHA = tc.gitSHA
+			BuildDate = tc.buildDate
+
+			rec := httptest.NewRecorder()
+			BuildInfoHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+			}
+
+			var info BuildInfo
+			if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+				t.Fatalf("Failed to decode response: %s", err)
+			}
+			if info.Version == "" || info.GitSHA == "" || info.BuildDate == "" || info.GoVersion == "" {
+				t.Fatalf("Expected all fields to be populated, got %+v", info)
+			}
+			if info.Version != tc.expected.Version {
+				t.Fatalf("Expected version %q, got %q", tc.expected.Version, info.Version)
+			}
+			// Test binaries aren't stamped with VCS information, so the
+			// fallbacks apply when the fields aren't set at link time.
+			if info.GitSHA != tc.expected.GitSHA {
+				t.Fatalf("Expected git SHA %q, got %q", tc.expected.GitSHA, info.GitSHA)
+			}
+			if info.BuildDate != tc.expected.BuildDate {
+				t.Fatalf("Expected build date %q, got %q", tc.expected.BuildDate, info.BuildDate)
+			}
+		})
+	}
+}
+
+func TestBuildInfoHandlerMethodNotAllowed(t *testing.T) {
+	rec := httptest.NewRecorder()
+	BuildInfoHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/version", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected status %d, got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+}
@@ -113,6 +113,8 @@ func createTestCaseTable(controlNs, endpointNs string) []testCase {
     "pod": "linkerd-destination\-[a-f0-9]+\-[a-z0-9]+",
     "service": "linkerd-dst\.\S*",
     "weight": \d+,
+    "identity": "\S+",
+    "protocolHint": "h2",
     "http2": \{(?s).*\},
     "labels": \{(?s).*\}
   \}
@@ -130,6 +132,8 @@ func createTestCaseTable(controlNs, endpointNs string) []testCase {
     "pod": "linkerd-identity\-[a-f0-9]+\-[a-z0-9]+",
     "service": "linkerd-identity\.\S*",
     "weight": \d+,
+    "identity": "\S+",
+    "protocolHint": "h2",
     "http2": \{(?s).*\},
     "labels": \{(?s).*\}
   \}
@@ -147,6 +151,8 @@ func createTestCaseTable(controlNs, endpointNs string) []testCase {
     "pod": "linkerd-proxy-injector-[a-f0-9]+\-[a-z0-9]+",
     "service": "linkerd-proxy-injector\.\S*",
     "weight": \d+,
+    "identity": "\S+",
+    "protocolHint": "h2",
     "http2": \{(?s).*\},
     "labels": \{(?s).*\}
   \}
@@ -164,6 +170,8 @@ func createTestCaseTable(controlNs, endpointNs string) []testCase {
     "pod": "nginx-[a-f0-9]+\-[a-z0-9]+",
     "service": "nginx\.\S*",
     "weight": \d+,
+    "identity": "\S+",
+    "protocolHint": "h2",
     "http2": \{(?s).*\},
     "labels": \{(?s).*\}
   \}
@@ -9,6 +9,7 @@ import (
 	"io"
 	"os"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/golang/protobuf/ptypes/duration"
@@ -45,17 +46,23 @@ type tapOptions struct {
 	labelSelector string
 }
 
+// endpoint is the address of a tap event's peer, along with the labels of the
+// resources it belongs to.
 type endpoint struct {
 	IP       string            `json:"ip"`
 	Port     uint32            `json:"port"`
 	Metadata map[string]string `json:"metadata"`
 }
 
+// streamID identifies a request across its request init, response init and
+// response end events.
 type streamID struct {
 	Base   uint32 `json:"base"`
 	Stream uint64 `json:"stream"`
 }
 
+// metadata is a header or trailer, rendered as either a metadataStr or, for
+// values that aren't valid UTF-8, a base64-encoded metadataBin.
 type metadata interface {
 	isMetadata()
 }
@@ -74,6 +81,7 @@ type metadataBin struct {
 
 func (*metadataBin) isMetadata() {}
 
+// requestInitEvent is emitted when a request's headers are sent.
 type requestInitEvent struct {
 	ID        *streamID  `json:"id"`
 	Method    string     `json:"method"`
@@ -81,8 +89,12 @@ type requestInitEvent struct {
 	Authority string     `json:"authority"`
 	Path      string     `json:"path"`
 	Headers   []metadata `json:"headers"`
+	// GrpcMethod is the full method name (package.Service/Method) of gRPC
+	// requests, and is omitted for other requests.
+	GrpcMethod string `json:"grpcMethod,omitempty"`
 }
 
+// responseInitEvent is emitted when a response's headers are received.
 type responseInitEvent struct {
 	ID               *streamID          `json:"id"`
 	SinceRequestInit *duration.Duration `json:"sinceRequestInit"`
@@ -90,6 +102,9 @@ type responseInitEvent struct {
 	Headers          []metadata         `json:"headers"`
 }
 
+// responseEndEvent is emitted when a response's stream ends. GrpcStatusCode
+// is only meaningful for gRPC responses, and ResetErrorCode is only set for
+// streams ended by a reset.
 type responseEndEvent struct {
 	ID                *streamID          `json:"id"`
 	SinceRequestInit  *duration.Duration `json:"sinceRequestInit"`
@@ -98,9 +113,18 @@ type responseEndEvent struct {
 	Trailers          []metadata         `json:"trailers"`
 	GrpcStatusCode    uint32             `json:"grpcStatusCode"`
 	ResetErrorCode    uint32             `json:"resetErrorCode,omitempty"`
-}
-
-// Private type used for displaying JSON encoded tap events
+	// GrpcStatus is the name of the gRPC status (e.g. "Unavailable"), and is
+	// omitted for responses without one.
+	GrpcStatus string `json:"grpcStatus,omitempty"`
+}
+
+// tapEvent is the JSON representation of a tap event. With `--output json`,
+// each event is written as a single line, so that the output can be piped
+// into tools like jq. Exactly one of RequestInitEvent, ResponseInitEvent and
+// ResponseEndEvent is set.
+//
+// This is part of the CLI's output format: fields may be added, but existing
+// fields must not be renamed or removed.
 type tapEvent struct {
 	Source            *endpoint          `json:"source"`
 	Destination       *endpoint          `json:"destination"`
@@ -169,6 +193,7 @@ func NewCmdTap() *cobra.Command {
   * rs/my-replicaset
   * sts
   * sts/my-statefulset
+  * svc/my-service
 
   Valid resource types include:
   * cronjobs
@@ -179,8 +204,8 @@ func NewCmdTap() *cobra.Command {
   * pods
   * replicasets
   * replicationcontrollers
-  * statefulsets
-  * services (only supported as a --to resource)`,
+  * services
+  * statefulsets`,
 		Example: `  # tap the web deployment in the default namespace
   linkerd viz tap deploy/web
 
@@ -233,6 +258,10 @@ func NewCmdTap() *cobra.Command {
 				VizNamespaceOverride: vizNamespace,
 			})
 
+			// Headers are only extracted for the JSON output. The default
+			// output identifies gRPC requests by their method and path, and
+			// gRPC responses by the grpc-status the proxy reports when their
+			// stream ends, neither of which needs them.
 			requestParams := pkg.TapRequestParams{
 				Resource:      strings.Join(args, "/"),
 				Namespace:     options.namespace,
@@ -291,7 +320,7 @@ func NewCmdTap() *cobra.Command {
 	cmd.PersistentFlags().StringVar(&options.path, "path", options.path,
 		"Display requests with paths that start with this prefix")
 	cmd.PersistentFlags().StringVarP(&options.output, "output", "o", options.output,
-		fmt.Sprintf("Output format. One of: \"%s\", \"%s\", \"%s\"", wideOutput, jsonOutput, jsonPathOutput))
+		fmt.Sprintf("Output format. One of: \"%s\", \"%s\" (one JSON object per line), \"%s\"", wideOutput, jsonOutput, jsonPathOutput))
 	cmd.PersistentFlags().StringVarP(&options.labelSelector, "selector", "l", options.labelSelector,
 		"Selector (label query) to filter on, supports '=', '==', and '!='")
 
@@ -391,7 +420,7 @@ func renderTapEvent(event *tapPb.TapEvent, _ ...renderOptions) string {
 
 	switch ev := event.GetHttp().GetEvent().(type) {
 	case *tapPb.TapEvent_Http_RequestInit_:
-		return fmt.Sprintf("req id=%d:%d %s :method=%s :authority=%s :path=%s",
+		req := fmt.Sprintf("req id=%d:%d %s :method=%s :authority=%s :path=%s",
 			ev.RequestInit.GetId().GetBase(),
 			ev.RequestInit.GetId().GetStream(),
 			flow,
@@ -399,6 +428,10 @@ func renderTapEvent(event *tapPb.TapEvent, _ ...renderOptions) string {
 			ev.RequestInit.GetAuthority(),
 			ev.RequestInit.GetPath(),
 		)
+		if method := grpcMethod(ev.RequestInit); method != "" {
+			req = fmt.Sprintf("%s grpc-method=%s", req, method)
+		}
+		return req
 
 	case *tapPb.TapEvent_Http_ResponseInit_:
 		return fmt.Sprintf("rsp id=%d:%d %s :status=%d latency=%dµs",
@@ -410,18 +443,19 @@ func renderTapEvent(event *tapPb.TapEvent, _ ...renderOptions) string {
 		)
 
 	case *tapPb.TapEvent_Http_ResponseEnd_:
-		switch eos := ev.ResponseEnd.GetEos().GetEnd().(type) {
-		case *metricsPb.Eos_GrpcStatusCode:
+		if code, ok := grpcStatus(ev.ResponseEnd); ok {
 			return fmt.Sprintf(
 				"end id=%d:%d %s grpc-status=%s duration=%dµs response-length=%dB",
 				ev.ResponseEnd.GetId().GetBase(),
 				ev.ResponseEnd.GetId().GetStream(),
 				flow,
-				codes.Code(eos.GrpcStatusCode),
+				code,
 				ev.ResponseEnd.GetSinceResponseInit().AsDuration().Microseconds(),
 				ev.ResponseEnd.GetResponseBytes(),
 			)
+		}
 
+		switch eos := ev.ResponseEnd.GetEos().GetEnd().(type) {
 		case *metricsPb.Eos_ResetErrorCode:
 			return fmt.Sprintf(
 				"end id=%d:%d %s reset-error=%+v duration=%dµs response-length=%dB",
@@ -448,7 +482,7 @@ func renderTapEvent(event *tapPb.TapEvent, _ ...renderOptions) string {
 	}
 }
 
-// renderTapEventJSON renders a Public API TapEvent to a string in JSON format.
+// renderTapEventJSON renders a Public API TapEvent to a single line of JSON.
 func renderTapEventJSON(event *tapPb.TapEvent, opts ...renderOptions) string {
 	filter := &renderFilter{}
 	for _, opt := range opts {
@@ -462,7 +496,7 @@ func renderTapEventJSON(event *tapPb.TapEvent, opts ...renderOptions) string {
 		}
 		return filteredJson[0]
 	}
-	e, err := json.MarshalIndent(m, "", "  ")
+	e, err := json.Marshal(m)
 	if err != nil {
 		return fmt.Sprintf("{\"error marshalling JSON\": \"%s\"}", err)
 	}
@@ -509,12 +543,13 @@ func getRequestInitEvent(pubEv *tapPb.TapEvent_Http) *requestInitEvent {
 		Stream: reqI.GetId().GetStream(),
 	}
 	return &requestInitEvent{
-		ID:        sid,
-		Method:    formatMethod(reqI.GetMethod()),
-		Scheme:    formatScheme(reqI.GetScheme()),
-		Authority: reqI.GetAuthority(),
-		Path:      reqI.GetPath(),
-		Headers:   formatHeadersTrailers(reqI.GetHeaders()),
+		ID:         sid,
+		Method:     formatMethod(reqI.GetMethod()),
+		Scheme:     formatScheme(reqI.GetScheme()),
+		Authority:  reqI.GetAuthority(),
+		Path:       reqI.GetPath(),
+		Headers:    formatHeadersTrailers(reqI.GetHeaders()),
+		GrpcMethod: grpcMethod(reqI),
 	}
 }
 
@@ -566,15 +601,64 @@ func getResponseEndEvent(pubEv *tapPb.TapEvent_Http) *responseEndEvent {
 		Base:   resE.GetId().GetBase(),
 		Stream: resE.GetId().GetStream(),
 	}
-	return &responseEndEvent{
+	ev := &responseEndEvent{
 		ID:                sid,
 		SinceRequestInit:  resE.GetSinceRequestInit(),
 		SinceResponseInit: resE.GetSinceResponseInit(),
 		ResponseBytes:     resE.GetResponseBytes(),
 		Trailers:          formatHeadersTrailers(resE.GetTrailers()),
-		GrpcStatusCode:    resE.GetEos().GetGrpcStatusCode(),
 		ResetErrorCode:    resE.GetEos().GetResetErrorCode(),
 	}
+	if code, ok := grpcStatus(resE); ok {
+		ev.GrpcStatusCode = uint32(code)
+		ev.GrpcStatus = code.String()
+	}
+	return ev
+}
+
+// grpcMethod returns the full method name of a gRPC request, taken from its
+// path, or an empty string if the request isn't gRPC. When the request's
+// headers were extracted, it's identified as gRPC by its content-type.
+// Otherwise, it's identified by being a POST to a path of the form
+// /package.Service/Method.
+func grpcMethod(req *tapPb.TapEvent_Http_RequestInit) string {
+	if headers := req.GetHeaders().GetHeaders(); len(headers) > 0 {
+		for _, h := range headers {
+			if strings.EqualFold(h.GetName(), "content-type") && strings.HasPrefix(h.GetValueStr(), "application/grpc") {
+				return strings.TrimPrefix(req.GetPath(), "/")
+			}
+		}
+		return ""
+	}
+
+	if vizutil.HTTPMethodToString(req.GetMethod()) != metricsPb.HttpMethod_POST.String() {
+		return ""
+	}
+	path, ok := strings.CutPrefix(req.GetPath(), "/")
+	if !ok {
+		return ""
+	}
+	service, method, ok := strings.Cut(path, "/")
+	if !ok || !strings.Contains(service, ".") || method == "" || strings.ContainsAny(method, "/?") {
+		return ""
+	}
+	return path
+}
+
+// grpcStatus returns the gRPC status of a response, as reported by the proxy
+// when the stream ended or, failing that, from its grpc-status trailer.
+func grpcStatus(end *tapPb.TapEvent_Http_ResponseEnd) (codes.Code, bool) {
+	if eos, ok := end.GetEos().GetEnd().(*metricsPb.Eos_GrpcStatusCode); ok {
+		return codes.Code(eos.GrpcStatusCode), true
+	}
+	for _, h := range end.GetTrailers().GetHeaders() {
+		if strings.EqualFold(h.GetName(), "grpc-status") {
+			if code, err := strconv.ParseUint(h.GetValueStr(), 10, 32); err == nil {
+				return codes.Code(code), true
+			}
+		}
+	}
+	return 0, false
 }
 
 func formatHeadersTrailers(hs *metricsPb.Headers) []metadata {
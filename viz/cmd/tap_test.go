@@ -3,9 +3,11 @@ package cmd
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -307,7 +309,7 @@ func TestEventToString(t *testing.T) {
 			},
 		})
 
-		expectedOutput := "req id=7:8 proxy=out src=1.2.3.4:5555 dst=2.3.4.5:6666 tls= :method=POST :authority=hello.default:7777 :path=/hello.v1.HelloService/Hello"
+		expectedOutput := "req id=7:8 proxy=out src=1.2.3.4:5555 dst=2.3.4.5:6666 tls= :method=POST :authority=hello.default:7777 :path=/hello.v1.HelloService/Hello grpc-method=hello.v1.HelloService/Hello"
 		output := renderTapEvent(event)
 		if output != expectedOutput {
 			t.Fatalf("Expecting command output to be [%s], got [%s]", expectedOutput, output)
@@ -420,3 +422,270 @@ func TestEventToString(t *testing.T) {
 		}
 	})
 }
+
+func TestRenderTapEventJSON(t *testing.T) {
+	srcIP, _ := addr.ParsePublicIP("1.2.3.4")
+	destIP, _ := addr.ParsePublicIP("2.3.4.5")
+	toTapEvent := func(httpEvent *tapPb.TapEvent_Http) *tapPb.TapEvent {
+		return &tapPb.TapEvent{
+			ProxyDirection: tapPb.TapEvent_INBOUND,
+			Source: &netPb.TcpAddress{
+				Ip:   srcIP,
+				Port: 5555,
+			},
+			SourceMeta: &tapPb.TapEvent_EndpointMeta{
+				Labels: map[string]string{"pod": "web"},
+			},
+			Destination: &netPb.TcpAddress{
+				Ip:   destIP,
+				Port: 6666,
+			},
+			RouteMeta: &tapPb.TapEvent_RouteMeta{
+				Labels: map[string]string{"route": "hello"},
+			},
+			Event: &tapPb.TapEvent_Http_{Http: httpEvent},
+		}
+	}
+	peers := `"source":{"ip":"1.2.3.4","port":5555,"metadata":{"pod":"web"}},` +
+		`"destination":{"ip":"2.3.4.5","port":6666,"metadata":null},` +
+		`"routeMeta":{"route":"hello"},"proxyDirection":"INBOUND"`
+
+	testCases := []struct {
+		name     string
+		event    *tapPb.TapEvent
+		expected string
+	}{
+		{
+			name: "request init",
+			event: toTapEvent(&tapPb.TapEvent_Http{
+				Event: &tapPb.TapEvent_Http_RequestInit_{
+					RequestInit: &tapPb.TapEvent_Http_RequestInit{
+						Id: &tapPb.TapEvent_Http_StreamId{Base: 7, Stream: 8},
+						Method: &metricsPb.HttpMethod{
+							Type: &metricsPb.HttpMethod_Registered_{
+								Registered: metricsPb.HttpMethod_POST,
+							},
+						},
+						Scheme: &metricsPb.Scheme{
+							Type: &metricsPb.Scheme_Registered_{
+								Registered: metricsPb.Scheme_HTTPS,
+							},
+						},
+						Authority: "hello.default:7777",
+						Path:      "/hello.v1.HelloService/Hello",
+						Headers: &metricsPb.Headers{
+							Headers: []*metricsPb.Headers_Header{
+								{Name: "content-type", Value: &metricsPb.Headers_Header_ValueStr{ValueStr: "application/grpc"}},
+								{Name: "x-bin", Value: &metricsPb.Headers_Header_ValueBin{ValueBin: []byte{0xff}}},
+							},
+						},
+					},
+				},
+			}),
+			expected: `{` + peers + `,"requestInitEvent":{"id":{"base":7,"stream":8},"method":"POST","scheme":"HTTPS",` +
+				`"authority":"hello.default:7777","path":"/hello.v1.HelloService/Hello",` +
+				`"headers":[{"name":"content-type","valueStr":"application/grpc"},{"name":"x-bin","valueBin":"/w=="}],` +
+				`"grpcMethod":"hello.v1.HelloService/Hello"}}`,
+		},
+		{
+			name: "response init",
+			event: toTapEvent(&tapPb.TapEvent_Http{
+				Event: &tapPb.TapEvent_Http_ResponseInit_{
+					ResponseInit: &tapPb.TapEvent_Http_ResponseInit{
+						Id:               &tapPb.TapEvent_Http_StreamId{Base: 7, Stream: 8},
+						SinceRequestInit: &duration.Duration{Seconds: 1, Nanos: 500},
+						HttpStatus:       http.StatusOK,
+					},
+				},
+			}),
+			expected: `{` + peers + `,"responseInitEvent":{"id":{"base":7,"stream":8},` +
+				`"sinceRequestInit":{"seconds":1,"nanos":500},"httpStatus":200,"headers":null}}`,
+		},
+		{
+			name: "response end",
+			event: toTapEvent(&tapPb.TapEvent_Http{
+				Event: &tapPb.TapEvent_Http_ResponseEnd_{
+					ResponseEnd: &tapPb.TapEvent_Http_ResponseEnd{
+						Id:                &tapPb.TapEvent_Http_StreamId{Base: 7, Stream: 8},
+						SinceRequestInit:  &duration.Duration{Seconds: 2},
+						SinceResponseInit: &duration.Duration{Seconds: 1},
+						ResponseBytes:     1337,
+						Eos: &metricsPb.Eos{
+							End: &metricsPb.Eos_GrpcStatusCode{GrpcStatusCode: uint32(codes.Unavailable)},
+						},
+						Trailers: &metricsPb.Headers{
+							Headers: []*metricsPb.Headers_Header{
+								{Name: "grpc-status", Value: &metricsPb.Headers_Header_ValueStr{ValueStr: "14"}},
+							},
+						},
+					},
+				},
+			}),
+			expected: `{` + peers + `,"responseEndEvent":{"id":{"base":7,"stream":8},` +
+				`"sinceRequestInit":{"seconds":2},"sinceResponseInit":{"seconds":1},"responseBytes":1337,` +
+				`"trailers":[{"name":"grpc-status","valueStr":"14"}],"grpcStatusCode":14,"grpcStatus":"Unavailable"}}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			output := renderTapEventJSON(tc.event)
+			if strings.Contains(output, "\n") {
+				t.Fatalf("Expected a single line of JSON, got:\n%s", output)
+			}
+
+			var actual, expected map[string]interface{}
+			if err := json.Unmarshal([]byte(output), &actual); err != nil {
+				t.Fatalf("Failed to parse rendered event %s: %s", output, err)
+			}
+			if err := json.Unmarshal([]byte(tc.expected), &expected); err != nil {
+				t.Fatalf("Failed to parse expected event: %s", err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Fatalf("Expected event to round-trip to\n%s\ngot\n%s", tc.expected, output)
+			}
+		})
+	}
+}
+
+func TestRenderGrpcTapEvents(t *testing.T) {
+	grpcHeaders := &metricsPb.Headers{
+		Headers: []*metricsPb.Headers_Header{
+			{Name: "content-type", Value: &metricsPb.Headers_Header_ValueStr{ValueStr: "application/grpc+proto"}},
+		},
+	}
+	toTapEvent := func(httpEvent *tapPb.TapEvent_Http) *tapPb.TapEvent {
+		return &tapPb.TapEvent{
+			ProxyDirection: tapPb.TapEvent_OUTBOUND,
+			Event:          &tapPb.TapEvent_Http_{Http: httpEvent},
+		}
+	}
+	requestInit := func(headers *metricsPb.Headers) *tapPb.TapEvent {
+		return toTapEvent(&tapPb.TapEvent_Http{
+			Event: &tapPb.TapEvent_Http_RequestInit_{
+				RequestInit: &tapPb.TapEvent_Http_RequestInit{
+					Id:      &tapPb.TapEvent_Http_StreamId{Base: 7, Stream: 8},
+					Path:    "/emojivoto.v1.EmojiService/ListAll",
+					Headers: headers,
+				},
+			},
+		})
+	}
+	postWithoutHeaders := func(path string) *tapPb.TapEvent {
+		return toTapEvent(&tapPb.TapEvent_Http{
+			Event: &tapPb.TapEvent_Http_RequestInit_{
+				RequestInit: &tapPb.TapEvent_Http_RequestInit{
+					Id: &tapPb.TapEvent_Http_StreamId{Base: 7, Stream: 8},
+					Method: &metricsPb.HttpMethod{
+						Type: &metricsPb.HttpMethod_Registered_{
+							Registered: metricsPb.HttpMethod_POST,
+						},
+					},
+					Path: path,
+				},
+			},
+		})
+	}
+	responseEnd := func(eos *metricsPb.Eos, trailers *metricsPb.Headers) *tapPb.TapEvent {
+		return toTapEvent(&tapPb.TapEvent_Http{
+			Event: &tapPb.TapEvent_Http_ResponseEnd_{
+				ResponseEnd: &tapPb.TapEvent_Http_ResponseEnd{
+					Id:       &tapPb.TapEvent_Http_StreamId{Base: 7, Stream: 8},
+					Eos:      eos,
+					Trailers: trailers,
+				},
+			},
+		})
+	}
+
+	testCases := []struct {
+		name           string
+		event          *tapPb.TapEvent
+		expectedOutput string
+		expectedMethod string
+		expectedCode   uint32
+		expectedStatus string
+	}{
+		{
+			name:           "gRPC request",
+			event:          requestInit(grpcHeaders),
+			expectedOutput: "req id=7:8 proxy=out src=:0 dst=:0 tls= :method=GET :authority= :path=/emojivoto.v1.EmojiService/ListAll grpc-method=emojivoto.v1.EmojiService/ListAll",
+			expectedMethod: "emojivoto.v1.EmojiService/ListAll",
+		},
+		{
+			name: "HTTP request",
+			event: requestInit(&metricsPb.Headers{
+				Headers: []*metricsPb.Headers_Header{
+					{Name: "content-type", Value: &metricsPb.Headers_Header_ValueStr{ValueStr: "application/json"}},
+				},
+			}),
+			expectedOutput: "req id=7:8 proxy=out src=:0 dst=:0 tls= :method=GET :authority= :path=/emojivoto.v1.EmojiService/ListAll",
+		},
+		{
+			// Headers are only extracted for the JSON output, so the default
+			// output only shows the method of POSTs to gRPC paths
+			name:           "request without headers",
+			event:          requestInit(nil),
+			expectedOutput: "req id=7:8 proxy=out src=:0 dst=:0 tls= :method=GET :authority= :path=/emojivoto.v1.EmojiService/ListAll",
+		},
+		{
+			name:           "gRPC request without headers",
+			event:          postWithoutHeaders("/emojivoto.v1.EmojiService/ListAll"),
+			expectedOutput: "req id=7:8 proxy=out src=:0 dst=:0 tls= :method=POST :authority= :path=/emojivoto.v1.EmojiService/ListAll grpc-method=emojivoto.v1.EmojiService/ListAll",
+			expectedMethod: "emojivoto.v1.EmojiService/ListAll",
+		},
+		{
+			name:           "HTTP request without headers",
+			event:          postWithoutHeaders("/api/vote?choice=:doughnut:"),
+			expectedOutput: "req id=7:8 proxy=out src=:0 dst=:0 tls= :method=POST :authority= :path=/api/vote?choice=:doughnut:",
+		},
+		{
+			name: "gRPC status from end of stream",
+			event: responseEnd(&metricsPb.Eos{
+				End: &metricsPb.Eos_GrpcStatusCode{GrpcStatusCode: uint32(codes.NotFound)},
+			}, nil),
+			expectedOutput: "end id=7:8 proxy=out src=:0 dst=:0 tls= grpc-status=NotFound duration=0µs response-length=0B",
+			expectedCode:   uint32(codes.NotFound),
+			expectedStatus: "NotFound",
+		},
+		{
+			name: "gRPC status from trailers",
+			event: responseEnd(nil, &metricsPb.Headers{
+				Headers: []*metricsPb.Headers_Header{
+					{Name: "grpc-status", Value: &metricsPb.Headers_Header_ValueStr{ValueStr: "0"}},
+				},
+			}),
+			expectedOutput: "end id=7:8 proxy=out src=:0 dst=:0 tls= grpc-status=OK duration=0µs response-length=0B",
+			expectedStatus: "OK",
+		},
+		{
+			name:           "HTTP response",
+			event:          responseEnd(nil, nil),
+			expectedOutput: "end id=7:8 proxy=out src=:0 dst=:0 tls= duration=0µs response-length=0B",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			output := renderTapEvent(tc.event)
+			if output != tc.expectedOutput {
+				t.Fatalf("Expecting command output to be [%s], got [%s]", tc.expectedOutput, output)
+			}
+
+			ev := mapPublicToDisplayTapEvent(tc.event)
+			if ev.RequestInitEvent != nil && ev.RequestInitEvent.GrpcMethod != tc.expectedMethod {
+				t.Fatalf("Expected gRPC method [%s], got [%s]", tc.expectedMethod, ev.RequestInitEvent.GrpcMethod)
+			}
+			if ev.ResponseEndEvent != nil {
+				if ev.ResponseEndEvent.GrpcStatusCode != tc.expectedCode {
+					t.Fatalf("Expected gRPC status code %d, got %d", tc.expectedCode, ev.ResponseEndEvent.GrpcStatusCode)
+				}
+				if ev.ResponseEndEvent.GrpcStatus != tc.expectedStatus {
+					t.Fatalf("Expected gRPC status [%s], got [%s]", tc.expectedStatus, ev.ResponseEndEvent.GrpcStatus)
+				}
+			}
+		})
+	}
+}
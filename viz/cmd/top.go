@@ -304,6 +304,7 @@ func NewCmdTop() *cobra.Command {
   * rs/my-replicaset
   * sts
   * sts/my-statefulset
+  * svc/my-service
 
   Valid resource types include:
   * cronjobs
@@ -314,8 +315,8 @@ func NewCmdTop() *cobra.Command {
   * pods
   * replicasets
   * replicationcontrollers
-  * statefulsets
-  * services (only supported as a --to resource)`,
+  * services
+  * statefulsets`,
 		Example: `  # display traffic for the web deployment in the default namespace
   linkerd viz top deploy/web
 
@@ -11,6 +11,7 @@ import (
 )
 
 func (s *grpcServer) Authz(ctx context.Context, req *pb.AuthzRequest) (*pb.AuthzResponse, error) {
+	req.TimeWindow = s.timeWindow(req.TimeWindow)
 
 	// check for well-formed request
 	if req.GetResource() == nil {
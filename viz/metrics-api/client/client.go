@@ -8,6 +8,10 @@ import (
 	"go.opencensus.io/plugin/ocgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+
+	// Registers the gzip compressor, so that clients advertise support for
+	// gzip-compressed responses.
+	_ "google.golang.org/grpc/encoding/gzip"
 )
 
 const (
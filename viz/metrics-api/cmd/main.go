@@ -25,7 +25,7 @@ func main() {
 
 	addr := cmd.String("addr", ":8085", "address to serve on")
 	kubeConfigPath := cmd.String("kubeconfig", "", "path to kube config")
-	prometheusURL := cmd.String("prometheus-url", "", "prometheus url")
+	prometheusURL := cmd.String("prometheus-url", "", "comma separated list of prometheus urls; queries fail over to the next url when one errors")
 	prometheusUser := cmd.String("prometheus-user-file", "", "file containing username for prometheus basic auth")
 	prometheusPassword := cmd.String("prometheus-password-file", "", "file containing password for prometheus basic auth")
 	metricsAddr := cmd.String("metrics-addr", ":9995", "address to serve scrapable metrics on")
@@ -33,11 +33,19 @@ func main() {
 	ignoredNamespaces := cmd.String("ignore-namespaces", "kube-system", "comma separated list of namespaces to not list pods from")
 	clusterDomain := cmd.String("cluster-domain", "cluster.local", "kubernetes cluster domain")
 	enablePprof := cmd.Bool("enable-pprof", false, "Enable pprof endpoints on the admin server")
+	defaultWindow := cmd.String("default-window", api.DefaultWindow, "time window of stat queries that don't set one, between 15s and 24h")
+	enableGzip := cmd.Bool("enable-gzip", true, "compress responses with gzip for clients that support it")
+	queryCacheTTL := cmd.Duration("query-cache-ttl", 0, "how long to serve identical prometheus queries from a cache (0 disables the cache)")
 
 	traceCollector := flags.AddTraceFlags(cmd)
+	watchNamespaces := flags.AddWatchNamespaceFlag(cmd)
 
 	flags.ConfigureAndParse(cmd, os.Args[1:])
 
+	if err := api.ValidateDefaultWindow(*defaultWindow); err != nil {
+		log.Fatalf("invalid --default-window: %s", err)
+	}
+
 	ready := false
 	adminServer := admin.NewServer(*metricsAddr, *enablePprof, &ready)
 
@@ -53,20 +61,22 @@ func main() {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
-	k8sAPI, err := k8s.InitializeAPI(
+	k8sAPI, err := k8s.InitializeAPIForNamespaces(
 		ctx,
 		*kubeConfigPath,
 		true,
 		"local",
+		*watchNamespaces,
+		k8s.ExtWorkloadSelector{},
 		k8s.CJ, k8s.DS, k8s.Deploy, k8s.Job, k8s.NS, k8s.Pod, k8s.RC, k8s.RS, k8s.Svc, k8s.SS, k8s.SP,
 	)
 	if err != nil {
 		log.Fatalf("Failed to initialize K8s API: %s", err)
 	}
 
-	var prometheusClient promApi.Client
+	var promBackends []api.PrometheusBackend
 	if *prometheusURL != "" {
-		promConfig := promApi.Config{Address: *prometheusURL}
+		roundTripper := promApi.DefaultRoundTripper
 		if *prometheusUser != "" && *prometheusPassword != "" {
 			user, err := os.ReadFile(*prometheusUser)
 			if err != nil {
@@ -76,7 +86,7 @@ func main() {
 			if err != nil {
 				log.Fatalf("failed to read file containing password for prometheus basic auth: %s", err)
 			}
-			promConfig.RoundTripper = config.NewBasicAuthRoundTripper(
+			roundTripper = config.NewBasicAuthRoundTripper(
 				config.NewInlineSecret(string(user)),
 				config.NewInlineSecret(string(password)),
 				promApi.DefaultRoundTripper,
@@ -84,13 +94,23 @@ func main() {
 		} else if *prometheusUser != "" || *prometheusPassword != "" {
 			log.Fatal("both prometheus-user-file and prometheus-password-file must be set")
 		}
-		prometheusClient, err = promApi.NewClient(promConfig)
-		if err != nil {
-			log.Fatal(err.Error())
+		for _, url := range strings.Split(*prometheusURL, ",") {
+			url = strings.TrimSpace(url)
+			if url == "" {
+				continue
+			}
+			prometheusClient, err := promApi.NewClient(promApi.Config{Address: url, RoundTripper: roundTripper})
+			if err != nil {
+				log.Fatal(err.Error())
+			}
+			log.Infof("Using prometheus backend %s", url)
+			promBackends = append(promBackends, api.PrometheusBackend{
+				URL: url,
+				API: promv1.NewAPI(prometheusClient),
+			})
 		}
 	}
 
-	log.Infof("prometheusClient: %#v", prometheusClient)
 	log.Info("Using cluster domain: ", *clusterDomain)
 
 	if *traceCollector != "" {
@@ -100,8 +120,11 @@ func main() {
 	}
 
 	var promAPI promv1.API
-	if prometheusClient != nil {
-		promAPI = promv1.NewAPI(prometheusClient)
+	if len(promBackends) > 0 {
+		promAPI, err = api.NewFailoverPrometheusAPI(promBackends)
+		if err != nil {
+			log.Fatal(err.Error())
+		}
 	}
 
 	server := api.NewGrpcServer(
@@ -110,6 +133,9 @@ func main() {
 		*controllerNamespace,
 		*clusterDomain,
 		strings.Split(*ignoredNamespaces, ","),
+		*queryCacheTTL,
+		*defaultWindow,
+		*enableGzip,
 	)
 
 	k8sAPI.Sync(nil) // blocks until caches are synced
@@ -0,0 +1,31 @@
+package api
+
+import (
+	"context"
+	"slices"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/encoding/gzip"
+)
+
+// compressionInterceptor picks how responses are compressed. When gzip is
+// enabled, clients advertising support for it in grpc-accept-encoding get
+// gzip-compressed responses; every other response is left uncompressed, even
+// if the request itself was compressed.
+func compressionInterceptor(enableGzip bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		compressor := encoding.Identity
+		if enableGzip {
+			supported, err := grpc.ClientSupportedCompressors(ctx)
+			if err == nil && slices.Contains(supported, gzip.Name) {
+				compressor = gzip.Name
+			}
+		}
+		if err := grpc.SetSendCompressor(ctx, compressor); err != nil {
+			log.Debugf("Failed to set %s response compression for %s: %s", compressor, info.FullMethod, err)
+		}
+		return handler(ctx, req)
+	}
+}
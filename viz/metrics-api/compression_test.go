@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	pb "github.com/linkerd/linkerd2/viz/metrics-api/gen/viz"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/stats"
+)
+
+// compressionRecorder is a client stats.Handler recording the compression
+// of the responses received.
+type compressionRecorder struct {
+	sync.Mutex
+	compression string
+}
+
+func (r *compressionRecorder) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (r *compressionRecorder) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if header, ok := s.(*stats.InHeader); ok {
+		r.Lock()
+		r.compression = header.Compression
+		r.Unlock()
+	}
+}
+
+func (r *compressionRecorder) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (r *compressionRecorder) HandleConn(context.Context, stats.ConnStats) {}
+
+func TestCompressionInterceptor(t *testing.T) {
+	testCases := []struct {
+		name        string
+		enableGzip  bool
+		compressReq bool
+		expected    string
+	}{
+		{"gzip enabled", true, false, "gzip"},
+		{"gzip disabled", false, false, ""},
+		{"gzip disabled with a compressed request", false, true, ""},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			_, fakeGrpcServer, err := newMockGrpcServer(expectedStatRPC{})
+			if err != nil {
+				t.Fatalf("Error creating mock grpc server: %s", err)
+			}
+
+			server := grpc.NewServer(grpc.ChainUnaryInterceptor(compressionInterceptor(tc.enableGzip)))
+			pb.RegisterApiServer(server, fakeGrpcServer)
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("Failed to listen: %s", err)
+			}
+			go server.Serve(lis)
+			defer server.Stop()
+
+			recorder := &compressionRecorder{}
+			conn, err := grpc.NewClient(lis.Addr().String(),
+				grpc.WithTransportCredentials(insecure.NewCredentials()),
+				grpc.WithStatsHandler(recorder),
+			)
+			if err != nil {
+				t.Fatalf("Failed to create client: %s", err)
+			}
+			defer conn.Close()
+
+			var opts []grpc.CallOption
+			if tc.compressReq {
+				opts = append(opts, grpc.UseCompressor("gzip"))
+			}
+			_, err = pb.NewApiClient(conn).ListServices(context.Background(), &pb.ListServicesRequest{Namespace: "emojivoto"}, opts...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			recorder.Lock()
+			defer recorder.Unlock()
+			compression := recorder.compression
+			if compression == "identity" {
+				compression = ""
+			}
+			if compression != tc.expected {
+				t.Fatalf("Expected response compression %q, got %q", tc.expected, compression)
+			}
+		})
+	}
+}
@@ -19,7 +19,7 @@ const (
 
 func (s *grpcServer) Gateways(ctx context.Context, req *pb.GatewaysRequest) (*pb.GatewaysResponse, error) {
 	array := []*pb.GatewaysTable_Row{}
-	metrics, err := s.getGatewaysMetrics(ctx, req, req.TimeWindow)
+	metrics, err := s.getGatewaysMetrics(ctx, req, s.timeWindow(req.TimeWindow))
 
 	if err != nil {
 		return nil, err
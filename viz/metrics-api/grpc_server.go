@@ -13,6 +13,7 @@ import (
 	pb "github.com/linkerd/linkerd2/viz/metrics-api/gen/viz"
 	"github.com/linkerd/linkerd2/viz/metrics-api/util"
 	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
 	log "github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
 	"gopkg.in/yaml.v2"
@@ -32,6 +33,10 @@ type grpcServer struct {
 	controllerNamespace string
 	clusterDomain       string
 	ignoredNamespaces   []string
+	queryCache          *queryCache
+
+	// defaultWindow is the time window used by requests that don't set one.
+	defaultWindow string
 }
 
 type podReport struct {
@@ -45,6 +50,14 @@ const (
 	k8sClientCheckDescription  = "linkerd viz can talk to Kubernetes"
 	promClientSubsystemName    = "prometheus"
 	promClientCheckDescription = "linkerd viz can talk to Prometheus"
+
+	// DefaultWindow is the default time window of stat queries.
+	DefaultWindow = "1m"
+	// MinDefaultWindow and MaxDefaultWindow bound the configurable default
+	// time window: shorter windows don't span enough scrapes to compute
+	// rates, and longer ones make queries needlessly expensive.
+	MinDefaultWindow = 15 * time.Second
+	MaxDefaultWindow = 24 * time.Hour
 )
 
 // NewGrpcServer creates a new instance of the Api server and registers it
@@ -55,6 +68,9 @@ func NewGrpcServer(
 	controllerNamespace string,
 	clusterDomain string,
 	ignoredNamespaces []string,
+	queryCacheTTL time.Duration,
+	defaultWindow string,
+	enableGzip bool,
 ) *grpc.Server {
 
 	server := &grpcServer{
@@ -63,9 +79,16 @@ func NewGrpcServer(
 		controllerNamespace: controllerNamespace,
 		clusterDomain:       clusterDomain,
 		ignoredNamespaces:   ignoredNamespaces,
+		defaultWindow:       defaultWindow,
+	}
+	if queryCacheTTL > 0 {
+		server.queryCache = newQueryCache(queryCacheTTL)
 	}
 
-	s := prometheus.NewGrpcServer(grpc.MaxConcurrentStreams(0))
+	s := prometheus.NewGrpcServer(
+		grpc.MaxConcurrentStreams(0),
+		grpc.ChainUnaryInterceptor(compressionInterceptor(enableGzip)),
+	)
 	pb.RegisterApiServer(s, server)
 
 	return s
@@ -238,6 +261,29 @@ func (s *grpcServer) ListServices(ctx context.Context, req *pb.ListServicesReque
 	return &pb.ListServicesResponse{Services: svcs}, nil
 }
 
+// ValidateDefaultWindow returns an error if window can't be used as the
+// default time window of stat queries: it must be a Prometheus duration
+// between MinDefaultWindow and MaxDefaultWindow.
+func ValidateDefaultWindow(window string) error {
+	d, err := model.ParseDuration(window)
+	if err != nil {
+		return err
+	}
+	if time.Duration(d) < MinDefaultWindow || time.Duration(d) > MaxDefaultWindow {
+		return fmt.Errorf("default time window %s must be between %s and %s", window, MinDefaultWindow, MaxDefaultWindow)
+	}
+	return nil
+}
+
+// timeWindow returns the time window requested, or the server's default one
+// if the request doesn't set one.
+func (s *grpcServer) timeWindow(window string) string {
+	if window == "" {
+		return s.defaultWindow
+	}
+	return window
+}
+
 // validateTimeWindow returns an error if the Prometheus scrape interval
 // is longer than the query time window. This is an opportunistic, best-effort
 // validation: if we cannot determine the Prometheus scrape interval for any
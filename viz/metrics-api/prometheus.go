@@ -59,6 +59,14 @@ func (s *grpcServer) queryProm(ctx context.Context, query string) (model.Vector,
 		return nil, ErrNoPrometheusInstance
 	}
 
+	if s.queryCache != nil {
+		if vec, ok := s.queryCache.get(query); ok {
+			log.Debugf("Query served from cache: %q", query)
+			span.AddAttributes(trace.BoolAttribute("cached", true))
+			return vec, nil
+		}
+	}
+
 	// single data point (aka summary) query
 	res, warn, err := s.prometheusAPI.Query(ctx, query, time.Time{})
 	if err != nil {
@@ -73,7 +81,11 @@ func (s *grpcServer) queryProm(ctx context.Context, query string) (model.Vector,
 		return nil, fmt.Errorf("Unexpected query result type (expected Vector): %s", res.Type())
 	}
 
-	return res.(model.Vector), nil
+	vec := res.(model.Vector)
+	if s.queryCache != nil {
+		s.queryCache.set(query, vec)
+	}
+	return vec, nil
 }
 
 // insert a not-nil check into a LabelSet to verify that data for a specified
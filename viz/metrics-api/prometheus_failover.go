@@ -0,0 +1,84 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	log "github.com/sirupsen/logrus"
+)
+
+var prometheusBackendQueries = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "metrics_api_prometheus_backend_queries_total",
+		Help: "Number of Prometheus queries, by the backend that was tried and whether it served them",
+	},
+	[]string{"backend", "result"},
+)
+
+// PrometheusBackend is one of the Prometheus instances the metrics API can
+// query, identified by its URL.
+type PrometheusBackend struct {
+	URL string
+	API promv1.API
+}
+
+// failoverPrometheusAPI is a promv1.API that sends the queries the metrics
+// API relies on to each of its backends in turn until one succeeds. All other
+// calls go to the first backend.
+type failoverPrometheusAPI struct {
+	promv1.API
+	backends []PrometheusBackend
+}
+
+// NewFailoverPrometheusAPI returns a promv1.API that queries the given
+// backends in order, moving on to the next one when a backend errors.
+func NewFailoverPrometheusAPI(backends []PrometheusBackend) (promv1.API, error) {
+	if len(backends) == 0 {
+		return nil, errors.New("at least one Prometheus backend is required")
+	}
+	if len(backends) == 1 {
+		return backends[0].API, nil
+	}
+	return &failoverPrometheusAPI{backends[0].API, backends}, nil
+}
+
+// Query performs a query against the first backend that can serve it.
+func (f *failoverPrometheusAPI) Query(ctx context.Context, query string, ts time.Time, opts ...promv1.Option) (model.Value, promv1.Warnings, error) {
+	var errs []error
+	for _, backend := range f.backends {
+		res, warn, err := backend.API.Query(ctx, query, ts, opts...)
+		if err == nil {
+			prometheusBackendQueries.WithLabelValues(backend.URL, "served").Inc()
+			return res, warn, nil
+		}
+		prometheusBackendQueries.WithLabelValues(backend.URL, "failed").Inc()
+		errs = append(errs, fmt.Errorf("%s: %w", backend.URL, err))
+		if ctx.Err() != nil {
+			break
+		}
+		log.Warnf("Prometheus backend %s failed, trying the next one: %s", backend.URL, err)
+	}
+	return nil, nil, errors.Join(errs...)
+}
+
+// Config returns the configuration of the first backend that can serve it.
+func (f *failoverPrometheusAPI) Config(ctx context.Context) (promv1.ConfigResult, error) {
+	var errs []error
+	for _, backend := range f.backends {
+		config, err := backend.API.Config(ctx)
+		if err == nil {
+			return config, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", backend.URL, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return promv1.ConfigResult{}, errors.Join(errs...)
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/linkerd/linkerd2/pkg/prometheus"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+)
+
+// failingProm is a promv1.API whose queries always fail.
+type failingProm struct {
+	prometheus.MockProm
+	queries int
+}
+
+func (f *failingProm) Query(ctx context.Context, query string, ts time.Time, opts ...promv1.Option) (model.Value, promv1.Warnings, error) {
+	f.queries++
+	return nil, nil, errors.New("connection refused")
+}
+
+func (f *failingProm) Config(ctx context.Context) (promv1.ConfigResult, error) {
+	return promv1.ConfigResult{}, errors.New("connection refused")
+}
+
+func backendQueryCount(t *testing.T, backend, result string) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := prometheusBackendQueries.WithLabelValues(backend, result).Write(&m); err != nil {
+		t.Fatalf("Failed to read metric: %s", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestFailoverPrometheusAPI(t *testing.T) {
+	vector := model.Vector{&model.Sample{Value: 1}}
+
+	t.Run("Requires a backend", func(t *testing.T) {
+		if _, err := NewFailoverPrometheusAPI(nil); err == nil {
+			t.Fatalf("Expected an error, got none")
+		}
+	})
+
+	t.Run("Uses a single backend directly", func(t *testing.T) {
+		primary := &prometheus.MockProm{Res: vector}
+		promAPI, err := NewFailoverPrometheusAPI([]PrometheusBackend{{URL: "http://single", API: primary}})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if promAPI != primary {
+			t.Fatalf("Expected the single backend to be returned unwrapped")
+		}
+	})
+
+	t.Run("Serves queries from the primary", func(t *testing.T) {
+		primary := &prometheus.MockProm{Res: vector}
+		secondary := &prometheus.MockProm{Res: vector}
+		promAPI, err := NewFailoverPrometheusAPI([]PrometheusBackend{
+			{URL: "http://healthy-primary", API: primary},
+			{URL: "http://unused-secondary", API: secondary},
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if _, _, err := promAPI.Query(context.Background(), "up", time.Time{}); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(primary.QueriesExecuted) != 1 || len(secondary.QueriesExecuted) != 0 {
+			t.Fatalf("Expected only the primary to be queried, got %d and %d queries", len(primary.QueriesExecuted), len(secondary.QueriesExecuted))
+		}
+		if served := backendQueryCount(t, "http://healthy-primary", "served"); served != 1 {
+			t.Fatalf("Expected 1 query served by the primary, got %v", served)
+		}
+	})
+
+	t.Run("Falls back when the primary fails", func(t *testing.T) {
+		primary := &failingProm{}
+		secondary := &prometheus.MockProm{Res: vector}
+		promAPI, err := NewFailoverPrometheusAPI([]PrometheusBackend{
+			{URL: "http://failing-primary", API: primary},
+			{URL: "http://fallback-secondary", API: secondary},
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		res, _, err := promAPI.Query(context.Background(), "up", time.Time{})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if res.String() != vector.String() {
+			t.Fatalf("Expected %s, got %s", vector, res)
+		}
+		if primary.queries != 1 || len(secondary.QueriesExecuted) != 1 {
+			t.Fatalf("Expected both backends to be queried once, got %d and %d queries", primary.queries, len(secondary.QueriesExecuted))
+		}
+		if failed := backendQueryCount(t, "http://failing-primary", "failed"); failed != 1 {
+			t.Fatalf("Expected 1 failed query for the primary, got %v", failed)
+		}
+		if served := backendQueryCount(t, "http://fallback-secondary", "served"); served != 1 {
+			t.Fatalf("Expected 1 query served by the secondary, got %v", served)
+		}
+
+		if _, err := promAPI.Config(context.Background()); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	})
+
+	t.Run("Fails when every backend fails", func(t *testing.T) {
+		promAPI, err := NewFailoverPrometheusAPI([]PrometheusBackend{
+			{URL: "http://failing-1", API: &failingProm{}},
+			{URL: "http://failing-2", API: &failingProm{}},
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		if _, _, err := promAPI.Query(context.Background(), "up", time.Time{}); err == nil {
+			t.Fatalf("Expected an error, got none")
+		}
+		if _, err := promAPI.Config(context.Background()); err == nil {
+			t.Fatalf("Expected an error, got none")
+		}
+	})
+}
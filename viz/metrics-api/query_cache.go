@@ -0,0 +1,86 @@
+package api
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+)
+
+var queryCacheRequests = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "metrics_api_query_cache_requests_total",
+		Help: "Number of Prometheus queries looked up in the query cache, by whether they were served from it",
+	},
+	[]string{"result"},
+)
+
+type queryCacheEntry struct {
+	vec     model.Vector
+	expires time.Time
+}
+
+// queryCache holds Prometheus query results for a short TTL, so that clients
+// polling the same stats (such as the dashboard) don't send the same queries
+// to Prometheus over and over. Queries embed their time window, so the
+// normalized query string is enough to key the cache.
+type queryCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	sync.Mutex
+	entries map[string]queryCacheEntry
+}
+
+func newQueryCache(ttl time.Duration) *queryCache {
+	return &queryCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: map[string]queryCacheEntry{},
+	}
+}
+
+// normalizeQuery collapses whitespace so that queries that only differ in
+// formatting share a cache entry.
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+func (c *queryCache) get(query string) (model.Vector, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	entry, ok := c.entries[normalizeQuery(query)]
+	if !ok || !c.now().Before(entry.expires) {
+		queryCacheRequests.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	queryCacheRequests.WithLabelValues("hit").Inc()
+
+	// Callers may reorder the vector, so each of them gets its own copy.
+	vec := make(model.Vector, len(entry.vec))
+	copy(vec, entry.vec)
+	return vec, true
+}
+
+func (c *queryCache) set(query string, vec model.Vector) {
+	c.Lock()
+	defer c.Unlock()
+
+	now := c.now()
+	for key, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, key)
+		}
+	}
+
+	stored := make(model.Vector, len(vec))
+	copy(stored, vec)
+	c.entries[normalizeQuery(query)] = queryCacheEntry{
+		vec:     stored,
+		expires: now.Add(c.ttl),
+	}
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/linkerd/linkerd2/pkg/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+)
+
+func queryCacheCount(t *testing.T, result string) float64 {
+	t.Helper()
+	var m dto.Metric
+	if err := queryCacheRequests.WithLabelValues(result).Write(&m); err != nil {
+		t.Fatalf("Failed to read metric: %s", err)
+	}
+	return m.GetCounter().GetValue()
+}
+
+func TestQueryCache(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	mockProm := &prometheus.MockProm{Res: model.Vector{&model.Sample{Value: 1}}}
+	cache := newQueryCache(10 * time.Second)
+	cache.now = func() time.Time { return now }
+	server := &grpcServer{
+		prometheusAPI: mockProm,
+		queryCache:    cache,
+	}
+
+	query := `sum(increase(response_total{namespace="emojivoto"}[1m])) by (pod)`
+	hits, misses := queryCacheCount(t, "hit"), queryCacheCount(t, "miss")
+
+	if _, err := server.queryProm(context.Background(), query); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(mockProm.QueriesExecuted) != 1 {
+		t.Fatalf("Expected the first query to reach Prometheus, got %d queries", len(mockProm.QueriesExecuted))
+	}
+
+	// Within the TTL, the same query (even formatted differently) is served
+	// from the cache.
+	now = now.Add(5 * time.Second)
+	vec, err := server.queryProm(context.Background(), "sum(increase(response_total{namespace=\"emojivoto\"}[1m]))   by (pod)")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(vec) != 1 {
+		t.Fatalf("Expected a cached vector with 1 sample, got %v", vec)
+	}
+	if len(mockProm.QueriesExecuted) != 1 {
+		t.Fatalf("Expected the cached query not to reach Prometheus, got %d queries", len(mockProm.QueriesExecuted))
+	}
+
+	// A different time window is a different query.
+	if _, err := server.queryProm(context.Background(), `sum(increase(response_total{namespace="emojivoto"}[5m])) by (pod)`); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(mockProm.QueriesExecuted) != 2 {
+		t.Fatalf("Expected a query with another window to reach Prometheus, got %d queries", len(mockProm.QueriesExecuted))
+	}
+
+	// Once the TTL expires, the query is sent again.
+	now = now.Add(10 * time.Second)
+	if _, err := server.queryProm(context.Background(), query); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(mockProm.QueriesExecuted) != 3 {
+		t.Fatalf("Expected the expired query to reach Prometheus, got %d queries", len(mockProm.QueriesExecuted))
+	}
+
+	if got := queryCacheCount(t, "hit") - hits; got != 1 {
+		t.Fatalf("Expected 1 cache hit, got %v", got)
+	}
+	if got := queryCacheCount(t, "miss") - misses; got != 3 {
+		t.Fatalf("Expected 3 cache misses, got %v", got)
+	}
+}
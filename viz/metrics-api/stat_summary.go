@@ -66,6 +66,7 @@ type podStats struct {
 }
 
 func (s *grpcServer) StatSummary(ctx context.Context, req *pb.StatSummaryRequest) (*pb.StatSummaryResponse, error) {
+	req.TimeWindow = s.timeWindow(req.TimeWindow)
 
 	// check for well-formed request
 	if req.GetSelector().GetResource() == nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/linkerd/linkerd2/controller/k8s"
@@ -1707,3 +1708,87 @@ status:
 		testStatSummary(t, expectations)
 	})
 }
+
+func TestStatSummaryDefaultTimeWindow(t *testing.T) {
+	mockProm, fakeGrpcServer, err := newMockGrpcServer(expectedStatRPC{
+		k8sConfigs: []string{`
+apiVersion: v1
+kind: Pod
+metadata:
+  name: emoji
+  namespace: emojivoto
+  labels:
+    app: emoji-svc
+    linkerd.io/control-plane-ns: linkerd
+status:
+  phase: Running
+`,
+		},
+		mockPromResponse: prometheusMetric("emoji", "pod"),
+	})
+	if err != nil {
+		t.Fatalf("Error creating mock grpc server: %s", err)
+	}
+	fakeGrpcServer.defaultWindow = "5m"
+
+	rsp, err := fakeGrpcServer.StatSummary(context.TODO(), &pb.StatSummaryRequest{
+		Selector: &pb.ResourceSelection{
+			Resource: &pb.Resource{
+				Namespace: "emojivoto",
+				Type:      pkgK8s.Pod,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if rsp.GetError() != nil {
+		t.Fatalf("Unexpected error response: %s", rsp.GetError().GetError())
+	}
+
+	if len(mockProm.QueriesExecuted) == 0 {
+		t.Fatalf("Expected queries to be sent to Prometheus")
+	}
+	for _, query := range mockProm.QueriesExecuted {
+		if !strings.Contains(query, "[5m]") {
+			t.Fatalf("Expected query to use the default 5m window: %s", query)
+		}
+	}
+
+	for _, table := range rsp.GetOk().GetStatTables() {
+		for _, row := range table.GetPodGroup().GetRows() {
+			if row.GetTimeWindow() != "5m" {
+				t.Fatalf("Expected row time window to be 5m, got %q", row.GetTimeWindow())
+			}
+		}
+	}
+}
+
+func TestValidateDefaultWindow(t *testing.T) {
+	testCases := []struct {
+		window string
+		valid  bool
+	}{
+		{"1m", true},
+		{"15s", true},
+		{"5m", true},
+		{"1d", true},
+		{"10s", false},
+		{"2d", false},
+		{"", false},
+		{"five minutes", false},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.window, func(t *testing.T) {
+			err := ValidateDefaultWindow(tc.window)
+			if tc.valid && err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+			if !tc.valid && err == nil {
+				t.Fatalf("Expected an error for %q", tc.window)
+			}
+		})
+	}
+}
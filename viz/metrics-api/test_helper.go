@@ -316,6 +316,7 @@ func newMockGrpcServer(exp expectedStatRPC) (*prometheus.MockProm, *grpcServer,
 		controllerNamespace: "linkerd",
 		clusterDomain:       "cluster.local",
 		ignoredNamespaces:   []string{},
+		defaultWindow:       DefaultWindow,
 	}
 
 	k8sAPI.Sync(nil)
@@ -40,6 +40,7 @@ type resourceTable struct {
 
 func (s *grpcServer) TopRoutes(ctx context.Context, req *pb.TopRoutesRequest) (*pb.TopRoutesResponse, error) {
 	log.Debugf("TopRoutes request: %+v", req)
+	req.TimeWindow = s.timeWindow(req.TimeWindow)
 
 	if !s.k8sAPI.SPAvailable() {
 		return topRoutesError(req, "Routes are not available"), nil
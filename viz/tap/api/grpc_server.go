@@ -39,6 +39,10 @@ import (
 const ipIndex = "ip"
 const defaultMaxRps = 100.0
 
+// redactedHeaderValue replaces the value of headers in the redaction list, so
+// that tap still shows they were sent without exposing their contents.
+const redactedHeaderValue = "***"
+
 // GRPCTapServer describes the gRPC server implementing pb.TapServer
 type GRPCTapServer struct {
 	tapPb.UnimplementedTapServer
@@ -47,6 +51,8 @@ type GRPCTapServer struct {
 	controllerNamespace string
 	trustDomain         string
 	ignoreHeaders       map[string]bool
+	redactHeaders       map[string]bool
+	sessions            *sessionLimiter
 }
 
 var (
@@ -81,31 +87,21 @@ func (s *GRPCTapServer) TapByResource(req *tapPb.TapByResourceRequest, stream ta
 		req.MaxRps = defaultMaxRps
 	}
 
-	objects, err := s.k8sAPI.GetObjects(res.GetNamespace(), res.GetType(), res.GetName(), labelSelector)
-	if err != nil {
-		return pkgUtil.GRPCError(err)
-	}
-
-	pods := []*corev1.Pod{}
-	tapDisabled := []*corev1.Pod{}
-	tapNotEnabled := []*corev1.Pod{}
-	for _, object := range objects {
-		podsFor, err := s.k8sAPI.GetPodsFor(object, false)
+	// Services are watched before their pods are first resolved, so that no
+	// endpoint change is missed in between.
+	var endpointsChanged <-chan struct{}
+	if res.GetType() == pkgK8s.Service {
+		changed, stop, err := s.watchServiceEndpoints(res.GetNamespace(), res.GetName())
 		if err != nil {
 			return pkgUtil.GRPCError(err)
 		}
+		defer stop()
+		endpointsChanged = changed
+	}
 
-		for _, pod := range podsFor {
-			if pkgK8s.IsMeshed(pod, s.controllerNamespace) {
-				if vizLabels.IsTapDisabled(pod) {
-					tapDisabled = append(tapDisabled, pod)
-				} else if !vizLabels.IsTapEnabled(pod) {
-					tapNotEnabled = append(tapNotEnabled, pod)
-				} else {
-					pods = append(pods, pod)
-				}
-			}
-		}
+	pods, tapDisabled, tapNotEnabled, err := s.resolveTapTargets(res, labelSelector)
+	if err != nil {
+		return pkgUtil.GRPCError(err)
 	}
 
 	if len(pods) == 0 {
@@ -128,6 +124,11 @@ func (s *GRPCTapServer) TapByResource(req *tapPb.TapByResourceRequest, stream ta
 		return status.Error(codes.NotFound, errs.String())
 	}
 
+	if !s.sessions.acquire() {
+		return status.Errorf(codes.ResourceExhausted, "too many concurrent tap sessions (limit %d)", s.sessions.limit)
+	}
+	defer s.sessions.release()
+
 	log.Infof("Tapping %d pods for target: %q", len(pods), res.String())
 
 	events := make(chan *tapPb.TapEvent)
@@ -152,7 +153,7 @@ func (s *GRPCTapServer) TapByResource(req *tapPb.TapByResourceRequest, stream ta
 		extract = buildExtractHTTP(extractHTTP)
 	}
 
-	for _, pod := range pods {
+	taps := newPodTaps(func(ctx context.Context, pod *corev1.Pod) {
 		// create the expected pod identity from the pod spec
 		ns := res.GetNamespace()
 		if res.GetType() == pkgK8s.Namespace {
@@ -162,18 +163,28 @@ func (s *GRPCTapServer) TapByResource(req *tapPb.TapByResourceRequest, stream ta
 		log.Debugf("initiating tap request to %s with required name %s", pod.Spec.ServiceAccountName, name)
 
 		// pass the header metadata into the request context
-		ctx := stream.Context()
 		ctx = metadata.AppendToOutgoingContext(ctx, pkgK8s.RequireIDHeader, name)
 
 		// initiate a tap on the pod
 		go s.tapProxy(ctx, rpsPerPod, match, extract, pod.Status.PodIP, events)
-	}
+	})
+	taps.update(stream.Context(), pods)
 
 	// read events from the taps and send them back
 	for {
 		select {
 		case <-stream.Context().Done():
 			return nil
+		case <-endpointsChanged:
+			// Pods added to the service are tapped with the rate computed
+			// for the pods it had when the session started.
+			pods, _, _, err := s.resolveTapTargets(res, labelSelector)
+			if err != nil {
+				log.Warnf("failed to resolve pods for target %q: %s", res.String(), err)
+				continue
+			}
+			taps.update(stream.Context(), pods)
+			log.Infof("Endpoints changed, tapping %d pods for target: %q", taps.len(), res.String())
 		case event := <-events:
 			err := stream.Send(event)
 			if err != nil {
@@ -337,6 +348,10 @@ func (s *GRPCTapServer) tapProxy(ctx context.Context, maxRps float32, match *pro
 					log.Debugf("[%s] proxy terminated the stream", addr)
 					break
 				}
+				if ctx.Err() != nil {
+					log.Debugf("[%s] tap was stopped", addr)
+					return
+				}
 				log.Errorf("[%s] encountered an error: %s", addr, err)
 				return
 			}
@@ -425,6 +440,13 @@ func (s *GRPCTapServer) translateEvent(ctx context.Context, orig *proxy.TapEvent
 				if s.ignoreHeaders[n] {
 					continue
 				}
+				if s.redactHeaders[strings.ToLower(n)] {
+					headers = append(headers, &metricsPb.Headers_Header{
+						Name:  n,
+						Value: &metricsPb.Headers_Header_ValueStr{ValueStr: redactedHeaderValue},
+					})
+					continue
+				}
 				b := header.GetValue()
 				h := metricsPb.Headers_Header{Name: n, Value: &metricsPb.Headers_Header_ValueBin{ValueBin: b}}
 				if utf8.Valid(b) {
@@ -545,6 +567,8 @@ func NewGrpcTapServer(
 	trustDomain string,
 	k8sAPI *k8s.API,
 	ignoreHeaders map[string]bool,
+	redactHeaders map[string]bool,
+	maxConcurrentTaps int,
 ) (*GRPCTapServer, error) {
 	if err := k8sAPI.Pod().Informer().AddIndexers(cache.Indexers{ipIndex: indexByIP}); err != nil {
 		return nil, err
@@ -553,7 +577,7 @@ func NewGrpcTapServer(
 		return nil, err
 	}
 
-	return newGRPCTapServer(tapPort, controllerNamespace, trustDomain, k8sAPI, ignoreHeaders), nil
+	return newGRPCTapServer(tapPort, controllerNamespace, trustDomain, k8sAPI, ignoreHeaders, redactHeaders, maxConcurrentTaps), nil
 }
 
 func newGRPCTapServer(
@@ -562,6 +586,8 @@ func newGRPCTapServer(
 	trustDomain string,
 	k8sAPI *k8s.API,
 	ignoreHeaders map[string]bool,
+	redactHeaders map[string]bool,
+	maxConcurrentTaps int,
 ) *GRPCTapServer {
 	srv := &GRPCTapServer{
 		tapPort:             tapPort,
@@ -569,6 +595,8 @@ func newGRPCTapServer(
 		controllerNamespace: controllerNamespace,
 		trustDomain:         trustDomain,
 		ignoreHeaders:       ignoreHeaders,
+		redactHeaders:       redactHeaders,
+		sessions:            &sessionLimiter{limit: maxConcurrentTaps},
 	}
 
 	s := prometheus.NewGrpcServer(grpc.MaxConcurrentStreams(0))
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/go-test/deep"
+	httpPb "github.com/linkerd/linkerd2-proxy-api/go/http_types"
 	proxy "github.com/linkerd/linkerd2-proxy-api/go/tap"
 	"github.com/linkerd/linkerd2/controller/api/util"
 	"github.com/linkerd/linkerd2/controller/k8s"
@@ -15,6 +16,7 @@ import (
 	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
 	metricsPb "github.com/linkerd/linkerd2/viz/metrics-api/gen/viz"
 	tapPb "github.com/linkerd/linkerd2/viz/tap/gen/tap"
+	dto "github.com/prometheus/client_model/go"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -375,6 +377,113 @@ status:
 			},
 			requireID: "emojivoto-meshed-sa.emojivoto.serviceaccount.identity.controller-ns.cluster.local",
 		},
+		{
+			err: status.Errorf(codes.NotFound, "no pods to tap for type=\"service\" name=\"emoji-svc\"\n"),
+			k8sRes: []string{`
+apiVersion: v1
+kind: Service
+metadata:
+  name: emoji-svc
+  namespace: emojivoto
+spec:
+  selector:
+    app: emoji-svc
+`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: emojivoto-meshed
+  namespace: emojivoto
+  labels:
+    app: emoji-svc
+    linkerd.io/control-plane-ns: controller-ns
+  annotations:
+    viz.linkerd.io/tap-enabled: "true"
+    linkerd.io/proxy-version: testinjectversion
+spec:
+  serviceAccountName: emojivoto-meshed-sa
+status:
+  phase: Running
+  podIP: 127.0.0.1
+`,
+			},
+			req: &tapPb.TapByResourceRequest{
+				Target: &metricsPb.ResourceSelection{
+					Resource: &metricsPb.Resource{
+						Namespace: "emojivoto",
+						Type:      pkgK8s.Service,
+						Name:      "emoji-svc",
+					},
+				},
+				Match: &tapPb.TapByResourceRequest_Match{
+					Match: &tapPb.TapByResourceRequest_Match_All{
+						All: &tapPb.TapByResourceRequest_Match_Seq{},
+					},
+				},
+			},
+		},
+		{
+			err: nil,
+			k8sRes: []string{`
+apiVersion: v1
+kind: Service
+metadata:
+  name: emoji-svc
+  namespace: emojivoto
+spec:
+  selector:
+    app: emoji-svc
+`, `
+apiVersion: discovery.k8s.io/v1
+kind: EndpointSlice
+metadata:
+  name: emoji-svc-abc
+  namespace: emojivoto
+  labels:
+    kubernetes.io/service-name: emoji-svc
+addressType: IPv4
+endpoints:
+- addresses:
+  - 127.0.0.1
+  targetRef:
+    kind: Pod
+    name: emojivoto-meshed
+    namespace: emojivoto
+`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: emojivoto-meshed
+  namespace: emojivoto
+  labels:
+    app: emoji-svc
+    linkerd.io/control-plane-ns: controller-ns
+  annotations:
+    viz.linkerd.io/tap-enabled: "true"
+    linkerd.io/proxy-version: testinjectversion
+spec:
+  serviceAccountName: emojivoto-meshed-sa
+status:
+  phase: Running
+  podIP: 127.0.0.1
+`,
+			},
+			req: &tapPb.TapByResourceRequest{
+				Target: &metricsPb.ResourceSelection{
+					Resource: &metricsPb.Resource{
+						Namespace: "emojivoto",
+						Type:      pkgK8s.Service,
+						Name:      "emoji-svc",
+					},
+				},
+				Match: &tapPb.TapByResourceRequest_Match{
+					Match: &tapPb.TapByResourceRequest_Match_All{
+						All: &tapPb.TapByResourceRequest_Match_Seq{},
+					},
+				},
+			},
+			requireID: "emojivoto-meshed-sa.emojivoto.serviceaccount.identity.controller-ns.cluster.local",
+		},
 	}
 
 	for i, exp := range expectations {
@@ -425,7 +534,7 @@ status:
 				t.Fatalf("Invalid port: %s", port)
 			}
 
-			fakeGrpcServer := newGRPCTapServer(uint(tapPort), "controller-ns", "cluster.local", k8sAPI, nil)
+			fakeGrpcServer := newGRPCTapServer(uint(tapPort), "controller-ns", "cluster.local", k8sAPI, nil, nil, 0)
 
 			k8sAPI.Sync(nil)
 
@@ -455,6 +564,185 @@ status:
 	}
 }
 
+func TestTapByResourceMaxConcurrentTaps(t *testing.T) {
+	k8sAPI, err := k8s.NewFakeAPI(`
+apiVersion: v1
+kind: Pod
+metadata:
+  name: emojivoto-meshed
+  namespace: emojivoto
+  labels:
+    app: emoji-svc
+    linkerd.io/control-plane-ns: controller-ns
+  annotations:
+    viz.linkerd.io/tap-enabled: "true"
+    linkerd.io/proxy-version: testinjectversion
+spec:
+  serviceAccountName: emojivoto-meshed-sa
+status:
+  phase: Running
+  podIP: 127.0.0.1
+`)
+	if err != nil {
+		t.Fatalf("NewFakeAPI returned an error: %s", err)
+	}
+
+	s := newGRPCTapServer(4190, "controller-ns", "cluster.local", k8sAPI, nil, nil, 1)
+	k8sAPI.Sync(nil)
+
+	req := &tapPb.TapByResourceRequest{
+		Target: &metricsPb.ResourceSelection{
+			Resource: &metricsPb.Resource{
+				Namespace: "emojivoto",
+				Type:      pkgK8s.Pod,
+				Name:      "emojivoto-meshed",
+			},
+		},
+		Match: &tapPb.TapByResourceRequest_Match{
+			Match: &tapPb.TapByResourceRequest_Match_All{
+				All: &tapPb.TapByResourceRequest_Match_Seq{},
+			},
+		},
+	}
+
+	// Occupy the only session, as a tap in progress would.
+	if !s.sessions.acquire() {
+		t.Fatal("Expected the first session to be allowed")
+	}
+	if active := activeSessions(t); active != 1 {
+		t.Fatalf("Expected 1 active tap session, got %v", active)
+	}
+
+	stream := mockTapByResourceServer{
+		MockServerStream: util.NewMockServerStream(),
+	}
+	err = s.TapByResource(req, &stream)
+	if code := status.Code(err); code != codes.ResourceExhausted {
+		t.Fatalf("Expected code [%s], got [%s]: %v", codes.ResourceExhausted, code, err)
+	}
+	if err.Error() != "rpc error: code = ResourceExhausted desc = too many concurrent tap sessions (limit 1)" {
+		t.Fatalf("Unexpected error message: %s", err)
+	}
+	if active := activeSessions(t); active != 1 {
+		t.Fatalf("Expected a rejected tap not to be counted, got %v active sessions", active)
+	}
+
+	s.sessions.release()
+	if active := activeSessions(t); active != 0 {
+		t.Fatalf("Expected 0 active tap sessions, got %v", active)
+	}
+	if !s.sessions.acquire() {
+		t.Fatal("Expected a session to be allowed once the previous one ended")
+	}
+	s.sessions.release()
+}
+
+func activeSessions(t *testing.T) float64 {
+	t.Helper()
+	var metric dto.Metric
+	if err := activeTapSessions.Write(&metric); err != nil {
+		t.Fatalf("Failed to read metric: %s", err)
+	}
+	return metric.GetGauge().GetValue()
+}
+
+func TestTranslateEventHeaders(t *testing.T) {
+	testCases := []struct {
+		name          string
+		ignoreHeaders map[string]bool
+		redactHeaders map[string]bool
+		expected      []string
+	}{
+		{
+			name: "no ignored or redacted headers",
+			expected: []string{
+				"authorization: Bearer secret",
+				"Cookie: session=secret",
+				"content-type: application/json",
+			},
+		},
+		{
+			name:          "ignored headers are dropped",
+			ignoreHeaders: map[string]bool{"authorization": true},
+			expected: []string{
+				"Cookie: session=secret",
+				"content-type: application/json",
+			},
+		},
+		{
+			name:          "redacted headers are kept with their values replaced",
+			redactHeaders: parseRedactHeaders(defaultRedactHeaders),
+			expected: []string{
+				"authorization: ***",
+				"Cookie: ***",
+				"content-type: application/json",
+			},
+		},
+		{
+			name:          "ignoring takes precedence over redacting",
+			ignoreHeaders: map[string]bool{"authorization": true},
+			redactHeaders: parseRedactHeaders(defaultRedactHeaders),
+			expected: []string{
+				"Cookie: ***",
+				"content-type: application/json",
+			},
+		},
+		{
+			name:          "disabled redaction",
+			redactHeaders: parseRedactHeaders(""),
+			expected: []string{
+				"authorization: Bearer secret",
+				"Cookie: session=secret",
+				"content-type: application/json",
+			},
+		},
+	}
+
+	event := &proxy.TapEvent{
+		ProxyDirection: proxy.TapEvent_OUTBOUND,
+		Event: &proxy.TapEvent_Http_{
+			Http: &proxy.TapEvent_Http{
+				Event: &proxy.TapEvent_Http_RequestInit_{
+					RequestInit: &proxy.TapEvent_Http_RequestInit{
+						Headers: &httpPb.Headers{
+							Headers: []*httpPb.Headers_Header{
+								{Name: "authorization", Value: []byte("Bearer secret")},
+								{Name: "Cookie", Value: []byte("session=secret")},
+								{Name: "content-type", Value: []byte("application/json")},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			k8sAPI, err := k8s.NewFakeAPI()
+			if err != nil {
+				t.Fatalf("NewFakeAPI returned an error: %s", err)
+			}
+			s, err := NewGrpcTapServer(4190, "controller-ns", "cluster.local", k8sAPI, tc.ignoreHeaders, tc.redactHeaders, 0)
+			if err != nil {
+				t.Fatalf("NewGrpcTapServer returned an error: %s", err)
+			}
+			k8sAPI.Sync(nil)
+
+			ev := s.translateEvent(context.Background(), event)
+
+			var headers []string
+			for _, h := range ev.GetHttp().GetRequestInit().GetHeaders().GetHeaders() {
+				headers = append(headers, fmt.Sprintf("%s: %s", h.GetName(), h.GetValueStr()))
+			}
+			if diff := deep.Equal(headers, tc.expected); diff != nil {
+				t.Fatalf("Unexpected headers: %+v", diff)
+			}
+		})
+	}
+}
+
 func TestHydrateIPLabels(t *testing.T) {
 	expectations := []struct {
 		k8sRes      []string
@@ -663,7 +951,7 @@ status:
 			if err != nil {
 				t.Fatalf("NewFakeAPI returned an error: %s", err)
 			}
-			s, _ := NewGrpcTapServer(4190, "controller-ns", "cluster.local", k8sAPI, nil)
+			s, _ := NewGrpcTapServer(4190, "controller-ns", "cluster.local", k8sAPI, nil, nil, 0)
 			k8sAPI.Sync(nil)
 
 			labels := make(map[string]string)
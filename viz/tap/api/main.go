@@ -18,6 +18,10 @@ import (
 
 const defaultDomain = "cluster.local"
 
+// defaultRedactHeaders are the headers carrying credentials, whose values are
+// redacted from tap events unless configured otherwise.
+const defaultRedactHeaders = "authorization,proxy-authorization,cookie,set-cookie"
+
 // Main executes the tap subcommand
 func Main(args []string) {
 	cmd := flag.NewFlagSet("tap", flag.ExitOnError)
@@ -29,9 +33,11 @@ func Main(args []string) {
 	disableCommonNames := cmd.Bool("disable-common-names", false, "disable checks for Common Names (for development)")
 	trustDomain := cmd.String("identity-trust-domain", defaultDomain, "configures the name suffix used for identities")
 	enablePprof := cmd.Bool("enable-pprof", false, "Enable pprof endpoints on the admin server")
+	maxConcurrentTaps := cmd.Int("max-concurrent-taps", 0, "maximum number of tap sessions served at once; 0 means no limit")
 
 	var ignoreHeaders = &stringMap{}
 	cmd.Var(ignoreHeaders, "ignore-headers", "list of headers to ignore")
+	redactHeaders := cmd.String("redact-headers", defaultRedactHeaders, "comma-separated list of headers whose values are replaced with \""+redactedHeaderValue+"\" in tap events; set to empty to disable redaction")
 
 	traceCollector := flags.AddTraceFlags(cmd)
 	flags.ConfigureAndParse(cmd, args)
@@ -56,6 +62,7 @@ func Main(args []string) {
 		"local",
 		k8s.CJ,
 		k8s.DS,
+		k8s.ES,
 		k8s.SS,
 		k8s.Deploy,
 		k8s.Job,
@@ -75,7 +82,7 @@ func Main(args []string) {
 			log.Warnf("failed to initialize tracing: %s", err)
 		}
 	}
-	grpcTapServer, err := NewGrpcTapServer(*tapPort, *apiNamespace, *trustDomain, k8sAPI, *ignoreHeaders)
+	grpcTapServer, err := NewGrpcTapServer(*tapPort, *apiNamespace, *trustDomain, k8sAPI, *ignoreHeaders, parseRedactHeaders(*redactHeaders), *maxConcurrentTaps)
 	if err != nil {
 		log.Fatal(err.Error())
 	}
@@ -107,3 +114,16 @@ func (m stringMap) Set(value string) error {
 	}
 	return nil
 }
+
+// parseRedactHeaders builds the redaction list from a comma-separated list of
+// header names. Header names are case-insensitive, so they're lowercased.
+func parseRedactHeaders(value string) map[string]bool {
+	headers := make(map[string]bool)
+	for _, h := range strings.Split(value, ",") {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h != "" {
+			headers[h] = true
+		}
+	}
+	return headers
+}
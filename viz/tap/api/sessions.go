@@ -0,0 +1,47 @@
+package api
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var activeTapSessions = promauto.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "tap_active_sessions",
+		Help: "Number of tap sessions currently being served",
+	},
+)
+
+// sessionLimiter bounds the number of tap sessions served at once, since each
+// session opens a stream to every proxy it taps. A limit of zero allows any
+// number of sessions.
+type sessionLimiter struct {
+	limit int
+
+	sync.Mutex
+	active int
+}
+
+// acquire reserves a session and reports whether it's within the limit. Every
+// successful acquire must be followed by a release.
+func (sl *sessionLimiter) acquire() bool {
+	sl.Lock()
+	defer sl.Unlock()
+
+	if sl.limit > 0 && sl.active >= sl.limit {
+		return false
+	}
+	sl.active++
+	activeTapSessions.Inc()
+	return true
+}
+
+func (sl *sessionLimiter) release() {
+	sl.Lock()
+	defer sl.Unlock()
+
+	sl.active--
+	activeTapSessions.Dec()
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"fmt"
+
+	pkgK8s "github.com/linkerd/linkerd2/pkg/k8s"
+	metricsPb "github.com/linkerd/linkerd2/viz/metrics-api/gen/viz"
+	vizLabels "github.com/linkerd/linkerd2/viz/pkg/labels"
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// resolveTapTargets returns the meshed pods selected by res, split by whether
+// they can be tapped.
+func (s *GRPCTapServer) resolveTapTargets(res *metricsPb.Resource, labelSelector labels.Selector) (pods, tapDisabled, tapNotEnabled []*corev1.Pod, err error) {
+	objects, err := s.k8sAPI.GetObjects(res.GetNamespace(), res.GetType(), res.GetName(), labelSelector)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	pods = []*corev1.Pod{}
+	tapDisabled = []*corev1.Pod{}
+	tapNotEnabled = []*corev1.Pod{}
+	for _, object := range objects {
+		podsFor, err := s.podsFor(object)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		for _, pod := range podsFor {
+			if pkgK8s.IsMeshed(pod, s.controllerNamespace) {
+				if vizLabels.IsTapDisabled(pod) {
+					tapDisabled = append(tapDisabled, pod)
+				} else if !vizLabels.IsTapEnabled(pod) {
+					tapNotEnabled = append(tapNotEnabled, pod)
+				} else {
+					pods = append(pods, pod)
+				}
+			}
+		}
+	}
+
+	return pods, tapDisabled, tapNotEnabled, nil
+}
+
+// podsFor returns the pods backing object. Services are resolved through
+// their EndpointSlices rather than their selector, so that only the pods
+// actually receiving the service's traffic are tapped.
+func (s *GRPCTapServer) podsFor(object runtime.Object) ([]*corev1.Pod, error) {
+	if svc, ok := object.(*corev1.Service); ok {
+		return s.podsForService(svc.Namespace, svc.Name)
+	}
+	return s.k8sAPI.GetPodsFor(object, false)
+}
+
+// podsForService returns the pods referenced by the endpoints of the given
+// service.
+func (s *GRPCTapServer) podsForService(namespace, name string) ([]*corev1.Pod, error) {
+	selector := labels.Set{discovery.LabelServiceName: name}.AsSelector()
+	slices, err := s.k8sAPI.ES().Lister().EndpointSlices(namespace).List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{})
+	pods := []*corev1.Pod{}
+	for _, slice := range slices {
+		for _, endpoint := range slice.Endpoints {
+			if endpoint.TargetRef == nil || endpoint.TargetRef.Kind != "Pod" {
+				continue
+			}
+			if _, ok := seen[endpoint.TargetRef.Name]; ok {
+				continue
+			}
+			seen[endpoint.TargetRef.Name] = struct{}{}
+
+			pod, err := s.k8sAPI.Pod().Lister().Pods(namespace).Get(endpoint.TargetRef.Name)
+			if err != nil {
+				if kerrors.IsNotFound(err) {
+					// The pod was deleted before its endpoint was removed.
+					log.Debugf("no pod found for endpoint %s/%s of service %s", namespace, endpoint.TargetRef.Name, name)
+					continue
+				}
+				return nil, err
+			}
+			pods = append(pods, pod)
+		}
+	}
+
+	return pods, nil
+}
+
+// watchServiceEndpoints signals on the returned channel whenever an
+// EndpointSlice of the service changes. If name is empty, the EndpointSlices
+// of every service in the namespace are watched. The returned function stops
+// the watch.
+func (s *GRPCTapServer) watchServiceEndpoints(namespace, name string) (<-chan struct{}, func(), error) {
+	changed := make(chan struct{}, 1)
+	notify := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		slice, ok := obj.(*discovery.EndpointSlice)
+		if !ok {
+			return
+		}
+		if slice.Namespace != namespace {
+			return
+		}
+		if name != "" && slice.Labels[discovery.LabelServiceName] != name {
+			return
+		}
+		// Changes are coalesced, since every change results in the
+		// service's pods being resolved again.
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+
+	informer := s.k8sAPI.ES().Informer()
+	handler, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    notify,
+		UpdateFunc: func(_, obj interface{}) { notify(obj) },
+		DeleteFunc: notify,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	stop := func() {
+		if err := informer.RemoveEventHandler(handler); err != nil {
+			log.Warnf("error removing EndpointSlice informer handler: %s", err)
+		}
+	}
+	return changed, stop, nil
+}
+
+// podTaps tracks the taps running for a session, so that the set of tapped
+// pods can change while the session is in progress.
+type podTaps struct {
+	start   func(ctx context.Context, pod *corev1.Pod)
+	cancels map[string]context.CancelFunc
+}
+
+func newPodTaps(start func(ctx context.Context, pod *corev1.Pod)) *podTaps {
+	return &podTaps{
+		start:   start,
+		cancels: make(map[string]context.CancelFunc),
+	}
+}
+
+// update starts tapping the pods that aren't tapped yet and stops tapping the
+// pods that are no longer in pods.
+func (pt *podTaps) update(ctx context.Context, pods []*corev1.Pod) {
+	current := make(map[string]struct{}, len(pods))
+	for _, pod := range pods {
+		// A pod recreated with the same name gets a new IP, so it's keyed by
+		// both to have it tapped again.
+		key := fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.Name, pod.Status.PodIP)
+		current[key] = struct{}{}
+		if _, ok := pt.cancels[key]; ok {
+			continue
+		}
+
+		tapCtx, cancel := context.WithCancel(ctx)
+		pt.cancels[key] = cancel
+		pt.start(tapCtx, pod)
+	}
+
+	for key, cancel := range pt.cancels {
+		if _, ok := current[key]; !ok {
+			cancel()
+			delete(pt.cancels, key)
+		}
+	}
+}
+
+// len returns the number of pods being tapped.
+func (pt *podTaps) len() int {
+	return len(pt.cancels)
+}
@@ -0,0 +1,306 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-test/deep"
+	"github.com/linkerd/linkerd2/controller/k8s"
+	corev1 "k8s.io/api/core/v1"
+	discovery "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodsForService(t *testing.T) {
+	k8sAPI, err := k8s.NewFakeAPI(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: emoji-svc
+  namespace: emojivoto
+spec:
+  selector:
+    app: emoji-svc
+`, `
+apiVersion: discovery.k8s.io/v1
+kind: EndpointSlice
+metadata:
+  name: emoji-svc-abc
+  namespace: emojivoto
+  labels:
+    kubernetes.io/service-name: emoji-svc
+addressType: IPv4
+endpoints:
+- addresses:
+  - 10.0.0.1
+  targetRef:
+    kind: Pod
+    name: emoji-1
+    namespace: emojivoto
+- addresses:
+  - 10.0.0.9
+  targetRef:
+    kind: Pod
+    name: emoji-deleted
+    namespace: emojivoto
+- addresses:
+  - 10.0.0.10
+`, `
+apiVersion: discovery.k8s.io/v1
+kind: EndpointSlice
+metadata:
+  name: emoji-svc-def
+  namespace: emojivoto
+  labels:
+    kubernetes.io/service-name: emoji-svc
+addressType: IPv6
+endpoints:
+- addresses:
+  - fd00::1
+  targetRef:
+    kind: Pod
+    name: emoji-1
+    namespace: emojivoto
+`, `
+apiVersion: discovery.k8s.io/v1
+kind: EndpointSlice
+metadata:
+  name: voting-svc-abc
+  namespace: emojivoto
+  labels:
+    kubernetes.io/service-name: voting-svc
+addressType: IPv4
+endpoints:
+- addresses:
+  - 10.0.0.3
+  targetRef:
+    kind: Pod
+    name: voting-1
+    namespace: emojivoto
+`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: emoji-1
+  namespace: emojivoto
+  labels:
+    app: emoji-svc
+status:
+  podIP: 10.0.0.1
+`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: emoji-2
+  namespace: emojivoto
+  labels:
+    app: emoji-svc
+status:
+  podIP: 10.0.0.2
+`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: voting-1
+  namespace: emojivoto
+status:
+  podIP: 10.0.0.3
+`)
+	if err != nil {
+		t.Fatalf("NewFakeAPI returned an error: %s", err)
+	}
+	s, err := NewGrpcTapServer(4190, "controller-ns", "cluster.local", k8sAPI, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("NewGrpcTapServer returned an error: %s", err)
+	}
+	k8sAPI.Sync(nil)
+
+	pods, err := s.podsForService("emojivoto", "emoji-svc")
+	if err != nil {
+		t.Fatalf("podsForService returned an error: %s", err)
+	}
+
+	// emoji-2 matches the service's selector but isn't one of its endpoints,
+	// and emoji-1 is listed once even though it's in both slices.
+	if diff := deep.Equal(podNames(pods), []string{"emoji-1"}); diff != nil {
+		t.Fatalf("Unexpected pods: %+v", diff)
+	}
+}
+
+func TestWatchServiceEndpoints(t *testing.T) {
+	k8sAPI, err := k8s.NewFakeAPI(`
+apiVersion: discovery.k8s.io/v1
+kind: EndpointSlice
+metadata:
+  name: emoji-svc-abc
+  namespace: emojivoto
+  labels:
+    kubernetes.io/service-name: emoji-svc
+addressType: IPv4
+endpoints:
+- addresses:
+  - 10.0.0.1
+  targetRef:
+    kind: Pod
+    name: emoji-1
+    namespace: emojivoto
+`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: emoji-1
+  namespace: emojivoto
+status:
+  podIP: 10.0.0.1
+`, `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: emoji-2
+  namespace: emojivoto
+status:
+  podIP: 10.0.0.2
+`)
+	if err != nil {
+		t.Fatalf("NewFakeAPI returned an error: %s", err)
+	}
+	s, err := NewGrpcTapServer(4190, "controller-ns", "cluster.local", k8sAPI, nil, nil, 0)
+	if err != nil {
+		t.Fatalf("NewGrpcTapServer returned an error: %s", err)
+	}
+	k8sAPI.Sync(nil)
+
+	changed, stop, err := s.watchServiceEndpoints("emojivoto", "emoji-svc")
+	if err != nil {
+		t.Fatalf("watchServiceEndpoints returned an error: %s", err)
+	}
+	defer stop()
+
+	// The informer replays the existing slice to the new handler.
+	waitForChange(t, changed)
+
+	ctx := context.Background()
+
+	// Slices of other services don't signal a change.
+	_, err = k8sAPI.Client.DiscoveryV1().EndpointSlices("emojivoto").Create(ctx, &discovery.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "voting-svc-abc",
+			Namespace: "emojivoto",
+			Labels:    map[string]string{discovery.LabelServiceName: "voting-svc"},
+		},
+		AddressType: discovery.AddressTypeIPv4,
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("Failed to create EndpointSlice: %s", err)
+	}
+	select {
+	case <-changed:
+		t.Fatal("Unexpected change for another service's EndpointSlice")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// emoji-2 is added to the service.
+	slice, err := k8sAPI.Client.DiscoveryV1().EndpointSlices("emojivoto").Get(ctx, "emoji-svc-abc", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Failed to get EndpointSlice: %s", err)
+	}
+	slice.Endpoints = append(slice.Endpoints, discovery.Endpoint{
+		Addresses: []string{"10.0.0.2"},
+		TargetRef: &corev1.ObjectReference{Kind: "Pod", Name: "emoji-2", Namespace: "emojivoto"},
+	})
+	if _, err := k8sAPI.Client.DiscoveryV1().EndpointSlices("emojivoto").Update(ctx, slice, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update EndpointSlice: %s", err)
+	}
+	waitForChange(t, changed)
+
+	pods, err := s.podsForService("emojivoto", "emoji-svc")
+	if err != nil {
+		t.Fatalf("podsForService returned an error: %s", err)
+	}
+	if diff := deep.Equal(podNames(pods), []string{"emoji-1", "emoji-2"}); diff != nil {
+		t.Fatalf("Unexpected pods after adding an endpoint: %+v", diff)
+	}
+
+	// The service's slice is deleted.
+	if err := k8sAPI.Client.DiscoveryV1().EndpointSlices("emojivoto").Delete(ctx, "emoji-svc-abc", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("Failed to delete EndpointSlice: %s", err)
+	}
+	waitForChange(t, changed)
+
+	pods, err = s.podsForService("emojivoto", "emoji-svc")
+	if err != nil {
+		t.Fatalf("podsForService returned an error: %s", err)
+	}
+	if len(pods) != 0 {
+		t.Fatalf("Expected no pods after deleting the EndpointSlice, got %v", podNames(pods))
+	}
+}
+
+func TestPodTapsUpdate(t *testing.T) {
+	started := []string{}
+	contexts := make(map[string]context.Context)
+	taps := newPodTaps(func(ctx context.Context, pod *corev1.Pod) {
+		started = append(started, pod.Name)
+		contexts[pod.Name] = ctx
+	})
+
+	pod := func(name, ip string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "emojivoto"},
+			Status:     corev1.PodStatus{PodIP: ip},
+		}
+	}
+
+	ctx := context.Background()
+
+	taps.update(ctx, []*corev1.Pod{pod("emoji-1", "10.0.0.1"), pod("emoji-2", "10.0.0.2")})
+	if diff := deep.Equal(started, []string{"emoji-1", "emoji-2"}); diff != nil {
+		t.Fatalf("Unexpected started taps: %+v", diff)
+	}
+
+	// emoji-1 is removed and emoji-3 added; emoji-2 keeps its running tap.
+	emoji1 := contexts["emoji-1"]
+	taps.update(ctx, []*corev1.Pod{pod("emoji-2", "10.0.0.2"), pod("emoji-3", "10.0.0.3")})
+	if diff := deep.Equal(started, []string{"emoji-1", "emoji-2", "emoji-3"}); diff != nil {
+		t.Fatalf("Unexpected started taps: %+v", diff)
+	}
+	if emoji1.Err() == nil {
+		t.Fatal("Expected the tap of the removed pod to be stopped")
+	}
+	if contexts["emoji-2"].Err() != nil {
+		t.Fatal("Expected the tap of a remaining pod to keep running")
+	}
+
+	// emoji-2 is recreated with a new IP.
+	emoji2 := contexts["emoji-2"]
+	taps.update(ctx, []*corev1.Pod{pod("emoji-2", "10.0.0.4"), pod("emoji-3", "10.0.0.3")})
+	if diff := deep.Equal(started, []string{"emoji-1", "emoji-2", "emoji-3", "emoji-2"}); diff != nil {
+		t.Fatalf("Unexpected started taps: %+v", diff)
+	}
+	if emoji2.Err() == nil {
+		t.Fatal("Expected the tap of the pod's previous IP to be stopped")
+	}
+	if taps.len() != 2 {
+		t.Fatalf("Expected 2 running taps, got %d", taps.len())
+	}
+}
+
+func waitForChange(t *testing.T, changed <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for an EndpointSlice change")
+	}
+}
+
+func podNames(pods []*corev1.Pod) []string {
+	names := []string{}
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	sort.Strings(names)
+	return names
+}
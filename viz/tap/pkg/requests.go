@@ -24,6 +24,20 @@ var ValidTapDestinations = []string{
 	k8s.StatefulSet,
 }
 
+// ValidTapTargets specifies resource types allowed as a tap target
+var ValidTapTargets = []string{
+	k8s.CronJob,
+	k8s.DaemonSet,
+	k8s.Deployment,
+	k8s.Job,
+	k8s.Namespace,
+	k8s.Pod,
+	k8s.ReplicaSet,
+	k8s.ReplicationController,
+	k8s.Service,
+	k8s.StatefulSet,
+}
+
 // TapRequestParams contains parameters that are used to build a
 // TapByResourceRequest.
 type TapRequestParams struct {
@@ -47,7 +61,7 @@ func BuildTapByResourceRequest(params TapRequestParams) (*tapPb.TapByResourceReq
 	if err != nil {
 		return nil, fmt.Errorf("target resource invalid: %w", err)
 	}
-	if !contains(util.ValidTargets, target.Type) {
+	if !contains(ValidTapTargets, target.Type) {
 		return nil, fmt.Errorf("unsupported resource type [%s]", target.Type)
 	}
 
@@ -4,9 +4,12 @@ import (
 	"context"
 	"flag"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"regexp"
+	"strings"
 	"syscall"
 	"time"
 
@@ -32,20 +35,30 @@ func main() {
 	grafanaAddr := cmd.String("grafana-addr", "", "address of the linkerd-grafana service")
 	grafanaExternalAddr := cmd.String("grafana-external-addr", "", "address of the external grafana service")
 	grafanaPrefix := cmd.String("grafana-prefix", "", "prefix for Grafana dashboard UID's")
+	grafanaAuthHeader := cmd.String("grafana-auth-header", "Authorization", "header injected into the requests proxied to the linkerd-grafana service")
+	grafanaAuthFile := cmd.String("grafana-auth-file", "", "path to a file holding the value of the header injected into the requests proxied to the linkerd-grafana service (e.g. \"Bearer <token>\"); if empty, no header is injected")
 	jaegerAddr := cmd.String("jaeger-addr", "", "address of the jaeger service")
 	templateDir := cmd.String("template-dir", "templates", "directory to search for template files")
 	staticDir := cmd.String("static-dir", "app/dist", "directory to search for static files")
 	reload := cmd.Bool("reload", true, "reloading set to true or false")
 	controllerNamespace := cmd.String("controller-namespace", "linkerd", "namespace in which Linkerd is installed")
-	enforcedHost := cmd.String("enforced-host", "", "regexp describing the allowed values for the Host header; protects from DNS-rebinding attacks")
+	enforcedHosts := hostPatterns{}
+	cmd.Var(&enforcedHosts, "enforced-host", "regexp describing the allowed values for the Host header; protects from DNS-rebinding attacks. Can be repeated, in which case a Host header matching any of them is allowed")
 	kubeConfigPath := cmd.String("kubeconfig", "", "path to kube config")
+	kubeCAFile := cmd.String("kube-ca-file", "", "path to a file with additional PEM-encoded CA certificates to trust when connecting to the Kubernetes API")
 	clusterDomain := cmd.String("cluster-domain", "", "kubernetes cluster domain")
 	enablePprof := cmd.Bool("enable-pprof", false, "Enable pprof endpoints on the admin server")
+	vizAPIReadiness := cmd.String("linkerd-metrics-api-readiness", "warn", "what to do when the linkerd-metrics-api service can't be reached: \"fail\" the readiness check, or only \"warn\" about it")
+	tapIdleTimeout := cmd.Duration("tap-idle-timeout", 0, "close dashboard tap sessions that receive no message from the browser for this long; 0 disables the timeout")
 
 	traceCollector := flags.AddTraceFlags(cmd)
 
 	flags.ConfigureAndParse(cmd, os.Args[1:])
 
+	if *vizAPIReadiness != "fail" && *vizAPIReadiness != "warn" {
+		log.Fatalf("invalid --linkerd-metrics-api-readiness parameter %q: must be \"fail\" or \"warn\"", *vizAPIReadiness)
+	}
+
 	ready := false
 	adminServer := admin.NewServer(*metricsAddr, *enablePprof, &ready)
 
@@ -72,7 +85,16 @@ func main() {
 		log.Warnf("expected cluster domain through args (falling back to %s)", *clusterDomain)
 	}
 
-	k8sAPI, err := k8s.NewAPI(*kubeConfigPath, "", "", []string{}, 0)
+	k8sConfig, err := k8s.GetConfig(*kubeConfigPath, "")
+	if err != nil {
+		log.Fatalf("failed to configure Kubernetes API client: [%s]", err)
+	}
+	if *kubeCAFile != "" {
+		if err := k8s.AddCAFile(k8sConfig, *kubeCAFile); err != nil {
+			log.Fatalf("failed to load Kubernetes API CA file: [%s]", err)
+		}
+	}
+	k8sAPI, err := k8s.NewAPIForConfig(k8sConfig, "", []string{}, 0, 0, 0)
 	if err != nil {
 		log.Fatalf("failed to construct Kubernetes API client: [%s]", err)
 	}
@@ -102,13 +124,13 @@ func main() {
 		}
 	}
 
-	reHost, err := regexp.Compile(*enforcedHost)
+	grafanaHeaders, err := grafanaAuthHeaders(*grafanaAuthHeader, *grafanaAuthFile)
 	if err != nil {
-		log.Fatalf("invalid --enforced-host parameter: %s", err)
+		log.Fatalf("failed to read Grafana auth header: %s", err)
 	}
 
-	server := srv.NewServer(*addr, *grafanaAddr, *grafanaExternalAddr, *grafanaPrefix, *jaegerAddr, *templateDir, *staticDir, uuid, version,
-		*controllerNamespace, *clusterDomain, *reload, reHost, client, k8sAPI, hc)
+	server := srv.NewServer(*addr, *grafanaAddr, *grafanaExternalAddr, *grafanaPrefix, grafanaHeaders, *jaegerAddr, *templateDir, *staticDir, uuid, version,
+		*controllerNamespace, *clusterDomain, *reload, enforcedHosts, client, k8sAPI, hc, *tapIdleTimeout)
 
 	go func() {
 		log.Infof("starting HTTP server on %+v", *addr)
@@ -118,6 +140,7 @@ func main() {
 	}()
 
 	ready = true
+	go srv.MonitorVizAPI(ctx, client, *vizAPIReadiness == "fail", &ready)
 
 	<-stop
 
@@ -148,3 +171,41 @@ func getUUIDAndVersion(ctx context.Context, k8sAPI *k8s.KubernetesAPI, controlle
 
 	return uuid, version
 }
+
+// grafanaAuthHeaders returns the header to inject into the requests proxied to
+// Grafana, whose value is read from authFile (usually a mounted secret).
+func grafanaAuthHeaders(header, authFile string) (http.Header, error) {
+	if authFile == "" {
+		return nil, nil
+	}
+
+	value, err := os.ReadFile(filepath.Clean(authFile))
+	if err != nil {
+		return nil, err
+	}
+
+	headers := http.Header{}
+	headers.Set(header, strings.TrimSpace(string(value)))
+	return headers, nil
+}
+
+// hostPatterns holds the regexps given through repeated --enforced-host
+// flags. They aren't split on commas, as commas are valid in a regexp.
+type hostPatterns []*regexp.Regexp
+
+func (h *hostPatterns) String() string {
+	patterns := make([]string, len(*h))
+	for i, re := range *h {
+		patterns[i] = re.String()
+	}
+	return strings.Join(patterns, ",")
+}
+
+func (h *hostPatterns) Set(value string) error {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return err
+	}
+	*h = append(*h, re)
+	return nil
+}
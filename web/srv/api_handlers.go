@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"regexp"
 	"strings"
@@ -95,7 +96,9 @@ func renderJSONBytes(w http.ResponseWriter, b []byte) {
 
 func (h *handler) handleAPIVersion(w http.ResponseWriter, req *http.Request, p httprouter.Params) {
 	resp := map[string]interface{}{
-		"version": h.version,
+		"version":       h.version,
+		"uuid":          h.uuid,
+		"clusterDomain": h.clusterDomain,
 	}
 	renderJSON(w, resp)
 }
@@ -325,8 +328,23 @@ func (h *handler) handleAPITap(w http.ResponseWriter, req *http.Request, p httpr
 	}()
 
 	for {
+		// Any message from the browser counts as activity and pushes back
+		// the idle timeout.
+		if h.tapIdleTimeout > 0 {
+			if err := ws.SetReadDeadline(time.Now().Add(h.tapIdleTimeout)); err != nil {
+				log.Errorf("Failed to set tap session read deadline: %s", err)
+				return
+			}
+		}
+
 		_, _, err := ws.ReadMessage()
 		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				log.Debugf("Closing tap session idle for %s", h.tapIdleTimeout)
+				websocketError(ws, websocket.CloseGoingAway, fmt.Errorf("tap session closed after %s of inactivity", h.tapIdleTimeout))
+				return
+			}
 			log.Debugf("Received close frame: %v", err)
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) {
 				log.Errorf("Unexpected close error: %s", err)
@@ -8,11 +8,15 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-test/deep"
+	"github.com/gorilla/websocket"
 	"github.com/julienschmidt/httprouter"
 	"github.com/linkerd/linkerd2/pkg/healthcheck"
+	"github.com/linkerd/linkerd2/pkg/k8s"
 	vizApi "github.com/linkerd/linkerd2/viz/metrics-api"
 	pb "github.com/linkerd/linkerd2/viz/metrics-api/gen/viz"
 )
@@ -98,6 +102,38 @@ func TestHandleApiCheck(t *testing.T) {
 	}
 }
 
+func TestHandleApiVersion(t *testing.T) {
+	handler := &handler{
+		uuid:          "b5d1a2c3-uuid",
+		version:       releaseVersion,
+		clusterDomain: "cluster.local",
+	}
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/api/version", nil)
+	handler.handleAPIVersion(recorder, req, httprouter.Params{})
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Fatalf("Expected Content-Type application/json, got %s", contentType)
+	}
+
+	var resp map[string]string
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response %s: %s", recorder.Body.String(), err)
+	}
+	expected := map[string]string{
+		"version":       releaseVersion,
+		"uuid":          "b5d1a2c3-uuid",
+		"clusterDomain": "cluster.local",
+	}
+	if diff := deep.Equal(resp, expected); diff != nil {
+		t.Fatalf("Unexpected response: %v", diff)
+	}
+}
+
 func TestHandleApiGateway(t *testing.T) {
 	mockAPIClient := &vizApi.MockAPIClient{
 		GatewaysResponseToReturn: &pb.GatewaysResponse{
@@ -182,3 +218,71 @@ func TestHandleApiGateway(t *testing.T) {
 		}
 	})
 }
+
+func TestHandleAPITapIdleTimeout(t *testing.T) {
+	// The tap API holds the tap open, without sending any events, until the
+	// web server cancels it.
+	tapCancelled := make(chan struct{})
+	tapAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(tapCancelled)
+	}))
+	defer tapAPI.Close()
+
+	k8sAPI, err := k8s.NewFakeAPI()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	k8sAPI.Config.Host = tapAPI.URL
+
+	h := &handler{
+		k8sAPI:         k8sAPI,
+		tapIdleTimeout: 100 * time.Millisecond,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h.handleAPITap(w, req, httprouter.Params{})
+	}))
+	defer server.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Failed to open websocket: %s", err)
+	}
+	defer ws.Close()
+
+	if err := ws.WriteMessage(websocket.TextMessage, []byte(`{"resource": "deploy/web", "namespace": "emojivoto"}`)); err != nil {
+		t.Fatalf("Failed to send tap request: %s", err)
+	}
+
+	// Activity from the browser keeps the session open past the timeout.
+	for i := 0; i < 3; i++ {
+		time.Sleep(50 * time.Millisecond)
+		if err := ws.WriteMessage(websocket.TextMessage, []byte("ping")); err != nil {
+			t.Fatalf("Failed to send message: %s", err)
+		}
+	}
+
+	// Once the browser goes quiet, the session is closed.
+	if err := ws.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	_, _, err = ws.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("Expected the session to be closed, got: %v", err)
+	}
+	if closeErr.Code != websocket.CloseGoingAway {
+		t.Fatalf("Expected close code %d, got %d", websocket.CloseGoingAway, closeErr.Code)
+	}
+	if closeErr.Text != "tap session closed after 100ms of inactivity" {
+		t.Fatalf("Unexpected close message: %s", closeErr.Text)
+	}
+
+	select {
+	case <-tapCancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the tap to be cancelled when the session was closed")
+	}
+}
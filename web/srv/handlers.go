@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/linkerd/linkerd2/pkg/k8s"
@@ -35,6 +36,7 @@ type (
 		jaegerProxy         *reverseProxy
 		hc                  healthChecker
 		statCache           *cache.Cache
+		tapIdleTimeout      time.Duration
 	}
 )
 
@@ -0,0 +1,48 @@
+package srv
+
+import (
+	"context"
+	"time"
+
+	vizPb "github.com/linkerd/linkerd2/viz/metrics-api/gen/viz"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	vizAPICheckInterval = 10 * time.Second
+	vizAPICheckTimeout  = 5 * time.Second
+)
+
+// MonitorVizAPI periodically checks that the metrics-api can be reached,
+// until ctx is done. An unreachable metrics-api is always logged. If
+// failReadiness is true, it also makes the web server not ready, as the
+// dashboard can't show anything without it.
+func MonitorVizAPI(ctx context.Context, apiClient vizPb.ApiClient, failReadiness bool, ready *bool) {
+	ticker := time.NewTicker(vizAPICheckInterval)
+	defer ticker.Stop()
+
+	for {
+		checkVizAPI(ctx, apiClient, failReadiness, ready)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func checkVizAPI(ctx context.Context, apiClient vizPb.ApiClient, failReadiness bool, ready *bool) {
+	ctx, cancel := context.WithTimeout(ctx, vizAPICheckTimeout)
+	defer cancel()
+
+	_, err := apiClient.SelfCheck(ctx, &vizPb.SelfCheckRequest{})
+	if err != nil {
+		log.Warnf("metrics-api is unreachable: %s", err)
+		if failReadiness {
+			*ready = false
+		}
+		return
+	}
+	*ready = true
+}
@@ -0,0 +1,57 @@
+package srv
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	vizApi "github.com/linkerd/linkerd2/viz/metrics-api"
+	pb "github.com/linkerd/linkerd2/viz/metrics-api/gen/viz"
+)
+
+func TestCheckVizAPI(t *testing.T) {
+	testCases := []struct {
+		name          string
+		err           error
+		failReadiness bool
+		ready         bool
+		expectedReady bool
+	}{
+		{
+			name:          "reachable metrics-api",
+			failReadiness: true,
+			ready:         false,
+			expectedReady: true,
+		},
+		{
+			name:          "unreachable metrics-api fails readiness",
+			err:           errors.New("connection refused"),
+			failReadiness: true,
+			ready:         true,
+			expectedReady: false,
+		},
+		{
+			name:          "unreachable metrics-api only warns",
+			err:           errors.New("connection refused"),
+			failReadiness: false,
+			ready:         true,
+			expectedReady: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			apiClient := &vizApi.MockAPIClient{
+				ErrorToReturn:             tc.err,
+				SelfCheckResponseToReturn: &pb.SelfCheckResponse{},
+			}
+
+			ready := tc.ready
+			checkVizAPI(context.Background(), apiClient, tc.failReadiness, &ready)
+			if ready != tc.expectedReady {
+				t.Fatalf("Expected ready to be %t, got %t", tc.expectedReady, ready)
+			}
+		})
+	}
+}
@@ -8,17 +8,23 @@ import (
 
 // reverseProxy is an HTTP reverse proxy that forwards all web requests
 // containing paths prefixed  to the corresponding service. The proxy
-// strips the prefix and rewrites the Host header before sending.
+// strips the prefix and rewrites the Host header before sending. The given
+// headers, if any, are set on every forwarded request, replacing any value
+// sent by the client.
 type reverseProxy struct {
 	*httputil.ReverseProxy
 }
 
-func newReverseProxy(addr string, prefix string) *reverseProxy {
+func newReverseProxy(addr string, prefix string, headers http.Header) *reverseProxy {
 	director := func(req *http.Request) {
 		req.URL.Host = addr
 		req.URL.Scheme = "http"
 		req.URL.Path = strings.TrimPrefix(req.URL.Path, prefix)
 
+		for name, values := range headers {
+			req.Header[name] = values
+		}
+
 		// the default director implementation does this, so we will too
 		if _, ok := req.Header["User-Agent"]; !ok {
 			// explicitly disable User-Agent so it's not set to default value
@@ -0,0 +1,81 @@
+package srv
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestGrafanaProxy(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		headers               http.Header
+		expectedAuthorization string
+	}{
+		{
+			name:                  "without injected headers",
+			expectedAuthorization: "Basic client",
+		},
+		{
+			name:                  "with an injected auth header",
+			headers:               http.Header{"Authorization": []string{"Bearer grafana-token"}},
+			expectedAuthorization: "Bearer grafana-token",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			requests := make(chan *http.Request, 1)
+			grafana := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests <- r
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte("dashboard"))
+			}))
+			defer grafana.Close()
+
+			grafanaURL, err := url.Parse(grafana.URL)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+
+			h := &handler{
+				grafanaProxy: newReverseProxy(grafanaURL.Host, "/grafana", tc.headers),
+			}
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/grafana/d/abc?orgId=1", nil)
+			req.Header.Set("Authorization", "Basic client")
+			req.Header.Set("X-Custom", "forwarded")
+			h.handleGrafana(recorder, req, httprouter.Params{})
+
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, recorder.Code)
+			}
+			if body := recorder.Body.String(); body != "dashboard" {
+				t.Fatalf("Expected body [dashboard], got [%s]", body)
+			}
+			var received *http.Request
+			select {
+			case received = <-requests:
+			default:
+				t.Fatal("Expected the request to be forwarded to Grafana")
+			}
+			if received.URL.Path != "/d/abc" {
+				t.Fatalf("Expected path [/d/abc], got [%s]", received.URL.Path)
+			}
+			if received.URL.RawQuery != "orgId=1" {
+				t.Fatalf("Expected query [orgId=1], got [%s]", received.URL.RawQuery)
+			}
+			if auth := received.Header.Get("Authorization"); auth != tc.expectedAuthorization {
+				t.Fatalf("Expected Authorization header [%s], got [%s]", tc.expectedAuthorization, auth)
+			}
+			if custom := received.Header.Get("X-Custom"); custom != "forwarded" {
+				t.Fatalf("Expected X-Custom header [forwarded], got [%s]", custom)
+			}
+		})
+	}
+}
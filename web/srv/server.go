@@ -8,6 +8,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -38,7 +39,7 @@ type (
 		reload      bool
 		templates   map[string]*template.Template
 		router      *httprouter.Router
-		reHost      *regexp.Regexp
+		reHosts     []*regexp.Regexp
 	}
 
 	templatePayload struct {
@@ -64,12 +65,16 @@ type (
 
 // this is called by the HTTP server to actually respond to a request
 func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
-	if !s.reHost.MatchString(req.Host) {
+	if !s.allowedHost(req.Host) {
+		patterns := make([]string, len(s.reHosts))
+		for i, reHost := range s.reHosts {
+			patterns[i] = "/" + html.EscapeString(reHost.String()) + "/"
+		}
 		err := fmt.Sprintf(`It appears that you are trying to reach this service with a host of '%s'.
-This does not match /%s/ and has been denied for security reasons.
+This does not match %s and has been denied for security reasons.
 Please see https://linkerd.io/dns-rebinding for an explanation of what is happening and how to fix it.`,
 			html.EscapeString(req.Host),
-			html.EscapeString(s.reHost.String()))
+			strings.Join(patterns, " or "))
 		http.Error(w, err, http.StatusBadRequest)
 		return
 	}
@@ -79,6 +84,20 @@ Please see https://linkerd.io/dns-rebinding for an explanation of what is happen
 	s.router.ServeHTTP(w, req)
 }
 
+// allowedHost returns true if host matches any of the enforced host patterns.
+// Every host is allowed if there are none.
+func (s *Server) allowedHost(host string) bool {
+	if len(s.reHosts) == 0 {
+		return true
+	}
+	for _, reHost := range s.reHosts {
+		if reHost.MatchString(host) {
+			return true
+		}
+	}
+	return false
+}
+
 // NewServer returns an initialized `http.Server`, configured to listen on an
 // address, render templates, and serve static assets, for a given Linkerd
 // control plane.
@@ -87,6 +106,7 @@ func NewServer(
 	grafanaAddr string,
 	grafanaExternalAddr string,
 	grafanaPrefix string,
+	grafanaHeaders http.Header,
 	jaegerAddr string,
 	templateDir string,
 	staticDir string,
@@ -95,15 +115,16 @@ func NewServer(
 	controllerNamespace string,
 	clusterDomain string,
 	reload bool,
-	reHost *regexp.Regexp,
+	reHosts []*regexp.Regexp,
 	apiClient vizPb.ApiClient,
 	k8sAPI *k8s.KubernetesAPI,
 	hc healthChecker,
+	tapIdleTimeout time.Duration,
 ) *http.Server {
 	server := &Server{
 		templateDir: templateDir,
 		reload:      reload,
-		reHost:      reHost,
+		reHosts:     reHosts,
 	}
 
 	server.router = &httprouter.Router{
@@ -121,18 +142,19 @@ func NewServer(
 		version:             version,
 		controllerNamespace: controllerNamespace,
 		clusterDomain:       clusterDomain,
-		jaegerProxy:         newReverseProxy(jaegerAddr, ""),
+		jaegerProxy:         newReverseProxy(jaegerAddr, "", nil),
 		grafana:             grafanaAddr,
 		grafanaExternalURL:  grafanaExternalAddr,
 		grafanaPrefix:       grafanaPrefix,
 		jaeger:              jaegerAddr,
 		hc:                  hc,
 		statCache:           cache.New(statExpiration, statCleanupInterval),
+		tapIdleTimeout:      tapIdleTimeout,
 	}
 
 	// Only create the grafana reverse proxy if we aren't using external grafana
 	if grafanaExternalAddr == "" {
-		handler.grafanaProxy = newReverseProxy(grafanaAddr, "/grafana")
+		handler.grafanaProxy = newReverseProxy(grafanaAddr, "/grafana", grafanaHeaders)
 	}
 
 	httpServer := &http.Server{
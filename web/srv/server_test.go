@@ -0,0 +1,83 @@
+package srv
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestServeHTTPEnforcedHosts(t *testing.T) {
+	testCases := []struct {
+		name         string
+		patterns     []string
+		host         string
+		expectedCode int
+	}{
+		{
+			name:         "no patterns allows every host",
+			host:         "evil.example.com",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "host matching the only pattern",
+			patterns:     []string{`^localhost(:\d+)?$`},
+			host:         "localhost:50750",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "host matching the first of several patterns",
+			patterns:     []string{`^localhost(:\d+)?$`, `^dashboard\.example\.com$`},
+			host:         "localhost:50750",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "host matching the second of several patterns",
+			patterns:     []string{`^localhost(:\d+)?$`, `^dashboard\.example\.com$`},
+			host:         "dashboard.example.com",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "host matching none of several patterns",
+			patterns:     []string{`^localhost(:\d+)?$`, `^dashboard\.example\.com$`},
+			host:         "evil.example.com",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc // pin
+		t.Run(tc.name, func(t *testing.T) {
+			reHosts := []*regexp.Regexp{}
+			for _, pattern := range tc.patterns {
+				reHosts = append(reHosts, regexp.MustCompile(pattern))
+			}
+
+			router := &httprouter.Router{}
+			router.GET("/", func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+				w.WriteHeader(http.StatusOK)
+			})
+			server := &Server{router: router, reHosts: reHosts}
+
+			recorder := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Host = tc.host
+			server.ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tc.expectedCode, recorder.Code)
+			}
+			if tc.expectedCode == http.StatusBadRequest {
+				body := recorder.Body.String()
+				for _, pattern := range tc.patterns {
+					if !strings.Contains(body, "/"+pattern+"/") {
+						t.Fatalf("Expected pattern [%s] to be present in [%s]", pattern, body)
+					}
+				}
+			}
+		})
+	}
+}